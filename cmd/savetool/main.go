@@ -0,0 +1,7 @@
+package main
+
+import "github.com/qbradq/sharduo/internal/cmd/uod"
+
+func main() {
+	uod.SaveTool()
+}
@@ -0,0 +1,36 @@
+package ai
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("Aggressive", func() AIModel { return &aggressive{} })
+}
+
+// aggressive implements an AI that wanders about and attacks any player or
+// player-owned pet it sees. It flees when its hit points run low.
+type aggressive struct {
+	combat
+	wander walkRandom // Wandering behavior when there is nothing to fight
+}
+
+// Act implements the AIModel interface.
+func (a *aggressive) Act(m game.Mobile, t uo.Time) {
+	if a.fight(m, 0) {
+		return
+	}
+	if a.scanDue(t) {
+		if e := findEnemy(m, isPlayerOwned); e != nil {
+			engage(m, e)
+			return
+		}
+	}
+	a.wander.Act(m, t)
+}
+
+// Target implements the AIModel interface.
+func (a *aggressive) Target(m game.Mobile, t uo.Time) {
+	switchToNearest(m, &a.combat, isPlayerOwned)
+}
@@ -0,0 +1,31 @@
+package ai
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("Animal", func() AIModel { return &animal{} })
+}
+
+// animal implements an AI that wanders about peacefully until attacked, then
+// fights back. It flees when its hit points run low.
+type animal struct {
+	combat
+	wander walkRandom // Wandering behavior when there is nothing to fight
+}
+
+// Act implements the AIModel interface.
+func (a *animal) Act(m game.Mobile, t uo.Time) {
+	// Mobile.Damage sets our combatant when we are attacked
+	if a.fight(m, 0) {
+		return
+	}
+	a.wander.Act(m, t)
+}
+
+// Target implements the AIModel interface.
+func (a *animal) Target(m game.Mobile, t uo.Time) {
+	// No target selection
+}
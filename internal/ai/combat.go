@@ -0,0 +1,260 @@
+package ai
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// Percentage of maximum hit points below which mobiles flee from combat
+const fleeHitPointsPercent int = 20
+
+// Percentage of maximum hit points at which fleeing mobiles return to combat
+const rallyHitPointsPercent int = 50
+
+// Time between scans for enemies
+const scanDelay uo.Time = uo.DurationSecond
+
+// combat holds the state shared by all AI models that fight.
+type combat struct {
+	fleeing  bool    // If true we are running away from our combatant
+	nextScan uo.Time // Next time we should scan for enemies
+}
+
+// scanDue returns true if it is time to scan for enemies again. The first scan
+// is delayed by a random amount so mobiles spawned together do not all scan on
+// the same tick.
+func (c *combat) scanDue(t uo.Time) bool {
+	if c.nextScan == 0 {
+		c.nextScan = t + uo.Time(game.GetWorld().Random().Random(1, int(scanDelay)))
+	}
+	if t < c.nextScan {
+		return false
+	}
+	c.nextScan = t + scanDelay
+	return true
+}
+
+// fight drives the mobile's combat against its current combatant. It returns
+// false if the mobile is not fighting anything. The mobile flees when its hit
+// points run low, and if keepAway is greater than zero and the mobile's weapon
+// reaches further than that, the mobile backs away from combatants that come
+// closer than keepAway tiles.
+func (c *combat) fight(m game.Mobile, keepAway int16) bool {
+	cm := m.Combatant()
+	if cm == nil || cm.Removed() || cm.IsDead() || !m.CanSee(cm) {
+		c.fleeing = false
+		if m.IsInWarMode() {
+			disengage(m)
+		}
+		return false
+	}
+	c.fleeing = shouldFlee(m.HitPoints(), m.MaxHitPoints(), c.fleeing)
+	if c.fleeing {
+		retreat(m, cm.Location())
+		return true
+	}
+	r := game.AttackRange(m)
+	d := m.Location().XYDistance(cm.Location())
+	if keepAway > 0 && r > keepAway && d < keepAway {
+		retreat(m, cm.Location())
+		return true
+	}
+	los := m.HasLineOfSight(cm)
+	if d <= r && los {
+		// Close enough to attack, the combat system takes it from here
+		return true
+	}
+	within := r
+	if !los && within >= d {
+		// Get closer to see around whatever is in the way
+		within = d - 1
+	}
+	if within < 1 {
+		within = 1
+	}
+	if approach(m, cm.Location(), within) == game.PathNotFound {
+		// We can't get there from here
+		disengage(m)
+		return false
+	}
+	return true
+}
+
+// shouldFlee returns true if a mobile should be fleeing given its current and
+// maximum hit points. Mobiles that are already fleeing keep running until they
+// have recovered some.
+func shouldFlee(hp, max int, fleeing bool) bool {
+	if fleeing {
+		return hp*100 < max*rallyHitPointsPercent
+	}
+	return hp*100 < max*fleeHitPointsPercent
+}
+
+// isPlayerOwned returns true if the mobile is a player character or is
+// controlled by one.
+func isPlayerOwned(m game.Mobile) bool {
+	if m.IsPlayerCharacter() {
+		return true
+	}
+	cm := m.ControlMaster()
+	return cm != nil && cm.IsPlayerCharacter()
+}
+
+// canAttack returns true if the mobile is able to see and attack the other.
+func canAttack(m, o game.Mobile) bool {
+	if o == nil || o.Serial() == m.Serial() || o.Removed() || o.IsDead() ||
+		o.HitPoints() <= 0 || !m.CanHarm(o) {
+		return false
+	}
+	if o.NetState() != nil && o.NetState().Account().HasRole(game.RoleGameMaster) {
+		// Leave the staff alone
+		return false
+	}
+	if m.Location().XYDistance(o.Location()) > m.ViewRange() {
+		return false
+	}
+	return m.CanSee(o) && m.HasLineOfSight(o)
+}
+
+// findEnemy returns the nearest mobile the mobile can attack that is accepted
+// by the filter function, or nil if there is none. Ties are broken at random.
+func findEnemy(m game.Mobile, fn func(game.Mobile) bool) game.Mobile {
+	var mobs []game.Mobile
+	var ls []uo.Location
+	for _, o := range game.MapOf(m).GetMobilesInRange(m.Location(), m.ViewRange()) {
+		if !fn(o) || !canAttack(m, o) {
+			continue
+		}
+		mobs = append(mobs, o)
+		ls = append(ls, o.Location())
+	}
+	i := pickNearest(m.Location(), ls, game.GetWorld().Random())
+	if i < 0 {
+		return nil
+	}
+	return mobs[i]
+}
+
+// pickNearest returns the index of the location nearest to the given location,
+// or -1 if the slice is empty. Ties are broken at random.
+func pickNearest(l uo.Location, ls []uo.Location, rng uo.RandomSource) int {
+	ret := -1
+	var best int16
+	n := 0
+	for i, ol := range ls {
+		d := l.XYDistance(ol)
+		if ret < 0 || d < best {
+			ret = i
+			best = d
+			n = 1
+			continue
+		}
+		if d == best {
+			// Every location at the same distance has the same chance of
+			// being selected
+			n++
+			if rng.Random(1, n) == 1 {
+				ret = i
+			}
+		}
+	}
+	return ret
+}
+
+// engage makes the mobile attack the other.
+func engage(m, o game.Mobile) {
+	if !m.IsInWarMode() {
+		m.SetWarMode(true)
+	}
+	m.SetCombatant(o)
+	m.SetRunning(true)
+}
+
+// disengage ends all combat of the mobile.
+func disengage(m game.Mobile) {
+	m.SetWarMode(false)
+	game.MapOf(m).ClearPath(m)
+	// Pets always run to keep up with their masters
+	m.SetRunning(m.ControlMaster() != nil)
+}
+
+// approach steps the mobile toward the location until it is within the given
+// range and returns the status of the path search.
+func approach(m game.Mobile, l uo.Location, within int16) game.PathStatus {
+	if !m.CanTakeStep() {
+		return game.PathPending
+	}
+	d, s := game.MapOf(m).PathStep(m, l, within)
+	if s != game.PathFound {
+		// Still searching, already there or there is no way to get there
+		return s
+	}
+	if !m.Step(d) {
+		// Something moved into our way, find a new path next time
+		game.MapOf(m).ClearPath(m)
+	}
+	return s
+}
+
+// retreat steps the mobile away from the location. If the way straight away is
+// blocked the directions to either side are tried. Returns true if the mobile
+// took a step or turned to face a new direction.
+func retreat(m game.Mobile, from uo.Location) bool {
+	if !m.CanTakeStep() {
+		return false
+	}
+	for _, d := range retreatDirections(from, m.Location(), m.Facing(),
+		game.GetWorld().Random()) {
+		if m.Step(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// retreatDirections returns the directions a mobile at location l facing the
+// given direction should try in order to get away from the from location.
+// Mobile.Step only turns the mobile when it is not already facing the
+// direction, so the list starts with the facing when that leads away. This
+// way the mobile keeps going the way it is facing and moves on to the next
+// direction when that is blocked.
+func retreatDirections(from, l uo.Location, facing uo.Direction, rng uo.RandomSource) []uo.Direction {
+	var d uo.Direction
+	if from.X == l.X && from.Y == l.Y {
+		d = uo.Direction(rng.Random(0, 7))
+	} else {
+		d = from.DirectionTo(l)
+	}
+	ds := []uo.Direction{d, d.Left(), d.Right(), d.Left().Left(), d.Right().Right()}
+	if rng.Random(0, 1) == 1 {
+		// Don't always favor the same side
+		ds[1], ds[2] = ds[2], ds[1]
+		ds[3], ds[4] = ds[4], ds[3]
+	}
+	facing = facing.Bound().StripRunningFlag()
+	for i, sd := range ds {
+		if sd == facing {
+			return append(ds[i:], ds[:i]...)
+		}
+	}
+	return ds
+}
+
+// switchToNearest switches the mobile's combatant to the nearest enemy
+// accepted by the filter function if that enemy is closer than the current
+// combatant. This keeps mobiles from chasing one enemy across the map while
+// being beaten on by another.
+func switchToNearest(m game.Mobile, c *combat, fn func(game.Mobile) bool) {
+	cm := m.Combatant()
+	if cm == nil || c.fleeing {
+		return
+	}
+	e := findEnemy(m, fn)
+	if e == nil || e.Serial() == cm.Serial() {
+		return
+	}
+	if m.Location().XYDistance(e.Location()) < m.Location().XYDistance(cm.Location()) {
+		m.SetCombatant(e)
+		game.MapOf(m).ClearPath(m)
+	}
+}
@@ -0,0 +1,425 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// sequenceRNG is a deterministic uo.RandomSource that returns the values in
+// order, bounded to the requested range.
+type sequenceRNG struct {
+	values []int
+	next   int
+}
+
+func (r *sequenceRNG) Random(min, max int) int {
+	v := r.values[r.next%len(r.values)]
+	r.next++
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func (r *sequenceRNG) RandomBool() bool {
+	return r.Random(0, 1) == 1
+}
+
+func TestShouldFlee(t *testing.T) {
+	var tests = []struct {
+		hp, max int
+		fleeing bool
+		want    bool
+	}{
+		{100, 100, false, false},
+		{20, 100, false, false},
+		{19, 100, false, true},
+		{19, 100, true, true},
+		{49, 100, true, true},
+		{50, 100, true, false},
+		{0, 0, false, false},
+	}
+	for _, test := range tests {
+		if got := shouldFlee(test.hp, test.max, test.fleeing); got != test.want {
+			t.Errorf("shouldFlee(%d, %d, %v) = %v", test.hp, test.max,
+				test.fleeing, got)
+		}
+	}
+}
+
+func TestPickNearest(t *testing.T) {
+	l := uo.Location{X: 100, Y: 100}
+	if i := pickNearest(l, nil, &sequenceRNG{values: []int{1}}); i != -1 {
+		t.Fatalf("empty slice returned %d", i)
+	}
+	ls := []uo.Location{
+		{X: 105, Y: 100},
+		{X: 102, Y: 101},
+		{X: 98, Y: 100},
+		{X: 110, Y: 110},
+	}
+	// The RNG only breaks the tie between the second and third locations
+	if i := pickNearest(l, ls, &sequenceRNG{values: []int{1}}); i != 2 {
+		t.Errorf("tie break 1 selected %d", i)
+	}
+	if i := pickNearest(l, ls, &sequenceRNG{values: []int{2}}); i != 1 {
+		t.Errorf("tie break 2 selected %d", i)
+	}
+	ls[3] = uo.Location{X: 100, Y: 99}
+	if i := pickNearest(l, ls, &sequenceRNG{values: []int{1}}); i != 3 {
+		t.Errorf("nearest location not selected, got %d", i)
+	}
+}
+
+func TestRetreatDirections(t *testing.T) {
+	from := uo.Location{X: 100, Y: 100}
+	l := uo.Location{X: 100, Y: 101}
+	// Running straight away to the south, favoring the left side
+	ds := retreatDirections(from, l, uo.DirectionNorth, &sequenceRNG{values: []int{0}})
+	want := []uo.Direction{
+		uo.DirectionSouth,
+		uo.DirectionSouthEast,
+		uo.DirectionSouthWest,
+		uo.DirectionEast,
+		uo.DirectionWest,
+	}
+	if len(ds) != len(want) {
+		t.Fatalf("got %d directions", len(ds))
+	}
+	for i := range want {
+		if ds[i] != want[i] {
+			t.Fatalf("direction %d is %d, expected %d", i, ds[i], want[i])
+		}
+	}
+	// Favoring the right side
+	ds = retreatDirections(from, l, uo.DirectionNorth, &sequenceRNG{values: []int{1}})
+	if ds[1] != uo.DirectionSouthWest || ds[3] != uo.DirectionWest {
+		t.Fatalf("right side not favored: %v", ds)
+	}
+	// Keep going the way we are facing
+	ds = retreatDirections(from, l, uo.DirectionSouthWest|uo.DirectionRunningFlag,
+		&sequenceRNG{values: []int{0}})
+	if ds[0] != uo.DirectionSouthWest || ds[len(ds)-1] != uo.DirectionSouthEast {
+		t.Fatalf("facing not preferred: %v", ds)
+	}
+	// Standing on top of the threat picks a random direction
+	ds = retreatDirections(from, from, uo.DirectionEast, &sequenceRNG{values: []int{6, 0}})
+	if ds[0] != uo.DirectionWest {
+		t.Fatalf("random direction %d", ds[0])
+	}
+}
+
+// testWorld is a game.World with a single empty map and a deterministic random
+// source. Calling any other method of the world panics.
+type testWorld struct {
+	game.World
+	m      *game.Map
+	rng    *sequenceRNG
+	serial uo.Serial
+}
+
+func (w *testWorld) Map(uo.Facet) *game.Map                               { return w.m }
+func (w *testWorld) Maps() []*game.Map                                    { return []*game.Map{w.m} }
+func (w *testWorld) Random() uo.RandomSource                              { return w.rng }
+func (w *testWorld) UpdateOPLInfo(game.Object)                            {}
+func (w *testWorld) Update(game.Object)                                   {}
+func (w *testWorld) Find(uo.Serial) game.Object                           { return nil }
+func (w *testWorld) Delete(game.Object)                                   {}
+func (w *testWorld) Accounts() []*game.Account                            { return nil }
+func (w *testWorld) BroadcastMessage(game.Object, string, ...interface{}) {}
+
+// newTestWorld registers and returns a new test world. The random source
+// returns the values in order. The smallest facet is used to keep memory use
+// down.
+func newTestWorld(values ...int) *testWorld {
+	w := &testWorld{
+		m:      game.NewMap(uo.FacetTokuno),
+		rng:    &sequenceRNG{values: values},
+		serial: 0x00000001,
+	}
+	game.RegisterWorld(w)
+	return w
+}
+
+// testMobile is a game.Mobile that records the steps it takes. Path finding is
+// not available as the map has no terrain, so tests must keep the mobiles
+// within attack range of their combatants. Calling any method not implemented
+// here panics.
+type testMobile struct {
+	game.Mobile
+	serial        uo.Serial
+	location      uo.Location
+	facet         uo.Facet
+	facing        uo.Direction
+	hp, maxHP     int
+	player        bool
+	warMode       bool
+	running       bool
+	combatant     game.Mobile
+	controlMaster game.Mobile
+	aiGoal        game.Object
+	weapon        game.Weapon
+	steps         []uo.Direction
+}
+
+// mobile places a new mobile with full hit points on the map.
+func (w *testWorld) mobile(x, y int16) *testMobile {
+	m := &testMobile{
+		serial:   w.serial,
+		location: uo.Location{X: x, Y: y},
+		hp:       100,
+		maxHP:    100,
+	}
+	w.serial++
+	w.m.ForceAddObject(m)
+	return m
+}
+
+func (m *testMobile) Serial() uo.Serial               { return m.serial }
+func (m *testMobile) Location() uo.Location           { return m.location }
+func (m *testMobile) Facet() uo.Facet                 { return m.facet }
+func (m *testMobile) SetFacet(f uo.Facet)             { m.facet = f }
+func (m *testMobile) SetParent(game.Object)           {}
+func (m *testMobile) SpawnerRegion() *game.Region     { return nil }
+func (m *testMobile) NetState() game.NetState         { return nil }
+func (m *testMobile) StandingOn() uo.CommonObject     { return uo.NewTile(0, nil) }
+func (m *testMobile) Removed() bool                   { return false }
+func (m *testMobile) IsDead() bool                    { return false }
+func (m *testMobile) HitPoints() int                  { return m.hp }
+func (m *testMobile) MaxHitPoints() int               { return m.maxHP }
+func (m *testMobile) IsPlayerCharacter() bool         { return m.player }
+func (m *testMobile) ViewRange() int16                { return uo.MaxViewRange }
+func (m *testMobile) CanSee(game.Object) bool         { return true }
+func (m *testMobile) HasLineOfSight(game.Object) bool { return true }
+func (m *testMobile) CanHarm(game.Mobile) bool        { return true }
+func (m *testMobile) IsInWarMode() bool               { return m.warMode }
+func (m *testMobile) SetWarMode(v bool)               { m.warMode = v }
+func (m *testMobile) IsRunning() bool                 { return m.running }
+func (m *testMobile) SetRunning(v bool)               { m.running = v }
+func (m *testMobile) Combatant() game.Mobile          { return m.combatant }
+func (m *testMobile) SetCombatant(c game.Mobile)      { m.combatant = c }
+func (m *testMobile) ControlMaster() game.Mobile      { return m.controlMaster }
+func (m *testMobile) AIGoal() game.Object             { return m.aiGoal }
+func (m *testMobile) Weapon() game.Weapon             { return m.weapon }
+func (m *testMobile) Facing() uo.Direction            { return m.facing }
+func (m *testMobile) CanTakeStep() bool               { return true }
+
+func (m *testMobile) Step(d uo.Direction) bool {
+	d = d.Bound().StripRunningFlag()
+	m.steps = append(m.steps, d)
+	if m.facing != d {
+		m.facing = d
+		return true
+	}
+	m.location = m.location.Forward(d)
+	return true
+}
+
+// testWeapon is a weapon with the given range.
+type testWeapon struct {
+	game.BaseWeapon
+	r int16
+}
+
+func (w *testWeapon) Range() int16 { return w.r }
+
+func TestAnimalPassiveUntilAttacked(t *testing.T) {
+	w := newTestWorld(0)
+	m := w.mobile(100, 100)
+	p := w.mobile(101, 100)
+	p.player = true
+	a := &animal{}
+	for tick := uo.Time(1); tick < uo.DurationSecond*30; tick++ {
+		a.Act(m, tick)
+		if m.combatant != nil || m.warMode {
+			t.Fatalf("animal engaged on tick %d", tick)
+		}
+	}
+	a.Target(m, uo.DurationSecond*30)
+	if m.combatant != nil {
+		t.Fatal("animal selected a target")
+	}
+	// Mobile.Damage sets the combatant when attacked
+	m.combatant = p
+	m.location = uo.Location{X: 100, Y: 100}
+	steps := len(m.steps)
+	a.Act(m, uo.DurationSecond*31)
+	if m.combatant != p || len(m.steps) != steps {
+		t.Error("animal did not stand and fight its attacker")
+	}
+}
+
+func TestGuardPicksOwnersAttacker(t *testing.T) {
+	w := newTestWorld(0)
+	owner := w.mobile(100, 100)
+	owner.player = true
+	m := w.mobile(101, 100)
+	m.controlMaster = owner
+	w.mobile(100, 102) // Bystander
+	attacker := w.mobile(98, 100)
+	attacker.combatant = owner
+	a := &guard{combat: combat{nextScan: 1}}
+	a.Act(m, 10)
+	if m.combatant != attacker || !m.warMode {
+		t.Fatalf("guard engaged %v, expected the owner's attacker", m.combatant)
+	}
+	// The owner's own combatant comes first
+	other := w.mobile(100, 105)
+	owner.combatant = other
+	m.combatant = nil
+	a = &guard{combat: combat{nextScan: 1}}
+	a.Act(m, 10)
+	if m.combatant != other {
+		t.Errorf("guard engaged %v, expected the owner's combatant", m.combatant)
+	}
+	// The AI goal is guarded when there is no control master
+	owner.combatant = nil
+	m.combatant = nil
+	m.controlMaster = nil
+	m.aiGoal = owner
+	a = &guard{combat: combat{nextScan: 1}}
+	a.Act(m, 10)
+	if m.combatant != attacker {
+		t.Errorf("guard engaged %v, expected the goal's attacker", m.combatant)
+	}
+}
+
+func TestRangedKeepsDistance(t *testing.T) {
+	w := newTestWorld(0)
+	m := w.mobile(100, 100)
+	m.facing = uo.DirectionWest
+	m.weapon = &testWeapon{r: 10}
+	e := w.mobile(101, 100)
+	e.player = true
+	m.combatant = e
+	a := &ranged{}
+	a.Act(m, 10)
+	if len(m.steps) != 1 || m.steps[0] != uo.DirectionWest {
+		t.Fatalf("ranged attacker took steps %v, expected to back away west", m.steps)
+	}
+	a.Act(m, 11)
+	a.Act(m, 12)
+	if m.location.X != 98 {
+		t.Errorf("ranged attacker at %v, expected to keep backing away", m.location)
+	}
+	// Far enough away to shoot
+	e.location = uo.Location{X: 93, Y: 100}
+	steps := len(m.steps)
+	a.Act(m, 13)
+	if len(m.steps) != steps {
+		t.Errorf("ranged attacker moved at range %d", m.location.XYDistance(e.location))
+	}
+	// Melee attackers stand their ground
+	mm := w.mobile(110, 110)
+	mm.combatant = w.mobile(111, 110)
+	(&aggressive{}).Act(mm, 15)
+	if len(mm.steps) != 0 {
+		t.Errorf("melee attacker took steps %v", mm.steps)
+	}
+}
+
+func TestFleeAtHitPointThreshold(t *testing.T) {
+	w := newTestWorld(0)
+	m := w.mobile(100, 100)
+	m.facing = uo.DirectionWest
+	e := w.mobile(101, 100)
+	e.player = true
+	m.combatant = e
+	a := &aggressive{}
+	var tests = []struct {
+		hp   int
+		flee bool
+	}{
+		{20, false},
+		{19, true},
+		{49, true},
+		{50, false},
+		{19, true},
+	}
+	for _, test := range tests {
+		m.hp = test.hp
+		m.location = uo.Location{X: 100, Y: 100}
+		steps := len(m.steps)
+		a.Act(m, 10)
+		fled := len(m.steps) > steps
+		if fled != test.flee || a.fleeing != test.flee {
+			t.Errorf("at %d hit points fled=%v fleeing=%v, expected %v", test.hp,
+				fled, a.fleeing, test.flee)
+		}
+		if fled && m.steps[len(m.steps)-1] != uo.DirectionWest {
+			t.Errorf("at %d hit points fled %d", test.hp, m.steps[len(m.steps)-1])
+		}
+	}
+}
+
+func TestFleeModel(t *testing.T) {
+	w := newTestWorld(0)
+	m := w.mobile(100, 100)
+	m.facing = uo.DirectionWest
+	p := w.mobile(103, 100)
+	p.player = true
+	w.mobile(99, 99) // Monsters are not a threat
+	a := &flee{combat: combat{nextScan: 1}}
+	a.Act(m, 10)
+	if a.threat != p || !m.running {
+		t.Fatal("timid mobile did not run from the player")
+	}
+	if len(m.steps) != 1 || m.steps[0] != uo.DirectionWest {
+		t.Fatalf("timid mobile took steps %v, expected to run west", m.steps)
+	}
+	// Out of range of the threat
+	p.location = uo.Location{X: 120, Y: 100}
+	a.Act(m, 11)
+	if a.threat != nil || m.running {
+		t.Error("timid mobile kept running from a player out of range")
+	}
+	// Attackers are threats regardless of who they are
+	o := w.mobile(101, 101)
+	m.combatant = o
+	a.Act(m, 12)
+	if a.threat != o {
+		t.Error("timid mobile did not run from its attacker")
+	}
+}
+
+func TestAggressiveTargetSelection(t *testing.T) {
+	w := newTestWorld(0)
+	m := w.mobile(100, 100)
+	w.mobile(101, 100) // Monsters leave each other alone
+	owner := w.mobile(110, 110)
+	owner.player = true
+	pet := w.mobile(100, 103)
+	pet.controlMaster = owner
+	p := w.mobile(105, 100)
+	p.player = true
+	a := &aggressive{combat: combat{nextScan: 1}}
+	a.Act(m, 10)
+	if m.combatant != pet || !m.warMode {
+		t.Fatalf("aggressive mobile engaged %v, expected the nearest pet", m.combatant)
+	}
+	// Periodic target selection switches to closer enemies
+	p.location = uo.Location{X: 101, Y: 101}
+	a.Target(m, 20)
+	if m.combatant != p {
+		t.Errorf("aggressive mobile kept %v, expected to switch to the player", m.combatant)
+	}
+	// But not while running away
+	pet.location = uo.Location{X: 100, Y: 99}
+	a.fleeing = true
+	a.Target(m, 30)
+	if m.combatant != p {
+		t.Error("fleeing aggressive mobile switched targets")
+	}
+	// Ranged mobiles select targets the same way
+	r := &ranged{}
+	p.location = uo.Location{X: 105, Y: 100}
+	r.Target(m, 40)
+	if m.combatant != pet {
+		t.Errorf("ranged mobile kept %v, expected to switch to the pet", m.combatant)
+	}
+}
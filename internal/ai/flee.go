@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("Flee", func() AIModel { return &flee{} })
+}
+
+// Distance at which timid mobiles notice and run from players
+const fleeRange int16 = 8
+
+// flee implements an AI for timid mobiles that wander about and run away from
+// players and their pets, as well as from anything that attacks them.
+type flee struct {
+	combat
+	wander walkRandom  // Wandering behavior when there is nothing to run from
+	threat game.Mobile // What we are running from
+}
+
+// Act implements the AIModel interface.
+func (a *flee) Act(m game.Mobile, t uo.Time) {
+	if c := m.Combatant(); c != nil {
+		a.threat = c
+	} else if a.scanDue(t) {
+		a.threat = findEnemy(m, isPlayerOwned)
+	}
+	if a.threat != nil && (a.threat.Removed() || a.threat.IsDead() ||
+		m.Location().XYDistance(a.threat.Location()) > fleeRange) {
+		a.threat = nil
+	}
+	if a.threat == nil {
+		if m.IsInWarMode() || m.IsRunning() {
+			disengage(m)
+		}
+		a.wander.Act(m, t)
+		return
+	}
+	m.SetRunning(true)
+	retreat(m, a.threat.Location())
+}
+
+// Target implements the AIModel interface.
+func (a *flee) Target(m game.Mobile, t uo.Time) {
+	// No target selection
+}
@@ -28,24 +28,7 @@ func (a *follow) Act(m game.Mobile, t uo.Time) {
 		return
 	}
 	// Step toward the target
-	if !m.CanTakeStep() {
-		return
-	}
-	d := m.Location().DirectionTo(ft.Location())
-	if m.Step(d) {
-		return
-	}
-	// Something is blocking us, try to step around
-	step := 1
-	if !d.IsDiagonal() {
-		step++
-	}
-	if m.Step(d.RotateCounterclockwise(step)) {
-		return
-	}
-	m.Step(d.RotateClockwise(step))
-	// If we couldn't get around the blocking object we're stuck
-	// TODO Path finding?
+	approach(m, ft.Location(), 2)
 }
 
 // Target implements the AIModel interface.
@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("Guard", func() AIModel { return &guard{} })
+}
+
+// guard implements an AI that follows its owner and attacks anything that
+// fights with the owner. The owner is the control master of the mobile, or
+// the AI goal when there is no control master.
+type guard struct {
+	combat
+}
+
+// owner returns the mobile being guarded or nil.
+func (a *guard) owner(m game.Mobile) game.Mobile {
+	if cm := m.ControlMaster(); cm != nil {
+		return cm
+	}
+	o, _ := m.AIGoal().(game.Mobile)
+	return o
+}
+
+// Act implements the AIModel interface.
+func (a *guard) Act(m game.Mobile, t uo.Time) {
+	if a.fight(m, 0) {
+		return
+	}
+	o := a.owner(m)
+	if o == nil || o.Removed() {
+		return
+	}
+	if a.scanDue(t) {
+		if e := a.findAttacker(m, o); e != nil {
+			engage(m, e)
+			return
+		}
+	}
+	if m.Location().XYDistance(o.Location()) > 2 {
+		approach(m, o.Location(), 2)
+	}
+}
+
+// findAttacker returns the mobile the owner is fighting, or the nearest
+// mobile that is fighting the owner, or nil if there is none.
+func (a *guard) findAttacker(m, o game.Mobile) game.Mobile {
+	if c := o.Combatant(); c != nil && c.Serial() != m.Serial() && canAttack(m, c) {
+		return c
+	}
+	return findEnemy(m, func(e game.Mobile) bool {
+		c := e.Combatant()
+		return c != nil && c.Serial() == o.Serial()
+	})
+}
+
+// Target implements the AIModel interface.
+func (a *guard) Target(m game.Mobile, t uo.Time) {
+	// No target selection
+}
@@ -29,7 +29,7 @@ func (a *player) Target(m game.Mobile, t uo.Time) {
 	// Music triggers
 	if t >= a.lastMusicSent+uo.DurationMinute*5 {
 		exp := ""
-		for _, r := range game.GetWorld().Map().RegionsAt(m.Location()) {
+		for _, r := range game.MapOf(m).RegionsAt(m.Location()) {
 			if len(r.Music) > 0 {
 				exp = r.Music
 			}
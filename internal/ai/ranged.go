@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("Ranged", func() AIModel { return &ranged{} })
+}
+
+// Distance ranged attackers try to keep between themselves and their
+// combatant
+const rangedKeepAway int16 = 3
+
+// ranged implements an AI like aggressive that attacks from a distance with a
+// ranged weapon, backing away from enemies that get too close.
+type ranged struct {
+	combat
+	wander walkRandom // Wandering behavior when there is nothing to fight
+}
+
+// Act implements the AIModel interface.
+func (a *ranged) Act(m game.Mobile, t uo.Time) {
+	if a.fight(m, rangedKeepAway) {
+		return
+	}
+	if a.scanDue(t) {
+		if e := findEnemy(m, isPlayerOwned); e != nil {
+			engage(m, e)
+			return
+		}
+	}
+	a.wander.Act(m, t)
+}
+
+// Target implements the AIModel interface.
+func (a *ranged) Target(m game.Mobile, t uo.Time) {
+	switchToNearest(m, &a.combat, isPlayerOwned)
+}
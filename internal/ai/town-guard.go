@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("TownGuard", func() AIModel { return &townGuard{} })
+}
+
+// townGuard implements an AI that hunts down criminals, murderers and monsters
+// within guarded regions. Town guards are removed by their despawn timer once
+// there is nothing left to fight.
+type townGuard struct {
+	combat
+}
+
+// Act implements the AIModel interface.
+func (a *townGuard) Act(m game.Mobile, t uo.Time) {
+	if game.IsGuardTarget(m.Combatant()) && a.fight(m, 0) {
+		return
+	}
+	if a.scanDue(t) {
+		if e := findEnemy(m, game.IsGuardTarget); e != nil {
+			game.GuardEngage(m, e)
+			return
+		}
+	}
+	if m.IsInWarMode() {
+		disengage(m)
+	}
+}
+
+// Target implements the AIModel interface.
+func (a *townGuard) Target(m game.Mobile, t uo.Time) {
+	// Targets are selected in Act
+}
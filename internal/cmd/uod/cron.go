@@ -13,7 +13,6 @@ import (
 	"time"
 
 	"github.com/qbradq/sharduo/data"
-	"github.com/qbradq/sharduo/internal/commands"
 	"github.com/qbradq/sharduo/internal/configuration"
 )
 
@@ -156,9 +155,14 @@ func (c *Cron) Main(wg *sync.WaitGroup) {
 				if j.day >= 0 && j.day != int(t.Weekday()) {
 					continue
 				}
-				// Execute the command
+				// Execute the command within the world goroutine
 				n.account = world.superUser
-				commands.Execute(n, j.command)
+				world.SendRequest(&CommandRequest{
+					BaseWorldRequest: BaseWorldRequest{
+						NetState: n,
+					},
+					Command: j.command,
+				})
 			}
 		case <-c.done:
 			return
@@ -3,14 +3,19 @@ package uod
 import (
 	"github.com/qbradq/sharduo/internal/events"
 	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/internal/spells"
 	"github.com/qbradq/sharduo/lib/clientpacket"
 	"github.com/qbradq/sharduo/lib/serverpacket"
+	"github.com/qbradq/sharduo/lib/uo"
 	"github.com/qbradq/sharduo/lib/util"
 )
 
 func init() {
+	giHandlers.Add(0x06, handlePartyCommand)
 	giHandlers.Add(0x13, handleContextMenuRequest)
 	giHandlers.Add(0x15, handleContextMenuSelection)
+	giHandlers.Add(0x1A, handleStatLockChange)
+	giHandlers.Add(0x1C, handleCastSpell)
 }
 
 var giHandlers = util.NewRegistry[byte, func(*NetState, clientpacket.GeneralInformationPacket)]("gi-packet-handlers")
@@ -35,6 +40,14 @@ func handleContextMenuRequest(n *NetState, cp clientpacket.GeneralInformationPac
 	}
 }
 
+func handleStatLockChange(n *NetState, cp clientpacket.GeneralInformationPacket) {
+	if n.m == nil {
+		return
+	}
+	p := cp.(*clientpacket.StatLockChange)
+	n.m.SetStatLock(p.Stat, p.Lock)
+}
+
 func handleContextMenuSelection(n *NetState, cp clientpacket.GeneralInformationPacket) {
 	p := cp.(*clientpacket.ContextMenuSelection)
 	o := world.Find(p.Serial)
@@ -47,3 +60,63 @@ func handleContextMenuSelection(n *NetState, cp clientpacket.GeneralInformationP
 	}
 	(*fn)(o, n.m, nil)
 }
+
+func handlePartyCommand(n *NetState, cp clientpacket.GeneralInformationPacket) {
+	if n.m == nil {
+		return
+	}
+	switch p := cp.(type) {
+	case *clientpacket.PartyAddMember:
+		if p.Serial != uo.SerialZero {
+			game.InviteToParty(n.m, game.Find[game.Mobile](p.Serial))
+			return
+		}
+		n.Speech(nil, "Who would you like to add to your party?")
+		n.TargetSendCursor(uo.TargetTypeObject, func(tr *clientpacket.TargetResponse) {
+			m := game.Find[game.Mobile](tr.TargetObject)
+			if m == nil {
+				n.Speech(nil, "The creature ignores your offer.")
+				return
+			}
+			game.InviteToParty(n.m, m)
+		})
+	case *clientpacket.PartyRemoveMember:
+		if p.Serial != uo.SerialZero {
+			game.RemoveFromParty(n.m, game.Find[game.Mobile](p.Serial))
+			return
+		}
+		n.Speech(nil, "Who would you like to remove from your party?")
+		n.TargetSendCursor(uo.TargetTypeObject, func(tr *clientpacket.TargetResponse) {
+			game.RemoveFromParty(n.m, game.Find[game.Mobile](tr.TargetObject))
+		})
+	case *clientpacket.PartyMessage:
+		party := game.PartyOf(n.m)
+		if party == nil {
+			n.Speech(nil, "You are not in a party.")
+			return
+		}
+		if p.To == uo.SerialZero {
+			party.Message(n.m, p.Text)
+		} else {
+			party.PrivateMessage(n.m, game.Find[game.Mobile](p.To), p.Text)
+		}
+	case *clientpacket.PartyCanLoot:
+		if party := game.PartyOf(n.m); party != nil {
+			party.SetCanLoot(n.m, p.CanLoot)
+		}
+	case *clientpacket.PartyInvitationReply:
+		if p.Accepted {
+			game.AcceptPartyInvitation(n.m, p.Leader)
+		} else {
+			game.DeclinePartyInvitation(n.m, p.Leader)
+		}
+	}
+}
+
+func handleCastSpell(n *NetState, cp clientpacket.GeneralInformationPacket) {
+	if n.m == nil {
+		return
+	}
+	p := cp.(*clientpacket.CastSpell)
+	spells.Cast(n, p.Spell)
+}
@@ -2,6 +2,8 @@ package uod
 
 import (
 	"bufio"
+	"crypto/rand"
+	"encoding/binary"
 	"log"
 	"net"
 	"runtime/debug"
@@ -30,6 +32,52 @@ type loginServerConnection struct {
 	deadline time.Time
 }
 
+// loginKey is the information the login server hands off to the game server
+// through the connection key.
+type loginKey struct {
+	// Client version negotiated with the login server
+	version uo.ClientVersion
+	// Deadline for the game server connection to use this key
+	deadline time.Time
+}
+
+// Outstanding login keys
+var loginKeys sync.Map
+
+// newLoginKey generates a new, unique connection key for the client version
+// and registers it for the game server.
+func newLoginKey(v uo.ClientVersion) uo.Serial {
+	var buf [4]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			log.Printf("error: generating login key: %s", err.Error())
+			continue
+		}
+		key := uo.Serial(binary.BigEndian.Uint32(buf[:]))
+		if key == uo.SerialZero {
+			continue
+		}
+		_, duplicate := loginKeys.LoadOrStore(key, &loginKey{
+			version:  v,
+			deadline: time.Now().Add(time.Minute * 5),
+		})
+		if !duplicate {
+			return key
+		}
+	}
+}
+
+// consumeLoginKey returns the client version registered for the connection
+// key by the login server and removes the key. uo.ClientVersionUnknown is
+// returned if the key is unknown.
+func consumeLoginKey(key uo.Serial) uo.ClientVersion {
+	v, found := loginKeys.LoadAndDelete(key)
+	if !found {
+		return uo.ClientVersionUnknown
+	}
+	return v.(*loginKey).version
+}
+
 // StopLoginService attempts to gracefully stop the login service.
 func StopLoginService() {
 	if loginServerListener != nil {
@@ -52,6 +100,12 @@ func cleanStaleLoginConnections(done chan bool) {
 				}
 				return true
 			})
+			loginKeys.Range(func(key, value interface{}) bool {
+				if t.After(value.(*loginKey).deadline) {
+					loginKeys.Delete(key)
+				}
+				return true
+			})
 		case <-done:
 			return
 		}
@@ -133,7 +187,8 @@ func handleLoginConnection(conn *net.TCPConn) {
 	conn.SetReadBuffer(64 * 1024)
 	conn.SetWriteBuffer(64 * 1024)
 	conn.SetDeadline(time.Now().Add(time.Minute * 5))
-	r := clientpacket.NewReader(conn)
+	br := bufio.NewReader(conn)
+	r := clientpacket.NewReader(br)
 
 	// Connection registration
 	c := &loginServerConnection{
@@ -146,27 +201,40 @@ func handleLoginConnection(conn *net.TCPConn) {
 	// Packet writer
 	pw := bufio.NewWriterSize(conn, 64*1024)
 
-	// Login seed packet
-	var vMajor, vMinor, vPatch, vExtra int = 7, 0, 15, 1
-	cp, err := r.ReadPacket()
+	// Login seed packet. Clients older than 6.0.5.0 send a bare four-byte seed
+	// and only report their version to the game server.
+	version := uo.ClientVersionUnknown
+	b, err := br.Peek(1)
 	if err != nil {
 		log.Println("warning: client disconnected waiting for login seed", err)
 		return
 	}
-	lsp, ok := cp.(*clientpacket.LoginSeed)
-	if !ok {
-		log.Println("warning: client sent wrong packet waiting for login seed", cp)
-		return
-	}
-	if lsp.VersionMajor != vMajor || lsp.VersionMinor != vMinor || lsp.VersionPatch != vPatch || lsp.VersionExtra != vExtra {
-		log.Printf("warning: bad client version %d.%d.%d.%d wanted %d.%d.%d.%d\n",
-			lsp.VersionMajor, lsp.VersionMinor, lsp.VersionPatch, lsp.VersionExtra,
-			vMajor, vMinor, vPatch, vExtra)
+	if b[0] == 0xEF {
+		cp, err := r.ReadPacket()
+		if err != nil {
+			log.Println("warning: client disconnected waiting for login seed", err)
+			return
+		}
+		lsp, ok := cp.(*clientpacket.LoginSeed)
+		if !ok {
+			log.Println("warning: client sent wrong packet waiting for login seed", cp)
+			return
+		}
+		version = uo.NewClientVersion(lsp.VersionMajor, lsp.VersionMinor,
+			lsp.VersionPatch, lsp.VersionExtra)
+		if !configuration.ClientVersions.Contains(version) {
+			log.Printf("warning: bad client version %s wanted %s\n",
+				version.String(), configuration.ClientVersions.String())
+			return
+		}
+	} else if err := r.ReadConnectionHeader(); err != nil {
+		log.Println("warning: client disconnected waiting for login seed", err)
 		return
 	}
+	r.Version = version
 
 	// Account login
-	cp, err = r.ReadPacket()
+	cp, err := r.ReadPacket()
 	if err != nil {
 		log.Println("warning: client disconnected waiting for account login", err)
 		return
@@ -185,6 +253,9 @@ func handleLoginConnection(conn *net.TCPConn) {
 	} else if account.Locked() || account.SuspendedUntil().After(time.Now()) {
 		reject = true
 		rejectReason = uo.LoginDeniedReasonAccountBlocked
+	} else if configuration.MaintenanceMode && !account.HasRole(game.RoleStaff) {
+		reject = true
+		rejectReason = uo.LoginDeniedReasonAccountBlocked
 	}
 	if reject {
 		log.Println("info: user login failed for", alp.Username)
@@ -234,7 +305,7 @@ func handleLoginConnection(conn *net.TCPConn) {
 	sp = &serverpacket.ConnectToGameServer{
 		IP:   net.ParseIP(configuration.GameServerPublicAddress),
 		Port: 7777,
-		Key:  0xBAADF00D,
+		Key:  newLoginKey(version),
 	}
 	sp.Write(pw)
 	if err := pw.Flush(); err != nil {
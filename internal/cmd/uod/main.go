@@ -2,6 +2,8 @@ package uod
 
 import (
 	"errors"
+	"flag"
+	"fmt"
 	"image"
 	"image/png"
 	"io"
@@ -10,16 +12,21 @@ import (
 	"os/signal"
 	"path"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/pkg/profile"
 	"github.com/qbradq/sharduo/internal/ai"
 	"github.com/qbradq/sharduo/internal/commands"
 	"github.com/qbradq/sharduo/internal/configuration"
+	"github.com/qbradq/sharduo/internal/crafting"
 	"github.com/qbradq/sharduo/internal/events"
 	"github.com/qbradq/sharduo/internal/game"
 	"github.com/qbradq/sharduo/internal/gumps"
+	"github.com/qbradq/sharduo/internal/harvest"
+	"github.com/qbradq/sharduo/internal/spells"
 	"github.com/qbradq/sharduo/lib/marshal"
 	"github.com/qbradq/sharduo/lib/template"
 	"github.com/qbradq/sharduo/lib/uo"
@@ -51,14 +58,19 @@ func trap() {
 		if sig == syscall.SIGINT || sig == syscall.SIGQUIT {
 			gracefulShutdown()
 		} else {
-			// Last-ditch save attempt
+			// Last-ditch save attempt, the world goroutine has to take it
 			log.Println("warning: attempting last-ditch save from signal handler")
-			wg, err := world.Marshal()
-			if err != nil {
-				log.Printf("error: last-ditch save from signal handler failed: %s", err.Error())
-			} else {
-				log.Printf("info: writing last-ditch save to disk")
-				wg.Wait()
+			world.WaitForSave()
+			saved := make(chan *sync.WaitGroup, 1)
+			world.SendRequest(&SaveRequest{Saved: saved})
+			select {
+			case wg := <-saved:
+				if wg != nil {
+					log.Printf("info: writing last-ditch save to disk")
+					wg.Wait()
+				}
+			case <-time.After(time.Minute):
+				log.Println("error: last-ditch save from signal handler timed out")
 			}
 			os.Exit(0)
 		}
@@ -82,6 +94,13 @@ func initialize() {
 	if err := configuration.Load(); err != nil {
 		log.Fatal(err)
 	}
+	parseFlags()
+	if configuration.MaintenanceMode {
+		log.Println("warning: maintenance mode, only staff accounts may log in")
+	}
+	if configuration.ReadOnly {
+		log.Println("warning: read-only mode, the world will not be saved")
+	}
 
 	// Load crontab
 	if err := InitializeCron(); err != nil {
@@ -91,57 +110,138 @@ func initialize() {
 	// Load client data files
 	log.Println("info: loading client files")
 	tiledatamul = file.NewTileDataMul(path.Join(configuration.ClientFilesDirectory, "tiledata.mul"))
-	mapmul := file.NewMapMulFromFile(path.Join(configuration.ClientFilesDirectory, "map0.mul"), tiledatamul)
-	staticsmul := file.NewStaticsMulFromFile(
-		path.Join(configuration.ClientFilesDirectory, "staidx0.mul"),
-		path.Join(configuration.ClientFilesDirectory, "statics0.mul"),
-		tiledatamul)
-	if tiledatamul == nil || mapmul == nil || staticsmul == nil {
-		if tiledatamul == nil {
-			log.Fatal("failed to load tiledata.mul")
-		}
-		if mapmul == nil {
-			log.Fatal("failed to load map0.mul")
-		}
-		if staticsmul == nil {
-			log.Fatal("failed to load statics0.mul")
-		}
+	if tiledatamul == nil {
+		log.Fatal("failed to load tiledata.mul")
 	}
 
-	log.Println("info: misc startup operations")
-	if configuration.GenerateDebugMaps {
-		log.Println("debug: generating debug map...")
-		rcolmul := file.NewRadarColMulFromFile(path.Join(configuration.ClientFilesDirectory, "radarcol.mul"))
-		if rcolmul == nil {
-			log.Fatal("failed to load radarcol.mul")
-		}
-		rcols := rcolmul.Colors()
-		mapimg := image.NewRGBA(image.Rect(0, 0, uo.MapWidth, uo.MapHeight))
-		// Lay down the tiles
-		for iy := 0; iy < uo.MapHeight; iy++ {
-			for ix := 0; ix < uo.MapWidth; ix++ {
-				t := mapmul.GetTile(ix, iy)
-				mapimg.Set(ix, iy, rcols[t.BaseGraphic()])
-			}
+	// RNG initialization
+	rng := util.NewRNG()
+
+	initializeObjects(rng)
+
+	// Command system initialization
+	commands.RegisterCallbacks(
+		GlobalChat,
+		func() error {
+			_, err := world.Marshal()
+			return err
+		},
+		Broadcast,
+		gracefulShutdown,
+		func() string { return world.LatestSavePath() },
+		func(fn func()) { world.SendRequest(&FunctionRequest{Function: fn}) })
+
+	// GUMP system initialization
+	gumps.InjectMethods(func(n game.NetState, s string) {
+		commands.Execute(n, s)
+	})
+
+	// Load starting cities
+	log.Println("info: loading starting cities")
+	errs := loadStartingCities()
+	for _, err := range errs {
+		log.Println(err)
+	}
+	if len(errs) > 0 {
+		log.Fatalf("error: %d errors while loading starting cities", len(errs))
+	}
+
+	// Load crafting recipes
+	log.Println("info: loading crafting recipes")
+	errs = crafting.Load(configuration.CraftingDirectory)
+	for _, err := range errs {
+		log.Println(err)
+	}
+	if len(errs) > 0 {
+		log.Fatalf("error: %d errors while loading crafting recipes", len(errs))
+	}
+
+	// Load harvest definitions
+	log.Println("info: loading harvest definitions")
+	errs = harvest.Load(configuration.HarvestDirectory)
+	for _, err := range errs {
+		log.Println(err)
+	}
+	if len(errs) > 0 {
+		log.Fatalf("error: %d errors while loading harvest definitions", len(errs))
+	}
+
+	// Initialize our data structures
+	log.Println("info: allocating world data structures")
+	saveBackend, err := marshal.NewSaveBackend(configuration.GameSaveType)
+	if err != nil {
+		log.Fatal(err)
+	}
+	world = NewWorld(configuration.SaveDirectory, saveBackend, rng, configuration.Facets)
+	for _, m := range world.Maps() {
+		log.Printf("info: populating map data structures for %s", m.Facet())
+		loadFacet(m)
+		m.SetPathfindingBudget(configuration.PathfindingBudget)
+	}
+	game.SetCaps(configuration.SkillCap, configuration.TotalSkillCap,
+		configuration.TotalStatCap)
+	game.SetNotorietyRules(configuration.PvP, configuration.MurderCountDecay)
+	game.SetGuardRules(configuration.GuardsInstantKill)
+	harvest.SetVeinSeed(uint32(configuration.VeinSeed))
+	game.RegisterWorld(world)
+
+	// Inject server-side dynamic objects
+	log.Println("info: creating dynamic map objects")
+
+	// Try to load the save asked for or the most recent save
+	if err := loadWorld(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Println("warning: no save files found, executing first-start routine")
+			firstStart()
+		} else {
+			log.Fatal("error: while trying to load data stores from main goroutine", err)
 		}
-		// Add statics
-		for _, static := range staticsmul.Statics() {
-			mapimg.Set(int(static.Location.X), int(static.Location.Y), rcols[static.BaseGraphic()+0x4000])
+	}
+}
+
+// parseFlags overrides the configuration with the command line options. This
+// must be called after the configuration is loaded.
+func parseFlags() {
+	fs := flag.NewFlagSet("uod", flag.ExitOnError)
+	save := fs.String("save", configuration.LoadSave,
+		"load the named save or archive instead of the most recent save")
+	maintenance := fs.Bool("maintenance", configuration.MaintenanceMode,
+		"only allow staff accounts to log in")
+	readOnly := fs.Bool("read-only", configuration.ReadOnly,
+		"never save the world")
+	fs.Parse(os.Args[1:])
+	configuration.LoadSave = *save
+	configuration.MaintenanceMode = *maintenance
+	configuration.ReadOnly = *readOnly
+}
+
+// loadWorld loads the save of a pending rollback, the save named by the
+// configuration or the most recent save, in that order. The rollback file is
+// kept until the rolled back world has been saved, otherwise a restart before
+// that would load the most recent save and undo the rollback.
+func loadWorld() error {
+	if d, err := os.ReadFile(configuration.RollbackFile); err == nil {
+		p := strings.TrimSpace(string(d))
+		log.Printf("warning: rolling back the world to %s", p)
+		if err := world.UnmarshalSave(p); err != nil {
+			return err
 		}
-		// Write out the map
-		mapimgf, err := os.Create("debug-map.png")
+		world.rollbackSave = p
+		return nil
+	}
+	if configuration.LoadSave != "" {
+		p, err := configuration.FindSave(configuration.LoadSave)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		if err := png.Encode(mapimgf, mapimg); err != nil {
-			log.Fatal(err)
-		}
-		mapimgf.Close()
+		return world.UnmarshalSave(p)
 	}
+	return world.Unmarshal()
+}
 
-	// RNG initialization
-	rng := util.NewRNG()
-
+// initializeObjects injects the callbacks the game package needs to create and
+// unmarshal objects and loads the object templates.
+func initializeObjects(rng uo.RandomSource) {
 	// AI system initialization
 	game.SetAIGetter(func(s string) game.AIModel {
 		return ai.GetModel(s)
@@ -155,18 +255,8 @@ func initialize() {
 		return events.GetEventHandlerIndex(which)
 	})
 
-	// Command system initialization
-	commands.RegisterCallbacks(
-		GlobalChat,
-		func() { world.Marshal() },
-		Broadcast,
-		gracefulShutdown,
-		func() string { return world.LatestSavePath() })
-
-	// GUMP system initialization
-	gumps.InjectMethods(func(n game.NetState, s string) {
-		commands.Execute(n, s)
-	})
+	// Magery initialization
+	game.SetDisturbHandler(spells.Disturb)
 
 	// Marshal system initialization
 	marshal.SetInsertFunction(func(i interface{}) {
@@ -198,26 +288,73 @@ func initialize() {
 	if len(errs) > 0 {
 		log.Fatalf("error: %d errors while loading object templates", len(errs))
 	}
+}
 
-	// Initialize our data structures
-	log.Println("info: allocating world data structures")
-	world = NewWorld(configuration.SaveDirectory, rng)
-	log.Println("info: populating map data structures")
-	world.Map().LoadFromMuls(mapmul, staticsmul)
-	game.RegisterWorld(world)
-
-	// Inject server-side dynamic objects
-	log.Println("info: creating dynamic map objects")
-
-	// Try to load the most recent save
-	if err := world.Unmarshal(); err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			log.Println("warning: no save files found, executing first-start routine")
-			firstStart()
-		} else {
-			log.Fatal("error: while trying to load data stores from main goroutine", err)
+// loadFacet loads the map and statics files of the map's facet into the map.
+func loadFacet(m *game.Map) {
+	f := m.Facet()
+	clientFile := func(format string) string {
+		return path.Join(configuration.ClientFilesDirectory, fmt.Sprintf(format, f))
+	}
+	// Modern clients ship the map in a UOP archive
+	mapName := fmt.Sprintf("map%dLegacyMUL.uop", f)
+	if _, err := os.Stat(clientFile("map%dLegacyMUL.uop")); err != nil {
+		mapName = fmt.Sprintf("map%d.mul", f)
+	}
+	staticsName := fmt.Sprintf("statics%d.mul", f)
+	mapmul := file.NewMapMulFromFile(path.Join(configuration.ClientFilesDirectory, mapName), f, tiledatamul)
+	if mapmul == nil {
+		log.Fatalf("failed to load %s", mapName)
+	}
+	staticsmul := file.NewStaticsMulFromFile(clientFile("staidx%d.mul"),
+		clientFile("statics%d.mul"), f, tiledatamul)
+	if staticsmul == nil {
+		log.Fatalf("failed to load %s", staticsName)
+	}
+	if configuration.UseMapDiffs {
+		n, err := mapmul.ApplyDiffs(clientFile("mapdifl%d.mul"),
+			clientFile("mapdif%d.mul"), tiledatamul)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Fatal(err)
+		}
+		log.Printf("info: applied %d map diff blocks to %s", n, f)
+		n, err = staticsmul.ApplyDiffs(clientFile("stadifl%d.mul"),
+			clientFile("stadifi%d.mul"), clientFile("stadif%d.mul"), tiledatamul)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Fatal(err)
 		}
+		log.Printf("info: applied %d statics diff blocks to %s", n, f)
 	}
+	if configuration.GenerateDebugMaps {
+		log.Printf("debug: generating debug map for %s...", f)
+		rcolmul := file.NewRadarColMulFromFile(path.Join(configuration.ClientFilesDirectory, "radarcol.mul"))
+		if rcolmul == nil {
+			log.Fatal("failed to load radarcol.mul")
+		}
+		rcols := rcolmul.Colors()
+		mapimg := image.NewRGBA(image.Rect(0, 0, f.Width(), f.Height()))
+		// Lay down the tiles
+		for iy := 0; iy < f.Height(); iy++ {
+			for ix := 0; ix < f.Width(); ix++ {
+				t := mapmul.GetTile(ix, iy)
+				mapimg.Set(ix, iy, rcols[t.BaseGraphic()])
+			}
+		}
+		// Add statics
+		for _, static := range staticsmul.Statics() {
+			mapimg.Set(int(static.Location.X), int(static.Location.Y), rcols[static.BaseGraphic()+0x4000])
+		}
+		// Write out the map
+		mapimgf, err := os.Create(fmt.Sprintf("debug-map%d.png", f))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := png.Encode(mapimgf, mapimg); err != nil {
+			log.Fatal(err)
+		}
+		mapimgf.Close()
+	}
+	m.LoadFromMuls(mapmul, staticsmul)
 }
 
 // Executed on the first start of a new server.
@@ -233,11 +370,14 @@ func startCommands() {
 	commands.Execute(n, "loadstatics")
 	commands.Execute(n, "loaddoors")
 	commands.Execute(n, "loadsigns")
+	commands.Execute(n, "loadmoongates")
 	commands.Execute(n, "respawn")
 	// This is really hacky, but the mobiles need to update what they are
 	// standing on in this step and what they are standing on won't be there if
 	// it's a dynamic static object created by [loadstatics and friends.
-	world.m.AfterUnmarshal()
+	for _, m := range world.Maps() {
+		m.AfterUnmarshal()
+	}
 }
 
 // Main is the entry point for uod.
@@ -269,9 +409,17 @@ func Main() {
 		ps.Stop()
 	}
 
-	// Always save right before we go down
+	// Always save right before we go down, after any save in progress, unless
+	// the world is about to be rolled back
+	world.WaitForSave()
+	if commands.RollbackPending() {
+		log.Println("info: not saving the world, a rollback is pending")
+		return
+	}
 	wg, err := world.Marshal()
-	if err != nil {
+	if errors.Is(err, ErrReadOnly) {
+		log.Println("info: not saving the world in read-only mode")
+	} else if err != nil {
 		log.Printf("error: saving world at end of main: %s", err.Error())
 	} else {
 		wg.Wait()
@@ -10,8 +10,10 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/qbradq/sharduo/internal/configuration"
 	"github.com/qbradq/sharduo/internal/game"
 	"github.com/qbradq/sharduo/internal/gumps"
 	"github.com/qbradq/sharduo/lib/clientpacket"
@@ -59,8 +61,12 @@ type NetState struct {
 	gumps map[uo.Serial]*gumpDescription
 	// When the next action can be taken
 	nextActionTime uo.Time
+	// When the next skill can be used
+	nextSkillTime uo.Time
 	// Function to trigger in response to a text GUMP reply (packet 0xAC)
 	textReplyFn func(string)
+	// Negotiated client version, accessed atomically
+	version uint32
 }
 
 // NewNetState constructs a new NetState object.
@@ -75,6 +81,17 @@ func NewNetState(conn *net.TCPConn) *NetState {
 	}
 }
 
+// Version returns the negotiated client version, or uo.ClientVersionUnknown if
+// the client has not reported it yet.
+func (n *NetState) Version() uo.ClientVersion {
+	return uo.ClientVersion(atomic.LoadUint32(&n.version))
+}
+
+// setVersion sets the negotiated client version.
+func (n *NetState) setVersion(v uo.ClientVersion) {
+	atomic.StoreUint32(&n.version, uint32(v))
+}
+
 // Mobile returns the mobile associated with the state if any.
 func (n *NetState) Mobile() game.Mobile { return n.m }
 
@@ -163,6 +180,16 @@ func (n *NetState) TakeAction() bool {
 	return true
 }
 
+// TakeSkillAction implements the game.NetState interface.
+func (n *NetState) TakeSkillAction(d uo.Time) bool {
+	now := world.Time()
+	if now < n.nextSkillTime {
+		return false
+	}
+	n.nextSkillTime = now + d
+	return true
+}
+
 // Service is the goroutine that services the netstate.
 func (n *NetState) Service() {
 	// When this goroutine ends so will the TCP connection.
@@ -206,32 +233,83 @@ func (n *NetState) Service() {
 		log.Println("error: failed to create new account, reason unknown")
 		return
 	}
+	if configuration.MaintenanceMode && !account.HasRole(game.RoleStaff) {
+		log.Printf("info: refused game login for %s in maintenance mode", account.Username())
+		return
+	}
 	n.account = account
+	n.setVersion(consumeLoginKey(gslp.Key))
+	r.Version = n.Version()
 
-	// Character list
-	n.Send(&serverpacket.CharacterList{
-		Names: []string{
-			account.Username(), "", "", "", "", "",
-		},
-	})
-
-	// Character login
-	cp, err = r.ReadPacket()
-	if err != nil {
-		log.Printf("error: %s", err.Error())
-		return
+	// Character list, the layout depends on the client version so legacy
+	// clients that did not report their version to the login server are asked
+	// for it first.
+	sentList := false
+	sendList := func() {
+		sentList = true
+		world.SendRequest(&CharacterListRequest{
+			BaseWorldRequest: BaseWorldRequest{
+				NetState: n,
+			},
+		})
 	}
-	_, ok = cp.(*clientpacket.CharacterLogin)
-	if !ok {
-		log.Println("error: unexpected packet during character login")
-		return
+	if r.Version == uo.ClientVersionUnknown {
+		n.Send(&serverpacket.Version{})
+	} else {
+		sendList()
 	}
 
-	world.SendRequest(&CharacterLoginRequest{
-		BaseWorldRequest: BaseWorldRequest{
+	// Character selection, creation and deletion
+	for loggedIn := false; !loggedIn; {
+		cp, err = r.ReadPacket()
+		if err != nil {
+			log.Printf("error: %s", err.Error())
+			return
+		}
+		bwr := BaseWorldRequest{
 			NetState: n,
-		},
-	})
+		}
+		switch p := cp.(type) {
+		case *clientpacket.Version:
+			if v, err := uo.ParseClientVersion(p.String); err == nil {
+				r.Version = v
+			}
+			world.SendRequest(&ClientPacketRequest{
+				BaseWorldRequest: bwr,
+				Packet:           p,
+			})
+			if !sentList {
+				sendList()
+			}
+		case *clientpacket.Ping:
+			world.SendRequest(&ClientPacketRequest{
+				BaseWorldRequest: bwr,
+				Packet:           p,
+			})
+		case *clientpacket.CharacterDelete:
+			world.SendRequest(&CharacterDeleteRequest{
+				BaseWorldRequest: bwr,
+				Packet:           p,
+			})
+		case *clientpacket.CharacterCreate:
+			world.SendRequest(&CharacterCreateRequest{
+				BaseWorldRequest: bwr,
+				Packet:           p,
+			})
+			loggedIn = true
+		case *clientpacket.CharacterLogin:
+			world.SendRequest(&CharacterLoginRequest{
+				BaseWorldRequest: bwr,
+				Slot:             p.Slot,
+			})
+			loggedIn = true
+		case *clientpacket.UnknownPacket:
+			log.Printf("error: unknown %s packet 0x%02X", p.PType, cp.ID())
+			return
+		default:
+			log.Printf("trace: ignoring packet 0x%02X during character login", cp.ID())
+		}
+	}
 
 	// Start the read loop
 	n.readLoop(r)
@@ -282,6 +360,12 @@ func (n *NetState) readLoop(r *clientpacket.Reader) {
 		n.deadline = world.Time() + uo.DurationMinute
 
 		cp := clientpacket.New(data)
+		if vp, ok := cp.(*clientpacket.Version); ok {
+			// The packet lengths we expect depend on the client version
+			if v, err := uo.ParseClientVersion(vp.String); err == nil {
+				r.Version = v
+			}
+		}
 		switch p := cp.(type) {
 		case nil:
 			log.Printf("error: unknown packet 0x%02X", data[0])
@@ -387,6 +471,7 @@ func (n *NetState) itemInfo(item game.Item) {
 			Location:      item.Location(),
 			Container:     container.Serial(),
 			Hue:           item.Hue(),
+			Version:       n.Version(),
 		})
 	} else {
 		// Item on ground
@@ -399,6 +484,7 @@ func (n *NetState) itemInfo(item game.Item) {
 			Layer:            layer,
 			Hue:              item.Hue(),
 			Movable:          item.Movable(),
+			Version:          n.Version(),
 		})
 	}
 	// OPL support
@@ -470,7 +556,7 @@ func (n *NetState) updateMobile(mobile game.Mobile) {
 			Gold:           mobile.Gold(),
 			ArmorRating:    0,
 			Weight:         int(mobile.Weight()),
-			StatsCap:       uo.StatsCapDefault,
+			StatsCap:       game.TotalStatCap(),
 			Followers:      0,
 			MaxFollowers:   uo.MaxFollowers,
 		})
@@ -527,8 +613,8 @@ func (n *NetState) SendObject(o game.Object) {
 func (n *NetState) MoveMobile(mob game.Mobile) {
 	noto := uo.NotorietyAttackable
 	if n.m != nil {
-		noto = mob.GetNotorietyFor(n.m)
-		if !mob.CanSee(n.m) {
+		noto = n.m.GetNotorietyFor(mob)
+		if !n.m.CanSee(mob) {
 			return
 		}
 	}
@@ -632,9 +718,12 @@ func (n *NetState) ContainerOpen(c game.Container) {
 	n.Send(&serverpacket.OpenContainerGump{
 		GumpSerial: c.Serial(),
 		Gump:       uo.GUMP(c.GumpGraphic()),
+		Version:    n.Version(),
 	})
 	if c.ItemCount() > 0 {
-		p := &serverpacket.Contents{}
+		p := &serverpacket.Contents{
+			Version: n.Version(),
+		}
 		p.Items = make([]serverpacket.ContentsItem, 0, c.ItemCount())
 		for _, item := range c.Contents() {
 			p.Items = append(p.Items, serverpacket.ContentsItem{
@@ -687,6 +776,7 @@ func (n *NetState) ContainerItemAdded(c game.Container, item game.Item) {
 		Location:      item.Location(),
 		Container:     c.Serial(),
 		Hue:           item.Hue(),
+		Version:       n.Version(),
 	})
 	_, oi := item.OPLPackets(item)
 	if oi != nil {
@@ -795,6 +885,65 @@ func (n *NetState) OpenPaperDoll(m game.Mobile) {
 	}
 }
 
+// OpenSpellbook implements the game.NetState interface.
+func (n *NetState) OpenSpellbook(sb *game.Spellbook) {
+	if sb == nil {
+		return
+	}
+	n.Send(&serverpacket.OpenContainerGump{
+		GumpSerial: sb.Serial(),
+		Gump:       0xFFFF, // Spellbook gumps are chosen by the client
+		Version:    n.Version(),
+	})
+	n.Send(&serverpacket.SpellbookContent{
+		Spellbook: sb.Serial(),
+		Graphic:   sb.BaseGraphic(),
+		Offset:    1,
+		Content:   sb.Content(),
+	})
+}
+
+// SecureTradeOpen implements the game.NetState interface.
+func (n *NetState) SecureTradeOpen(own, other game.Container, with game.Mobile) {
+	for _, c := range []game.Container{own, other} {
+		n.Send(&serverpacket.AddItemToContainer{
+			Item:          c.Serial(),
+			Graphic:       c.Graphic(),
+			GraphicOffset: c.GraphicOffset(),
+			Amount:        c.Amount(),
+			Location:      c.Location(),
+			Container:     c.Parent().Serial(),
+			Hue:           c.Hue(),
+			Version:       n.Version(),
+		})
+	}
+	n.Send(&serverpacket.SecureTrade{
+		Action:         uo.SecureTradeActionDisplay,
+		Mobile:         with.Serial(),
+		Container:      own.Serial(),
+		OtherContainer: other.Serial(),
+		Name:           with.DisplayName(),
+	})
+}
+
+// SecureTradeUpdate implements the game.NetState interface.
+func (n *NetState) SecureTradeUpdate(own game.Container, accepted, otherAccepted bool) {
+	n.Send(&serverpacket.SecureTrade{
+		Action:        uo.SecureTradeActionUpdate,
+		Container:     own.Serial(),
+		Accepted:      accepted,
+		OtherAccepted: otherAccepted,
+	})
+}
+
+// SecureTradeClose implements the game.NetState interface.
+func (n *NetState) SecureTradeClose(own game.Container) {
+	n.Send(&serverpacket.SecureTrade{
+		Action:    uo.SecureTradeActionClose,
+		Container: own.Serial(),
+	})
+}
+
 // TargetSendCursor implements the game.NetState interface
 func (n *NetState) TargetSendCursor(ttype uo.TargetType, fn func(*clientpacket.TargetResponse)) {
 	if n.m == nil {
@@ -837,6 +986,7 @@ func (n *NetState) UpdateSkill(which uo.Skill, lock uo.SkillLock, value int) {
 		Skill: which,
 		Value: value,
 		Lock:  lock,
+		Cap:   game.SkillCap(),
 	})
 }
 
@@ -847,6 +997,8 @@ func (n *NetState) SendAllSkills() {
 	}
 	n.Send(&serverpacket.FullSkillUpdate{
 		SkillValues: n.m.Skills(),
+		Locks:       n.m.SkillLocks(),
+		Cap:         game.SkillCap(),
 	})
 }
 
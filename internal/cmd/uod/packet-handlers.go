@@ -6,7 +6,10 @@ import (
 	"strings"
 
 	"github.com/qbradq/sharduo/internal/commands"
+	"github.com/qbradq/sharduo/internal/configuration"
 	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/internal/skills"
+	"github.com/qbradq/sharduo/internal/spells"
 	"github.com/qbradq/sharduo/lib/clientpacket"
 	"github.com/qbradq/sharduo/lib/serverpacket"
 	"github.com/qbradq/sharduo/lib/template"
@@ -18,6 +21,7 @@ import (
 // access to the memory model.
 func init() {
 	packetHandlers.Add(0x02, handleWalkRequest)
+	packetHandlers.Add(0x05, handleAttackRequest)
 	packetHandlers.Add(0x06, handleDoubleClickRequest)
 	packetHandlers.Add(0x07, handleLiftRequest)
 	packetHandlers.Add(0x08, handleDropRequest)
@@ -25,8 +29,11 @@ func init() {
 	packetHandlers.Add(0x12, handleMacroRequest)
 	packetHandlers.Add(0x13, handleWearItemRequest)
 	packetHandlers.Add(0x34, handleStatusRequest)
+	packetHandlers.Add(0x3A, handleSkillLockChange)
 	packetHandlers.Add(0x3B, handleBuyRequest)
 	packetHandlers.Add(0x6C, handleTargetResponse)
+	packetHandlers.Add(0x6F, handleSecureTrade)
+	packetHandlers.Add(0x72, handleWarMode)
 	packetHandlers.Add(0x73, handlePing)
 	packetHandlers.Add(0x75, handleRenameRequest)
 	packetHandlers.Add(0x98, handleNameRequest)
@@ -55,9 +62,13 @@ func handleSpeech(n *NetState, cp clientpacket.Packet) {
 	if len(p.Text) == 0 || n.m == nil {
 		return
 	}
+	if p.Text[0] != '[' {
+		// Talking gives away hidden mobiles
+		n.m.RevealingAction()
+	}
 	switch p.Type {
 	case uo.SpeechTypeWhisper:
-		world.Map().SendSpeech(n.m, uo.SpeechWhisperRange, p.Text)
+		game.MapOf(n.m).SendSpeech(n.m, uo.SpeechWhisperRange, p.Text)
 	case uo.SpeechTypeNormal:
 		if p.Text[0] == '[' {
 			// Server command request
@@ -68,21 +79,31 @@ func handleSpeech(n *NetState, cp clientpacket.Packet) {
 			commands.Execute(n, cl)
 		} else {
 			// Normal speech request
-			world.Map().SendSpeech(n.m, uo.SpeechNormalRange, p.Text)
+			game.MapOf(n.m).SendSpeech(n.m, uo.SpeechNormalRange, p.Text)
 		}
 	case uo.SpeechTypeEmote:
-		world.Map().SendSpeech(n.m, uo.SpeechEmoteRange, p.Text)
+		game.MapOf(n.m).SendSpeech(n.m, uo.SpeechEmoteRange, p.Text)
 	case uo.SpeechTypeYell:
-		world.Map().SendSpeech(n.m, uo.SpeechYellRange, p.Text)
+		game.MapOf(n.m).SendSpeech(n.m, uo.SpeechYellRange, p.Text)
+	}
+	if p.Text[0] != '[' && strings.Contains(strings.ToLower(p.Text), "guards") {
+		game.CallGuards(n.m)
 	}
 }
 
 func handleVersion(n *NetState, cp clientpacket.Packet) {
 	p := cp.(*clientpacket.Version)
-	if p.String != "7.0.15.1" {
+	v, err := uo.ParseClientVersion(p.String)
+	if err != nil || !configuration.ClientVersions.Contains(v) {
 		log.Printf("error: bad client version %s: disconnecting client", p.String)
 		n.Disconnect()
+		return
 	}
+	if n.Version() != uo.ClientVersionUnknown && n.Version() != v {
+		log.Printf("warning: client reported version %s to the game server but %s to the login server",
+			v.String(), n.Version().String())
+	}
+	n.setVersion(v)
 }
 
 func handleTargetResponse(n *NetState, cp clientpacket.Packet) {
@@ -90,6 +111,59 @@ func handleTargetResponse(n *NetState, cp clientpacket.Packet) {
 	n.TargetResponse(p)
 }
 
+func handleAttackRequest(n *NetState, cp clientpacket.Packet) {
+	if n.m == nil {
+		return
+	}
+	p := cp.(*clientpacket.AttackRequest)
+	m := game.Find[game.Mobile](p.Defender)
+	if m == nil || m.Serial() == n.m.Serial() || !n.m.CanSee(m) ||
+		n.m.IsDead() || m.IsDead() {
+		n.m.SetCombatant(nil)
+		return
+	}
+	if !n.m.CanHarm(m) {
+		n.Speech(nil, "You cannot harm them.")
+		n.m.SetCombatant(nil)
+		return
+	}
+	n.m.SetWarMode(true)
+	n.m.SetCombatant(m)
+}
+
+func handleWarMode(n *NetState, cp clientpacket.Packet) {
+	if n.m == nil {
+		return
+	}
+	p := cp.(*clientpacket.WarMode)
+	n.m.SetWarMode(p.WarMode)
+}
+
+func handleSkillLockChange(n *NetState, cp clientpacket.Packet) {
+	if n.m == nil {
+		return
+	}
+	p := cp.(*clientpacket.SkillLockChange)
+	n.m.SetSkillLock(p.Skill, p.Lock)
+}
+
+func handleSecureTrade(n *NetState, cp clientpacket.Packet) {
+	if n.m == nil {
+		return
+	}
+	p := cp.(*clientpacket.SecureTrade)
+	c := game.Find[*game.SecureTradeContainer](p.Container)
+	if c == nil || c.Trade() == nil || c.Trade().Container(n.m) != c {
+		return
+	}
+	switch p.Action {
+	case uo.SecureTradeActionClose:
+		c.Trade().Cancel()
+	case uo.SecureTradeActionUpdate:
+		c.Trade().Accept(n.m, p.Accepted)
+	}
+}
+
 func handleStatusRequest(n *NetState, cp clientpacket.Packet) {
 	p := cp.(*clientpacket.PlayerStatusRequest)
 	switch p.StatusRequestType {
@@ -107,10 +181,10 @@ func handleWalkRequest(n *NetState, cp clientpacket.Packet) {
 		return
 	}
 	n.m.SetRunning(p.IsRunning)
-	if world.Map().MoveMobile(n.m, p.Direction) {
+	if game.MapOf(n.m).MoveMobile(n.m, p.Direction) {
 		n.Send(&serverpacket.MoveAcknowledge{
 			Sequence:  p.Sequence,
-			Notoriety: uo.NotorietyInnocent,
+			Notoriety: n.m.GetNotorietyFor(n.m),
 		})
 	} else {
 		n.Send(&serverpacket.MoveReject{
@@ -175,7 +249,7 @@ func handleViewRange(n *NetState, cp clientpacket.Packet) {
 		return
 	}
 	p := cp.(*clientpacket.ViewRange)
-	world.Map().UpdateViewRangeForMobile(n.m, p.Range)
+	game.MapOf(n.m).UpdateViewRangeForMobile(n.m, p.Range)
 	n.Send(&serverpacket.ClientViewRange{
 		Range: byte(n.m.ViewRange()),
 	})
@@ -195,6 +269,11 @@ func handleLiftRequest(n *NetState, cp clientpacket.Packet) {
 		n.DropReject(uo.MoveItemRejectReasonAlreadyHoldingItem)
 		return
 	}
+	if n.m.IsDead() {
+		// Ghosts can't pick things up
+		n.DropReject(uo.MoveItemRejectReasonCannotLift)
+		return
+	}
 	p := cp.(*clientpacket.LiftRequest)
 	o := world.Find(p.Item)
 	if o == nil {
@@ -223,9 +302,18 @@ func handleLiftRequest(n *NetState, cp clientpacket.Packet) {
 		n.DropReject(uo.MoveItemRejectReasonOutOfSight)
 		return
 	}
+	// Taking items from an innocent's corpse is a crime
+	criminal := false
+	if c := game.CorpseOf(item); c != nil && c.LootingIsCriminal(n.m) {
+		criminal = true
+	}
 	item.Split(p.Amount)
 	if !n.m.PickUp(item) {
 		n.DropReject(uo.MoveItemRejectReasonUnspecified)
+		return
+	}
+	if criminal {
+		n.m.CriminalAction()
 	}
 	// Play lift sound
 	n.Sound(item.LiftSound(), game.RootParent(item).Location())
@@ -262,7 +350,7 @@ func handleDropRequest(n *NetState, cp clientpacket.Packet) {
 			n.DropReject(uo.MoveItemRejectReasonOutOfSight)
 			return
 		}
-		if !world.Map().SetNewParent(item, nil) {
+		if !game.MapOf(n.m).SetNewParent(item, nil) {
 			n.m.DropItemInCursor()
 			n.DropReject(uo.MoveItemRejectReasonUnspecified)
 			return
@@ -272,7 +360,7 @@ func handleDropRequest(n *NetState, cp clientpacket.Packet) {
 			// Play drop sound
 			n.Sound(item.DropSoundOverride(uo.SoundDefaultDrop), newLocation)
 			// Distribute drag packets
-			for _, mob := range world.Map().GetNetStatesInRange(n.m.Location(), uo.MaxViewRange) {
+			for _, mob := range game.MapOf(n.m).GetNetStatesInRange(n.m.Location(), uo.MaxViewRange) {
 				mob.NetState().DragItem(item, n.m, n.m.Location(), nil, newLocation)
 			}
 		}
@@ -301,7 +389,7 @@ func handleDropRequest(n *NetState, cp clientpacket.Packet) {
 			n.Sound(item.DropSoundOverride(uo.SoundDefaultDrop), newLocation)
 		}
 		// Distribute drag packets
-		for _, mob := range world.Map().GetNetStatesInRange(n.m.Location(), uo.MaxViewRange) {
+		for _, mob := range game.MapOf(n.m).GetNetStatesInRange(n.m.Location(), uo.MaxViewRange) {
 			mob.NetState().DragItem(item, n.m, n.m.Location(), nil, newLocation)
 		}
 	}
@@ -401,7 +489,7 @@ func handleBuyRequest(n *NetState, cp clientpacket.Packet) {
 			m.SetControlMaster(n.m)
 			m.SetAI("Follow")
 			m.SetAIGoal(n.m)
-			world.Map().AddObject(m)
+			game.MapOf(n.m).AddObject(m)
 		} else {
 			ni := template.Create[game.Item](tn)
 			if ni == nil {
@@ -415,7 +503,7 @@ func handleBuyRequest(n *NetState, cp clientpacket.Packet) {
 			}
 		}
 	}
-	world.Map().SendCliloc(vendor, uo.SpeechNormalRange, 1080013, strconv.Itoa(total)) // The total of thy purchase is ~1_VAL~ gold,
+	game.MapOf(vendor).SendCliloc(vendor, uo.SpeechNormalRange, 1080013, strconv.Itoa(total)) // The total of thy purchase is ~1_VAL~ gold,
 	n.Sound(0x02E6, n.m.Location())
 }
 
@@ -523,6 +611,18 @@ func handleMacroRequest(n *NetState, cp clientpacket.Packet) {
 	}
 	p := cp.(*clientpacket.MacroRequest)
 	switch p.MacroType {
+	case uo.MacroTypeSkill:
+		skills.Use(n, uo.Skill(p.Offset))
+	case uo.MacroTypeSpell:
+		spells.Cast(n, uo.Spell(p.Offset))
+	case uo.MacroTypeOpenSpellbook:
+		// Only the magery book is supported
+		if p.Offset != 1 {
+			break
+		}
+		if sb := game.FirstSpellbook(n.m); sb != nil {
+			n.OpenSpellbook(sb)
+		}
 	case uo.MacroTypeOpenDoor:
 		l := n.m.Location().Forward(n.m.Facing())
 		b := uo.Bounds{
@@ -533,7 +633,7 @@ func handleMacroRequest(n *NetState, cp clientpacket.Packet) {
 			H: 1,
 			D: int16(uo.PlayerHeight),
 		}
-		doors := world.Map().ItemBaseQuery("BaseDoor", b)
+		doors := game.MapOf(n.m).ItemBaseQuery("BaseDoor", b)
 		if len(doors) > 0 {
 			if !n.TakeAction() {
 				n.Cliloc(nil, 500119) // You must wait to perform another action.
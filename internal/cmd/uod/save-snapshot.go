@@ -0,0 +1,233 @@
+package uod
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/marshal"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// saveRecord is a top-level object of a save snapshot along with the segment
+// it is written to.
+type saveRecord struct {
+	// The map or deep storage object
+	o game.Object
+	// Segment the object is written to
+	s *marshal.TagFileSegment
+	// If true the object has been written to the segment
+	saved bool
+}
+
+// marshal writes the object of the record to its segment.
+func (r *saveRecord) marshal() {
+	r.s.PutObject(r.o)
+	r.s.IncrementRecordCount()
+	r.saved = true
+}
+
+// saveSnapshot is a world save in progress. Everything but the top-level
+// objects of the maps and deep storage is written while the world is paused to
+// capture the snapshot. The top-level objects are written by worker goroutines
+// while the world goroutine is idle. Every change to saved state within the
+// game package goes through the save barrier first, see game.SetSaveBarrier,
+// where the world goroutine writes the object tree if it has not been written
+// yet. This way every object is saved exactly once and as it was when the
+// snapshot was captured.
+type saveSnapshot struct {
+	// The tag file being written
+	tf *marshal.TagFile
+	// Path of the save file
+	path string
+	// WaitGroup that is done when the save file has been written
+	wg *sync.WaitGroup
+	// Time the capture started
+	start time.Time
+	// How long the world was paused to capture the snapshot
+	pause time.Duration
+	// How long the world goroutine spent writing objects through the save
+	// barrier, waiting for the workers to stop and giving them time slices
+	stall time.Duration
+	// All records by the serial of the object
+	records map[uo.Serial]*saveRecord
+	// Records of each segment, every queue is only ever worked by one worker
+	queues [][]*saveRecord
+	// Index of the next record of each queue
+	next []int
+	// Number of worker goroutines
+	workers int
+	// Set to request the workers to stop
+	stop atomic.Bool
+	// Done when all workers have stopped
+	running sync.WaitGroup
+}
+
+// captureSnapshot captures a snapshot of the world to be saved to the path.
+// The world must not change until this returns.
+func (w *World) captureSnapshot(p string) *saveSnapshot {
+	nThreads := runtime.NumCPU()
+	if nThreads > maxSaveThreads {
+		nThreads = maxSaveThreads
+	}
+	ss := &saveSnapshot{
+		path:    p,
+		wg:      &sync.WaitGroup{},
+		start:   time.Now(),
+		records: make(map[uo.Serial]*saveRecord),
+		workers: nThreads,
+	}
+	ss.wg.Add(1)
+	wg := &sync.WaitGroup{}
+	ss.tf, _ = marshal.NewTagFile(nil)
+	segment := func(id marshal.Segment) *marshal.TagFileSegment {
+		s := ss.tf.Segment(id)
+		s.Grow(w.snapshotSizes[id])
+		return s
+	}
+	queue := func(s *marshal.TagFileSegment, objs []game.Object) {
+		q := make([]*saveRecord, 0, len(objs))
+		for _, o := range objs {
+			r := &saveRecord{
+				o: o,
+				s: s,
+			}
+			ss.records[o.Serial()] = r
+			q = append(q, r)
+		}
+		ss.queues = append(ss.queues, q)
+	}
+	// Global data
+	s := segment(marshal.SegmentWorld)
+	s.PutInt(uint32(nThreads))
+	s.PutLong(uint64(w.time))
+	// Timers
+	s = segment(marshal.SegmentTimers)
+	wg.Add(1)
+	go func(s *marshal.TagFileSegment) {
+		defer wg.Done()
+		game.MarshalTimers(s)
+	}(s)
+	// Accounting data
+	s = segment(marshal.SegmentAccounts)
+	wg.Add(1)
+	go func(s *marshal.TagFileSegment) {
+		defer wg.Done()
+		w.alock.Lock()
+		defer w.alock.Unlock()
+		for _, a := range w.accounts {
+			a.Marshal(s)
+			s.IncrementRecordCount()
+		}
+	}(s)
+	// Facets
+	s = segment(marshal.SegmentFacets)
+	for _, m := range w.Maps() {
+		s.PutByte(byte(m.Facet()))
+		s.IncrementRecordCount()
+	}
+	for _, m := range w.Maps() {
+		deep, banks, objects := facetSegments(m.Facet(), nThreads)
+		// Harvest banks
+		wg.Add(1)
+		go m.Marshal(wg, segment(banks))
+		// Top-level objects are only collected here
+		for i := 0; i < nThreads; i++ {
+			queue(segment(objects+marshal.Segment(i)), m.ObjectsToSave(i, nThreads))
+		}
+		queue(segment(deep), m.StoredObjects())
+	}
+	wg.Wait()
+	ss.next = make([]int, len(ss.queues))
+	ss.pause = time.Since(ss.start)
+	return ss
+}
+
+// preserve writes the object tree rooted at the object if it has not been
+// written yet. This is the save barrier and must only be called while the
+// workers are stopped. Records are looked up by serial as the barrier may be
+// called with an embedded base object, such as the *game.BaseItem of a
+// spellbook.
+func (ss *saveSnapshot) preserve(o game.Object) {
+	r, found := ss.records[o.Serial()]
+	if !found || r.saved {
+		return
+	}
+	start := time.Now()
+	r.marshal()
+	ss.stall += time.Since(start)
+}
+
+// startWorkers starts the workers writing the remaining records.
+func (ss *saveSnapshot) startWorkers() {
+	ss.stop.Store(false)
+	ss.running.Add(ss.workers)
+	for i := 0; i < ss.workers; i++ {
+		go ss.work(i)
+	}
+}
+
+// work writes the records of every queue belonging to the worker until all
+// are written or the worker is asked to stop.
+func (ss *saveSnapshot) work(worker int) {
+	defer ss.running.Done()
+	for qi := worker; qi < len(ss.queues); qi += ss.workers {
+		q := ss.queues[qi]
+		for ; ss.next[qi] < len(q); ss.next[qi]++ {
+			if ss.stop.Load() {
+				return
+			}
+			if r := q[ss.next[qi]]; !r.saved {
+				r.marshal()
+			}
+		}
+	}
+}
+
+// runFor lets the workers write records for up to the duration, then stops
+// them. The caller is blocked the whole time.
+func (ss *saveSnapshot) runFor(d time.Duration) {
+	start := time.Now()
+	ss.startWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		ss.running.Wait()
+		close(stopped)
+	}()
+	timer := time.NewTimer(d)
+	select {
+	case <-stopped:
+	case <-timer.C:
+	}
+	timer.Stop()
+	ss.stop.Store(true)
+	<-stopped
+	ss.stall += time.Since(start)
+}
+
+// stopWorkers stops all workers and returns once they have stopped.
+func (ss *saveSnapshot) stopWorkers() {
+	start := time.Now()
+	ss.stop.Store(true)
+	ss.running.Wait()
+	ss.stall += time.Since(start)
+}
+
+// done returns true if all records have been written. The workers must be
+// stopped.
+func (ss *saveSnapshot) done() bool {
+	for qi, q := range ss.queues {
+		if ss.next[qi] < len(q) {
+			return false
+		}
+	}
+	return true
+}
+
+// finish writes all remaining records and returns once they are written.
+func (ss *saveSnapshot) finish() {
+	ss.startWorkers()
+	ss.running.Wait()
+}
@@ -0,0 +1,434 @@
+package uod
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qbradq/sharduo/internal/configuration"
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/marshal"
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+// Facet Bob was logged out on by save name
+var corpusFacets = map[string]uo.Facet{
+	"baseline.sav.gz":  uo.FacetFelucca,
+	"facets.sav.gz":    uo.FacetTrammel,
+	"checksums.sav.gz": uo.FacetTrammel,
+	"directory.sav":    uo.FacetTrammel,
+	"manifest.sav":     uo.FacetTrammel,
+}
+
+// goldIn returns the total amount of gold coins directly within the object.
+func goldIn(o game.Object) int {
+	ret := 0
+	held, _ := childrenOf(o)
+	for _, c := range held {
+		if i, ok := c.(game.Item); ok && c.TemplateName() == "GoldCoin" {
+			ret += i.Amount()
+		}
+	}
+	return ret
+}
+
+// checkCorpusWorld checks the world against the contents of every save of the
+// corpus.
+func checkCorpusWorld(t *testing.T, f uo.Facet) {
+	a, err := findAccount("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.ComparePasswordHash(game.HashPassword("password")) {
+		t.Error("account root password hash changed")
+	}
+	if len(a.Characters()) != 1 {
+		t.Fatalf("account root has %d characters, expected 1", len(a.Characters()))
+	}
+	bob, ok := world.Find(a.Characters()[0]).(game.Mobile)
+	if !ok {
+		t.Fatal("character of account root not found")
+	}
+	if bob.Name() != "Bob" || !bob.IsPlayerCharacter() {
+		t.Errorf("character %s is not the player Bob", bob.Name())
+	}
+	if bob.Location() != (uo.Location{X: 1000, Y: 1000, Z: 0}) || bob.Facet() != f {
+		t.Errorf("Bob is at %v on %s, expected 1000,1000,0 on %s", bob.Location(),
+			bob.Facet(), f)
+	}
+	stored := false
+	for _, o := range world.Map(f).StoredObjects() {
+		stored = stored || o == bob
+	}
+	if !stored {
+		t.Errorf("Bob is not in the deep storage of %s", f)
+	}
+	bp := bob.EquipmentInSlot(uo.LayerBackpack)
+	if bp == nil {
+		t.Fatal("Bob has no backpack")
+	}
+	if n := goldIn(bp); n != 1000 {
+		t.Errorf("Bob has %d gold, expected 1000", n)
+	}
+	var found bool
+	for _, o := range world.Map(uo.FacetFelucca).Objects() {
+		if o.TemplateName() != "Backpack" {
+			continue
+		}
+		found = true
+		if o.Location() != (uo.Location{X: 1001, Y: 1001, Z: 0}) {
+			t.Errorf("backpack at %v, expected 1001,1001,0", o.Location())
+		}
+		if n := goldIn(o); n != 50 {
+			t.Errorf("backpack on the map holds %d gold, expected 50", n)
+		}
+	}
+	if !found {
+		t.Error("backpack on the map not found")
+	}
+}
+
+// loadCorpusSave loads the save into the world.
+func loadCorpusSave(t *testing.T, p string) {
+	if _, err := loadSave(p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupCorpusTest changes into a new temporary directory holding the client
+// files savetool needs and returns the absolute paths of the corpus and the
+// temporary directory.
+func setupCorpusTest(t *testing.T) (corpus, dir string) {
+	corpus, err := filepath.Abs(filepath.Join("testdata", "saves"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The configuration and client files are loaded from the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir = t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	if err := os.Mkdir("client", 0777); err != nil {
+		t.Fatal(err)
+	}
+	// Empty tile data with 512 land tile groups and 2048 static tile groups
+	if err := os.WriteFile(filepath.Join("client", "tiledata.mul"),
+		make([]byte, 512*964+2048*1316), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return corpus, dir
+}
+
+// TestSaveCorpus loads every save of the corpus in testdata/saves, then
+// writes it with the current save format and loads it again.
+func TestSaveCorpus(t *testing.T) {
+	corpus, dir := setupCorpusTest(t)
+	saves := marshal.ListSaves(corpus)
+	if len(saves) != len(corpusFacets) {
+		t.Fatalf("found %d saves in the corpus, expected %d", len(saves), len(corpusFacets))
+	}
+	saveToolInitialize()
+	for _, p := range saves {
+		name := filepath.Base(p)
+		t.Run(name, func(t *testing.T) {
+			loadCorpusSave(t, p)
+			checkCorpusWorld(t, corpusFacets[name])
+			// Save through the snapshot pipeline of the world
+			world.savePath = filepath.Join(dir, name)
+			wg, err := world.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wg.Wait()
+			world.WaitForSave()
+			out := world.LatestSavePath()
+			if marshal.SaveBackendFor(out) != marshal.SaveBackendFor(p) {
+				t.Fatalf("rewritten save %s not written by the backend of %s", out, p)
+			}
+			tf, err := marshal.ReadSave(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tf.Close()
+			if tf.FormatVersion() != marshal.FormatVersion {
+				t.Errorf("rewritten save has format version %d, expected %d",
+					tf.FormatVersion(), marshal.FormatVersion)
+			}
+			loadCorpusSave(t, out)
+			checkCorpusWorld(t, corpusFacets[name])
+		})
+	}
+}
+
+// moveCorpusGold removes the coins of the backpack on the map and moves the
+// coins of Bob into it.
+func moveCorpusGold() error {
+	a, err := findAccount("root")
+	if err != nil {
+		return err
+	}
+	bob, ok := world.Find(a.Characters()[0]).(game.Mobile)
+	if !ok {
+		return errors.New("character of account root not found")
+	}
+	bp, ok := bob.EquipmentInSlot(uo.LayerBackpack).(game.Container)
+	if !ok {
+		return errors.New("Bob has no backpack")
+	}
+	var ground game.Container
+	for _, o := range world.Map(uo.FacetFelucca).Objects() {
+		if c, ok := o.(game.Container); ok && o.TemplateName() == "Backpack" {
+			ground = c
+		}
+	}
+	if ground == nil {
+		return errors.New("backpack on the map not found")
+	}
+	for _, i := range ground.Contents() {
+		game.Remove(i)
+	}
+	for _, i := range bp.Contents() {
+		if i.TemplateName() == "GoldCoin" {
+			bp.ForceRemoveObject(i)
+			ground.ForceAddObject(i)
+		}
+	}
+	if n := goldIn(ground); n != 1000 {
+		return fmt.Errorf("backpack on the map holds %d gold after the move, expected 1000", n)
+	}
+	return nil
+}
+
+// TestSaveSnapshot changes the world after a save snapshot was captured and
+// checks that the save holds the world as it was at the time of the capture.
+func TestSaveSnapshot(t *testing.T) {
+	corpus, dir := setupCorpusTest(t)
+	saveToolInitialize()
+	for _, workers := range []bool{false, true} {
+		t.Run(fmt.Sprintf("workers=%v", workers), func(t *testing.T) {
+			loadCorpusSave(t, filepath.Join(corpus, "baseline.sav.gz"))
+			bob := corpusBob(t)
+			magery := bob.Skill(uo.SkillMagery)
+			ss := world.captureSnapshot("")
+			game.SetSaveBarrier(ss.preserve)
+			if workers {
+				// Some objects are written before the change
+				ss.startWorkers()
+				ss.stopWorkers()
+			}
+			// Changes that do not move anything are held back as well
+			bob.SetName("Robert")
+			bob.SetSkill(uo.SkillMagery, magery+100)
+			err := moveCorpusGold()
+			game.SetSaveBarrier(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ss.finish()
+			p := filepath.Join(dir, fmt.Sprintf("snapshot-%v.sav.gz", workers))
+			if err := marshal.SaveBackendFor(p).Write(p, ss.tf, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			ss.tf.Close()
+			loadCorpusSave(t, p)
+			checkCorpusWorld(t, uo.FacetFelucca)
+			if v := corpusBob(t).Skill(uo.SkillMagery); v != magery {
+				t.Errorf("Bob has %d magery, expected %d", v, magery)
+			}
+		})
+	}
+}
+
+// testRequest executes a function within the world goroutine.
+type testRequest struct {
+	BaseWorldRequest
+	fn func() error
+}
+
+// Execute implements the WorldRequest interface
+func (r *testRequest) Execute() error { return r.fn() }
+
+// TestSaveBetweenTicks saves the world while Main is running and changes the
+// world before the save is written.
+func TestSaveBetweenTicks(t *testing.T) {
+	corpus, dir := setupCorpusTest(t)
+	saveToolInitialize()
+	loadCorpusSave(t, filepath.Join(corpus, "baseline.sav.gz"))
+	world.savePath = filepath.Join(dir, "saves")
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go world.Main(wg)
+	saved := make(chan *sync.WaitGroup, 1)
+	moved := make(chan error, 1)
+	world.SendRequest(&SaveRequest{Saved: saved})
+	world.SendRequest(&testRequest{fn: func() error {
+		err := moveCorpusGold()
+		moved <- err
+		return err
+	}})
+	swg := <-saved
+	if err := <-moved; err != nil {
+		t.Error(err)
+	}
+	world.Stop()
+	wg.Wait()
+	if swg == nil {
+		t.Fatal("save not started")
+	}
+	swg.Wait()
+	loadCorpusSave(t, world.LatestSavePath())
+	checkCorpusWorld(t, uo.FacetFelucca)
+}
+
+// TestSaveBusyQueue saves the world while the request queue is never empty.
+func TestSaveBusyQueue(t *testing.T) {
+	corpus, dir := setupCorpusTest(t)
+	saveToolInitialize()
+	loadCorpusSave(t, filepath.Join(corpus, "baseline.sav.gz"))
+	world.savePath = filepath.Join(dir, "saves")
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go world.Main(wg)
+	// Requests that queue another request until the test is done
+	var busy atomic.Bool
+	busy.Store(true)
+	var spin func() error
+	spin = func() error {
+		time.Sleep(time.Millisecond)
+		if busy.Load() {
+			world.SendRequest(&testRequest{fn: spin})
+		}
+		return nil
+	}
+	saved := make(chan *sync.WaitGroup, 1)
+	world.SendRequest(&SaveRequest{Saved: saved})
+	for i := 0; i < 2; i++ {
+		world.SendRequest(&testRequest{fn: spin})
+	}
+	swg := <-saved
+	if swg != nil {
+		completed := make(chan struct{})
+		go func() {
+			swg.Wait()
+			close(completed)
+		}()
+		select {
+		case <-completed:
+		case <-time.After(time.Minute):
+			t.Error("save not completed while the request queue was busy")
+		}
+	}
+	busy.Store(false)
+	// Requests queued before this one may still be queuing more
+	drained := make(chan struct{})
+	world.SendRequest(&testRequest{fn: func() error {
+		close(drained)
+		return nil
+	}})
+	<-drained
+	world.Stop()
+	wg.Wait()
+	if swg == nil {
+		t.Fatal("save not started")
+	}
+	swg.Wait()
+	loadCorpusSave(t, world.LatestSavePath())
+	checkCorpusWorld(t, uo.FacetFelucca)
+}
+
+// TestSaveSecureTrade saves the world while Bob is offering all of his gold in
+// a secure trade.
+func TestSaveSecureTrade(t *testing.T) {
+	corpus, dir := setupCorpusTest(t)
+	saveToolInitialize()
+	loadCorpusSave(t, filepath.Join(corpus, "baseline.sav.gz"))
+	world.savePath = filepath.Join(dir, "saves")
+	bob := corpusBob(t)
+	bp, ok := bob.EquipmentInSlot(uo.LayerBackpack).(game.Container)
+	if !ok {
+		t.Fatal("Bob has no backpack")
+	}
+	st := game.OpenSecureTrade(bob, template.Create[game.Mobile]("Banker"))
+	if st == nil {
+		t.Fatal("secure trade not opened")
+	}
+	for _, i := range bp.Contents() {
+		if i.TemplateName() == "GoldCoin" {
+			bp.ForceRemoveObject(i)
+			st.Container(bob).ForceAddObject(i)
+		}
+	}
+	if n := goldIn(st.Container(bob)); n != 1000 {
+		t.Fatalf("Bob is offering %d gold, expected 1000", n)
+	}
+	wg, err := world.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	// Regular saves leave open trades alone
+	if n := goldIn(st.Container(bob)); n != 1000 {
+		t.Errorf("Bob is offering %d gold after the save, expected 1000", n)
+	}
+	// Open trades outlive the world they were opened in
+	st.Cancel()
+	loadCorpusSave(t, world.LatestSavePath())
+	checkCorpusWorld(t, uo.FacetFelucca)
+}
+
+// TestRollbackFile checks that the rollback file is kept until the rolled back
+// world has been saved.
+func TestRollbackFile(t *testing.T) {
+	corpus, dir := setupCorpusTest(t)
+	saveToolInitialize()
+	baseline := filepath.Join(corpus, "baseline.sav.gz")
+	if err := os.MkdirAll(configuration.SaveDirectory, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configuration.RollbackFile, []byte(baseline+"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	world = nil
+	runtime.GC()
+	world = NewWorld(filepath.Join(dir, configuration.SaveDirectory),
+		marshal.SaveBackendFor(baseline), util.NewRNG(), configuration.Facets)
+	game.RegisterWorld(world)
+	if err := loadWorld(); err != nil {
+		t.Fatal(err)
+	}
+	checkCorpusWorld(t, uo.FacetFelucca)
+	if _, err := os.Stat(configuration.RollbackFile); err != nil {
+		t.Fatalf("rollback file removed before the world was saved: %s", err)
+	}
+	wg, err := world.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if _, err := os.Stat(configuration.RollbackFile); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("rollback file not removed after the world was saved: %v", err)
+	}
+	// A rollback scheduled since the rolled back world was loaded is kept
+	other := []byte(world.LatestSavePath() + "\n")
+	if err := os.WriteFile(configuration.RollbackFile, other, 0666); err != nil {
+		t.Fatal(err)
+	}
+	world.rollbackSave = baseline
+	world.clearRollback()
+	if d, err := os.ReadFile(configuration.RollbackFile); err != nil || string(d) != string(other) {
+		t.Errorf("rollback file of a scheduled rollback changed: %q %v", d, err)
+	}
+}
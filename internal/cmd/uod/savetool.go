@@ -0,0 +1,589 @@
+package uod
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/qbradq/sharduo/internal/configuration"
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/marshal"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/uo/file"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+// saveToolUsage is printed when savetool is called incorrectly.
+const saveToolUsage = `Usage: savetool command [options] save...
+
+Inspects and repairs save files while the server is stopped. Execute this
+program inside the server directory, the configuration, templates and client
+files are loaded just like uod does. Options must precede the save paths.
+
+Commands:
+    list SAVE                 List the segments and record counts of the save
+    dump [options] SAVE       Dump objects as JSON
+        -serial SERIAL        Only dump the object with this serial
+        -template NAME        Only dump objects created from this template
+    validate SAVE             Validate parent / child links and serial uniqueness
+    diff OLD NEW              List the objects added, removed or changed
+    password [options] SAVE   Reset the password of an account
+        -account NAME         Name of the account
+        -password PASSWORD    The new password
+        -out PATH             Path of the new save to write
+    unlock [options] SAVE     Unlock an account and lift any suspension
+        -account NAME         Name of the account
+        -out PATH             Path of the new save to write
+    move [options] SAVE       Move a stuck character or other top-level object
+        -serial SERIAL        Serial of the object to move
+        -location X,Y,Z       The new location
+        -facet NAME           The new facet, defaults to the current facet
+        -out PATH             Path of the new save to write
+`
+
+// saveToolOutput is where savetool commands print their results.
+var saveToolOutput io.Writer = os.Stdout
+
+// saveToolCommands are all of the savetool commands by name.
+var saveToolCommands = map[string]func(*flag.FlagSet, []string) error{
+	"list":     saveToolList,
+	"dump":     saveToolDump,
+	"validate": saveToolValidate,
+	"diff":     saveToolDiff,
+	"password": saveToolPassword,
+	"unlock":   saveToolUnlock,
+	"move":     saveToolMove,
+}
+
+// SaveTool is the entry point for savetool.
+func SaveTool() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, saveToolUsage)
+		os.Exit(2)
+	}
+	fn, found := saveToolCommands[os.Args[1]]
+	if !found {
+		fmt.Fprint(os.Stderr, saveToolUsage)
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, saveToolUsage) }
+	if err := fn(fs, os.Args[2:]); err != nil {
+		log.Fatalf("error: %s", err)
+	}
+}
+
+// saveToolArgs parses the options and returns exactly n save paths.
+func saveToolArgs(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != n {
+		return nil, fmt.Errorf("%s expects %d save paths, got %d", fs.Name(), n, fs.NArg())
+	}
+	return fs.Args(), nil
+}
+
+// saveToolInitialize loads everything needed to unmarshal objects.
+func saveToolInitialize() {
+	if err := configuration.Load(); err != nil {
+		log.Fatal(err)
+	}
+	tiledatamul = file.NewTileDataMul(path.Join(configuration.ClientFilesDirectory, "tiledata.mul"))
+	if tiledatamul == nil {
+		log.Fatal("failed to load tiledata.mul")
+	}
+	initializeObjects(util.NewRNG())
+}
+
+// loadSave replaces the world with the contents of the save at the path. The
+// serials used by more than one object are returned.
+func loadSave(p string) (duplicates []uo.Serial, err error) {
+	tf, err := marshal.ReadSave(p)
+	if err != nil {
+		return nil, err
+	}
+	defer tf.Close()
+	// Release the current world first, worlds are too large to keep two around
+	world = nil
+	runtime.GC()
+	world = NewWorld(path.Dir(p), marshal.SaveBackendFor(p), util.NewRNG(),
+		configuration.Facets)
+	game.RegisterWorld(world)
+	marshal.SetInsertFunction(func(i interface{}) {
+		o, ok := i.(game.Object)
+		if !ok {
+			return
+		}
+		if world.Find(o.Serial()) != nil {
+			duplicates = append(duplicates, o.Serial())
+		}
+		world.Insert(o)
+	})
+	// Damaged object data panics deep inside of the unmarshal code
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("unable to unmarshal objects: %v", p)
+		}
+	}()
+	return duplicates, world.unmarshalTagFile(tf)
+}
+
+// parseSerial parses a serial given in decimal or hexadecimal notation.
+func parseSerial(s string) (uo.Serial, error) {
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return uo.SerialZero, fmt.Errorf("bad serial %s", s)
+	}
+	return uo.Serial(v), nil
+}
+
+// serialOf returns the serial of the object as a string, "none" for nil or
+// "void" for game.TheVoid.
+func serialOf(o game.Object) string {
+	if o == nil {
+		return "none"
+	}
+	if o == game.TheVoid {
+		return "void"
+	}
+	return o.Serial().String()
+}
+
+// childrenOf returns the objects held by the object. Stabled pets are returned
+// separately as they do not need to be parented to the object.
+func childrenOf(o game.Object) (held, stabled []game.Object) {
+	if c, ok := o.(game.Container); ok {
+		for _, i := range c.Contents() {
+			held = append(held, i)
+		}
+	}
+	if mi, ok := o.(*game.MountItem); ok && mi.Mount() != nil {
+		held = append(held, mi.Mount())
+	}
+	if m, ok := o.(game.Mobile); ok {
+		m.MapEquipment(func(w game.Wearable) error {
+			held = append(held, w)
+			return nil
+		})
+		for _, p := range m.StabledPets() {
+			stabled = append(stabled, p)
+		}
+	}
+	return held, stabled
+}
+
+// walkWorld calls the function for every object reachable from the maps and
+// deep storage with the object holding it. The holder is nil for objects on
+// the map and game.TheVoid for objects in deep storage.
+func walkWorld(fn func(o, holder game.Object, stabled bool)) {
+	var walk func(o, holder game.Object, stabled bool)
+	walk = func(o, holder game.Object, stabled bool) {
+		fn(o, holder, stabled)
+		held, pets := childrenOf(o)
+		for _, c := range held {
+			walk(c, o, false)
+		}
+		for _, c := range pets {
+			walk(c, o, true)
+		}
+	}
+	for _, m := range world.Maps() {
+		for _, o := range m.Objects() {
+			walk(o, nil, false)
+		}
+		for _, o := range m.StoredObjects() {
+			walk(o, game.TheVoid, false)
+		}
+	}
+}
+
+// saveObject is the JSON representation of an object within a save.
+type saveObject struct {
+	Serial   string
+	Type     marshal.ObjectType
+	Template string
+	Name     string
+	Hue      uo.Hue
+	Location uo.Location
+	Facet    string
+	Parent   string
+	Graphic  uo.Graphic `json:",omitempty"`
+	Amount   int        `json:",omitempty"`
+	Body     uo.Body    `json:",omitempty"`
+	Player   bool       `json:",omitempty"`
+	Children []string   `json:",omitempty"`
+}
+
+// newSaveObject returns the JSON representation of the object.
+func newSaveObject(o game.Object) *saveObject {
+	r := &saveObject{
+		Serial:   o.Serial().String(),
+		Template: o.TemplateName(),
+		Name:     o.Name(),
+		Hue:      o.Hue(),
+		Location: o.Location(),
+		Facet:    o.Facet().String(),
+		Parent:   serialOf(o.Parent()),
+	}
+	if m, ok := o.(marshal.Marshaler); ok {
+		r.Type = m.ObjectType()
+	}
+	if i, ok := o.(game.Item); ok {
+		r.Graphic = i.Graphic()
+		r.Amount = i.Amount()
+	}
+	if m, ok := o.(game.Mobile); ok {
+		r.Body = m.Body()
+		r.Player = m.IsPlayerCharacter()
+	}
+	held, stabled := childrenOf(o)
+	for _, c := range append(held, stabled...) {
+		r.Children = append(r.Children, c.Serial().String())
+	}
+	// Equipment is held in a map so the order is not stable
+	sort.Strings(r.Children)
+	return r
+}
+
+// diff returns a description of every field that differs from the other
+// object.
+func (r *saveObject) diff(other *saveObject) []string {
+	var ret []string
+	a := reflect.ValueOf(*r)
+	b := reflect.ValueOf(*other)
+	for i := 0; i < a.NumField(); i++ {
+		av := fmt.Sprint(a.Field(i).Interface())
+		bv := fmt.Sprint(b.Field(i).Interface())
+		if av != bv {
+			ret = append(ret, fmt.Sprintf("%s: %s -> %s", a.Type().Field(i).Name, av, bv))
+		}
+	}
+	return ret
+}
+
+// saveObjects returns the JSON representations of all objects in the world by
+// serial.
+func saveObjects() map[uo.Serial]*saveObject {
+	ret := make(map[uo.Serial]*saveObject)
+	walkWorld(func(o, holder game.Object, stabled bool) {
+		ret[o.Serial()] = newSaveObject(o)
+	})
+	return ret
+}
+
+// sortedSerials returns the keys of the map in ascending order.
+func sortedSerials(m map[uo.Serial]*saveObject) []uo.Serial {
+	ret := make([]uo.Serial, 0, len(m))
+	for s := range m {
+		ret = append(ret, s)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret
+}
+
+// saveToolList lists the segments of the save.
+func saveToolList(fs *flag.FlagSet, args []string) error {
+	paths, err := saveToolArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	tf, err := marshal.ReadSave(paths[0])
+	if err != nil {
+		return err
+	}
+	defer tf.Close()
+	fmt.Fprintf(saveToolOutput, "Save format version %d\n", tf.FormatVersion())
+	fmt.Fprintf(saveToolOutput, "%-4s %-22s %10s %12s\n", "ID", "Segment", "Records", "Bytes")
+	for _, s := range tf.Segments() {
+		fmt.Fprintf(saveToolOutput, "0x%02X %-22s %10d %12d\n", byte(s.ID()), s.ID(), s.RecordCount(),
+			s.Length())
+	}
+	return nil
+}
+
+// saveToolDump dumps objects of the save as JSON.
+func saveToolDump(fs *flag.FlagSet, args []string) error {
+	serial := fs.String("serial", "", "")
+	tn := fs.String("template", "", "")
+	paths, err := saveToolArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	want := uo.SerialZero
+	if *serial != "" {
+		if want, err = parseSerial(*serial); err != nil {
+			return err
+		}
+	}
+	saveToolInitialize()
+	if _, err := loadSave(paths[0]); err != nil {
+		return err
+	}
+	objs := saveObjects()
+	ret := []*saveObject{}
+	for _, s := range sortedSerials(objs) {
+		o := objs[s]
+		if want != uo.SerialZero && s != want {
+			continue
+		}
+		if *tn != "" && o.Template != *tn {
+			continue
+		}
+		ret = append(ret, o)
+	}
+	e := json.NewEncoder(saveToolOutput)
+	e.SetIndent("", "  ")
+	return e.Encode(ret)
+}
+
+// saveToolValidate validates the parent / child links and serial uniqueness of
+// all objects in the save.
+func saveToolValidate(fs *flag.FlagSet, args []string) error {
+	paths, err := saveToolArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	saveToolInitialize()
+	duplicates, err := loadSave(paths[0])
+	if err != nil {
+		return err
+	}
+	problems := 0
+	report := func(format string, args ...any) {
+		fmt.Fprintf(saveToolOutput, format+"\n", args...)
+		problems++
+	}
+	for _, s := range duplicates {
+		report("%s: serial used by more than one object", s)
+	}
+	held := map[uo.Serial]int{}
+	walkWorld(func(o, holder game.Object, stabled bool) {
+		held[o.Serial()]++
+		if stabled {
+			return
+		}
+		p := o.Parent()
+		switch {
+		case holder == nil && p != nil:
+			report("%s: on the map but parented to %s", o.Serial(), serialOf(p))
+		case holder == game.TheVoid && p != nil && p != game.TheVoid:
+			report("%s: in deep storage but parented to %s", o.Serial(), serialOf(p))
+		case holder != nil && holder != game.TheVoid &&
+			(p == nil || p.Serial() != holder.Serial()):
+			report("%s: held by %s but parented to %s", o.Serial(), holder.Serial(),
+				serialOf(p))
+		}
+	})
+	for s, n := range held {
+		if n > 1 {
+			report("%s: held in %d places", s, n)
+		}
+	}
+	for s := range world.ods.Data() {
+		if held[s] == 0 {
+			report("%s: not held by the map, deep storage or any object", s)
+		}
+	}
+	for _, a := range world.Accounts() {
+		for _, s := range a.Characters() {
+			if _, ok := world.Find(s).(game.Mobile); !ok {
+				report("account %s: character %s is not a mobile in the save", a.Username(), s)
+			}
+		}
+	}
+	fmt.Fprintf(saveToolOutput, "%d objects, %d accounts, %d problems\n",
+		len(world.ods.Data()), len(world.accounts), problems)
+	if problems > 0 {
+		return fmt.Errorf("%d problems found in %s", problems, paths[0])
+	}
+	return nil
+}
+
+// saveToolDiff lists the differences between the objects of two saves.
+func saveToolDiff(fs *flag.FlagSet, args []string) error {
+	paths, err := saveToolArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	saveToolInitialize()
+	if _, err := loadSave(paths[0]); err != nil {
+		return err
+	}
+	before := saveObjects()
+	if _, err := loadSave(paths[1]); err != nil {
+		return err
+	}
+	after := saveObjects()
+	for _, s := range sortedSerials(before) {
+		o := before[s]
+		if _, found := after[s]; !found {
+			fmt.Fprintf(saveToolOutput, "- %s %s %q\n", o.Serial, o.Template, o.Name)
+		}
+	}
+	for _, s := range sortedSerials(after) {
+		o := after[s]
+		old, found := before[s]
+		if !found {
+			fmt.Fprintf(saveToolOutput, "+ %s %s %q\n", o.Serial, o.Template, o.Name)
+			continue
+		}
+		for _, d := range old.diff(o) {
+			fmt.Fprintf(saveToolOutput, "~ %s %s\n", o.Serial, d)
+		}
+	}
+	return nil
+}
+
+// editSave loads the save, applies the edit and writes the result to the new
+// save at out.
+func editSave(p, out string, edit func() error) error {
+	if out == "" {
+		return errors.New("the -out option is required")
+	}
+	b := marshal.SaveBackendFor(out)
+	if b == nil {
+		return fmt.Errorf("%s must end with a save extension like .sav.gz", out)
+	}
+	if _, err := os.Stat(out); err == nil {
+		return ErrSaveFileExists
+	}
+	saveToolInitialize()
+	if _, err := loadSave(p); err != nil {
+		return err
+	}
+	if err := edit(); err != nil {
+		return err
+	}
+	tf := world.marshalTagFile()
+	defer tf.Close()
+	if err := b.Write(out, tf, time.Now()); err != nil {
+		return err
+	}
+	log.Printf("info: wrote %s", out)
+	return nil
+}
+
+// findAccount returns the named account of the world.
+func findAccount(name string) (*game.Account, error) {
+	a, found := world.accounts[name]
+	if !found {
+		return nil, fmt.Errorf("account %s not found", name)
+	}
+	return a, nil
+}
+
+// saveToolPassword resets the password of an account.
+func saveToolPassword(fs *flag.FlagSet, args []string) error {
+	account := fs.String("account", "", "")
+	password := fs.String("password", "", "")
+	out := fs.String("out", "", "")
+	paths, err := saveToolArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *password == "" {
+		return errors.New("the -password option is required")
+	}
+	return editSave(paths[0], *out, func() error {
+		a, err := findAccount(*account)
+		if err != nil {
+			return err
+		}
+		a.UpdatePasswordByHash(game.HashPassword(*password))
+		log.Printf("info: reset the password of account %s", a.Username())
+		return nil
+	})
+}
+
+// saveToolUnlock unlocks an account.
+func saveToolUnlock(fs *flag.FlagSet, args []string) error {
+	account := fs.String("account", "", "")
+	out := fs.String("out", "", "")
+	paths, err := saveToolArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	return editSave(paths[0], *out, func() error {
+		a, err := findAccount(*account)
+		if err != nil {
+			return err
+		}
+		a.Unlock()
+		if a.SuspendedUntil().After(time.Now()) {
+			a.Suspend(0)
+		}
+		log.Printf("info: unlocked account %s", a.Username())
+		return nil
+	})
+}
+
+// saveToolMove moves a top-level object to a new location.
+func saveToolMove(fs *flag.FlagSet, args []string) error {
+	serial := fs.String("serial", "", "")
+	location := fs.String("location", "", "")
+	facet := fs.String("facet", "", "")
+	out := fs.String("out", "", "")
+	paths, err := saveToolArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	s, err := parseSerial(*serial)
+	if err != nil {
+		return err
+	}
+	var l uo.Location
+	if _, err := fmt.Sscanf(*location, "%d,%d,%d", &l.X, &l.Y, &l.Z); err != nil {
+		return fmt.Errorf("bad location %s, expected X,Y,Z", *location)
+	}
+	return editSave(paths[0], *out, func() error {
+		o := world.Find(s)
+		if o == nil {
+			return fmt.Errorf("object %s not found", s)
+		}
+		f := o.Facet()
+		if *facet != "" {
+			var ok bool
+			if f, ok = uo.FacetByName(*facet); !ok {
+				return fmt.Errorf("unknown facet %s", *facet)
+			}
+		}
+		to := world.Map(f)
+		if to == nil {
+			return fmt.Errorf("facet %s is not loaded", f)
+		}
+		if int(l.X) < 0 || int(l.X) >= f.Width() || int(l.Y) < 0 || int(l.Y) >= f.Height() {
+			return fmt.Errorf("location %s is outside of %s", *location, f)
+		}
+		from := game.MapOf(o)
+		if from.RetrieveObject(s) != nil {
+			// Logged out characters are in deep storage
+			o.SetLocation(l)
+			o.SetFacet(f)
+			to.StoreObject(o)
+		} else if o.Parent() == nil {
+			// On the map, the map tiles are not loaded so this bypasses all of
+			// the map logic just like unmarshaling does
+			from.GetChunk(o.Location()).Remove(o)
+			o.SetLocation(l)
+			o.SetFacet(f)
+			to.GetChunk(l).Add(o)
+		} else {
+			return fmt.Errorf("object %s is held by %s, move that instead", s,
+				serialOf(o.Parent()))
+		}
+		log.Printf("info: moved %s %q to %s on %s", s, o.Name(), *location, f)
+		return nil
+	})
+}
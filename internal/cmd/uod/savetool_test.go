@@ -0,0 +1,212 @@
+package uod
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/marshal"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// runSaveTool runs the savetool command with the arguments and returns its
+// output.
+func runSaveTool(name string, args ...string) (string, error) {
+	var out bytes.Buffer
+	defer func(w io.Writer) { saveToolOutput = w }(saveToolOutput)
+	saveToolOutput = &out
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	err := saveToolCommands[name](fs, args)
+	return out.String(), err
+}
+
+// corpusBob returns the player character of the loaded corpus save.
+func corpusBob(t *testing.T) game.Mobile {
+	a, err := findAccount("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, ok := world.Find(a.Characters()[0]).(game.Mobile)
+	if !ok {
+		t.Fatal("character of account root not found")
+	}
+	return bob
+}
+
+// writeWorld writes the world to a new save at the path.
+func writeWorld(t *testing.T, p string) {
+	tf := world.marshalTagFile()
+	defer tf.Close()
+	if err := marshal.SaveBackendFor(p).Write(p, tf, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// validateSave fails the test if savetool does not validate the save.
+func validateSave(t *testing.T, p string) {
+	out, err := runSaveTool("validate", p)
+	if err != nil {
+		t.Fatalf("%s did not validate: %s\n%s", p, err, out)
+	}
+}
+
+func TestSaveToolValidate(t *testing.T) {
+	corpus, dir := setupCorpusTest(t)
+	baseline := filepath.Join(corpus, "baseline.sav.gz")
+	out, err := runSaveTool("validate", baseline)
+	if err != nil {
+		t.Fatalf("baseline did not validate: %s\n%s", err, out)
+	}
+	if !strings.HasSuffix(out, " 0 problems\n") {
+		t.Errorf("unexpected validate output %q", out)
+	}
+	// Parent a coin stack in the backpack of Bob to the backpack on the map
+	loadCorpusSave(t, baseline)
+	bp, ok := corpusBob(t).EquipmentInSlot(uo.LayerBackpack).(game.Container)
+	if !ok {
+		t.Fatal("Bob has no backpack")
+	}
+	var gold game.Object
+	for _, o := range bp.Contents() {
+		if o.TemplateName() == "GoldCoin" {
+			gold = o
+		}
+	}
+	if gold == nil {
+		t.Fatal("no gold in the backpack of Bob")
+	}
+	var ground game.Object
+	for _, o := range world.Map(uo.FacetFelucca).Objects() {
+		if o.TemplateName() == "Backpack" {
+			ground = o
+		}
+	}
+	if ground == nil {
+		t.Fatal("backpack on the map not found")
+	}
+	gold.SetParent(ground)
+	broken := filepath.Join(dir, "broken.sav.gz")
+	writeWorld(t, broken)
+	out, err = runSaveTool("validate", broken)
+	if err == nil {
+		t.Fatal("broken parent link not reported as an error")
+	}
+	// The parent may be loaded after the coins so only the holder is checked
+	want := fmt.Sprintf("%s: held by %s but parented to ", gold.Serial(), bp.Serial())
+	if !strings.Contains(out, want) {
+		t.Errorf("validate output %q does not contain %q", out, want)
+	}
+	if !strings.HasSuffix(out, " 1 problems\n") {
+		t.Errorf("unexpected validate output %q", out)
+	}
+}
+
+func TestSaveToolDiff(t *testing.T) {
+	corpus, dir := setupCorpusTest(t)
+	baseline := filepath.Join(corpus, "baseline.sav.gz")
+	saveToolInitialize()
+	loadCorpusSave(t, baseline)
+	bob := corpusBob(t)
+	bob.SetName("Robert")
+	renamed := filepath.Join(dir, "renamed.sav.gz")
+	writeWorld(t, renamed)
+	out, err := runSaveTool("diff", baseline, baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("diff of a save with itself is not empty:\n%s", out)
+	}
+	out, err = runSaveTool("diff", baseline, renamed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("~ %s Name: Bob -> Robert\n", bob.Serial())
+	if out != want {
+		t.Errorf("diff output %q, expected %q", out, want)
+	}
+	// Equipment is held in a map, the output must not depend on its iteration
+	// order
+	for i := 0; i < 3; i++ {
+		again, err := runSaveTool("diff", baseline, renamed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again != out {
+			t.Fatalf("diff output is not stable:\n%s\n%s", out, again)
+		}
+	}
+}
+
+func TestSaveToolEdits(t *testing.T) {
+	corpus, dir := setupCorpusTest(t)
+	baseline := filepath.Join(corpus, "baseline.sav.gz")
+	saveToolInitialize()
+	loadCorpusSave(t, baseline)
+	bob := corpusBob(t).Serial()
+	// A locked and suspended account to unlock
+	a, err := findAccount("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Lock()
+	a.Suspend(time.Hour)
+	locked := filepath.Join(dir, "locked.sav.gz")
+	writeWorld(t, locked)
+
+	moved := filepath.Join(dir, "moved.sav.gz")
+	if _, err := runSaveTool("move", "-serial", bob.String(), "-location",
+		"1100,1200,5", "-facet", "Trammel", "-out", moved, baseline); err != nil {
+		t.Fatal(err)
+	}
+	validateSave(t, moved)
+	m := corpusBob(t)
+	if m.Location() != (uo.Location{X: 1100, Y: 1200, Z: 5}) || m.Facet() != uo.FacetTrammel {
+		t.Errorf("Bob is at %v on %s, expected 1100,1200,5 on Trammel", m.Location(),
+			m.Facet())
+	}
+	stored := false
+	for _, o := range world.Map(uo.FacetTrammel).StoredObjects() {
+		stored = stored || o == m
+	}
+	if !stored {
+		t.Error("Bob is not in the deep storage of Trammel")
+	}
+
+	password := filepath.Join(dir, "password.sav.gz")
+	if _, err := runSaveTool("password", "-account", "root", "-password",
+		"secret", "-out", password, baseline); err != nil {
+		t.Fatal(err)
+	}
+	validateSave(t, password)
+	if a, err = findAccount("root"); err != nil {
+		t.Fatal(err)
+	}
+	if !a.ComparePasswordHash(game.HashPassword("secret")) {
+		t.Error("password of account root not reset")
+	}
+
+	unlocked := filepath.Join(dir, "unlocked.sav.gz")
+	if _, err := runSaveTool("unlock", "-account", "root", "-out", unlocked,
+		locked); err != nil {
+		t.Fatal(err)
+	}
+	validateSave(t, unlocked)
+	if a, err = findAccount("root"); err != nil {
+		t.Fatal(err)
+	}
+	if a.Locked() || a.SuspendedUntil().After(time.Now()) {
+		t.Error("account root is still locked or suspended")
+	}
+	// Existing saves are never overwritten
+	if _, err := runSaveTool("unlock", "-account", "root", "-out", unlocked,
+		locked); err != ErrSaveFileExists {
+		t.Errorf("unlock over an existing save returned %v", err)
+	}
+}
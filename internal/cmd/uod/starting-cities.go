@@ -0,0 +1,57 @@
+package uod
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/qbradq/sharduo/data"
+	"github.com/qbradq/sharduo/internal/configuration"
+	"github.com/qbradq/sharduo/lib/serverpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+// All starting cities offered to new characters in the order they are listed
+var startingCities []*serverpacket.StartingCity
+
+// loadStartingCities loads the starting cities from the internal data file.
+func loadStartingCities() []error {
+	d, err := data.FS.ReadFile(configuration.StartingCitiesFile)
+	if err != nil {
+		return []error{err}
+	}
+	tfr := &util.TagFileReader{}
+	tfr.StartReading(bytes.NewReader(d))
+	for {
+		tfo := tfr.ReadObject()
+		if tfo == nil {
+			break
+		}
+		if tfo.TypeName() != "StartingCity" {
+			return []error{fmt.Errorf("unexpected object type %s in %s",
+				tfo.TypeName(), configuration.StartingCitiesFile)}
+		}
+		c := &serverpacket.StartingCity{
+			Name:        tfo.GetString("Name", ""),
+			Building:    tfo.GetString("Building", ""),
+			Location:    tfo.GetLocation("Location", configuration.StartingLocation),
+			Description: uo.Cliloc(tfo.GetNumber("Description", 0)),
+		}
+		fn := tfo.GetString("Facet", uo.FacetFelucca.String())
+		f, found := uo.FacetByName(fn)
+		if !found {
+			return []error{fmt.Errorf("starting city %s: unknown facet %s", c.Name, fn)}
+		}
+		loaded := false
+		for _, lf := range configuration.Facets {
+			loaded = loaded || lf == f
+		}
+		if !loaded {
+			// Cities on facets that are not loaded are not offered
+			continue
+		}
+		c.Facet = f
+		startingCities = append(startingCities, c)
+	}
+	return tfr.Errors()
+}
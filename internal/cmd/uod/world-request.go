@@ -2,8 +2,12 @@ package uod
 
 import (
 	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/qbradq/sharduo/internal/commands"
 	"github.com/qbradq/sharduo/internal/configuration"
 	"github.com/qbradq/sharduo/internal/game"
 	"github.com/qbradq/sharduo/internal/gumps"
@@ -53,89 +57,348 @@ func (r *ClientPacketRequest) Execute() error {
 	return nil
 }
 
+// CharacterListRequest is sent by the server after game server login to send
+// the list of characters on the account.
+type CharacterListRequest struct {
+	BaseWorldRequest
+}
+
+// Execute implements the WorldRequest interface
+func (r *CharacterListRequest) Execute() error {
+	r.NetState.Send(&serverpacket.CharacterList{
+		Names:   characterNames(r.NetState.account),
+		Cities:  startingCities,
+		Version: r.NetState.Version(),
+	})
+	return nil
+}
+
+// characterNames returns the names of all of the characters on the account in
+// slot order, with empty strings for open slots.
+func characterNames(a *game.Account) []string {
+	ret := make([]string, configuration.MaxCharactersPerAccount)
+	for i, s := range a.Characters() {
+		if i >= len(ret) {
+			break
+		}
+		if m := game.Find[game.Mobile](s); m != nil {
+			ret[i] = m.Name()
+		}
+	}
+	return ret
+}
+
 // CharacterLoginRequest is sent by the server accepting a character login
 type CharacterLoginRequest struct {
 	BaseWorldRequest
+	// Character slot to log into
+	Slot int
 }
 
 // Execute implements the WorldRequest interface
 func (r *CharacterLoginRequest) Execute() error {
-	var player game.Mobile
-	// Attempt to load the player
-	if r.NetState.account.Player() != uo.SerialMobileNil {
-		o := world.Find(r.NetState.account.Player())
-		if p, ok := o.(game.Mobile); ok {
-			if p.NetState() != nil {
-				// Connecting to an already connected player, disconnect the
-				// existing connection.
-				p.NetState().Disconnect()
-				p.SetNetState(nil)
-				return nil
-			}
-			player = p
-		}
-		// In case the player mobile was in deep storage we try to remove it
-		game.GetWorld().Map().RetrieveObject(player.Serial())
+	if disconnectAccountCharacters(r.NetState.account) {
+		return nil
 	}
-	// Create a new character if needed
+	player := game.Find[game.Mobile](r.NetState.account.Character(r.Slot))
 	if player == nil {
-		// New player setup
-		tn := "PlayerMobile"
-		if r.NetState.account.HasRole(game.RoleSuperUser | game.RoleDeveloper) {
-			tn = "DeveloperMobile"
-		} else if r.NetState.account.HasRole(game.RoleGameMaster) {
-			tn = "GameMasterMobile"
-		} else if r.NetState.account.HasRole(game.RoleStaff) {
-			tn = "AdministratorMobile"
-		}
-		player = template.Create[game.Mobile](tn)
-		player.SetLocation(configuration.StartingLocation)
-		player.SetFacing(configuration.StartingFacing)
-		// TODO Generic player starting equipment - gold, book, candle, dagger
-		i := template.Create[game.Item]("GoldCoin")
-		i.SetAmount(1000)
-		player.DropToBackpack(i, true)
-		// TODO DEBUG REMOVE Mining alpha test equipment
-		w := template.Create[game.Wearable]("Pickaxe")
-		if !player.Equip(w) {
-			player.DropToBackpack(w, true)
+		r.NetState.Disconnect()
+		return fmt.Errorf("account %s has no character in slot %d",
+			r.NetState.account.Username(), r.Slot)
+	}
+	// In case the player mobile was in deep storage we try to remove it
+	game.MapOf(player).RetrieveObject(player.Serial())
+	enterWorld(r.NetState, player)
+	return nil
+}
+
+// disconnectAccountCharacters disconnects all characters of the account that
+// are connected and returns true if there were any.
+func disconnectAccountCharacters(a *game.Account) bool {
+	ret := false
+	for _, s := range a.Characters() {
+		m := game.Find[game.Mobile](s)
+		if m != nil && m.NetState() != nil {
+			// Connecting to an already connected account, disconnect the
+			// existing connection.
+			m.NetState().Disconnect()
+			m.SetNetState(nil)
+			ret = true
 		}
-		i = template.Create[game.Item]("IronOre")
-		i.SetAmount(5)
-		player.DropToBackpack(i, true)
-		i = template.Create[game.Item]("IronIngot")
-		i.SetAmount(10)
-		player.DropToBackpack(i, true)
-	}
-	world.Map().SetNewParent(player, nil)
+	}
+	return ret
+}
+
+// enterWorld places the player mobile into the world and binds it to the net
+// state.
+func enterWorld(n *NetState, player game.Mobile) {
+	m := game.MapOf(player)
+	m.SetNewParent(player, nil)
 	world.Update(player)
-	r.NetState.m = player
-	r.NetState.account.SetPlayer(player.Serial())
-	r.NetState.m.SetNetState(r.NetState)
-	Broadcast("Welcome %s to %s!", r.NetState.m.DisplayName(),
+	n.m = player
+	n.m.SetNetState(n)
+	Broadcast("Welcome %s to %s!", n.m.DisplayName(),
 		configuration.GameServerName)
 	// Send the EnterWorld packet
-	facing := r.NetState.m.Facing()
-	if r.NetState.m.IsRunning() {
+	facing := n.m.Facing()
+	if n.m.IsRunning() {
 		facing = facing.SetRunningFlag()
 	} else {
 		facing = facing.StripRunningFlag()
 	}
-	r.NetState.Send(&serverpacket.EnterWorld{
-		Player:   r.NetState.m.Serial(),
-		Body:     r.NetState.m.Body(),
-		Location: r.NetState.m.Location(),
+	n.Send(&serverpacket.EnterWorld{
+		Player:   n.m.Serial(),
+		Body:     n.m.Body(),
+		Location: n.m.Location(),
 		Facing:   facing,
-		Width:    uo.MapWidth,
-		Height:   uo.MapHeight,
+		Width:    m.Facet().Width(),
+		Height:   m.Facet().Height(),
+	})
+	n.Send(&serverpacket.SetMap{
+		Facet: m.Facet(),
 	})
-	r.NetState.Send(&serverpacket.LoginComplete{})
-	r.NetState.Send(&serverpacket.Time{
+	n.Send(&serverpacket.LoginComplete{})
+	n.Send(&serverpacket.Time{
 		Time: time.Now(),
 	})
-	world.Map().SendEverything(r.NetState.m)
-	r.NetState.SendObject(r.NetState.m)
-	r.NetState.GUMP(gumps.New("welcome"), r.NetState.m, nil)
+	m.SendEverything(n.m)
+	n.SendObject(n.m)
+	if n.m.IsDead() {
+		n.Send(&serverpacket.DeathStatus{
+			Dead: true,
+		})
+	}
+	n.Send(&serverpacket.StatLocks{
+		Mobile:       n.m.Serial(),
+		Strength:     n.m.StatLock(uo.StatStrength),
+		Dexterity:    n.m.StatLock(uo.StatDexterity),
+		Intelligence: n.m.StatLock(uo.StatIntelligence),
+	})
+	n.GUMP(gumps.New("welcome"), n.m, nil)
+}
+
+// CharacterCreateRequest is sent by the server when the client requests a new
+// character.
+type CharacterCreateRequest struct {
+	BaseWorldRequest
+	// The character creation packet
+	Packet *clientpacket.CharacterCreate
+}
+
+// Execute implements the WorldRequest interface
+func (r *CharacterCreateRequest) Execute() error {
+	a := r.NetState.account
+	if len(a.Characters()) >= configuration.MaxCharactersPerAccount {
+		r.NetState.Disconnect()
+		return fmt.Errorf("account %s has no open character slots", a.Username())
+	}
+	if err := validateCharacterCreate(r.Packet); err != nil {
+		r.NetState.Disconnect()
+		return fmt.Errorf("account %s: %s", a.Username(), err.Error())
+	}
+	if disconnectAccountCharacters(a) {
+		return nil
+	}
+	player := createCharacter(a, r.Packet)
+	if player == nil {
+		r.NetState.Disconnect()
+		return fmt.Errorf("account %s: failed to create character", a.Username())
+	}
+	a.AddCharacter(player.Serial())
+	enterWorld(r.NetState, player)
+	return nil
+}
+
+// Graphics of all of the hair and facial hair styles offered during character
+// creation mapped to their templates
+var startingHairTemplates = map[uo.Graphic]string{
+	0x203B: "ShortHair",
+	0x203C: "LongHair",
+	0x203D: "PonyTailHair",
+	0x2044: "MohawkHair",
+	0x2045: "PageboyHair",
+	0x2046: "BunsHair",
+	0x2047: "AfroHair",
+	0x2048: "ReceedingHair",
+	0x2049: "PigTailsHair",
+	0x203E: "LongBeard",
+	0x203F: "ShortBeard",
+	0x2040: "Goatee",
+	0x2041: "Mustache",
+	0x204B: "MediumShortBeard",
+	0x204C: "MediumLongBeard",
+	0x204D: "VandykeBeard",
+}
+
+// validateCharacterCreate returns a descriptive error if the character
+// creation packet contains values the character creation screen would not
+// allow.
+func validateCharacterCreate(p *clientpacket.CharacterCreate) error {
+	name := strings.TrimSpace(p.Name)
+	if len(name) < 2 || len(name) > 16 {
+		return fmt.Errorf("bad character name length %d", len(name))
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && c != ' ' &&
+			c != '\'' && c != '-' && c != '.' {
+			return fmt.Errorf("bad character name %s", name)
+		}
+	}
+	total := 0
+	for _, v := range []int{p.Strength, p.Dexterity, p.Intelligence} {
+		if v < 10 || v > 60 {
+			return fmt.Errorf("bad starting stat value %d", v)
+		}
+		total += v
+	}
+	if total > 90 {
+		return fmt.Errorf("bad starting stat total %d", total)
+	}
+	total = 0
+	for i, s := range p.Skills {
+		if s.Skill >= uo.SkillCount || s.Value < 0 || s.Value > 50 {
+			return fmt.Errorf("bad starting skill %d value %d", s.Skill, s.Value)
+		}
+		for _, o := range p.Skills[:i] {
+			if s.Value > 0 && o.Value > 0 && o.Skill == s.Skill {
+				return fmt.Errorf("duplicate starting skill %d", s.Skill)
+			}
+		}
+		total += s.Value
+	}
+	if total > 120 {
+		return fmt.Errorf("bad starting skill total %d", total)
+	}
+	if len(startingCities) > 0 && (p.City < 0 || p.City >= len(startingCities)) {
+		return fmt.Errorf("bad starting city %d", p.City)
+	}
+	if p.HairStyle != 0 && startingHairTemplates[p.HairStyle] == "" {
+		return fmt.Errorf("bad hair style 0x%04X", p.HairStyle)
+	}
+	if p.BeardStyle != 0 && startingHairTemplates[p.BeardStyle] == "" {
+		return fmt.Errorf("bad facial hair style 0x%04X", p.BeardStyle)
+	}
+	return nil
+}
+
+// createCharacter creates a new player mobile for the account as described by
+// the character creation packet.
+func createCharacter(a *game.Account, p *clientpacket.CharacterCreate) game.Mobile {
+	tn := "NewPlayerMobile"
+	staff := true
+	if a.HasRole(game.RoleSuperUser | game.RoleDeveloper) {
+		tn = "DeveloperMobile"
+	} else if a.HasRole(game.RoleGameMaster) {
+		tn = "GameMasterMobile"
+	} else if a.HasRole(game.RoleStaff) {
+		tn = "AdministratorMobile"
+	} else {
+		staff = false
+	}
+	player := template.Create[game.Mobile](tn)
+	if player == nil {
+		return nil
+	}
+	player.SetName(strings.TrimSpace(p.Name))
+	player.SetFemale(p.Female)
+	player.SetBaseStats(p.Strength, p.Dexterity, p.Intelligence)
+	for _, s := range p.Skills {
+		player.SetSkill(s.Skill, int16(s.Value*10))
+	}
+	l := configuration.StartingLocation
+	if len(startingCities) > 0 {
+		l = startingCities[p.City].Location
+		player.SetFacet(startingCities[p.City].Facet)
+	}
+	player.SetLocation(l)
+	player.SetFacing(configuration.StartingFacing)
+	// Staff bodies and hues come from their templates
+	if !staff {
+		player.SetBody(p.Body)
+		player.SetHue(p.SkinHue.ClearPartial().SetPartial())
+		equip := func(tn string, hue uo.Hue) {
+			w := template.Create[game.Wearable](tn)
+			if w == nil {
+				return
+			}
+			w.SetHue(hue.ClearPartial())
+			if !player.Equip(w) {
+				player.DropToBackpack(w, true)
+			}
+		}
+		if p.HairStyle != 0 {
+			equip(startingHairTemplates[p.HairStyle], p.HairHue)
+		}
+		if p.BeardStyle != 0 && !p.Female {
+			equip(startingHairTemplates[p.BeardStyle], p.BeardHue)
+		}
+		equip("Shirt", p.ShirtHue)
+		if p.Female {
+			equip("Skirt", p.PantsHue)
+		} else {
+			equip("LongPants", p.PantsHue)
+		}
+		equip("Shoes", uo.HueDefault)
+	}
+	// TODO Generic player starting equipment - gold, book, candle, dagger
+	i := template.Create[game.Item]("GoldCoin")
+	i.SetAmount(1000)
+	player.DropToBackpack(i, true)
+	// TODO DEBUG REMOVE Mining alpha test equipment
+	w := template.Create[game.Wearable]("Pickaxe")
+	if !player.Equip(w) {
+		player.DropToBackpack(w, true)
+	}
+	i = template.Create[game.Item]("IronOre")
+	i.SetAmount(5)
+	player.DropToBackpack(i, true)
+	i = template.Create[game.Item]("IronIngot")
+	i.SetAmount(10)
+	player.DropToBackpack(i, true)
+	return player
+}
+
+// CharacterDeleteRequest is sent by the server when the client requests the
+// deletion of a character.
+type CharacterDeleteRequest struct {
+	BaseWorldRequest
+	// The character deletion packet
+	Packet *clientpacket.CharacterDelete
+}
+
+// Execute implements the WorldRequest interface
+func (r *CharacterDeleteRequest) Execute() error {
+	a := r.NetState.account
+	deny := func(reason uo.CharacterDeleteDeniedReason) {
+		r.NetState.Send(&serverpacket.CharacterDeleteDenied{
+			Reason: reason,
+		})
+	}
+	if !a.ComparePasswordHash(game.HashPassword(r.Packet.Password)) {
+		deny(uo.CharacterDeleteDeniedReasonBadPass)
+		return nil
+	}
+	m := game.Find[game.Mobile](a.Character(r.Packet.Slot))
+	if m == nil {
+		deny(uo.CharacterDeleteDeniedReasonNotFound)
+		return nil
+	}
+	if m.NetState() != nil {
+		deny(uo.CharacterDeleteDeniedReasonInUse)
+		return nil
+	}
+	if time.Since(a.CharacterCreated(r.Packet.Slot)) < configuration.MinimumCharacterAge {
+		deny(uo.CharacterDeleteDeniedReasonTooYoung)
+		return nil
+	}
+	log.Printf("info: account %s deleted character %s %s", a.Username(),
+		m.Serial().String(), m.Name())
+	a.RemoveCharacter(r.Packet.Slot)
+	game.MapOf(m).RetrieveObject(m.Serial())
+	game.Remove(m)
+	r.NetState.Send(&serverpacket.CharacterListUpdate{
+		Names: characterNames(a),
+	})
 	return nil
 }
 
@@ -150,7 +413,9 @@ type CharacterLogoutRequest struct {
 
 // Execute implements the WorldRequest interface
 func (r *CharacterLogoutRequest) Execute() error {
-	f := game.GetWorld().Map().RegionFeaturesAt(r.Mobile.Location())
+	game.CancelSecureTrades(r.Mobile)
+	game.LeaveParty(r.Mobile)
+	f := game.MapOf(r.Mobile).RegionFeaturesAt(r.Mobile.Location())
 	if f&game.RegionFeatureSafeLogout != 0 {
 		game.ExecuteEventHandler("PlayerLogout", r.Mobile, nil, nil)
 	} else {
@@ -158,3 +423,45 @@ func (r *CharacterLogoutRequest) Execute() error {
 	}
 	return nil
 }
+
+// CommandRequest is sent by the cron daemon to execute a command within the
+// world goroutine.
+type CommandRequest struct {
+	BaseWorldRequest
+	// The command line to execute
+	Command string
+}
+
+// Execute implements the WorldRequest interface
+func (r *CommandRequest) Execute() error {
+	commands.Execute(r.NetState, r.Command)
+	return nil
+}
+
+// FunctionRequest executes a function within the world goroutine. This is used
+// by other goroutines, like timers, that need to change the world.
+type FunctionRequest struct {
+	BaseWorldRequest
+	// The function to execute
+	Function func()
+}
+
+// Execute implements the WorldRequest interface
+func (r *FunctionRequest) Execute() error {
+	r.Function()
+	return nil
+}
+
+// SaveRequest is sent to save the world from outside of the world goroutine.
+type SaveRequest struct {
+	BaseWorldRequest
+	// Receives the WaitGroup of the save, or nil if the save was not started
+	Saved chan *sync.WaitGroup
+}
+
+// Execute implements the WorldRequest interface
+func (r *SaveRequest) Execute() error {
+	wg, err := world.Marshal()
+	r.Saved <- wg
+	return err
+}
@@ -1,19 +1,17 @@
 package uod
 
 import (
-	"compress/gzip"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"path"
-	"runtime"
 	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/qbradq/sharduo/internal/configuration"
 	"github.com/qbradq/sharduo/internal/game"
 	"github.com/qbradq/sharduo/lib/datastore"
 	"github.com/qbradq/sharduo/lib/marshal"
@@ -27,11 +25,24 @@ var ErrSaveFileLocked = errors.New("the save file is currently locked")
 // File truncation error
 var ErrSaveFileExists = errors.New("refusing to truncate existing save file")
 
+// Read-only mode error
+var ErrReadOnly = errors.New("saves are disabled in read-only mode")
+
+// Maximum number of object segments per facet, all facets must fit within the
+// object segments of the save file
+const maxSaveThreads = (0x100 - int(marshal.SegmentObjectsStart)) / uo.FacetCount
+
+// Longest time the save workers are given after every tick while requests are
+// waiting, so saves complete on busy shards as well
+const saveTimeSlice = 10 * time.Millisecond
+
 // World encapsulates all of the data for the world and the goroutine that
 // manipulates it.
 type World struct {
-	// The world map
-	m *game.Map
+	// The world maps by facet, nil for facets that are not loaded
+	maps [uo.FacetCount]*game.Map
+	// The maps of all loaded facets
+	loaded []*game.Map
 	// The object data store for the entire world
 	ods *datastore.T[game.Object]
 	// Collection of all accounts
@@ -46,6 +57,17 @@ type World struct {
 	lock sync.Mutex
 	// Save directory string
 	savePath string
+	// Backend new saves are written with
+	saveBackend marshal.SaveBackend
+	// Length of each segment of the last save snapshot
+	snapshotSizes map[marshal.Segment]int
+	// The save in progress that is written between ticks, if any
+	save *saveSnapshot
+	// True while Main is servicing the world
+	running bool
+	// Save the world was rolled back to at startup, the rollback file naming it
+	// is removed once the world has been saved
+	rollbackSave string
 	// Collection of all objects that need to be updated
 	updateList map[uo.Serial]struct{}
 	// Collection of all objects that need to have OPLInfo packets sent
@@ -58,48 +80,52 @@ type World struct {
 	superUser *game.Account
 }
 
-// NewWorld creates a new, empty world
-func NewWorld(savePath string, rng uo.RandomSource) *World {
-	return &World{
-		m:             game.NewMap(),
+// NewWorld creates a new, empty world with a map for each facet
+func NewWorld(savePath string, saveBackend marshal.SaveBackend, rng uo.RandomSource, facets []uo.Facet) *World {
+	w := &World{
 		ods:           datastore.NewDataStore[game.Object](rng),
 		accounts:      make(map[string]*game.Account),
 		rng:           rng,
 		requestQueue:  make(chan WorldRequest, 1024*16),
 		savePath:      savePath,
+		saveBackend:   saveBackend,
 		updateList:    make(map[uo.Serial]struct{}),
 		oplUpdateList: make(map[uo.Serial]struct{}),
 		time:          uo.TimeEpoch,
 		wallClockTime: time.Now(),
 	}
+	for _, f := range facets {
+		w.maps[f] = game.NewMap(f)
+		w.loaded = append(w.loaded, w.maps[f])
+	}
+	return w
+}
+
+// facetSegments returns the deep storage, harvest bank and first object
+// segments of the facet. Felucca uses the segments of saves that predate
+// facets.
+func facetSegments(f uo.Facet, nThreads int) (deep, banks, objects marshal.Segment) {
+	if f == uo.FacetFelucca {
+		return marshal.SegmentDeepStorage, marshal.SegmentHarvestBanks,
+			marshal.SegmentObjectsStart
+	}
+	return marshal.SegmentFacetDeepStorage + marshal.Segment(f),
+		marshal.SegmentFacetHarvestBanks + marshal.Segment(f),
+		marshal.SegmentObjectsStart + marshal.Segment(int(f)*nThreads)
 }
 
 // LatestSavePath returns the path to the most recent save file or directory
 func (w *World) LatestSavePath() string {
-	entries, err := os.ReadDir(w.savePath)
-	if err != nil {
-		return ""
-	}
-	if len(entries) == 0 {
+	paths := marshal.ListSaves(w.savePath)
+	if len(paths) == 0 {
 		return ""
 	}
-	latestIdx := -1
-	var latestModTime time.Time
-	for i, e := range entries {
-		info, err := e.Info()
-		if err != nil {
-			return ""
-		}
-		if info.ModTime().After(latestModTime) {
-			latestModTime = info.ModTime()
-			latestIdx = i
-		}
-	}
-	e := entries[latestIdx]
-	return path.Join(w.savePath, e.Name())
+	return paths[0]
 }
 
-// Unmarshal reads in all of the data stores we are responsible for.
+// Unmarshal reads in all of the data stores we are responsible for. Saves
+// written by any backend are considered. Saves that are damaged are skipped in
+// favor of the next most recent save.
 func (w *World) Unmarshal() error {
 	if !w.lock.TryLock() {
 		return ErrSaveFileLocked
@@ -107,31 +133,68 @@ func (w *World) Unmarshal() error {
 	defer w.lock.Unlock()
 
 	start := time.Now()
-	filePath := w.LatestSavePath()
-	gzf, err := os.Open(filePath)
-	if err != nil {
-		if strings.Contains(err.Error(), "handle is invalid") {
-			return os.ErrNotExist
+	paths := marshal.ListSaves(w.savePath)
+	if len(paths) == 0 {
+		return os.ErrNotExist
+	}
+	var tf *marshal.TagFile
+	for i, p := range paths {
+		var err error
+		tf, err = marshal.ReadSave(p)
+		if err == nil {
+			if i > 0 {
+				log.Printf("warning: loading save file %s because all newer saves are damaged", p)
+			}
+			break
 		}
-		return err
+		log.Printf("error: unable to load save file %s: %s", p, err)
 	}
-	gz, err := gzip.NewReader(gzf)
-	if err != nil {
-		return err
+	if tf == nil {
+		return fmt.Errorf("all %d save files in %s are damaged", len(paths), w.savePath)
 	}
-	d, err := io.ReadAll(gz)
-	if d == nil {
-		return os.ErrNotExist
-	} else if err != nil {
-		return err
+	return w.unmarshalReadSave(tf, start)
+}
+
+// UnmarshalSave reads in all of the data stores from the save at the path
+// instead of the most recent save. There is no fallback if the save is damaged.
+func (w *World) UnmarshalSave(p string) error {
+	if !w.lock.TryLock() {
+		return ErrSaveFileLocked
 	}
+	defer w.lock.Unlock()
+
+	start := time.Now()
+	log.Printf("info: loading save file %s", p)
+	tf, err := marshal.ReadSave(p)
+	if err != nil {
+		// Not wrapped so a missing save is never mistaken for a first start
+		return fmt.Errorf("unable to load save file %s: %s", p, err)
+	}
+	return w.unmarshalReadSave(tf, start)
+}
+
+// unmarshalReadSave unmarshals the tag file of a save that was read into
+// memory starting at the given time.
+func (w *World) unmarshalReadSave(tf *marshal.TagFile, start time.Time) error {
+	defer tf.Close()
 	end := time.Now()
 	elapsed := end.Sub(start)
 	log.Printf("info: read save file into memory in %ds%03dms", elapsed.Milliseconds()/1000, elapsed.Milliseconds()%1000)
 
-	// Global data
 	start = time.Now()
-	tf := marshal.NewTagFile(d)
+	if err := w.unmarshalTagFile(tf); err != nil {
+		return err
+	}
+	end = time.Now()
+	elapsed = end.Sub(start)
+	log.Printf("info: save unmarshaled in %ds%03dms", elapsed.Milliseconds()/1000, elapsed.Milliseconds()%1000)
+
+	return nil
+}
+
+// unmarshalTagFile reads all of the data stores from the tag file of a save.
+func (w *World) unmarshalTagFile(tf *marshal.TagFile) error {
+	// Global data
 	s := tf.Segment(marshal.SegmentWorld)
 	nThreads := int(s.Int())
 	w.time = uo.Time(s.Long())
@@ -147,18 +210,33 @@ func (w *World) Unmarshal() error {
 			w.superUser = a
 		}
 	}
-	// Unmarshal map deep storage
-	s = tf.Segment(marshal.SegmentDeepStorage)
-	w.m.UnmarshalDeepStorage(s)
-	// Unmarshal objects on the map
-	segStart := marshal.SegmentObjectsStart
-	segEnd := marshal.SegmentObjectsStart + marshal.Segment(nThreads)
-	for seg := segStart; seg < segEnd; seg++ {
-		s := tf.Segment(seg)
-		if s.IsEmpty() {
-			continue
+	// Facets, saves without a facet list only contain Felucca
+	facets := []uo.Facet{uo.FacetFelucca}
+	s = tf.Segment(marshal.SegmentFacets)
+	if !s.IsEmpty() {
+		facets = nil
+		for idx := 0; idx < int(s.RecordCount()); idx++ {
+			facets = append(facets, uo.Facet(s.Byte()))
+		}
+	}
+	for _, f := range facets {
+		if w.Map(f) == nil {
+			return fmt.Errorf("save contains facet %s which is not loaded", f)
+		}
+	}
+	for _, f := range facets {
+		m := w.Map(f)
+		deep, _, objects := facetSegments(f, nThreads)
+		// Unmarshal map deep storage
+		m.UnmarshalDeepStorage(tf.Segment(deep))
+		// Unmarshal objects on the map
+		for seg := objects; seg < objects+marshal.Segment(nThreads); seg++ {
+			s := tf.Segment(seg)
+			if s.IsEmpty() {
+				continue
+			}
+			m.UnmarshalObjects(s)
 		}
-		w.m.UnmarshalObjects(s)
 	}
 	// Call the AfterUnmarshal hook on all objects on the map
 	// w.m.AfterUnmarshal() // Moved out to startCommands()
@@ -167,12 +245,10 @@ func (w *World) Unmarshal() error {
 		o.RecalculateStats()
 	}
 	// Map data
-	w.m.Unmarshal(tf.Segment(marshal.SegmentMap))
-	// Done
-	end = time.Now()
-	elapsed = end.Sub(start)
-	log.Printf("info: save unmarshaled in %ds%03dms", elapsed.Milliseconds()/1000, elapsed.Milliseconds()%1000)
-
+	for _, f := range facets {
+		_, banks, _ := facetSegments(f, nThreads)
+		w.Map(f).Unmarshal(tf.Segment(banks))
+	}
 	return nil
 }
 
@@ -181,98 +257,154 @@ func (w *World) getFileName() string {
 	return time.Now().Format("2006-01-02_15-04-05")
 }
 
-// Marshal writes all of the data stores that the world is responsible for. A
-// WaitGroup is returned to wait for the file to be written to disk.
+// Marshal saves all of the data stores that the world is responsible for. The
+// world is only paused while a snapshot of the data stores is captured. While
+// Main is running the objects of the snapshot are written between ticks and
+// requests, otherwise they are written before Marshal returns. Either way the
+// save is checksummed, compressed and written to disk by another goroutine.
+// Marshal must be called from the world goroutine while Main is running. Only
+// one save may be in progress at a time and saves are refused in read-only
+// mode. A WaitGroup is returned to wait for the file to be written to disk.
 func (w *World) Marshal() (*sync.WaitGroup, error) {
-	nThreads := runtime.NumCPU()
-
+	if configuration.ReadOnly {
+		return nil, ErrReadOnly
+	}
 	if !w.lock.TryLock() {
 		return nil, ErrSaveFileLocked
 	}
-	defer w.lock.Unlock()
-
-	w.BroadcastMessage(nil, "The world is saving, please wait...")
-
-	filePath := path.Join(w.savePath, w.getFileName()+".sav.gz")
+	filePath := path.Join(w.savePath, w.getFileName()+w.saveBackend.Extension())
 	filePath = path.Clean(filePath)
 	os.MkdirAll(path.Dir(filePath), 0777)
 	log.Printf("info: saving data stores to %s", filePath)
 
-	start := time.Now()
-	wg := &sync.WaitGroup{}
-	tf := marshal.NewTagFile(nil)
-	// Global data
-	s := tf.Segment(marshal.SegmentWorld)
-	wg.Add(1)
-	go func(s *marshal.TagFileSegment) {
-		defer wg.Done()
-		s.PutInt(uint32(nThreads))
-		s.PutLong(uint64(w.time))
-	}(s)
-	// Timers
-	s = tf.Segment(marshal.SegmentTimers)
-	wg.Add(1)
-	go func(s *marshal.TagFileSegment) {
-		// Raw data for timers, this shouldn't change anymore
-		defer wg.Done()
-		game.MarshalTimers(s)
-	}(s)
-	// Accounting data
-	s = tf.Segment(marshal.SegmentAccounts)
-	wg.Add(1)
-	go func(s *marshal.TagFileSegment) {
-		defer wg.Done()
-		for _, a := range w.accounts {
-			a.Marshal(s)
-			s.IncrementRecordCount()
-		}
-	}(s)
-	// Kick off the object persistance goroutines
-	wg.Add(nThreads)
-	for i := 0; i < nThreads; i++ {
-		s := tf.Segment(marshal.SegmentObjectsStart + marshal.Segment(i))
-		go w.m.MarshalObjects(wg, s, i, nThreads)
+	ss := w.captureSnapshot(filePath)
+	log.Printf("info: captured save snapshot in %ds%03dms", ss.pause.Milliseconds()/1000,
+		ss.pause.Milliseconds()%1000)
+	if !w.running {
+		ss.finish()
+		w.completeSave(ss)
+		return ss.wg, nil
 	}
-	// Map data
-	s = tf.Segment(marshal.SegmentMap)
-	wg.Add(1)
-	go w.m.Marshal(wg, s)
-	s = tf.Segment(marshal.SegmentDeepStorage)
-	wg.Add(1)
-	go w.m.MarshalDeepStorage(wg, s)
-	// The main goroutine is blocked at this point
-	wg.Wait()
-	end := time.Now()
-	elapsed := end.Sub(start)
-	log.Printf("info: generated save data in %ds%03dms", elapsed.Milliseconds()/1000, elapsed.Milliseconds()%1000)
+	// The objects are written between ticks, see Main
+	w.save = ss
+	game.SetSaveBarrier(ss.preserve)
+	return ss.wg, nil
+}
 
-	w.BroadcastMessage(nil, "World save completed in %ds%03dms", elapsed.Milliseconds()/1000, elapsed.Milliseconds()%1000)
+// pauseSave stops the workers of the save in progress, if any. This must be
+// called before the world goroutine changes the world.
+func (w *World) pauseSave() {
+	if w.save != nil {
+		w.save.stopWorkers()
+	}
+}
 
-	// Kick off another goroutine to persist the save to disk and let the main
-	// goroutine continue.
-	wg.Add(1)
+// resumeSave lets the workers of the save in progress, if any, continue while
+// the world goroutine is idle. Once all objects are written the save is
+// completed.
+func (w *World) resumeSave() {
+	if w.save == nil {
+		return
+	}
+	if !w.save.done() {
+		w.save.startWorkers()
+		return
+	}
+	w.closeSave()
+}
+
+// sliceSave lets the workers of the save in progress, if any, write objects
+// for up to saveTimeSlice while the world goroutine waits. Once all objects
+// are written the save is completed.
+func (w *World) sliceSave() {
+	if w.save == nil {
+		return
+	}
+	if !w.save.done() {
+		w.save.runFor(saveTimeSlice)
+	}
+	if w.save.done() {
+		w.closeSave()
+	}
+}
+
+// closeSave removes the save barrier and completes the save in progress. All
+// objects must have been written.
+func (w *World) closeSave() {
+	ss := w.save
+	w.save = nil
+	game.SetSaveBarrier(nil)
+	w.completeSave(ss)
+}
+
+// completeSave writes the completed snapshot to disk on another goroutine. The
+// save lock is held until the write is done.
+func (w *World) completeSave(ss *saveSnapshot) {
+	w.snapshotSizes = make(map[marshal.Segment]int)
+	for _, s := range ss.tf.Segments() {
+		w.snapshotSizes[s.ID()] = s.Length()
+	}
+	serialized := time.Since(ss.start)
+	log.Printf("info: serialized save snapshot in %ds%03dms, the world goroutine spent %ds%03dms of that",
+		serialized.Milliseconds()/1000, serialized.Milliseconds()%1000,
+		(ss.pause+ss.stall).Milliseconds()/1000, (ss.pause+ss.stall).Milliseconds()%1000)
 	go func() {
-		defer wg.Done()
-		start := time.Now()
-		f, err := os.Create(filePath)
+		defer ss.wg.Done()
+		defer w.lock.Unlock()
+		writeStart := time.Now()
+		err := w.saveBackend.Write(ss.path, ss.tf, writeStart)
+		ss.tf.Close()
 		if err != nil {
-			log.Printf("error: unable to create save file %s", filePath)
+			log.Printf("error: unable to write save file %s: %s", ss.path, err)
+			w.BroadcastMessage(nil, "World save failed, the previous save was kept")
 			return
 		}
-		zw := gzip.NewWriter(f)
-		zw.Name = filePath
-		zw.ModTime = start
-		zw.Comment = "ShardUO save file"
-		tf.Output(zw)
-		zw.Close()
-		tf.Close()
-		f.Close()
+		w.clearRollback()
 		end := time.Now()
-		elapsed := end.Sub(start)
-		log.Printf("info: saved file to disk in %ds%03dms", elapsed.Milliseconds()/1000, elapsed.Milliseconds()%1000)
+		written := end.Sub(writeStart)
+		total := end.Sub(ss.start)
+		log.Printf("info: saved file to disk in %ds%03dms", written.Milliseconds()/1000, written.Milliseconds()%1000)
+		log.Printf("info: world save completed in %ds%03dms with a %ds%03dms capture pause",
+			total.Milliseconds()/1000, total.Milliseconds()%1000,
+			ss.pause.Milliseconds()/1000, ss.pause.Milliseconds()%1000)
+		w.BroadcastMessage(nil, "World save completed in %ds%03dms, paused for %ds%03dms",
+			total.Milliseconds()/1000, total.Milliseconds()%1000,
+			ss.pause.Milliseconds()/1000, ss.pause.Milliseconds()%1000)
 	}()
+}
+
+// clearRollback removes the rollback file once the world it rolled back to has
+// been saved. A rollback to another save scheduled since then is kept.
+// This must only be called while holding the save lock.
+func (w *World) clearRollback() {
+	if w.rollbackSave == "" {
+		return
+	}
+	d, err := os.ReadFile(configuration.RollbackFile)
+	if err == nil && strings.TrimSpace(string(d)) == w.rollbackSave {
+		if err := os.Remove(configuration.RollbackFile); err != nil {
+			log.Printf("error: unable to remove rollback file %s: %s",
+				configuration.RollbackFile, err)
+			return
+		}
+		log.Printf("info: rollback to %s completed", w.rollbackSave)
+	}
+	w.rollbackSave = ""
+}
 
-	return wg, nil
+// WaitForSave blocks until the save in progress, if any, is written to disk.
+// While Main is running this must not be called from the world goroutine.
+func (w *World) WaitForSave() {
+	w.lock.Lock()
+	w.lock.Unlock()
+}
+
+// marshalTagFile writes all of the data stores that the world is responsible
+// for to a new tag file. The world must not change until this returns.
+func (w *World) marshalTagFile() *marshal.TagFile {
+	ss := w.captureSnapshot("")
+	ss.finish()
+	return ss.tf
 }
 
 // SendRequest sends a WorldRequest to the world's goroutine. Returns true if
@@ -332,7 +464,8 @@ func (w *World) Delete(o game.Object) {
 // created for the user. If an account is found but the password hashes do not
 // match nil is returned. Otherwise the account is returned. If no accounts
 // exist in the accounts datastore at all, the newly created account will have
-// super-user permissions and a message will be logged.
+// super-user permissions and a message will be logged. New accounts are not
+// created in maintenance mode.
 func (w *World) AuthenticateAccount(username, passwordHash string) *game.Account {
 	w.alock.Lock()
 	defer w.alock.Unlock()
@@ -340,6 +473,9 @@ func (w *World) AuthenticateAccount(username, passwordHash string) *game.Account
 	a := w.accounts[username]
 	newAccount := false
 	if a == nil {
+		if configuration.MaintenanceMode && len(w.accounts) > 0 {
+			return nil
+		}
 		a = game.NewAccount(username, passwordHash, game.RolePlayer)
 		newAccount = true
 	}
@@ -380,9 +516,13 @@ func (w *World) Main(wg *sync.WaitGroup) {
 	defer wg.Done()
 	var done bool
 	ticker := time.NewTicker(time.Second / time.Duration(uo.DurationSecond))
+	w.running = true
 	for !done {
+		ticked := false
 		select {
 		case t := <-ticker.C:
+			w.pauseSave()
+			ticked = true
 			// The ticker has a higher priority than packets. This should ensure
 			// that the game service cannot be overwhelmed with packets and not
 			// be able to do cleanup tasks.
@@ -395,7 +535,9 @@ func (w *World) Main(wg *sync.WaitGroup) {
 			// Interleave net state updates
 			UpdateNetStates(int(w.time % uo.DurationSecond))
 			// Interleaved chunk updates, mobile think, etc
-			w.m.Update(w.time)
+			for _, m := range w.Maps() {
+				m.Update(w.time)
+			}
 			// OPLInfo updates
 			for s := range w.oplUpdateList {
 				o := w.Find(s)
@@ -409,7 +551,7 @@ func (w *World) Main(wg *sync.WaitGroup) {
 					}
 				} else {
 					rp := game.RootParent(o)
-					for _, m := range w.m.GetNetStatesInRange(rp.Location(), uo.MaxViewRange) {
+					for _, m := range game.MapOf(rp).GetNetStatesInRange(rp.Location(), uo.MaxViewRange) {
 						if rp.Location().XYDistance(m.Location()) <= m.ViewRange() {
 							oi := w.Find(o.Serial())
 							_, info := oi.OPLPackets(oi)
@@ -433,18 +575,28 @@ func (w *World) Main(wg *sync.WaitGroup) {
 					}
 				} else {
 					rp := game.RootParent(o)
-					for _, m := range w.m.GetNetStatesInRange(rp.Location(), uo.MaxViewRange) {
+					for _, m := range game.MapOf(rp).GetNetStatesInRange(rp.Location(), uo.MaxViewRange) {
 						if rp.Location().XYDistance(m.Location()) <= m.ViewRange() {
 							m.NetState().UpdateObject(o)
 						}
 					}
+					// Party members get health updates from anywhere
+					if m, ok := o.(game.Mobile); ok {
+						if p := game.PartyOf(m); p != nil {
+							p.SendHealth(m)
+						}
+					}
 				}
 			}
 			w.updateList = make(map[uo.Serial]struct{})
 		case r := <-w.requestQueue:
+			w.pauseSave()
 			// Handle graceful shutdown
 			if r == nil {
 				ticker.Stop()
+				// Close all trade windows before the final save, the offered
+				// items are returned to their owners.
+				game.CancelAllSecureTrades()
 				done = true
 				break
 			}
@@ -456,14 +608,38 @@ func (w *World) Main(wg *sync.WaitGroup) {
 				log.Println(err)
 			}
 		}
+		// Save objects while we wait for the next tick or request. The world
+		// goroutine is never idle while requests keep coming in, so the save
+		// is given a time slice after every tick as well.
+		if !done {
+			if len(w.requestQueue) == 0 {
+				w.resumeSave()
+			} else if ticked {
+				w.sliceSave()
+			}
+		}
+	}
+	// Complete the save in progress so the final save can be taken
+	w.running = false
+	if ss := w.save; ss != nil {
+		w.save = nil
+		game.SetSaveBarrier(nil)
+		ss.finish()
+		w.completeSave(ss)
 	}
 }
 
-// Map returns the map the world is using.
-func (w *World) Map() *game.Map {
-	return w.m
+// Map implements the game.World interface.
+func (w *World) Map(f uo.Facet) *game.Map {
+	if !f.Valid() {
+		return nil
+	}
+	return w.maps[f]
 }
 
+// Maps implements the game.World interface.
+func (w *World) Maps() []*game.Map { return w.loaded }
+
 // GetItemDefinition returns the uo.StaticDefinition that holds the static data
 // for a given item graphic.
 func (w *World) GetItemDefinition(g uo.Graphic) *uo.StaticDefinition {
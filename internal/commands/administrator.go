@@ -1,10 +1,15 @@
 package commands
 
 import (
+	"log"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/qbradq/sharduo/internal/configuration"
 	"github.com/qbradq/sharduo/internal/game"
 	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/marshal"
 	"github.com/qbradq/sharduo/lib/uo"
 )
 
@@ -13,6 +18,7 @@ import (
 func init() {
 	regcmd(&cmdesc{"broadcast", nil, commandBroadcast, game.RoleAdministrator, "broadcast text", "Broadcasts the given text to all connected players"})
 	regcmd(&cmdesc{"location", []string{"loc"}, commandLocation, game.RoleAdministrator, "location", "Tells the absolute location of the targeted location or object"})
+	regcmd(&cmdesc{"rollback", nil, commandRollback, game.RoleAdministrator, "rollback save [minutes] | rollback cancel", "Restarts the server after the given number of minutes, 1 by default, and loads the named save or archive"})
 	regcmd(&cmdesc{"save", nil, commandSave, game.RoleAdministrator, "save", "Executes a game.GetWorld() save immediately"})
 	regcmd(&cmdesc{"shutdown", nil, commandShutdown, game.RoleAdministrator, "shutdown", "Shuts down the server immediately"})
 }
@@ -26,8 +32,75 @@ func commandLocation(n game.NetState, args CommandArgs, cl string) {
 	})
 }
 
+// Timer of the pending rollback restart, if any
+var rollbackTimer *time.Timer
+
+// RollbackPending returns true if a rollback restart has been scheduled. The
+// world must not be saved on shutdown while a rollback is pending.
+func RollbackPending() bool { return rollbackTimer != nil }
+
+func commandRollback(n game.NetState, args CommandArgs, cl string) {
+	if len(args) < 2 || len(args) > 3 {
+		n.Speech(nil, "rollback command requires 1 or 2 arguments, got %d", len(args)-1)
+		return
+	}
+	if args[1] == "cancel" {
+		if rollbackTimer == nil {
+			n.Speech(nil, "no rollback is pending")
+			return
+		}
+		rollbackTimer.Stop()
+		rollbackTimer = nil
+		os.Remove(configuration.RollbackFile)
+		broadcast("Server restart aborted.")
+		return
+	}
+	minutes := 1
+	if len(args) == 3 {
+		minutes = args.Int(2)
+		if minutes < 1 {
+			n.Speech(nil, "invalid number of minutes %s", args[2])
+			return
+		}
+	}
+	p, err := configuration.FindSave(args[1])
+	if err != nil {
+		n.Speech(nil, "error: %s", err)
+		return
+	}
+	// Make sure the save can be loaded before going down
+	tf, err := marshal.ReadSave(p)
+	if err != nil {
+		n.Speech(nil, "error: %s", err)
+		return
+	}
+	tf.Close()
+	if err := os.WriteFile(configuration.RollbackFile, []byte(p+"\n"), 0666); err != nil {
+		n.Speech(nil, "error: %s", err)
+		return
+	}
+	if rollbackTimer != nil {
+		rollbackTimer.Stop()
+	}
+	// The timer fires on its own goroutine so the shutdown is sent to the world
+	// goroutine. The rollback may have been canceled or rescheduled by then.
+	var t *time.Timer
+	t = time.AfterFunc(time.Duration(minutes)*time.Minute, func() {
+		executeInWorld(func() {
+			if rollbackTimer == t {
+				shutdown()
+			}
+		})
+	})
+	rollbackTimer = t
+	log.Printf("info: rollback to %s scheduled in %d minutes", p, minutes)
+	broadcast("The server will be restarting in %d minute(s) to roll back the world!", minutes)
+}
+
 func commandSave(n game.NetState, args CommandArgs, cl string) {
-	saveWorld()
+	if err := saveWorld(); err != nil {
+		n.Speech(nil, "error: %s", err)
+	}
 }
 
 func commandShutdown(n game.NetState, args CommandArgs, cl string) {
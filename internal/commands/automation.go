@@ -2,17 +2,15 @@ package commands
 
 import (
 	"fmt"
-	"io"
-	"io/fs"
 	"log"
 	"os"
 	"path"
-	"path/filepath"
 	"runtime"
 	"time"
 
 	"github.com/qbradq/sharduo/internal/configuration"
 	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/marshal"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
@@ -43,92 +41,62 @@ func commandLogMemStats(n game.NetState, args CommandArgs, cl string) {
 
 func commandSnapshotClean(n game.NetState, args CommandArgs, cl string) {
 	t := time.Now().Add(time.Hour * 72 * -1)
-	filepath.WalkDir(configuration.SaveDirectory, func(p string, d fs.DirEntry, e error) error {
-		if d == nil || d.IsDir() {
-			return nil
-		}
-		di, err := d.Info()
+	for _, p := range marshal.ListSaves(configuration.SaveDirectory) {
+		info, err := os.Stat(p)
 		if err != nil {
-			return err
+			continue
 		}
-		if di.ModTime().Before(t) {
-			if err := os.Remove(p); err != nil {
-				return err
+		if info.ModTime().Before(t) {
+			if err := os.RemoveAll(p); err != nil {
+				n.Speech(nil, "error: failed to remove old save %s: %s", p, err)
+				return
 			}
 		}
-		return nil
-	})
+	}
 	n.Speech(nil, "old saves cleaned")
 }
 
-func commandSnapshotDaily(n game.NetState, args CommandArgs, cl string) {
+// archiveSave copies the most recent save into the archive directory as
+// name1, shifting older archives of the same name up to name<keep>.
+func archiveSave(name string, keep int) error {
 	// Make sure the archive directory exists
 	os.MkdirAll(configuration.ArchiveDirectory, 0777)
-	// Create an archive copy of the save file
+	// Create an archive copy of the save
 	p := latestSavePath()
-	src, err := os.Open(p)
-	if err != nil {
-		n.Speech(nil, "error: failed to create daily archive: %s", err)
-		return
+	b := marshal.SaveBackendFor(p)
+	if b == nil {
+		return fmt.Errorf("no save to archive")
 	}
-	defer src.Close()
-	dest, err := os.Create(path.Join(configuration.ArchiveDirectory, "daily.sav.gz"))
-	if err != nil {
-		n.Speech(nil, "error: failed to create daily archive: %s", err)
-		return
+	ext := b.Extension()
+	archive := func(i int) string {
+		return path.Join(configuration.ArchiveDirectory, fmt.Sprintf("%s%d%s", name, i, ext))
 	}
-	_, err = io.Copy(dest, src)
-	dest.Close()
-	if err != nil {
-		n.Speech(nil, "error: failed to create daily archive: %s", err)
-		return
+	tmp := path.Join(configuration.ArchiveDirectory, name+ext)
+	if err := marshal.CopySave(p, tmp); err != nil {
+		return err
 	}
 	// Remove the oldest save
-	os.Remove(path.Join(configuration.ArchiveDirectory, "daily7.sav.gz"))
-	// Rotate daily saves
-	for i := 6; i > 0; i-- {
-		op := path.Join(configuration.ArchiveDirectory, fmt.Sprintf("daily%d.sav.gz", i))
-		np := path.Join(configuration.ArchiveDirectory, fmt.Sprintf("daily%d.sav.gz", i+1))
-		os.Rename(op, np)
+	os.RemoveAll(archive(keep))
+	// Rotate saves
+	for i := keep - 1; i > 0; i-- {
+		os.Rename(archive(i), archive(i+1))
 	}
-	// Move the new save file into the archives
-	os.Rename(path.Join(configuration.ArchiveDirectory, "daily.sav.gz"),
-		path.Join(configuration.ArchiveDirectory, "daily1.sav.gz"))
-	n.Speech(nil, "daily archive complete")
+	// Move the new save into the archives
+	return os.Rename(tmp, archive(1))
 }
 
-func commandSnapshotWeekly(n game.NetState, args CommandArgs, cl string) {
-	// Make sure the archive directory exists
-	os.MkdirAll(configuration.ArchiveDirectory, 0777)
-	// Create an archive copy of the save file
-	p := latestSavePath()
-	src, err := os.Open(p)
-	if err != nil {
-		n.Speech(nil, "error: failed to create weekly archive: %s", err)
-		return
-	}
-	defer src.Close()
-	dest, err := os.Create(path.Join(configuration.ArchiveDirectory, "weekly.sav.gz"))
-	if err != nil {
-		n.Speech(nil, "error: failed to create weekly archive: %s", err)
+func commandSnapshotDaily(n game.NetState, args CommandArgs, cl string) {
+	if err := archiveSave("daily", 7); err != nil {
+		n.Speech(nil, "error: failed to create daily archive: %s", err)
 		return
 	}
-	_, err = io.Copy(dest, src)
-	dest.Close()
-	if err != nil {
+	n.Speech(nil, "daily archive complete")
+}
+
+func commandSnapshotWeekly(n game.NetState, args CommandArgs, cl string) {
+	if err := archiveSave("weekly", 52); err != nil {
 		n.Speech(nil, "error: failed to create weekly archive: %s", err)
 		return
 	}
-	// Remove the oldest save
-	os.Remove(path.Join(configuration.ArchiveDirectory, "weekly52.sav.gz"))
-	// Rotate daily saves
-	for i := 51; i > 0; i-- {
-		op := path.Join(configuration.ArchiveDirectory, fmt.Sprintf("weekly%d.sav.gz", i))
-		np := path.Join(configuration.ArchiveDirectory, fmt.Sprintf("weekly%d.sav.gz", i+1))
-		os.Rename(op, np)
-	}
-	// Move the new save file into the archives
-	os.Rename(path.Join(configuration.ArchiveDirectory, "weekly.sav.gz"),
-		path.Join(configuration.ArchiveDirectory, "weekly1.sav.gz"))
 	n.Speech(nil, "weekly archive complete")
 }
@@ -10,25 +10,28 @@ import (
 
 // Server callbacks
 var globalChat func(uo.Hue, string, string)
-var saveWorld func()
+var saveWorld func() error
 var broadcast func(string, ...any)
 var shutdown func()
 var latestSavePath func() string
+var executeInWorld func(func())
 
 // RegisterCallbacks registers the various server callbacks required to execute
 // certain commands.
 func RegisterCallbacks(
 	lGlobalChat func(uo.Hue, string, string),
-	lSaveWorld func(),
+	lSaveWorld func() error,
 	lBroadcast func(string, ...any),
 	lShutdown func(),
 	lLatestSavePath func() string,
+	lExecuteInWorld func(func()),
 ) {
 	globalChat = lGlobalChat
 	saveWorld = lSaveWorld
 	broadcast = lBroadcast
 	shutdown = lShutdown
 	latestSavePath = lLatestSavePath
+	executeInWorld = lExecuteInWorld
 }
 
 // regcmd registers a command description
@@ -92,7 +92,7 @@ func commandDebug(n game.NetState, args CommandArgs, cl string) {
 				l.X += int16(ix)
 				l.Y += int16(iy * 2)
 				r.SetLocation(l)
-				game.GetWorld().Map().SetNewParent(r, nil)
+				game.MapOf(n.Mobile()).SetNewParent(r, nil)
 			}
 		}
 	case "vendor_bag":
@@ -131,7 +131,7 @@ func commandDebug(n game.NetState, args CommandArgs, cl string) {
 	case "force_chunk_update":
 		n.Speech(n.Mobile(), "Target the chunk you wish to force-update")
 		n.TargetSendCursor(uo.TargetTypeLocation, func(tr *clientpacket.TargetResponse) {
-			game.GetWorld().Map().GetChunk(tr.Location).Update(game.GetWorld().Time())
+			game.MapOf(n.Mobile()).GetChunk(tr.Location).Update(game.GetWorld().Time())
 		})
 	case "global_light":
 		ll := uo.LightLevel(args.Int(2))
@@ -153,7 +153,7 @@ func commandDebug(n game.NetState, args CommandArgs, cl string) {
 		n.Music(which)
 	case "sound":
 		which := uo.Sound(args.Int(2))
-		game.GetWorld().Map().PlaySound(which, n.Mobile().Location())
+		game.MapOf(n.Mobile()).PlaySound(which, n.Mobile().Location())
 	case "memory_test":
 		start := time.Now()
 		for i := 0; i < 1_000_000; i++ {
@@ -162,16 +162,16 @@ func commandDebug(n game.NetState, args CommandArgs, cl string) {
 				continue
 			}
 			nl := uo.Location{
-				X: int16(game.GetWorld().Random().Random(100, uo.MapWidth-101)),
-				Y: int16(game.GetWorld().Random().Random(100, uo.MapHeight-101)),
+				X: int16(game.GetWorld().Random().Random(100, game.MapOf(n.Mobile()).Facet().Width()-101)),
+				Y: int16(game.GetWorld().Random().Random(100, game.MapOf(n.Mobile()).Facet().Height()-101)),
 				Z: uo.MapMaxZ,
 			}
-			f, _ := game.GetWorld().Map().GetFloorAndCeiling(nl, false, false)
+			f, _ := game.MapOf(n.Mobile()).GetFloorAndCeiling(nl, false, false)
 			if f != nil {
 				nl.Z = f.Z()
 			}
 			o.SetLocation(nl)
-			game.GetWorld().Map().ForceAddObject(o)
+			game.MapOf(n.Mobile()).ForceAddObject(o)
 		}
 		for i := 0; i < 150_000; i++ {
 			o := template.Create[game.Object]("Banker")
@@ -179,16 +179,16 @@ func commandDebug(n game.NetState, args CommandArgs, cl string) {
 				continue
 			}
 			nl := uo.Location{
-				X: int16(game.GetWorld().Random().Random(100, uo.MapWidth-101)),
-				Y: int16(game.GetWorld().Random().Random(100, uo.MapHeight-101)),
+				X: int16(game.GetWorld().Random().Random(100, game.MapOf(n.Mobile()).Facet().Width()-101)),
+				Y: int16(game.GetWorld().Random().Random(100, game.MapOf(n.Mobile()).Facet().Height()-101)),
 				Z: uo.MapMaxZ,
 			}
-			f, _ := game.GetWorld().Map().GetFloorAndCeiling(nl, false, false)
+			f, _ := game.MapOf(n.Mobile()).GetFloorAndCeiling(nl, false, false)
 			if f != nil {
 				nl.Z = f.Z()
 			}
 			o.SetLocation(nl)
-			game.GetWorld().Map().ForceAddObject(o)
+			game.MapOf(n.Mobile()).ForceAddObject(o)
 		}
 		end := time.Now()
 		n.Speech(n.Mobile(), fmt.Sprintf("operation completed in %s", end.Sub(start)))
@@ -216,7 +216,7 @@ func commandDebug(n game.NetState, args CommandArgs, cl string) {
 		llama.SetControlMaster(n.Mobile())
 		llama.SetAI("Follow")
 		llama.SetAIGoal(n.Mobile())
-		game.GetWorld().Map().AddObject(llama)
+		game.MapOf(n.Mobile()).AddObject(llama)
 		game.ExecuteEventHandler("Mount", llama, n.Mobile(), nil)
 	case "shirtbag":
 		backpack := template.Create[game.Container]("Backpack")
@@ -224,14 +224,14 @@ func commandDebug(n game.NetState, args CommandArgs, cl string) {
 			break
 		}
 		backpack.SetLocation(n.Mobile().Location())
-		game.GetWorld().Map().SetNewParent(backpack, nil)
+		game.MapOf(n.Mobile()).SetNewParent(backpack, nil)
 		for i := 0; i < 125; i++ {
 			shirt := template.Create[game.Object]("FancyShirt")
 			if shirt == nil {
 				continue
 			}
 			shirt.SetLocation(uo.RandomContainerLocation)
-			if !game.GetWorld().Map().SetNewParent(shirt, backpack) {
+			if !game.MapOf(n.Mobile()).SetNewParent(shirt, backpack) {
 				n.Speech(n.Mobile(), "failed to add an item to the backpack")
 				break
 			}
@@ -250,7 +250,7 @@ func commandDebug(n game.NetState, args CommandArgs, cl string) {
 					Y: iy,
 					Z: n.Mobile().Location().Z,
 				})
-				game.GetWorld().Map().SetNewParent(o, nil)
+				game.MapOf(n.Mobile()).SetNewParent(o, nil)
 				count++
 			}
 		}
@@ -23,6 +23,7 @@ import (
 func init() {
 	regcmd(&cmdesc{"decorate", []string{"deco"}, commandDecorate, game.RoleDeveloper, "decorate", "Calls up the decoration GUMP"})
 	regcmd(&cmdesc{"loaddoors", nil, commandLoadDoors, game.RoleDeveloper, "loaddoors", "Clears all doors then loads data/misc/doors.csv"})
+	regcmd(&cmdesc{"loadmoongates", nil, commandLoadMoongates, game.RoleDeveloper, "loadmoongates", "Clears all moongates then places them at the locations in data/misc/moongates.ini"})
 	regcmd(&cmdesc{"loadregions", nil, commandLoadRegions, game.RoleDeveloper, "loadregions", "Clears all regions then loads data/misc/regions.csv"})
 	regcmd(&cmdesc{"loadsigns", nil, commandLoadSigns, game.RoleDeveloper, "loadsigns", "Clears all signs then loads data/misc/signs.csv"})
 	regcmd(&cmdesc{"loadstatics", nil, commandLoadStatics, game.RoleDeveloper, "loadstatics", "Clears all statics then loads data/misc/statics.csv"})
@@ -43,7 +44,7 @@ func commandLoadStatics(n game.NetState, args CommandArgs, cl string) {
 		return int(v)
 	}
 	broadcast("Load Statics: clearing all statics")
-	for _, s := range game.GetWorld().Map().ItemQuery("StaticItem", uo.BoundsZero) {
+	for _, s := range game.GetWorld().Map(uo.FacetFelucca).ItemQuery("StaticItem", uo.BoundsZero) {
 		game.Remove(s)
 	}
 	broadcast("Load Statics: loading statics.csv")
@@ -75,14 +76,14 @@ func commandLoadStatics(n game.NetState, args CommandArgs, cl string) {
 		})
 		s.SetBaseGraphic(uo.Graphic(fn(fields[3])))
 		s.SetHue(uo.Hue(fn(fields[4])))
-		game.GetWorld().Map().ForceAddObject(s)
+		game.GetWorld().Map(uo.FacetFelucca).ForceAddObject(s)
 	}
 	broadcast("Load Statics: complete")
 }
 
 func commandSaveStatics(n game.NetState, args CommandArgs, cl string) {
 	broadcast("Save Statics: getting statics")
-	statics := game.GetWorld().Map().ItemQuery("StaticItem", uo.BoundsZero)
+	statics := game.GetWorld().Map(uo.FacetFelucca).ItemQuery("StaticItem", uo.BoundsZero)
 	broadcast("Save Statics: sorting statics")
 	sort.Slice(statics, func(i, j int) bool {
 		a := statics[i].Location()
@@ -124,7 +125,7 @@ func commandDecorate(n game.NetState, args CommandArgs, cl string) {
 
 func commandSaveDoors(n game.NetState, args CommandArgs, cl string) {
 	broadcast("Save Doors: getting doors")
-	doors := game.GetWorld().Map().ItemBaseQuery("BaseDoor", uo.BoundsZero)
+	doors := game.GetWorld().Map(uo.FacetFelucca).ItemBaseQuery("BaseDoor", uo.BoundsZero)
 	broadcast("Save Doors: sorting doors")
 	// Correct door locations for doors that happen to be open
 	for _, d := range doors {
@@ -133,11 +134,11 @@ func commandSaveDoors(n game.NetState, args CommandArgs, cl string) {
 			ofs := uo.DoorOffsets[d.Facing()]
 			l.X -= ofs.X
 			l.Y -= ofs.Y
-			game.GetWorld().Map().ForceRemoveObject(d)
+			game.GetWorld().Map(uo.FacetFelucca).ForceRemoveObject(d)
 			d.SetLocation(l)
 			d.Flip()
 			d.SetDefForGraphic(d.Graphic())
-			game.GetWorld().Map().ForceAddObject(d)
+			game.GetWorld().Map(uo.FacetFelucca).ForceAddObject(d)
 		}
 	}
 	// Sort based on corrected locations
@@ -184,7 +185,7 @@ func commandLoadDoors(n game.NetState, args CommandArgs, cl string) {
 		return int(v)
 	}
 	broadcast("Load Doors: clearing all doors")
-	for _, s := range game.GetWorld().Map().ItemBaseQuery("BaseDoor", uo.BoundsZero) {
+	for _, s := range game.GetWorld().Map(uo.FacetFelucca).ItemBaseQuery("BaseDoor", uo.BoundsZero) {
 		game.Remove(s)
 	}
 	broadcast("Load Doors: loading doors.csv")
@@ -217,14 +218,34 @@ func commandLoadDoors(n game.NetState, args CommandArgs, cl string) {
 		d.SetFacing(uo.Direction(fn(fields[4])))
 		d.SetBaseGraphic(d.BaseGraphic() + uo.Graphic(d.Facing()*2))
 		d.SetFlippedGraphic(d.FlippedGraphic() + uo.Graphic(d.Facing()*2))
-		game.GetWorld().Map().ForceAddObject(d)
+		game.GetWorld().Map(uo.FacetFelucca).ForceAddObject(d)
 	}
 	broadcast("Load Doors: complete")
 }
 
+func commandLoadMoongates(n game.NetState, args CommandArgs, cl string) {
+	broadcast("Load Moongates: clearing all moongates")
+	for _, m := range game.GetWorld().Maps() {
+		for _, g := range m.ItemQuery("Moongate", uo.BoundsZero) {
+			game.Remove(g)
+		}
+	}
+	broadcast("Load Moongates: generating new moongates")
+	for _, d := range gumps.MoongateDestinations() {
+		g := template.Create[game.Item]("Moongate")
+		if g == nil {
+			broadcast("Load Moongates: Moongate template not found")
+			return
+		}
+		g.SetLocation(d.Destination.Location)
+		game.GetWorld().Map(d.Destination.Facet).ForceAddObject(g)
+	}
+	broadcast("Load Moongates: complete")
+}
+
 func commandSaveSigns(n game.NetState, args CommandArgs, cl string) {
 	broadcast("Save Signs: getting signs")
-	signs := game.GetWorld().Map().ItemQuery("BaseSign", uo.BoundsZero)
+	signs := game.GetWorld().Map(uo.FacetFelucca).ItemQuery("BaseSign", uo.BoundsZero)
 	broadcast("Save Signs: sorting signs")
 	// Sort based on location
 	sort.Slice(signs, func(i, j int) bool {
@@ -270,7 +291,7 @@ func commandLoadSigns(n game.NetState, args CommandArgs, cl string) {
 		return int(v)
 	}
 	broadcast("Load Signs: clearing all signs")
-	for _, s := range game.GetWorld().Map().ItemBaseQuery("BaseSign", uo.BoundsZero) {
+	for _, s := range game.GetWorld().Map(uo.FacetFelucca).ItemBaseQuery("BaseSign", uo.BoundsZero) {
 		game.Remove(s)
 	}
 	broadcast("Load Signs: loading signs.csv")
@@ -302,7 +323,7 @@ func commandLoadSigns(n game.NetState, args CommandArgs, cl string) {
 		})
 		s.SetBaseGraphic(uo.Graphic(fn(fields[3])))
 		s.SetName(fields[4])
-		game.GetWorld().Map().ForceAddObject(s)
+		game.GetWorld().Map(uo.FacetFelucca).ForceAddObject(s)
 	}
 	broadcast("Load Doors: complete")
 }
@@ -313,10 +334,13 @@ func commandRegions(n game.NetState, args CommandArgs, cl string) {
 
 func commandSaveRegions(n game.NetState, args CommandArgs, cl string) {
 	broadcast("Save Regions: getting and sorting regions")
-	regions := game.GetWorld().Map().RegionsWithin(uo.BoundsFullMap)
+	regions := allRegions()
 	sort.Slice(regions, func(i, j int) bool {
 		a := regions[i]
 		b := regions[j]
+		if a.Facet != b.Facet {
+			return a.Facet < b.Facet
+		}
 		if a.Bounds.X < b.Bounds.X {
 			return true
 		}
@@ -352,6 +376,9 @@ func commandSaveRegions(n game.NetState, args CommandArgs, cl string) {
 		f.WriteString("\n")
 		f.WriteString("[Region]\n")
 		f.WriteString(fmt.Sprintf("Name=%s\n", r.Name))
+		if r.Facet != uo.FacetFelucca {
+			f.WriteString(fmt.Sprintf("Facet=%s\n", r.Facet))
+		}
 		f.WriteString(fmt.Sprintf("Music=%s\n", r.Music))
 		f.WriteString(fmt.Sprintf("Features=0x%04X\n", r.Features))
 		f.WriteString(fmt.Sprintf("SpawnMinZ=%d\n", r.SpawnMinZ))
@@ -375,9 +402,8 @@ func commandLoadRegions(n game.NetState, args CommandArgs, cl string) {
 		return int(v)
 	}
 	broadcast("Load Regions: clearing all regions")
-	regions := game.GetWorld().Map().RegionsWithin(uo.BoundsFullMap)
-	for _, r := range regions {
-		game.GetWorld().Map().RemoveRegion(r)
+	for _, r := range allRegions() {
+		game.GetWorld().Map(r.Facet).RemoveRegion(r)
 	}
 	broadcast("Load Regions: loading regions.ini")
 	f, err := data.FS.Open(path.Join("misc", "regions.ini"))
@@ -401,6 +427,13 @@ func commandLoadRegions(n game.NetState, args CommandArgs, cl string) {
 			switch name {
 			case "Name":
 				region.Name = value
+			case "Facet":
+				f, found := uo.FacetByName(value)
+				if !found {
+					broadcast("Load Regions: unknown facet %s in regions.ini", value)
+					return
+				}
+				region.Facet = f
 			case "Music":
 				region.Music = value
 			case "Features":
@@ -437,13 +470,27 @@ func commandLoadRegions(n game.NetState, args CommandArgs, cl string) {
 			}
 		}
 		region.ForceRecalculateBounds()
-		game.GetWorld().Map().AddRegion(region)
+		m := game.GetWorld().Map(region.Facet)
+		if m == nil {
+			// Regions of facets that are not loaded are skipped
+			continue
+		}
+		m.AddRegion(region)
 	}
 	broadcast("Load Regions: complete")
 }
 
 func commandRespawn(n game.NetState, args CommandArgs, cl string) {
-	for _, r := range game.GetWorld().Map().RegionsWithin(uo.BoundsFullMap) {
+	for _, r := range allRegions() {
 		r.FullRespawn()
 	}
 }
+
+// allRegions returns all regions of all loaded facets.
+func allRegions() []*game.Region {
+	var ret []*game.Region
+	for _, m := range game.GetWorld().Maps() {
+		ret = append(ret, m.RegionsWithin(m.Facet().Bounds())...)
+	}
+	return ret
+}
@@ -18,11 +18,12 @@ func init() {
 	regcmd(&cmdesc{"edit", nil, commandEdit, game.RoleGameMaster, "edit", "Opens the targeted object's edit GUMP if any"})
 	regcmd(&cmdesc{"new", []string{"add"}, commandNew, game.RoleGameMaster, "new template_name [stack_amount]", "Creates a new item with an optional stack amount"})
 	regcmd(&cmdesc{"remove", []string{"rem", "delete", "del"}, commandRemove, game.RoleGameMaster, "remove", "Removes the targeted object and all of its children from the game game.GetWorld()"})
+	regcmd(&cmdesc{"resurrect", []string{"res"}, commandResurrect, game.RoleGameMaster, "resurrect", "Resurrects the targeted ghost"})
 	regcmd(&cmdesc{"sethue", nil, commandSetHue, game.RoleGameMaster, "sethue", "Sets the hue of an object"})
 	regcmd(&cmdesc{"setz", nil, commandSetZ, game.RoleGameMaster, "setz", "Adjusts the Z location of the object"})
 	regcmd(&cmdesc{"static", nil, commandStatic, game.RoleGameMaster, "static graphic_number", "Creates a new static object with the given graphic number"})
 	regcmd(&cmdesc{"tame", nil, commandTame, game.RoleGameMaster, "tame", "makes you the control master of the targeted mobile"})
-	regcmd(&cmdesc{"teleport", []string{"tele"}, commandTeleport, game.RoleGameMaster, "teleport [x y|x y z|multi]", "Teleports you to the targeted location - optionally multiple times, or to the top Z of the given X/Y location, or to the absolute location"})
+	regcmd(&cmdesc{"teleport", []string{"tele"}, commandTeleport, game.RoleGameMaster, "teleport [x y|x y z|x y z facet|multi]", "Teleports you to the targeted location - optionally multiple times, or to the top Z of the given X/Y location, or to the absolute location on your or the named facet"})
 }
 
 func commandBank(n game.NetState, args CommandArgs, cl string) {
@@ -82,8 +83,9 @@ func commandNew(n game.NetState, args CommandArgs, cl string) {
 			o.SetLocation(r.Location)
 			// Try to add the object to the map legit, but if that fails just
 			// force it so we don't leak it.
-			if !game.GetWorld().Map().AddObject(o) {
-				game.GetWorld().Map().ForceAddObject(o)
+			m := game.MapOf(n.Mobile())
+			if !m.AddObject(o) {
+				m.ForceAddObject(o)
 			}
 		}
 
@@ -98,11 +100,23 @@ func commandTeleport(n game.NetState, args CommandArgs, cl string) {
 	multi := false
 	l := uo.Location{}
 	l.Z = uo.MapMaxZ
-	if len(args) > 4 {
-		n.Speech(n.Mobile(), "teleport command expects a maximum of 3 arguments")
+	m := game.MapOf(n.Mobile())
+	if len(args) > 5 {
+		n.Speech(n.Mobile(), "teleport command expects a maximum of 4 arguments")
 		return
 	}
-	if len(args) == 4 {
+	if len(args) == 5 {
+		f, found := uo.FacetByName(args[4])
+		if !found {
+			n.Speech(n.Mobile(), "unknown facet %s", args[4])
+			return
+		}
+		if m = game.GetWorld().Map(f); m == nil {
+			n.Speech(n.Mobile(), "facet %s is not loaded", f)
+			return
+		}
+	}
+	if len(args) >= 4 {
 		l.Z = int8(args.Int(3))
 	}
 	if len(args) >= 3 {
@@ -121,17 +135,17 @@ func commandTeleport(n game.NetState, args CommandArgs, cl string) {
 	if len(args) == 1 {
 		targeted = true
 	}
-	l = l.Bound()
+	l = m.Facet().Bound(l)
 	if !targeted {
 		if l.Z == uo.MapMaxZ {
-			floor, _ := game.GetWorld().Map().GetFloorAndCeiling(l, false, true)
+			floor, _ := m.GetFloorAndCeiling(l, false, true)
 			if floor == nil {
 				n.Speech(n.Mobile(), "location has no floor")
 				return
 			}
 			l.Z = floor.Z()
 		}
-		if !game.GetWorld().Map().TeleportMobile(n.Mobile(), l) {
+		if !m.TeleportMobile(n.Mobile(), l) {
 			n.Speech(n.Mobile(), "something is blocking that location")
 		}
 		return
@@ -142,7 +156,7 @@ func commandTeleport(n game.NetState, args CommandArgs, cl string) {
 		if n.Mobile() == nil {
 			return
 		}
-		if !game.GetWorld().Map().TeleportMobile(n.Mobile(), r.Location) {
+		if !game.MapOf(n.Mobile()).TeleportMobile(n.Mobile(), r.Location) {
 			n.Speech(n.Mobile(), "something is blocking that location")
 		}
 		if multi {
@@ -176,7 +190,7 @@ func commandStatic(n game.NetState, args CommandArgs, cl string) {
 		}
 		i.SetBaseGraphic(g)
 		i.SetLocation(r.Location)
-		game.GetWorld().Map().ForceAddObject(i)
+		game.MapOf(n.Mobile()).ForceAddObject(i)
 	})
 }
 
@@ -194,6 +208,19 @@ func commandRemove(n game.NetState, args CommandArgs, cl string) {
 	})
 }
 
+func commandResurrect(n game.NetState, args CommandArgs, cl string) {
+	if n == nil {
+		return
+	}
+	n.TargetSendCursor(uo.TargetTypeObject, func(r *clientpacket.TargetResponse) {
+		m := game.Find[game.Mobile](r.TargetObject)
+		if m == nil || !m.IsDead() {
+			return
+		}
+		m.Resurrect()
+	})
+}
+
 func commandEdit(n game.NetState, args CommandArgs, cl string) {
 	if n == nil || n.Mobile() == nil {
 		return
@@ -252,9 +279,10 @@ func commandSetZ(n game.NetState, args CommandArgs, cl string) {
 		l := o.Location()
 		l.Z = z
 		if o.Parent() == nil {
-			game.GetWorld().Map().ForceRemoveObject(o)
+			m := game.MapOf(o)
+			m.ForceRemoveObject(o)
 			o.SetLocation(l)
-			game.GetWorld().Map().ForceAddObject(o)
+			m.ForceAddObject(o)
 		} else {
 			o.SetLocation(l)
 		}
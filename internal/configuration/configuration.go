@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/qbradq/sharduo/data"
+	"github.com/qbradq/sharduo/lib/marshal"
 	"github.com/qbradq/sharduo/lib/uo"
 	"github.com/qbradq/sharduo/lib/util"
 )
@@ -31,6 +35,15 @@ var ListsDirectory string
 // Internal data file path for the template variables
 var TemplateVariablesFile string
 
+// Internal data file path for the starting cities
+var StartingCitiesFile string
+
+// Internal data directory where crafting recipes are loaded from
+var CraftingDirectory string
+
+// Internal data directory where harvest definitions are loaded from
+var HarvestDirectory string
+
 //
 // External data paths
 //
@@ -44,9 +57,18 @@ var SaveDirectory string
 // External directory path to write archived saves to
 var ArchiveDirectory string
 
+// External path to the file naming the save to roll back to at the next start
+var RollbackFile string
+
+// Save or archive to load instead of the most recent save, see FindSave
+var LoadSave string
+
 // External directory containing the client files
 var ClientFilesDirectory string
 
+// If true the map and statics diff files of older clients are applied
+var UseMapDiffs bool
+
 // External path to the crontab file
 var CrontabFile string
 
@@ -60,6 +82,9 @@ var LoginServerAddress string
 // TCP port to bind to
 var LoginServerPort int
 
+// Client versions allowed to connect
+var ClientVersions uo.ClientVersionSet
+
 //
 // Game service configuration
 //
@@ -73,12 +98,19 @@ var GameServerPublicAddress string
 // TCP port to bind to
 var GameServerPort int
 
-// Save file type
+// Save file type, Flat for a single file per save or Directory for a
+// directory holding one file per segment
 var GameSaveType string
 
 // Name of the game server
 var GameServerName string
 
+// If true only staff accounts may log in
+var MaintenanceMode bool
+
+// If true the world is never saved
+var ReadOnly bool
+
 //
 // Debug flags
 //
@@ -99,6 +131,39 @@ var StartingLocation uo.Location
 // Starting facing
 var StartingFacing uo.Direction
 
+// Maximum number of characters on one account
+var MaxCharactersPerAccount int
+
+// Minimum age of a character before it may be deleted
+var MinimumCharacterAge time.Duration
+
+// Number of path finding nodes AI movement may search every tick
+var PathfindingBudget int
+
+// Maximum raw value of any one skill, 1000 is 100.0
+var SkillCap int
+
+// Maximum raw value of all skills combined
+var TotalSkillCap int
+
+// Maximum of strength, dexterity and intelligence combined
+var TotalStatCap int
+
+// If true players may attack other players and loot their corpses
+var PvP bool
+
+// Time it takes for one murder count to decay
+var MurderCountDecay uo.Time
+
+// If true town guards kill their targets instantly, otherwise they fight them
+var GuardsInstantKill bool
+
+// Seed of the ore veins of the world
+var VeinSeed int
+
+// Facets to load, Felucca is always loaded
+var Facets []uo.Facet
+
 // Load loads the configuration from the file
 func Load() error {
 	d, err := os.ReadFile(ConfigurationFile)
@@ -131,20 +196,36 @@ func Load() error {
 	TemplatesDirectory = tfo.GetString("TemplatesDirectory", "templates")
 	ListsDirectory = tfo.GetString("ListsDirectory", "templates")
 	TemplateVariablesFile = tfo.GetString("TemplateVariablesFile", "misc/templates")
+	StartingCitiesFile = tfo.GetString("StartingCitiesFile", "misc/starting-cities.ini")
+	CraftingDirectory = tfo.GetString("CraftingDirectory", "misc/crafting")
+	HarvestDirectory = tfo.GetString("HarvestDirectory", "misc/harvest")
 	// External paths
 	SaveDirectory = tfo.GetString("SaveDirectory", "saves")
 	ArchiveDirectory = tfo.GetString("ArchiveDirectory", "archives")
+	RollbackFile = tfo.GetString("RollbackFile", path.Join(SaveDirectory, "rollback"))
+	LoadSave = tfo.GetString("LoadSave", "")
 	ClientFilesDirectory = tfo.GetString("ClientFilesDirectory", "client")
+	UseMapDiffs = tfo.GetBool("UseMapDiffs", false)
 	CrontabFile = tfo.GetString("CrontabFile", "crontab")
 	// Login service configuration
 	LoginServerAddress = tfo.GetString("LoginServerAddress", "0.0.0.0")
 	LoginServerPort = tfo.GetNumber("LoginServerPort", 7775)
+	ClientVersions, err = uo.ParseClientVersionSet(tfo.GetString("ClientVersions",
+		uo.ClientVersionDefault.String()))
+	if err != nil {
+		return err
+	}
 	// Game service configuration
 	GameServerAddress = tfo.GetString("GameServerAddress", "0.0.0.0")
 	GameServerPublicAddress = tfo.GetString("GameServerPublicAddress", "127.0.0.1")
 	GameServerPort = tfo.GetNumber("GameServerPort", 7777)
 	GameSaveType = tfo.GetString("GameSaveType", "Flat")
+	if _, err := marshal.NewSaveBackend(GameSaveType); err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
 	GameServerName = tfo.GetString("GameServerName", "ShardUO TC")
+	MaintenanceMode = tfo.GetBool("MaintenanceMode", false)
+	ReadOnly = tfo.GetBool("ReadOnly", false)
 	// Debug flags
 	GenerateDebugMaps = tfo.GetBool("GenerateDebugMaps", false)
 	CPUProfile = tfo.GetBool("CPUProfile", false)
@@ -155,10 +236,75 @@ func Load() error {
 		Z: 0,
 	})
 	StartingFacing = uo.Direction(tfo.GetNumber("StartingFacing", 4))
+	MaxCharactersPerAccount = tfo.GetNumber("MaxCharactersPerAccount", 5)
+	if MaxCharactersPerAccount < 1 || MaxCharactersPerAccount > 7 {
+		return fmt.Errorf("error: MaxCharactersPerAccount must be 1-7, got %d",
+			MaxCharactersPerAccount)
+	}
+	MinimumCharacterAge = time.Duration(tfo.GetNumber("MinimumCharacterAge", 168)) * time.Hour
+	PathfindingBudget = tfo.GetNumber("PathfindingBudget", 2000)
+	if PathfindingBudget < 1 {
+		return fmt.Errorf("error: PathfindingBudget must be at least 1, got %d",
+			PathfindingBudget)
+	}
+	SkillCap = tfo.GetNumber("SkillCap", uo.SkillCapDefault)
+	if SkillCap < 0 || SkillCap > 0xFFFF {
+		return fmt.Errorf("error: SkillCap must be 0-65535, got %d", SkillCap)
+	}
+	TotalSkillCap = tfo.GetNumber("TotalSkillCap", uo.TotalSkillCapDefault)
+	if TotalSkillCap < 0 {
+		return fmt.Errorf("error: TotalSkillCap must not be negative, got %d",
+			TotalSkillCap)
+	}
+	TotalStatCap = tfo.GetNumber("TotalStatCap", uo.StatsCapDefault)
+	if TotalStatCap < uo.StatMinimum*3 {
+		return fmt.Errorf("error: TotalStatCap must be at least %d, got %d",
+			uo.StatMinimum*3, TotalStatCap)
+	}
+	PvP = tfo.GetBool("PvP", true)
+	decay := tfo.GetNumber("MurderCountDecay", int(uo.MurderCountDecayDefault/uo.DurationHour))
+	if decay < 1 {
+		return fmt.Errorf("error: MurderCountDecay must be at least 1, got %d", decay)
+	}
+	MurderCountDecay = uo.Time(decay) * uo.DurationHour
+	GuardsInstantKill = tfo.GetBool("GuardsInstantKill", true)
+	VeinSeed = tfo.GetNumber("VeinSeed", 0)
+	Facets = []uo.Facet{uo.FacetFelucca}
+	loaded := map[uo.Facet]bool{uo.FacetFelucca: true}
+	for _, s := range strings.Split(tfo.GetString("Facets", "Felucca"), ",") {
+		f, found := uo.FacetByName(s)
+		if !found {
+			return fmt.Errorf("error: unknown facet %s in Facets", s)
+		}
+		if !loaded[f] {
+			Facets = append(Facets, f)
+			loaded[f] = true
+		}
+	}
 
 	return nil
 }
 
+// FindSave returns the path of the named save. The name may be the path of a
+// save or the name of a save within the save or archive directories, like
+// daily1.sav.gz.
+func FindSave(name string) (string, error) {
+	if marshal.SaveBackendFor(name) == nil {
+		return "", fmt.Errorf("%s is not a save", name)
+	}
+	for _, p := range []string{
+		name,
+		path.Join(SaveDirectory, name),
+		path.Join(ArchiveDirectory, name),
+	} {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("save %s not found in %s or %s", name, SaveDirectory,
+		ArchiveDirectory)
+}
+
 // writeDefaultConfiguration writes out the default configuration file
 func writeDefaultConfiguration() ([]byte, error) {
 	d, err := data.FS.ReadFile(DefaultConfigurationFile)
@@ -0,0 +1,305 @@
+package crafting
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/qbradq/sharduo/data"
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+// Maximum distance to a crafting station
+const stationRange int16 = 3
+
+// Station graphics by station name
+var stations = map[string]map[uo.Graphic]struct{}{
+	"Anvil": {
+		0x0FAF: {},
+		0x0FB0: {},
+		0x2DD5: {},
+		0x2DD6: {},
+	},
+	"Forge": {
+		0x0FB1: {},
+		0x197A: {},
+		0x197E: {},
+		0x1982: {},
+		0x1986: {},
+		0x198A: {},
+		0x198E: {},
+		0x1992: {},
+		0x1996: {},
+		0x199A: {},
+		0x199E: {},
+		0x19A2: {},
+		0x19A6: {},
+	},
+}
+
+// Craft describes a crafting profession like blacksmithy. Crafts are defined
+// by [Craft] objects within the crafting data files.
+type Craft struct {
+	// Name of the craft
+	Name string
+	// Skill used to repair items
+	Skill uo.Skill
+	// Names of the stations that must be near the crafter
+	Stations []string
+	// Message sent when the crafter is not near all stations
+	NoStationMessage uo.Cliloc
+	// Sound played when crafting
+	Sound uo.Sound
+	// If true items made by this craft may be repaired
+	Repair bool
+	// Name of the group of materials the craft works with, may be empty
+	MaterialGroup string
+	// Names of all recipe categories in the order they first appear
+	Categories []string
+	// All recipes of the craft in the order they appear
+	Recipes []*Recipe
+}
+
+// Resource is a resource consumed by a recipe.
+type Resource struct {
+	// Template name of the resource
+	Template string
+	// Display name of the resource
+	Name string
+	// Amount of the resource consumed
+	Amount int
+}
+
+// Recipe describes how to craft one item. Recipes are defined by [Recipe]
+// objects within the crafting data files.
+type Recipe struct {
+	// Craft the recipe belongs to
+	Craft *Craft
+	// Category the recipe is listed under
+	Category string
+	// Display name of the recipe
+	Name string
+	// Skill checked when crafting
+	Skill uo.Skill
+	// Raw skill value below which crafting always fails
+	MinSkill int
+	// Raw skill value above which crafting always succeeds
+	MaxSkill int
+	// Resources consumed from the backpack of the crafter
+	Resources []Resource
+	// Template name of the tool the recipe is crafted with
+	Tool string
+	// Template name of the item created
+	Template string
+	// Amount of the item created
+	Amount int
+}
+
+// All crafts by name
+var crafts = map[string]*Craft{}
+
+// All crafts by the template name of the tools used with them
+var tools = map[string]*Craft{}
+
+// All crafts by the template name of the items they create
+var products = map[string]*Craft{}
+
+// Load loads all crafts, recipes and materials from the crafting data files within the
+// given internal data directory. This must be called after templates are
+// loaded.
+func Load(dir string) []error {
+	var recipes []*util.TagFileObject
+	errs := data.Walk(dir, func(path string, d []byte) []error {
+		var errs []error
+		tfr := &util.TagFileReader{}
+		tfr.StartReading(bytes.NewReader(d))
+		for {
+			tfo := tfr.ReadObject()
+			if tfo == nil {
+				break
+			}
+			switch tfo.TypeName() {
+			case "Craft":
+				c, err := newCraft(tfo)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", path, err))
+					continue
+				}
+				crafts[c.Name] = c
+			case "Recipe":
+				recipes = append(recipes, tfo)
+			case "Material":
+				if _, err := newMaterial(tfo); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				}
+			default:
+				errs = append(errs, fmt.Errorf("unexpected object type %s in %s",
+					tfo.TypeName(), path))
+			}
+		}
+		return append(errs, tfr.Errors()...)
+	})
+	// Recipes are resolved after all crafts are known
+	for _, tfo := range recipes {
+		if err := addRecipe(tfo); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, c := range crafts {
+		if c.MaterialGroup != "" && len(c.Materials()) == 0 {
+			errs = append(errs, fmt.Errorf("craft %s: unknown material group %s",
+				c.Name, c.MaterialGroup))
+		}
+	}
+	return errs
+}
+
+// newCraft creates a craft from its data file object.
+func newCraft(tfo *util.TagFileObject) (*Craft, error) {
+	c := &Craft{
+		Name:             tfo.GetString("Name", ""),
+		Sound:            uo.Sound(tfo.GetNumber("Sound", 0)),
+		Repair:           tfo.GetBool("Repair", false),
+		NoStationMessage: uo.Cliloc(tfo.GetNumber("NoStationMessage", 0)),
+		MaterialGroup:    tfo.GetString("Materials", ""),
+	}
+	if c.Name == "" {
+		return nil, fmt.Errorf("craft without a name")
+	}
+	if _, duplicate := crafts[c.Name]; duplicate {
+		return nil, fmt.Errorf("duplicate craft %s", c.Name)
+	}
+	skill, found := uo.SkillByName(tfo.GetString("Skill", ""))
+	if !found {
+		return nil, fmt.Errorf("craft %s: unknown skill %s", c.Name, tfo.GetString("Skill", ""))
+	}
+	c.Skill = skill
+	if s := tfo.GetString("Stations", ""); s != "" {
+		for _, name := range strings.Split(s, ",") {
+			name = strings.TrimSpace(name)
+			if _, found := stations[name]; !found {
+				return nil, fmt.Errorf("craft %s: unknown station %s", c.Name, name)
+			}
+			c.Stations = append(c.Stations, name)
+		}
+	}
+	if tfo.HasErrors() {
+		return nil, tfo.Errors()[0]
+	}
+	return c, nil
+}
+
+// addRecipe creates a recipe from its data file object and adds it to its
+// craft.
+func addRecipe(tfo *util.TagFileObject) error {
+	r := &Recipe{
+		Category: tfo.GetString("Category", ""),
+		Name:     tfo.GetString("Name", ""),
+		MinSkill: tfo.GetNumber("MinSkill", 0),
+		MaxSkill: tfo.GetNumber("MaxSkill", 1000),
+		Tool:     tfo.GetString("Tool", ""),
+		Template: tfo.GetString("Template", ""),
+		Amount:   tfo.GetNumber("Amount", 1),
+	}
+	c := crafts[tfo.GetString("Craft", "")]
+	if c == nil {
+		return fmt.Errorf("recipe %s: unknown craft %s", r.Name,
+			tfo.GetString("Craft", ""))
+	}
+	r.Craft = c
+	if r.Name == "" || r.Category == "" {
+		return fmt.Errorf("recipe in craft %s without a name or category", c.Name)
+	}
+	skill, found := uo.SkillByName(tfo.GetString("Skill", ""))
+	if !found {
+		return fmt.Errorf("recipe %s: unknown skill %s", r.Name, tfo.GetString("Skill", ""))
+	}
+	r.Skill = skill
+	if r.MinSkill >= r.MaxSkill {
+		return fmt.Errorf("recipe %s: minimum skill must be less than maximum skill", r.Name)
+	}
+	for _, t := range []string{r.Tool, r.Template} {
+		if template.FindTemplate(t) == nil {
+			return fmt.Errorf("recipe %s: unknown template %s", r.Name, t)
+		}
+	}
+	for _, s := range strings.Split(tfo.GetString("Resources", ""), ",") {
+		if s == "" {
+			continue
+		}
+		res, err := parseResource(s)
+		if err != nil {
+			return fmt.Errorf("recipe %s: %w", r.Name, err)
+		}
+		r.Resources = append(r.Resources, res)
+	}
+	if tfo.HasErrors() {
+		return tfo.Errors()[0]
+	}
+	if oc := tools[r.Tool]; oc != nil && oc != c {
+		return fmt.Errorf("recipe %s: tool %s already used by craft %s", r.Name,
+			r.Tool, oc.Name)
+	}
+	tools[r.Tool] = c
+	products[r.Template] = c
+	c.Recipes = append(c.Recipes, r)
+	for _, cat := range c.Categories {
+		if cat == r.Category {
+			return nil
+		}
+	}
+	c.Categories = append(c.Categories, r.Category)
+	return nil
+}
+
+// parseResource parses a resource in the form TemplateName:Amount.
+func parseResource(s string) (Resource, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 2 {
+		return Resource{}, fmt.Errorf("malformed resource %s", s)
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil || n < 1 {
+		return Resource{}, fmt.Errorf("malformed resource amount %s", s)
+	}
+	t := template.FindTemplate(parts[0])
+	if t == nil {
+		return Resource{}, fmt.Errorf("unknown resource template %s", parts[0])
+	}
+	return Resource{
+		Template: parts[0],
+		Name:     t.GetString("Plural", t.GetString("Name", parts[0])),
+		Amount:   n,
+	}, nil
+}
+
+// ForTool returns the craft the tool is used with, or nil if the item is not a
+// crafting tool.
+func ForTool(tool game.Item) *Craft { return tools[tool.TemplateName()] }
+
+// RecipesFor returns all recipes of the category that are crafted with the
+// tool.
+func (c *Craft) RecipesFor(tool game.Item, category string) []*Recipe {
+	var ret []*Recipe
+	for _, r := range c.Recipes {
+		if r.Category == category && r.Tool == tool.TemplateName() {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+// CanRepair returns true if the craft is able to repair the item.
+func (c *Craft) CanRepair(item game.Item) bool {
+	return c.Repair && products[item.TemplateName()] == c
+}
+
+// NearStation returns true if a station with the given name is near the
+// object.
+func NearStation(o game.Object, station string) bool {
+	return game.MapOf(o).Query(o.Location(), stationRange, stations[station])
+}
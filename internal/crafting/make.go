@@ -0,0 +1,207 @@
+package crafting
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// CanUse returns true if the mobile is able to use the crafting tool right
+// now, sending the reason to the mobile if not.
+func CanUse(m game.Mobile, tool game.Item) bool {
+	n := m.NetState()
+	c := ForTool(tool)
+	if n == nil || c == nil || tool.Removed() {
+		return false
+	}
+	if m.IsDead() {
+		n.Cliloc(nil, 1019048) // I am dead and cannot do that.
+		return false
+	}
+	if root := game.RootParent(tool); root == nil || root.Serial() != m.Serial() {
+		n.Cliloc(nil, 1044263) // The tool must be on your person to use.
+		return false
+	}
+	for _, s := range c.Stations {
+		if !NearStation(m, s) {
+			n.Cliloc(nil, c.NoStationMessage)
+			return false
+		}
+	}
+	return true
+}
+
+// Chance returns the chance of the mobile to successfully craft the recipe in
+// the range 0-1000.
+func Chance(m game.Mobile, r *Recipe) int {
+	v := int(m.Skill(r.Skill))
+	if v < r.MinSkill {
+		return 0
+	}
+	if v >= r.MaxSkill {
+		return 1000
+	}
+	return (v - r.MinSkill) * 1000 / (r.MaxSkill - r.MinSkill)
+}
+
+// exceptionalChance returns the chance of the mobile to craft an item of
+// exceptional quality in the range 0-1000. This chance starts at the midpoint
+// between the minimum and maximum skill of the recipe.
+func exceptionalChance(m game.Mobile, r *Recipe) int {
+	v := int(m.Skill(r.Skill))
+	ret := (v - (r.MinSkill+r.MaxSkill)/2) * 1000 / (r.MaxSkill - r.MinSkill)
+	if ret < 0 {
+		return 0
+	}
+	if ret > 1000 {
+		return 1000
+	}
+	return ret
+}
+
+// hasResources returns true if the mobile carries all of the resources in its
+// backpack.
+func hasResources(m game.Mobile, resources []Resource) bool {
+	bp, ok := m.EquipmentInSlot(uo.LayerBackpack).(game.Container)
+	if !ok {
+		return len(resources) == 0
+	}
+	for _, res := range resources {
+		if bp.CountTemplate(res.Template) < res.Amount {
+			return false
+		}
+	}
+	return true
+}
+
+// consumeResources removes the resources from the backpack of the mobile. Only
+// half of each resource is consumed if whole is false.
+func consumeResources(m game.Mobile, resources []Resource, whole bool) {
+	bp, ok := m.EquipmentInSlot(uo.LayerBackpack).(game.Container)
+	if !ok {
+		return
+	}
+	for _, res := range resources {
+		n := res.Amount
+		if !whole {
+			n = (n + 1) / 2
+		}
+		bp.ConsumeTemplate(res.Template, n)
+	}
+}
+
+// Make attempts to craft the recipe from the material with the tool. The
+// material may be nil for recipes of crafts without materials. Items made from
+// a material take on its hue. If mark is true exceptional items bear the
+// maker's mark of the crafter. Failing to craft the item loses half of the
+// resources. Every attempt wears out the tool.
+func Make(m game.Mobile, tool game.Item, r *Recipe, mat *Material, mark bool) {
+	if !CanUse(m, tool) || r.Tool != tool.TemplateName() {
+		return
+	}
+	n := m.NetState()
+	if Chance(m, r) < 1 {
+		n.Cliloc(nil, 1044153) // You don't have the required skills to attempt this item.
+		return
+	}
+	resources, used := r.ResourcesFor(mat)
+	if used && int(m.Skill(r.Craft.Skill)) < mat.CraftSkill {
+		n.Cliloc(nil, 1044268) // You have no idea how to work this metal.
+		return
+	}
+	if !hasResources(m, resources) {
+		n.Cliloc(nil, 1044253) // You don't have the components needed to make that.
+		return
+	}
+	w := game.GetWorld()
+	game.MapOf(m).PlayAnimation(m, uo.AnimationTypeAttack, uo.AnimationActionSlash1H)
+	if r.Craft.Sound != 0 {
+		game.MapOf(m).PlaySound(r.Craft.Sound, m.Location())
+	}
+	if !m.SkillCheck(r.Skill, r.MinSkill, r.MaxSkill) {
+		consumeResources(m, resources, false)
+		n.Cliloc(nil, 1044043) // You failed to create the item, and some of your materials are lost.
+	} else {
+		consumeResources(m, resources, true)
+		item := template.Create[game.Item](r.Template)
+		if item == nil {
+			// Something is very wrong
+			return
+		}
+		if used && mat.Hue != uo.HueDefault {
+			item.SetHue(mat.Hue)
+		}
+		if item.Stackable() {
+			item.SetAmount(r.Amount)
+		}
+		exceptional := w.Random().Random(0, 999) < exceptionalChance(m, r)
+		if exceptional && mark {
+			item.SetCrafted(true, m.DisplayName())
+			n.Cliloc(nil, 1044155) // You create an exceptional quality item and affix your maker's mark.
+		} else if exceptional {
+			item.SetCrafted(true, "")
+			n.Cliloc(nil, 1044156) // You create an exceptional quality item.
+		} else {
+			n.Cliloc(nil, 1044154) // You create the item.
+		}
+		if !m.DropToBackpack(item, false) {
+			m.DropToFeet(item)
+		}
+	}
+	wearTool(m, tool)
+}
+
+// Repair sends a target cursor to the mobile to select an item to repair with
+// the tool. Repairing an item restores all of its durability. Failing to
+// repair an item damages it.
+func Repair(m game.Mobile, tool game.Item) {
+	c := ForTool(tool)
+	if !CanUse(m, tool) || !c.Repair {
+		return
+	}
+	n := m.NetState()
+	n.Cliloc(nil, 1044276) // Target an item to repair.
+	n.TargetSendCursor(uo.TargetTypeObject, func(tr *clientpacket.TargetResponse) {
+		if !CanUse(m, tool) {
+			return
+		}
+		item := game.Find[game.Wearable](tr.TargetObject)
+		if item == nil || !c.CanRepair(item) {
+			n.Cliloc(nil, 1044277) // That item cannot be repaired.
+			return
+		}
+		if root := game.RootParent(item); root == nil || root.Serial() != m.Serial() {
+			n.Cliloc(nil, 1044275) // The item must be in your backpack to repair it.
+			return
+		}
+		if item.Durability() >= item.MaxDurability() {
+			n.Cliloc(nil, 1044281) // That item is in full repair
+			return
+		}
+		if c.Sound != 0 {
+			game.MapOf(m).PlaySound(c.Sound, m.Location())
+		}
+		// Badly damaged items are harder to repair
+		damage := int((1 - item.Durability()/item.MaxDurability()) * 1000)
+		if m.SkillCheck(c.Skill, damage-500, damage+500) {
+			item.Repair(item, item.MaxDurability())
+			n.Cliloc(nil, 1044279) // You repair the item.
+		} else {
+			item.DamageDurability(item, 1)
+			n.Cliloc(nil, 1044280) // You fail to repair the item.
+		}
+		wearTool(m, tool)
+	})
+}
+
+// wearTool consumes one use of the tool and removes it once it is worn out.
+func wearTool(m game.Mobile, tool game.Item) {
+	if !tool.ConsumeUse() || tool.Uses() > 0 {
+		return
+	}
+	game.Remove(tool)
+	if m.NetState() != nil {
+		m.NetState().Cliloc(nil, 1044038) // You have worn out your tool!
+	}
+}
@@ -0,0 +1,121 @@
+package crafting
+
+import (
+	"fmt"
+
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+// Material is one kind of crafting resource like dull copper. Materials of the
+// same group may replace each other in recipes. The first material of a group
+// is its base material and recipes are written with its resource. Materials
+// are defined by [Material] objects within the crafting data files.
+type Material struct {
+	// Display name of the material
+	Name string
+	// Name of the material group
+	Group string
+	// Hue of items crafted from the material
+	Hue uo.Hue
+	// Template name of the resource consumed by recipes
+	Resource string
+	// Template name of the ore smelted into the resource, may be empty
+	Ore string
+	// Raw mining skill value below which smelting always fails
+	SmeltMinSkill int
+	// Raw mining skill value above which smelting always succeeds
+	SmeltMaxSkill int
+	// Raw skill value of the craft required to work the material
+	CraftSkill int
+}
+
+// All materials by group name in the order they appear
+var materials = map[string][]*Material{}
+
+// All materials by the template name of their ore
+var ores = map[string]*Material{}
+
+// newMaterial creates a material from its data file object and adds it to its
+// group.
+func newMaterial(tfo *util.TagFileObject) (*Material, error) {
+	m := &Material{
+		Name:          tfo.GetString("Name", ""),
+		Group:         tfo.GetString("Group", ""),
+		Hue:           uo.Hue(tfo.GetNumber("Hue", int(uo.HueDefault))),
+		Resource:      tfo.GetString("Resource", ""),
+		Ore:           tfo.GetString("Ore", ""),
+		SmeltMinSkill: tfo.GetNumber("SmeltMinSkill", 0),
+		SmeltMaxSkill: tfo.GetNumber("SmeltMaxSkill", 1000),
+		CraftSkill:    tfo.GetNumber("CraftSkill", 0),
+	}
+	if m.Name == "" || m.Group == "" {
+		return nil, fmt.Errorf("material without a name or group")
+	}
+	if template.FindTemplate(m.Resource) == nil {
+		return nil, fmt.Errorf("material %s: unknown resource template %s", m.Name,
+			m.Resource)
+	}
+	if m.Ore != "" {
+		if template.FindTemplate(m.Ore) == nil {
+			return nil, fmt.Errorf("material %s: unknown ore template %s", m.Name, m.Ore)
+		}
+		if om := ores[m.Ore]; om != nil {
+			return nil, fmt.Errorf("material %s: ore %s already used by material %s",
+				m.Name, m.Ore, om.Name)
+		}
+	}
+	if m.SmeltMinSkill >= m.SmeltMaxSkill {
+		return nil, fmt.Errorf("material %s: minimum smelting skill must be less than maximum smelting skill", m.Name)
+	}
+	if tfo.HasErrors() {
+		return nil, tfo.Errors()[0]
+	}
+	materials[m.Group] = append(materials[m.Group], m)
+	if m.Ore != "" {
+		ores[m.Ore] = m
+	}
+	return m, nil
+}
+
+// Materials returns all materials the craft works with, the first one being
+// the base material. nil is returned for crafts without materials.
+func (c *Craft) Materials() []*Material {
+	if c.MaterialGroup == "" {
+		return nil
+	}
+	return materials[c.MaterialGroup]
+}
+
+// MaterialForOre returns the material the ore smelts into, or nil if the item
+// is not an ore.
+func MaterialForOre(ore string) *Material { return ores[ore] }
+
+// ResourcesFor returns the resources consumed when crafting the recipe from
+// the material, and true if the material is used by the recipe. The resource
+// of the base material is replaced with the resource of the material.
+func (r *Recipe) ResourcesFor(mat *Material) ([]Resource, bool) {
+	all := r.Craft.Materials()
+	if mat == nil || len(all) == 0 {
+		return r.Resources, false
+	}
+	base := all[0]
+	used := false
+	ret := make([]Resource, len(r.Resources))
+	for i, res := range r.Resources {
+		ret[i] = res
+		if res.Template != base.Resource {
+			continue
+		}
+		used = true
+		if mat == base {
+			continue
+		}
+		ret[i].Template = mat.Resource
+		if t := template.FindTemplate(mat.Resource); t != nil {
+			ret[i].Name = t.GetString("Plural", t.GetString("Name", mat.Resource))
+		}
+	}
+	return ret, used
+}
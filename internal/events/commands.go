@@ -12,6 +12,7 @@ func init() {
 	reg("CommandDrop", CommandDrop)
 	reg("CommandFollow", CommandFollow)
 	reg("CommandFollowMe", CommandFollowMe)
+	reg("CommandGuard", CommandGuard)
 	reg("CommandRelease", CommandRelease)
 	reg("CommandStay", CommandStay)
 }
@@ -60,6 +61,25 @@ func CommandFollowMe(receiver, source game.Object, v any) bool {
 	return true
 }
 
+// CommandGuard commands a pet to follow the source mobile and attack anything
+// that fights with it.
+func CommandGuard(receiver, source game.Object, v any) bool {
+	rm, ok := receiver.(game.Mobile)
+	if !ok {
+		return false
+	}
+	sm, ok := source.(game.Mobile)
+	if !ok {
+		return false
+	}
+	if !rm.CanBeCommandedBy(sm) {
+		return false
+	}
+	rm.SetAI("Guard")
+	rm.SetAIGoal(sm)
+	return true
+}
+
 // CommandStay commands a pet to stay in its current location
 // command the receiving mobile.
 func CommandStay(receiver, source game.Object, v any) bool {
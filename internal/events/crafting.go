@@ -0,0 +1,30 @@
+package events
+
+// Crafting events
+
+import (
+	"github.com/qbradq/sharduo/internal/crafting"
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/internal/gumps"
+)
+
+func init() {
+	reg("OpenCraftMenu", OpenCraftMenu)
+}
+
+// OpenCraftMenu opens the crafting menu of the receiving tool.
+func OpenCraftMenu(receiver, source game.Object, v any) bool {
+	tool, ok := receiver.(game.Item)
+	if !ok {
+		return false
+	}
+	sm, ok := source.(game.Mobile)
+	if !ok || sm.NetState() == nil {
+		return false
+	}
+	if !crafting.CanUse(sm, tool) {
+		return false
+	}
+	sm.NetState().GUMP(gumps.New("craft"), sm, tool)
+	return true
+}
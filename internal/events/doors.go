@@ -71,12 +71,13 @@ func doUseDoor(receiver, source game.Object, force bool) bool {
 	}
 	// Skip map fit check, not all doors on the retail map have clearance with
 	// the terrain to properly open
-	game.GetWorld().Map().ForceRemoveObject(ri)
+	m := game.MapOf(ri)
+	m.ForceRemoveObject(ri)
 	ri.Flip()
 	ri.SetLocation(l)
 	ri.SetDefForGraphic(ri.Graphic())
-	game.GetWorld().Map().ForceAddObject(ri)
-	game.GetWorld().Map().PlaySound(s, l)
+	m.ForceAddObject(ri)
+	m.PlaySound(s, l)
 	// Auto-close functionality
 	if ri.Flipped() {
 		// Door is now open, setup a timer to close it
@@ -97,7 +98,7 @@ func UseDoor(receiver, source game.Object, v any) bool {
 	if !ok {
 		force = false
 	}
-	doors := game.GetWorld().Map().ItemBaseQuery("BaseDoor", receiver.Location().BoundsByRadius(1))
+	doors := game.MapOf(receiver).ItemBaseQuery("BaseDoor", receiver.Location().BoundsByRadius(1))
 	for _, d := range doors {
 		if !doUseDoor(d, source, force) {
 			return false
@@ -25,6 +25,7 @@ func init() {
 	reg("OpenTeleportGUMP", OpenTeleportGUMP)
 	reg("PlayerDoubleClick", PlayerDoubleClick)
 	reg("TransferHue", TransferHue)
+	reg("UseMoongate", UseMoongate)
 }
 
 // PlayerDoubleClick selects between the open paper doll and dismount actions
@@ -188,7 +189,7 @@ func OpenBankBox(receiver, source game.Object, v any) bool {
 		return false
 	}
 	bb.Open(m)
-	game.GetWorld().Map().SendCliloc(receiver, uo.SpeechNormalRange, 1080021,
+	game.MapOf(receiver).SendCliloc(receiver, uo.SpeechNormalRange, 1080021,
 		strconv.Itoa(bb.ItemCount()), strconv.Itoa(int(bb.Weight()))) // Bank container has ~1_VAL~ items, ~2_VAL~ stones
 	return true
 }
@@ -237,6 +238,23 @@ func OpenTeleportGUMP(receiver, source game.Object, v any) bool {
 	return true
 }
 
+// UseMoongate opens the destination menu of the receiving moongate.
+func UseMoongate(receiver, source game.Object, v any) bool {
+	if receiver == nil || source == nil {
+		return false
+	}
+	sm, ok := source.(game.Mobile)
+	if !ok || sm.NetState() == nil {
+		return false
+	}
+	if sm.Facet() != receiver.Facet() || sm.Location().XYDistance(receiver.Location()) > 1 {
+		sm.NetState().Cliloc(nil, 500446) // That is too far away.
+		return false
+	}
+	sm.NetState().GUMP(gumps.New("moongate"), source, receiver)
+	return true
+}
+
 func HarvestCrop(receiver, source game.Object, v any) bool {
 	sm, ok := source.(game.Mobile)
 	if !ok || sm.NetState() == nil {
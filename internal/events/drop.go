@@ -57,8 +57,19 @@ func DropToPlayer(receiver, source game.Object, v any) bool {
 		// Drop to self, just put it in the backpack
 		return rm.DropToBackpack(item, false)
 	} else if sm.IsPlayerCharacter() {
-		// TODO Secure trade
-		return false
+		// Drop to another player, offer the item in a secure trade
+		if !rm.IsPlayerCharacter() || rm.NetState() == nil {
+			return false
+		}
+		if sm.Location().XYDistance(rm.Location()) > uo.MaxDropRange {
+			return false
+		}
+		t := game.OpenSecureTrade(sm, rm)
+		if t == nil {
+			return false
+		}
+		item.SetDropLocation(uo.RandomContainerLocation)
+		return t.Container(sm).AddObject(item)
 	} // Else this is a non-player mobile trying to drop something on us. This
 	// should not happen.
 	return false
@@ -0,0 +1,111 @@
+package events
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/internal/harvest"
+	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("BeginHarvest", BeginHarvest)
+	reg("ContinueHarvest", ContinueHarvest)
+	reg("FinishHarvest", FinishHarvest)
+}
+
+// Time between the steps of one harvest attempt
+const harvestStepDelay uo.Time = 12
+
+// Registry of active harvesters
+var regHarvesters = map[uo.Serial]struct{}{}
+
+// startHarvestLoop begins one harvest attempt at the target.
+func startHarvestLoop(harvester game.Mobile, tool game.Item, p *clientpacket.TargetResponse) {
+	// Register the harvester blindly
+	regHarvesters[harvester.Serial()] = struct{}{}
+	// Sanity checks
+	if !harvest.CanUse(harvester, tool) || !harvest.CanHarvestAt(harvester, tool, p) {
+		delete(regHarvesters, harvester.Serial())
+		return
+	}
+	game.MapOf(harvester).PlayAnimation(harvester, uo.AnimationTypeAttack,
+		harvest.ForTool(tool).Animation)
+	game.NewTimer(harvestStepDelay, "ContinueHarvest", tool, harvester, true, p)
+}
+
+// BeginHarvest sends the target cursor to select where to harvest with the
+// receiving tool.
+func BeginHarvest(receiver, source game.Object, v any) bool {
+	if receiver == nil || source == nil {
+		return false
+	}
+	harvester, ok := source.(game.Mobile)
+	if !ok || harvester.NetState() == nil {
+		return false
+	}
+	tool, ok := receiver.(game.Item)
+	if !ok || tool.Removed() {
+		return false
+	}
+	// Sanity checks
+	if !harvest.CanUse(harvester, tool) {
+		return false
+	}
+	if _, found := regHarvesters[harvester.Serial()]; found {
+		harvester.NetState().Speech(nil, "You are already doing that.")
+		return false
+	}
+	// Targeting
+	harvester.NetState().TargetSendCursor(uo.TargetTypeLocation, func(p *clientpacket.TargetResponse) {
+		startHarvestLoop(harvester, tool, p)
+	})
+	return true
+}
+
+// ContinueHarvest plays the effects of the harvest in progress.
+func ContinueHarvest(receiver, source game.Object, v any) bool {
+	if receiver == nil || source == nil {
+		return false
+	}
+	p := v.(*clientpacket.TargetResponse)
+	harvester, ok := source.(game.Mobile)
+	if !ok {
+		return false
+	}
+	tool, ok := receiver.(game.Item)
+	if !ok || harvester.NetState() == nil || !harvest.CanUse(harvester, tool) {
+		delete(regHarvesters, harvester.Serial())
+		return false
+	}
+	harvest.PlayEffects(harvester, tool, p.Location)
+	game.NewTimer(harvestStepDelay, "FinishHarvest", receiver, source, true, p)
+	return true
+}
+
+// FinishHarvest completes the harvest in progress and starts the next one.
+func FinishHarvest(receiver, source game.Object, v any) bool {
+	if receiver == nil || source == nil {
+		return false
+	}
+	p := v.(*clientpacket.TargetResponse)
+	harvester, ok := source.(game.Mobile)
+	if !ok {
+		return false
+	}
+	tool, ok := receiver.(game.Item)
+	if !ok || harvester.NetState() == nil {
+		delete(regHarvesters, harvester.Serial())
+		return false
+	}
+	if !harvest.Harvest(harvester, tool, p) {
+		delete(regHarvesters, harvester.Serial())
+		return true
+	}
+	// Continue harvesting the spot if the player is still logged in
+	if harvester.NetState() != nil {
+		startHarvestLoop(harvester, tool, p)
+	} else {
+		delete(regHarvesters, harvester.Serial())
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/internal/gumps"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("OfferResurrection", OfferResurrection)
+}
+
+// OfferResurrection opens the resurrection GUMP for the source if it is a
+// ghost standing near the healer receiver.
+func OfferResurrection(receiver, source game.Object, v any) bool {
+	if source == nil || receiver == nil {
+		return false
+	}
+	sm, ok := source.(game.Mobile)
+	if !ok || sm.NetState() == nil {
+		return false
+	}
+	rm, ok := receiver.(game.Mobile)
+	if !ok || rm.IsDead() {
+		return false
+	}
+	if !sm.IsDead() {
+		sm.NetState().Speech(receiver, "Thou art not in need of my services.")
+		return true
+	}
+	if sm.Location().XYDistance(rm.Location()) > uo.MaxUseRange {
+		sm.NetState().Speech(receiver, "Come closer, spirit, so that I may help thee.")
+		return true
+	}
+	sm.NetState().GUMP(gumps.New("resurrect"), sm, rm)
+	return true
+}
@@ -6,10 +6,31 @@ import (
 )
 
 func init() {
+	reg("DecayCorpse", DecayCorpse)
+	reg("DespawnGuard", DespawnGuard)
 	reg("PlayerLogout", PlayerLogout)
 	reg("WhisperTime", WhisperTime)
 }
 
+// DecayCorpse removes the corpse receiver along with everything left in it.
+func DecayCorpse(receiver, source game.Object, v any) bool {
+	c, ok := receiver.(*game.Corpse)
+	if !ok {
+		return false
+	}
+	game.Remove(c)
+	return true
+}
+
+// DespawnGuard removes the town guard receiver once it is done fighting.
+func DespawnGuard(receiver, source game.Object, v any) bool {
+	g, ok := receiver.(game.Mobile)
+	if !ok {
+		return false
+	}
+	return game.DespawnGuard(g)
+}
+
 // WhisperTime whispers the current Sossarian time to the source.
 func WhisperTime(receiver, source game.Object, v any) bool {
 	if source == nil {
@@ -35,8 +56,8 @@ func PlayerLogout(receiver, source game.Object, v any) bool {
 	if !ok || rm.NetState() != nil {
 		return false
 	}
-	game.GetWorld().Map().PlayEffect(uo.GFXTypeFixed, receiver, receiver, 0x3728,
+	game.MapOf(receiver).PlayEffect(uo.GFXTypeFixed, receiver, receiver, 0x3728,
 		15, 10, true, false, uo.HueDefault, uo.GFXBlendModeNormal)
-	game.GetWorld().Map().StoreObject(receiver)
+	game.MapOf(receiver).StoreObject(receiver)
 	return true
 }
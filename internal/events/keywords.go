@@ -10,6 +10,7 @@ import (
 func init() {
 	reg("KeywordsBanker", KeywordsBanker)
 	reg("KeywordsCommand", KeywordsCommand)
+	reg("KeywordsHealer", KeywordsHealer)
 	reg("KeywordsStablemaster", KeywordsStablemaster)
 	reg("KeywordsVendor", KeywordsVendor)
 }
@@ -72,6 +73,15 @@ func KeywordsBanker(receiver, source game.Object, v any) bool {
 	}, receiver, source, words)
 }
 
+// KeywordsHealer handles healer speech triggers.
+func KeywordsHealer(receiver, source game.Object, v any) bool {
+	words := strings.Split(strings.ToLower(v.(string)), " ")
+	return doKeywords([]string{
+		"heal",
+		"resurrect",
+	}, receiver, source, words)
+}
+
 // KeywordsVendor handles common vendor speech triggers.
 func KeywordsVendor(receiver, source game.Object, v any) bool {
 	f, words := speechTarget([]string{"vendor"}, receiver, source, v)
@@ -110,6 +120,7 @@ func KeywordsCommand(receiver, source game.Object, v any) bool {
 		"come",
 		"drop",
 		"follow",
+		"guard",
 		"release",
 		"stay",
 		"stop",
@@ -118,19 +129,22 @@ func KeywordsCommand(receiver, source game.Object, v any) bool {
 
 // keywordEvents maps keywords to the event handlers they belong to
 var keywordEvents = map[string]EventHandler{
-	"balance":  BankBalance,
-	"bank":     OpenBankBox,
-	"buy":      VendorBuy,
-	"check":    BankCheck,
-	"claim":    ClaimAllPets,
-	"come":     CommandFollowMe,
-	"deposit":  BankDeposit,
-	"drop":     CommandDrop,
-	"follow":   CommandFollow,
-	"release":  CommandRelease,
-	"sell":     VendorSell,
-	"stable":   StablePet,
-	"stay":     CommandStay,
-	"stop":     CommandStay,
-	"withdraw": BankWithdraw,
+	"balance":   BankBalance,
+	"bank":      OpenBankBox,
+	"buy":       VendorBuy,
+	"check":     BankCheck,
+	"claim":     ClaimAllPets,
+	"come":      CommandFollowMe,
+	"deposit":   BankDeposit,
+	"drop":      CommandDrop,
+	"follow":    CommandFollow,
+	"guard":     CommandGuard,
+	"heal":      OfferResurrection,
+	"release":   CommandRelease,
+	"resurrect": OfferResurrection,
+	"sell":      VendorSell,
+	"stable":    StablePet,
+	"stay":      CommandStay,
+	"stop":      CommandStay,
+	"withdraw":  BankWithdraw,
 }
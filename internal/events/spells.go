@@ -0,0 +1,79 @@
+package events
+
+// Magery events
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/internal/spells"
+)
+
+func init() {
+	reg("DropToSpellbook", DropToSpellbook)
+	reg("FinishCasting", FinishCasting)
+	reg("OpenSpellbook", OpenSpellbook)
+}
+
+// DropToSpellbook inscribes the spell of the dropped scroll into the spellbook.
+func DropToSpellbook(receiver, source game.Object, v any) bool {
+	sb, ok := receiver.(*game.Spellbook)
+	if !ok {
+		return false
+	}
+	sm, ok := source.(game.Mobile)
+	if !ok || sm.NetState() == nil {
+		return false
+	}
+	item, ok := v.(game.Item)
+	if !ok {
+		return false
+	}
+	if !sm.CanAccess(sb) {
+		return false
+	}
+	s, ok := spells.ForScroll(item.TemplateName())
+	if !ok {
+		sm.NetState().Speech(nil, "That is not a spell scroll.")
+		return false
+	}
+	if !sb.AddSpell(s) {
+		sm.NetState().Speech(nil, "That spell is already in the spellbook.")
+		return false
+	}
+	if item.Amount() > 1 {
+		// Only one scroll of the stack is used up, the rest goes back into the
+		// backpack
+		item.Consume(1)
+		sm.DropToBackpack(item, true)
+	} else {
+		game.Remove(item)
+	}
+	return true
+}
+
+// FinishCasting finishes casting the spell of the receiving mobile.
+func FinishCasting(receiver, source game.Object, v any) bool {
+	rm, ok := receiver.(game.Mobile)
+	if !ok {
+		return false
+	}
+	spells.FinishCasting(rm)
+	return true
+}
+
+// OpenSpellbook opens the spellbook for the source mobile.
+func OpenSpellbook(receiver, source game.Object, v any) bool {
+	sb, ok := receiver.(*game.Spellbook)
+	if !ok {
+		return false
+	}
+	sm, ok := source.(game.Mobile)
+	if !ok || sm.NetState() == nil {
+		return false
+	}
+	if !sm.CanAccess(sb) {
+		sm.NetState().Cliloc(nil, 500447) // That is not accessible.
+		return false
+	}
+	sm.NetState().OpenSpellbook(sb)
+	return true
+}
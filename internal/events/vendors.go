@@ -119,7 +119,7 @@ func VendorSell(receiver, source game.Object, v any) bool {
 	items = make([]serverpacket.ContentsItem, 0, bp.ItemCount())
 	fn(bp)
 	if len(items) == 0 {
-		game.GetWorld().Map().SendCliloc(rm, uo.SpeechNormalRange, 1080012) // You have nothing I would be interested in.
+		game.MapOf(rm).SendCliloc(rm, uo.SpeechNormalRange, 1080012) // You have nothing I would be interested in.
 		return false
 	}
 	sm.NetState().Send(&serverpacket.SellWindow{
@@ -153,7 +153,7 @@ func StablePet(receiver, source game.Object, v any) bool {
 		if err := sm.Stable(pm); err != nil {
 			err.SendTo(sm.NetState(), receiver)
 		} else {
-			game.GetWorld().Map().StoreObject(pm)
+			game.MapOf(pm).StoreObject(pm)
 		}
 	})
 	return true
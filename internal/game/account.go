@@ -40,15 +40,16 @@ func HashPassword(password string) string {
 
 // Account holds all of the account information for one user
 type Account struct {
-	username            string    // Username
-	passwordHash        string    // Password hash
-	passwordSetAt       time.Time // The last time this account's password was changed
-	failedLoginAttempts int       // The number of times someone has tried to login to this account and failed consecutively
-	locked              bool      // If true this account is locked for interactive login, probably due to too many consecutive failed login attempts
-	suspendedUntil      time.Time // End of the most recent account suspension
-	emailAddress        string    // Email address
-	player              uo.Serial // Serial of the player's permanent mobile (not the currently controlled mobile)
-	roles               Role      // The roles this account has been assigned
+	username            string      // Username
+	passwordHash        string      // Password hash
+	passwordSetAt       time.Time   // The last time this account's password was changed
+	failedLoginAttempts int         // The number of times someone has tried to login to this account and failed consecutively
+	locked              bool        // If true this account is locked for interactive login, probably due to too many consecutive failed login attempts
+	suspendedUntil      time.Time   // End of the most recent account suspension
+	emailAddress        string      // Email address
+	characters          []uo.Serial // Serials of the player's permanent mobiles (not the currently controlled mobile) in slot order
+	created             []time.Time // Creation time of each character
+	roles               Role        // The roles this account has been assigned
 }
 
 // NewAccount creates a new account object
@@ -68,8 +69,7 @@ func (a *Account) SetTemplateName(name string) {}
 
 // Marshal writes the account data to a segment
 func (a *Account) Marshal(s *marshal.TagFileSegment) {
-	s.PutInt(0) // Version
-	s.PutInt(uint32(a.player))
+	s.PutInt(1) // Version
 	s.PutString(a.username)
 	s.PutString(a.passwordHash)
 	s.PutLong(uint64(a.passwordSetAt.Unix()))
@@ -78,6 +78,11 @@ func (a *Account) Marshal(s *marshal.TagFileSegment) {
 	s.PutLong(uint64(a.suspendedUntil.Unix()))
 	s.PutString(a.emailAddress)
 	s.PutByte(byte(a.roles))
+	s.PutByte(byte(len(a.characters)))
+	for i, c := range a.characters {
+		s.PutInt(uint32(c))
+		s.PutLong(uint64(a.created[i].Unix()))
+	}
 }
 
 // Deserialize does nothing
@@ -85,8 +90,11 @@ func (a *Account) Deserialize(t *template.Template, create bool) {}
 
 // Unmarshal reads the account data from a segment
 func (a *Account) Unmarshal(s *marshal.TagFileSegment) {
-	_ = s.Int() // Version
-	a.player = uo.Serial(s.Int())
+	v := s.Int() // Version
+	player := uo.SerialMobileNil
+	if v < 1 {
+		player = uo.Serial(s.Int())
+	}
 	a.username = s.String()
 	a.passwordHash = s.String()
 	a.passwordSetAt = time.Unix(int64(s.Long()), 0)
@@ -95,6 +103,19 @@ func (a *Account) Unmarshal(s *marshal.TagFileSegment) {
 	a.suspendedUntil = time.Unix(int64(s.Long()), 0)
 	a.emailAddress = s.String()
 	a.roles = Role(s.Byte())
+	if v < 1 {
+		// Version 0 accounts had a single character without a creation time
+		if !player.IsNil() {
+			a.characters = append(a.characters, player)
+			a.created = append(a.created, time.Time{})
+		}
+		return
+	}
+	n := int(s.Byte())
+	for i := 0; i < n; i++ {
+		a.characters = append(a.characters, uo.Serial(s.Int()))
+		a.created = append(a.created, time.Unix(int64(s.Long()), 0))
+	}
 }
 
 // Username returns the username of the account
@@ -107,11 +128,46 @@ func (a *Account) ComparePasswordHash(hash string) bool {
 	return a.passwordHash == hash
 }
 
-// Player returns the player mobile serial, or uo.SerialMobileNil if none
-func (a *Account) Player() uo.Serial { return a.player }
+// Characters returns the serials of all of the player mobiles on the account
+// in slot order. The returned slice must not be modified.
+func (a *Account) Characters() []uo.Serial { return a.characters }
+
+// Character returns the player mobile serial in the given slot, or
+// uo.SerialMobileNil if there is none.
+func (a *Account) Character(slot int) uo.Serial {
+	if slot < 0 || slot >= len(a.characters) {
+		return uo.SerialMobileNil
+	}
+	return a.characters[slot]
+}
+
+// CharacterCreated returns the time the character in the given slot was
+// created. The zero time is returned for characters created before creation
+// times were recorded.
+func (a *Account) CharacterCreated(slot int) time.Time {
+	if slot < 0 || slot >= len(a.created) {
+		return time.Time{}
+	}
+	return a.created[slot]
+}
 
-// SetPlayer sets the player mobile serial, or uo.SerialMobileNil if none
-func (a *Account) SetPlayer(s uo.Serial) { a.player = s }
+// AddCharacter adds the player mobile serial to the first open slot and
+// returns the slot index.
+func (a *Account) AddCharacter(s uo.Serial) int {
+	a.characters = append(a.characters, s)
+	a.created = append(a.created, time.Now())
+	return len(a.characters) - 1
+}
+
+// RemoveCharacter removes the player mobile in the given slot. All characters
+// in the following slots move up one slot.
+func (a *Account) RemoveCharacter(slot int) {
+	if slot < 0 || slot >= len(a.characters) {
+		return
+	}
+	a.characters = append(a.characters[:slot], a.characters[slot+1:]...)
+	a.created = append(a.created[:slot], a.created[slot+1:]...)
+}
 
 // HasRole returns true if the account has the given role
 func (a *Account) HasRole(r Role) bool { return a.roles&r != 0 }
@@ -50,7 +50,10 @@ func (i *Check) DisplayName() string {
 func (i *Check) CheckAmount() int { return i.checkAmount }
 
 // SetCheckAmount sets the amount on the check.
-func (i *Check) SetCheckAmount(v int) { i.checkAmount = v }
+func (i *Check) SetCheckAmount(v int) {
+	preserve(i)
+	i.checkAmount = v
+}
 
 // ConsumeGold removes the given amount of gold from the check if possible and
 // returns true if completed successfully.
@@ -59,6 +62,7 @@ func (i *Check) ConsumeGold(v int) bool {
 		// Requested amount exceeds check amount
 		return false
 	}
+	preserve(i)
 	i.checkAmount -= v
 	if i.checkAmount < 1 {
 		Remove(i)
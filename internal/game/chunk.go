@@ -19,10 +19,6 @@ type Chunk struct {
 	mobiles util.Slice[Mobile]
 	// Collection of all regions that overlap this chunk
 	regions []*Region
-	// Amount of ore left in this chunk
-	ore uint8
-	// oreDeadline is the time when this chunk should regenerate its ore
-	oreDeadline uo.Time
 }
 
 // newChunk creates and returns a new Chunk object
@@ -89,11 +85,6 @@ func (c *Chunk) setTile(x, y int, t uo.Tile) {
 
 // Update handles the 1-minute periodic update for this chunk.
 func (c *Chunk) Update(t uo.Time) {
-	// Ore respawn
-	if t >= c.oreDeadline {
-		c.ore = uint8(world.Random().Random(10, 24))
-		c.oreDeadline = t + uo.DurationMinute*uo.Time(world.Random().Random(25, 35))
-	}
 	// Item updates for items on the ground
 	items := make([]Item, len(c.items))
 	copy(items, c.items)
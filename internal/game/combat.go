@@ -0,0 +1,184 @@
+package game
+
+import (
+	"github.com/qbradq/sharduo/lib/serverpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// IsInWarMode implements the Mobile interface.
+func (m *BaseMobile) IsInWarMode() bool { return m.warMode }
+
+// SetWarMode implements the Mobile interface.
+func (m *BaseMobile) SetWarMode(v bool) {
+	changed := m.warMode != v
+	m.warMode = v
+	if !v {
+		m.SetCombatant(nil)
+	}
+	// Always confirm the state to the client so it stays in sync
+	if m.n != nil {
+		m.n.Send(&serverpacket.WarMode{
+			WarMode: v,
+		})
+	}
+	if !changed {
+		return
+	}
+	// Let everyone else see the war mode flag change
+	for _, om := range MapOf(m).GetNetStatesInRange(m.location, uo.MaxViewRange) {
+		if om.Serial() != m.serial && m.location.XYDistance(om.Location()) <= om.ViewRange() {
+			om.NetState().MoveMobile(m)
+		}
+	}
+}
+
+// Combatant implements the Mobile interface.
+func (m *BaseMobile) Combatant() Mobile { return m.combatant }
+
+// SetCombatant implements the Mobile interface.
+func (m *BaseMobile) SetCombatant(c Mobile) {
+	if c != nil && !m.CanHarm(c) {
+		c = nil
+	}
+	if c == m.combatant {
+		return
+	}
+	m.combatant = c
+	if c != nil {
+		m.AggressiveAction(c)
+	}
+	if c != nil && m.nextSwing < world.Time() {
+		// Give the defender a moment to react to the first swing
+		m.nextSwing = world.Time() + m.swingDelay()
+	}
+	if m.n != nil {
+		s := uo.SerialZero
+		if c != nil {
+			s = c.Serial()
+		}
+		m.n.Send(&serverpacket.ChangeCombatant{
+			Combatant: s,
+		})
+	}
+}
+
+// Weapon implements the Mobile interface.
+func (m *BaseMobile) Weapon() Weapon {
+	for _, l := range []uo.Layer{uo.LayerWeapon, uo.LayerShield} {
+		if w, ok := m.EquipmentInSlot(l).(Weapon); ok {
+			return w
+		}
+	}
+	return nil
+}
+
+// Damage implements the Mobile interface.
+func (m *BaseMobile) Damage(from Mobile, amount int) {
+	if amount < 1 || m.hitPoints <= 0 {
+		return
+	}
+	if from != nil {
+		if !from.CanHarm(m) {
+			return
+		}
+		from.AggressiveAction(m)
+	}
+	preserve(m)
+	m.hitPoints -= amount
+	if m.hitPoints < 0 {
+		m.hitPoints = 0
+	}
+	world.Update(m)
+	if disturbHandler != nil {
+		disturbHandler(m)
+	}
+	if m.hitPoints == 0 {
+		m.Kill(from)
+		return
+	}
+	// Fight back. Players only fight back automatically when in war mode.
+	if from != nil && m.combatant == nil && (m.warMode || !m.isPlayerCharacter) {
+		m.SetWarMode(true)
+		m.SetCombatant(from)
+	}
+}
+
+// weaponSkill returns the skill the mobile uses to attack and defend.
+func weaponSkill(m Mobile) uo.Skill {
+	if w := m.Weapon(); w != nil {
+		return w.Skill()
+	}
+	return uo.SkillWrestling
+}
+
+// AttackRange returns the distance in tiles from which the mobile can attack
+// with the weapon in use.
+func AttackRange(m Mobile) int16 {
+	if w := m.Weapon(); w != nil && w.Range() > uo.MaxMeleeRange {
+		return w.Range()
+	}
+	return uo.MaxMeleeRange
+}
+
+// swingDelay returns the time between swings based on the mobile's dexterity
+// and the speed of the weapon in use.
+func (m *BaseMobile) swingDelay() uo.Time {
+	speed := uo.FistsSpeed
+	if w := m.Weapon(); w != nil {
+		speed = w.Speed()
+	}
+	v := (m.Dexterity() + 100) * speed
+	if v < 1 {
+		v = 1
+	}
+	d := uo.Time(15000*int(uo.DurationSecond)) / uo.Time(v)
+	if d < uo.MinSwingDelay {
+		d = uo.MinSwingDelay
+	}
+	return d
+}
+
+// updateCombat is called every tick to drive the swing timer.
+func (m *BaseMobile) updateCombat(t uo.Time) {
+	c := m.combatant
+	if c == nil {
+		return
+	}
+	if c.Removed() || c.HitPoints() <= 0 || m.hitPoints <= 0 ||
+		m.location.XYDistance(c.Location()) > uo.MaxViewRange {
+		m.SetCombatant(nil)
+		return
+	}
+	if !m.warMode || t < m.nextSwing {
+		return
+	}
+	if m.location.XYDistance(c.Location()) > AttackRange(m) || !m.HasLineOfSight(c) {
+		// Keep the swing ready for when we get in range
+		return
+	}
+	m.nextSwing = t + m.swingDelay()
+	m.swing(c)
+}
+
+// swing executes one melee attack against the defender.
+func (m *BaseMobile) swing(defender Mobile) {
+	m.RevealingAction()
+	skill := uo.SkillWrestling
+	action := uo.AnimationActionWrestle
+	min := uo.FistsMinDamage
+	max := uo.FistsMaxDamage
+	if w := m.Weapon(); w != nil {
+		skill = w.Skill()
+		action = w.AnimationAction()
+		min = w.MinDamage()
+		max = w.MaxDamage()
+	}
+	MapOf(m).PlayAnimation(m, uo.AnimationTypeAttack, action)
+	// The attacker has an even chance to hit a defender of equal skill, and is
+	// guaranteed to hit a defender 50 points below them.
+	ds := int(defender.Skill(weaponSkill(defender)))
+	if !m.SkillCheck(skill, ds-500, ds+500) {
+		return
+	}
+	defender.Damage(m, world.Random().Random(min, max))
+}
@@ -87,6 +87,14 @@ type Container interface {
 	// checks. It returns true if this was successful. ConsumeGold only modifies
 	// items if it returns true.
 	ConsumeGold(int) bool
+	// CountTemplate returns the total amount of all items created from the
+	// named template within this container and all sub-containers.
+	CountTemplate(string) int
+	// ConsumeTemplate attempts to consume the given amount of items created
+	// from the named template from the container and all sub-containers. It
+	// returns true if this was successful. ConsumeTemplate only modifies items
+	// if it returns true.
+	ConsumeTemplate(string, int) bool
 }
 
 // BaseContainer implements the base implementation of the Container interface.
@@ -236,6 +244,7 @@ func (c *BaseContainer) doRemove(o Object, force bool) bool {
 	if !ok {
 		return force
 	}
+	preserve(c)
 	// This avoids a duplicate call to IndexOf
 	oldLength := len(c.contents)
 	c.contents = c.contents.Remove(item)
@@ -333,6 +342,8 @@ func (c *BaseContainer) ForceAddObject(o Object) {
 	if o == nil {
 		return
 	}
+	preserve(c)
+	preserve(o)
 	o.SetParent(c)
 	item, ok := o.(Item)
 	if !ok {
@@ -569,6 +580,51 @@ func (c *BaseContainer) CountGold() int {
 	return count
 }
 
+// CountTemplate implements the Container interface.
+func (c *BaseContainer) CountTemplate(tn string) int {
+	count := 0
+	for _, i := range c.contents {
+		if i.TemplateName() == tn {
+			count += i.Amount()
+		} else if sub, ok := i.(Container); ok {
+			count += sub.CountTemplate(tn)
+		}
+	}
+	return count
+}
+
+// ConsumeTemplate implements the Container interface.
+func (c *BaseContainer) ConsumeTemplate(tn string, amount int) bool {
+	if c.CountTemplate(tn) < amount {
+		return false
+	}
+	var consume func(Container)
+	consume = func(cont Container) {
+		// Consuming an entire stack removes it from the contents
+		contents := append([]Item(nil), cont.Contents()...)
+		for _, i := range contents {
+			if amount < 1 {
+				return
+			}
+			if sub, ok := i.(Container); ok {
+				consume(sub)
+				continue
+			}
+			if i.TemplateName() != tn {
+				continue
+			}
+			n := i.Amount()
+			if n > amount {
+				n = amount
+			}
+			i.Consume(n)
+			amount -= n
+		}
+	}
+	consume(c)
+	return true
+}
+
 // ConsumeGold implements the Container interface.
 func (c *BaseContainer) ConsumeGold(amount int) bool {
 	var total int
@@ -0,0 +1,125 @@
+package game
+
+import (
+	"github.com/qbradq/sharduo/lib/marshal"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("Corpse", marshal.ObjectTypeCorpse, func() any { return &Corpse{} })
+}
+
+// Corpse is the container left behind when a mobile dies. It holds the
+// equipment and backpack contents of the mobile. Corpses do not decay like
+// normal items, instead a timer removes them. See Mobile.Kill().
+type Corpse struct {
+	BaseContainer
+	// Serial of the mobile that died
+	owner uo.Serial
+	// Serial of the mobile that killed the owner, if any
+	killer uo.Serial
+	// Notoriety of the owner at the time of death
+	notoriety uo.Notoriety
+	// If true this is the corpse of a player character
+	isPlayerCorpse bool
+}
+
+// ObjectType implements the Object interface.
+func (c *Corpse) ObjectType() marshal.ObjectType { return marshal.ObjectTypeCorpse }
+
+// Marshal implements the marshal.Marshaler interface.
+func (c *Corpse) Marshal(s *marshal.TagFileSegment) {
+	c.BaseContainer.Marshal(s)
+	s.PutInt(0) // version
+	s.PutInt(uint32(c.owner))
+	s.PutInt(uint32(c.killer))
+	s.PutByte(byte(c.notoriety))
+	s.PutBool(c.isPlayerCorpse)
+}
+
+// Unmarshal implements the marshal.Unmarshaler interface.
+func (c *Corpse) Unmarshal(s *marshal.TagFileSegment) {
+	c.BaseContainer.Unmarshal(s)
+	_ = s.Int() // version
+	c.owner = uo.Serial(s.Int())
+	c.killer = uo.Serial(s.Int())
+	c.notoriety = uo.Notoriety(s.Byte())
+	c.isPlayerCorpse = s.Bool()
+}
+
+// Owner returns the serial of the mobile that left this corpse behind.
+func (c *Corpse) Owner() uo.Serial { return c.owner }
+
+// Killer returns the serial of the mobile that killed the owner of this
+// corpse, or uo.SerialZero if there was none.
+func (c *Corpse) Killer() uo.Serial { return c.killer }
+
+// IsPlayerCorpse returns true if this is the corpse of a player character.
+func (c *Corpse) IsPlayerCorpse() bool { return c.isPlayerCorpse }
+
+// Weight implements the Object interface. The amount of a corpse is the body
+// of the owner so it must not be considered.
+func (c *Corpse) Weight() float32 { return c.weight }
+
+// RefreshDecayDeadline implements the Item interface. Corpses are removed by
+// the timer set up in Mobile.Kill() instead.
+func (c *Corpse) RefreshDecayDeadline() {
+	c.decayDeadline = uo.TimeNever
+}
+
+// CanLoot returns true if the mobile is allowed to take items from the
+// corpse. When player versus player rules are enabled anyone may loot any
+// corpse, but see LootingIsCriminal.
+func (c *Corpse) CanLoot(m Mobile) bool {
+	if m == nil {
+		return false
+	}
+	return pvpEnabled || !c.LootingIsCriminal(m)
+}
+
+// LootingIsCriminal returns true if taking items from the corpse is a
+// criminal action for the mobile. The owner and the killer may always loot
+// the corpse, as may staff and members of the owner's party if the owner
+// allows it. Anyone else may only loot the corpse freely if the owner was not
+// innocent.
+func (c *Corpse) LootingIsCriminal(m Mobile) bool {
+	if m.Serial() == c.owner || (c.killer != uo.SerialZero && m.Serial() == c.killer) {
+		return false
+	}
+	if m.NetState() != nil && m.NetState().Account().HasRole(RoleGameMaster) {
+		return false
+	}
+	if p := PartyOf(m); p != nil {
+		if om := Find[Mobile](c.owner); om != nil && p.IsMember(om) && p.CanLoot(om) {
+			return false
+		}
+	}
+	switch c.notoriety {
+	case uo.NotorietyInnocent, uo.NotorietyInvulnerable:
+		return true
+	}
+	return false
+}
+
+// Open implements the Container interface.
+func (c *Corpse) Open(m Mobile) {
+	if !c.CanLoot(m) {
+		if m.NetState() != nil {
+			m.NetState().Speech(nil, "You may not loot this corpse.")
+		}
+		return
+	}
+	c.BaseContainer.Open(m)
+}
+
+// CorpseOf returns the corpse the object is contained in, or nil if it is not
+// within a corpse.
+func CorpseOf(o Object) *Corpse {
+	for o != nil {
+		if c, ok := o.(*Corpse); ok {
+			return c
+		}
+		o = o.Parent()
+	}
+	return nil
+}
@@ -29,6 +29,8 @@ func (c *Cursor) Occupied() bool { return c.item != nil }
 
 // PickUp attempts to pick up the object. Returns true if successful.
 func (c *Cursor) PickUp(o Object) bool {
+	preserve(c.m)
+	preserve(o)
 	world.Update(c.m)
 	if o == nil {
 		c.previousLocation = uo.Location{}
@@ -53,6 +55,7 @@ func (c *Cursor) PickUp(o Object) bool {
 
 // Return send the item on the cursor back to it's previous parent.
 func (c *Cursor) Return() {
+	preserve(c.m)
 	world.Update(c.m)
 	oldLocation := c.previousLocation
 	oldParent := c.previousParent
@@ -63,8 +66,8 @@ func (c *Cursor) Return() {
 	item.SetLocation(oldLocation)
 	// Old parent was the map, just send it back
 	if oldParent == nil {
-		if !world.Map().AddObject(item) {
-			world.Map().ForceAddObject(item)
+		if !MapOf(c.m).AddObject(item) {
+			MapOf(c.m).ForceAddObject(item)
 		}
 		return
 	}
@@ -76,8 +79,8 @@ func (c *Cursor) Return() {
 		}
 		// But the item back into the stack's parent
 		if oldParent.Parent() == nil {
-			if !world.Map().AddObject(item) {
-				world.Map().ForceAddObject(item)
+			if !MapOf(c.m).AddObject(item) {
+				MapOf(c.m).ForceAddObject(item)
 			}
 		} else {
 			if !oldParent.Parent().AddObject(item) {
@@ -95,7 +98,7 @@ func (c *Cursor) Return() {
 // if successful.
 func (c *Cursor) Drop(target Object) bool {
 	c.State = CursorStateDrop
-	if !world.Map().SetNewParent(c.item, target) {
+	if !MapOf(c.m).SetNewParent(c.item, target) {
 		c.State = CursorStateReturn
 		return false
 	}
@@ -107,7 +110,7 @@ func (c *Cursor) Drop(target Object) bool {
 // if successful.
 func (c *Cursor) Wear(target Object) bool {
 	c.State = CursorStateEquip
-	if !world.Map().SetNewParent(c.item, target) {
+	if !MapOf(c.m).SetNewParent(c.item, target) {
 		c.State = CursorStateReturn
 		return false
 	}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"github.com/qbradq/sharduo/lib/serverpacket"
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// IsDead implements the Mobile interface.
+func (m *BaseMobile) IsDead() bool { return m.isDead }
+
+// Kill implements the Mobile interface.
+func (m *BaseMobile) Kill(killer Mobile) {
+	if m.isDead || m.Removed() {
+		return
+	}
+	preserve(m)
+	m.hitPoints = 0
+	m.creditMurders(killer)
+	// End all combat with this mobile
+	m.SetCombatant(nil)
+	for _, om := range MapOf(m).GetMobilesInRange(m.location, uo.MaxViewRange) {
+		if om.Combatant() != nil && om.Combatant().Serial() == m.serial {
+			om.SetCombatant(nil)
+		}
+	}
+	CancelSecureTrades(m)
+	m.DropItemInCursor()
+	m.Dismount()
+	// Leave the corpse behind
+	c := m.makeCorpse(killer)
+	if c != nil {
+		MapOf(m).ForceAddObject(c)
+		for _, om := range MapOf(m).GetNetStatesInRange(m.location, uo.MaxViewRange) {
+			om.NetState().Send(&serverpacket.DisplayDeath{
+				Mobile: m.serial,
+				Corpse: c.Serial(),
+			})
+		}
+		d := uo.CorpseDecayTime
+		if m.isPlayerCharacter {
+			d = uo.PlayerCorpseDecayTime
+		}
+		NewTimer(d, "DecayCorpse", c, nil, false, nil)
+	}
+	if !m.isPlayerCharacter {
+		Remove(m)
+		return
+	}
+	// Player characters become ghosts
+	m.SetWarMode(false)
+	m.isDead = true
+	m.body = uo.GhostBody(m.body, m.isFemale)
+	if m.n != nil {
+		m.n.Send(&serverpacket.DeathStatus{
+			Dead: true,
+		})
+		m.n.DrawPlayer()
+		// Show all of the other ghosts in range
+		MapOf(m).SendEverything(m)
+	}
+	for _, om := range MapOf(m).GetNetStatesInRange(m.location, uo.MaxViewRange) {
+		if om.Serial() == m.serial {
+			continue
+		}
+		if om.CanSee(m) {
+			om.NetState().MoveMobile(m)
+		} else {
+			om.NetState().RemoveObject(m)
+		}
+	}
+	world.Update(m)
+}
+
+// makeCorpse creates the corpse of the mobile and moves all equipment and
+// backpack contents that do not stay with the mobile on death into it. The
+// corpse is not placed on the map.
+func (m *BaseMobile) makeCorpse(killer Mobile) *Corpse {
+	c := template.Create[*Corpse]("Corpse")
+	if c == nil {
+		// Something is very wrong
+		return nil
+	}
+	c.owner = m.serial
+	if killer != nil {
+		c.killer = killer.Serial()
+	}
+	c.notoriety = m.GetNotorietyFor(m)
+	c.isPlayerCorpse = m.isPlayerCharacter
+	c.name = "corpse of " + m.DisplayName()
+	c.articleA = false
+	c.articleAn = false
+	// The amount of a corpse item selects the body graphic to display
+	c.amount = int(m.body)
+	c.hue = m.hue
+	c.location = m.location
+	c.facing = m.facing
+	// Move the equipment
+	var toMove []Wearable
+	m.MapEquipment(func(w Wearable) error {
+		switch w.Layer() {
+		case uo.LayerBackpack, uo.LayerBankBox, uo.LayerHair, uo.LayerBeard,
+			uo.LayerMount:
+			return nil
+		}
+		if w.LootType() == uo.LootTypeNormal {
+			toMove = append(toMove, w)
+		}
+		return nil
+	})
+	for _, w := range toMove {
+		m.ForceUnequip(w)
+		w.SetDropLocation(uo.RandomContainerLocation)
+		c.ForceAddObject(w)
+	}
+	// Move the backpack contents
+	if bp, ok := m.EquipmentInSlot(uo.LayerBackpack).(Container); ok {
+		var items []Item
+		for _, item := range bp.Contents() {
+			if item.LootType() == uo.LootTypeNormal {
+				items = append(items, item)
+			}
+		}
+		for _, item := range items {
+			bp.ForceRemoveObject(item)
+			item.SetDropLocation(uo.RandomContainerLocation)
+			c.ForceAddObject(item)
+		}
+	}
+	return c
+}
+
+// Resurrect implements the Mobile interface.
+func (m *BaseMobile) Resurrect() {
+	if !m.isDead {
+		return
+	}
+	preserve(m)
+	m.isDead = false
+	m.body = uo.LivingBody(m.body)
+	m.hitPoints = uo.ResurrectionHitPoints
+	if m.hitPoints > m.MaxHitPoints() {
+		m.hitPoints = m.MaxHitPoints()
+	}
+	if m.n != nil {
+		m.n.Send(&serverpacket.DeathStatus{
+			Dead: false,
+		})
+		m.n.DrawPlayer()
+		// Hide all of the ghosts in range
+		for _, om := range MapOf(m).GetMobilesInRange(m.location, m.viewRange) {
+			if om.Serial() != m.serial && !m.CanSee(om) {
+				m.n.RemoveObject(om)
+			}
+		}
+	}
+	for _, om := range MapOf(m).GetNetStatesInRange(m.location, uo.MaxViewRange) {
+		if om.Serial() != m.serial {
+			om.NetState().SendObject(m)
+		}
+	}
+	MapOf(m).PlaySound(uo.SoundResurrect, m.location)
+	world.Update(m)
+}
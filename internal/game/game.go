@@ -20,6 +20,10 @@ type AIModel interface {
 
 var aiGetter func(string) AIModel
 
+var disturbHandler func(Mobile)
+
+var saveBarrier func(Object)
+
 // RootParent returns the top-most parent of the object who's parent is the map.
 // If this object's parent is the map this object is returned.
 func RootParent(o Object) Object {
@@ -75,14 +79,15 @@ func Remove(o Object) {
 	if o == nil {
 		return
 	}
+	preserve(o)
 	p := o.Parent()
 	if p == nil {
 		// If the object is a direct child of the map we have to send an object
 		// remove packet to all net states in range.
-		for _, m := range world.Map().GetNetStatesInRange(o.Location(), uo.MaxViewRange) {
+		for _, m := range MapOf(o).GetNetStatesInRange(o.Location(), uo.MaxViewRange) {
 			m.NetState().RemoveObject(o)
 		}
-		world.Map().ForceRemoveObject(o)
+		MapOf(o).ForceRemoveObject(o)
 	} else {
 		// If p is a container it will send remove packets to all observers
 		p.ForceRemoveObject(o)
@@ -115,3 +120,27 @@ func SetEventIndexGetter(fn func(string) uint16) {
 func SetAIGetter(fn func(string) AIModel) {
 	aiGetter = fn
 }
+
+// SetDisturbHandler sets the function called when a mobile is disturbed, such
+// as by taking damage. This is used to interrupt spell casting.
+func SetDisturbHandler(fn func(Mobile)) {
+	disturbHandler = fn
+}
+
+// SetSaveBarrier sets the function called with the top-most parent of an
+// object right before anything about the object that is saved changes, be it
+// its parent, amount, hit points, skills or any other field. The world uses
+// this to marshal the object tree before it changes while a save is in
+// progress. Pass nil to remove the barrier.
+func SetSaveBarrier(fn func(Object)) {
+	saveBarrier = fn
+}
+
+// preserve calls the save barrier for the object tree holding the object. This
+// must be called before the tree changes. It is safe to pass nil.
+func preserve(o Object) {
+	if saveBarrier == nil || o == nil {
+		return
+	}
+	saveBarrier(RootParent(o))
+}
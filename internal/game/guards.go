@@ -0,0 +1,114 @@
+package game
+
+import (
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// If true guards kill their targets the moment they arrive, otherwise they
+// fight them, see SetGuardRules
+var guardsInstantKill bool = true
+
+// SetGuardRules sets whether guards kill their targets instantly or fight
+// them.
+func SetGuardRules(instantKill bool) {
+	guardsInstantKill = instantKill
+}
+
+// Guards sent after a target by the serial of the target
+var guardsFor = make(map[uo.Serial]Mobile)
+
+// IsGuarded returns true if the object is within a guarded region.
+func IsGuarded(o Object) bool {
+	return MapOf(o).RegionFeaturesAt(o.Location())&RegionFeatureGuarded != 0
+}
+
+// IsGuardTarget returns true if guards should kill the mobile. Criminals,
+// murderers and their pets are guard targets, as are uncontrolled monsters
+// with murderer notoriety, the red monsters. Mobiles outside of guarded
+// regions are never guard targets.
+func IsGuardTarget(m Mobile) bool {
+	if m == nil || m.Removed() || m.IsDead() || m.Notoriety() == uo.NotorietyInvulnerable {
+		return false
+	}
+	if m.NetState() != nil && m.NetState().Account().HasRole(RoleGameMaster) {
+		return false
+	}
+	if !IsGuarded(m) {
+		return false
+	}
+	if rm := responsibleFor(m); rm != nil {
+		return rm.IsCriminal() || rm.IsMurderer()
+	}
+	return m.Notoriety() == uo.NotorietyMurderer
+}
+
+// CallGuards sends guards after every guard target near the caller. This
+// does nothing outside of guarded regions or when the caller is a ghost.
+func CallGuards(caller Mobile) {
+	if caller.IsDead() || !IsGuarded(caller) {
+		return
+	}
+	for _, m := range MapOf(caller).GetMobilesInRange(caller.Location(), uo.MaxViewRange) {
+		if IsGuardTarget(m) {
+			sendGuard(m)
+		}
+	}
+}
+
+// sendGuard spawns a guard next to the target unless one has already been
+// sent after it.
+func sendGuard(target Mobile) {
+	if g := guardsFor[target.Serial()]; g != nil && !g.Removed() {
+		GuardEngage(g, target)
+		return
+	}
+	g := template.Create[Mobile]("TownGuard")
+	if g == nil {
+		// Something is very wrong
+		return
+	}
+	g.SetLocation(target.Location())
+	MapOf(target).ForceAddObject(g)
+	MapOf(g).PlayEffect(uo.GFXTypeFixed, g, g, 0x3728, 10, 10, true, false,
+		uo.HueDefault, uo.GFXBlendModeNormal)
+	MapOf(g).PlaySound(0x1FE, g.Location())
+	NewTimer(uo.GuardDespawnDelay, "DespawnGuard", g, nil, true, nil)
+	GuardEngage(g, target)
+}
+
+// GuardEngage makes the guard go after the target. Depending on the guard
+// rules the target is either killed on the spot or the guard starts fighting
+// it.
+func GuardEngage(g, target Mobile) {
+	guardsFor[target.Serial()] = g
+	if guardsInstantKill {
+		if g.Location().XYDistance(target.Location()) > uo.MaxMeleeRange {
+			MapOf(target).TeleportMobile(g, target.Location())
+		}
+		g.SetFacing(g.Location().DirectionTo(target.Location()))
+		MapOf(g).PlayAnimation(g, uo.AnimationTypeAttack, uo.AnimationActionSlash1H)
+		target.Kill(g)
+		return
+	}
+	g.SetWarMode(true)
+	g.SetCombatant(target)
+}
+
+// DespawnGuard removes the guard unless it is still fighting a guard target.
+// It returns false if the guard was kept.
+func DespawnGuard(g Mobile) bool {
+	if IsGuardTarget(g.Combatant()) {
+		NewTimer(uo.GuardDespawnDelay, "DespawnGuard", g, nil, true, nil)
+		return false
+	}
+	for s, og := range guardsFor {
+		if og.Serial() == g.Serial() {
+			delete(guardsFor, s)
+		}
+	}
+	MapOf(g).PlayEffect(uo.GFXTypeFixed, g, g, 0x3728, 10, 10, true, false,
+		uo.HueDefault, uo.GFXBlendModeNormal)
+	Remove(g)
+	return true
+}
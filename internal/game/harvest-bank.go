@@ -0,0 +1,121 @@
+package game
+
+import (
+	"github.com/qbradq/sharduo/lib/marshal"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// HarvestBankDefinition describes a named kind of harvest resource bank. Banks
+// cover square groups of tiles, so a bank size of uo.ChunkWidth gives every
+// chunk one bank.
+type HarvestBankDefinition struct {
+	// Name of the bank
+	Name string
+	// Width and height of the tile group covered by one bank
+	Size int16
+	// Minimum amount of resources in a fresh bank
+	MinAmount int
+	// Maximum amount of resources in a fresh bank
+	MaxAmount int
+	// Minimum time until a bank regenerates
+	MinRespawn uo.Time
+	// Maximum time until a bank regenerates
+	MaxRespawn uo.Time
+}
+
+// All harvest bank definitions by name
+var harvestBankDefinitions = map[string]*HarvestBankDefinition{}
+
+// RegisterHarvestBank registers the harvest bank definition by name.
+func RegisterHarvestBank(d *HarvestBankDefinition) {
+	harvestBankDefinitions[d.Name] = d
+}
+
+// harvestBankKey identifies one bank by name and tile group.
+type harvestBankKey struct {
+	name string // Name of the bank definition
+	x    int16  // X coordinate of the tile group
+	y    int16  // Y coordinate of the tile group
+}
+
+// harvestBank is the state of one bank.
+type harvestBank struct {
+	amount   int     // Amount of resources left
+	deadline uo.Time // When the bank regenerates
+}
+
+// harvestBank returns the bank of the named definition covering the location,
+// regenerating it if needed. nil is returned for unknown bank definitions.
+func (m *Map) harvestBank(name string, l uo.Location) *harvestBank {
+	d := harvestBankDefinitions[name]
+	if d == nil || d.Size < 1 {
+		return nil
+	}
+	k := harvestBankKey{
+		name: name,
+		x:    l.X / d.Size,
+		y:    l.Y / d.Size,
+	}
+	b := m.harvestBanks[k]
+	if b == nil {
+		b = &harvestBank{}
+		m.harvestBanks[k] = b
+	}
+	t := world.Time()
+	if t >= b.deadline {
+		b.amount = world.Random().Random(d.MinAmount, d.MaxAmount)
+		b.deadline = t + uo.Time(world.Random().Random(int(d.MinRespawn), int(d.MaxRespawn)))
+	}
+	return b
+}
+
+// HarvestBankAmount returns the amount of resources left in the named bank
+// covering the location.
+func (m *Map) HarvestBankAmount(name string, l uo.Location) int {
+	b := m.harvestBank(name, l)
+	if b == nil {
+		return 0
+	}
+	return b.amount
+}
+
+// ConsumeHarvestBank attempts to consume the amount of resources from the
+// named bank covering the location and returns the amount consumed.
+func (m *Map) ConsumeHarvestBank(name string, l uo.Location, n int) int {
+	b := m.harvestBank(name, l)
+	if b == nil || n < 1 {
+		return 0
+	}
+	if n > b.amount {
+		n = b.amount
+	}
+	b.amount -= n
+	return n
+}
+
+// marshalHarvestBanks writes all harvest banks to the segment.
+func (m *Map) marshalHarvestBanks(s *marshal.TagFileSegment) {
+	for k, b := range m.harvestBanks {
+		s.PutString(k.name)
+		s.PutShort(uint16(k.x))
+		s.PutShort(uint16(k.y))
+		s.PutShort(uint16(b.amount))
+		s.PutLong(uint64(b.deadline))
+		s.IncrementRecordCount()
+	}
+}
+
+// unmarshalHarvestBanks reads all harvest banks from the segment.
+func (m *Map) unmarshalHarvestBanks(s *marshal.TagFileSegment) {
+	for i := uint32(0); i < s.RecordCount(); i++ {
+		k := harvestBankKey{
+			name: s.String(),
+			x:    int16(s.Short()),
+			y:    int16(s.Short()),
+		}
+		m.harvestBanks[k] = &harvestBank{
+			amount:   int(s.Short()),
+			deadline: uo.Time(s.Long()),
+		}
+	}
+}
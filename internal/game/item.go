@@ -96,10 +96,21 @@ type Item interface {
 	// ConsumeUse returns true if a generic use / charge was able to be consumed
 	// from the item.
 	ConsumeUse() bool
+	// LootType returns the loot type of the item, which controls what happens
+	// to the item when its owner dies.
+	LootType() uo.LootType
 	// SetDefForGraphic forces a different static definition to be used for this
 	// item instead of the one associated with the base graphic. This is really
 	// hacky and was put in for southward counter-clockwise-opening doors.
 	SetDefForGraphic(uo.Graphic)
+	// Exceptional returns true if the item was crafted with exceptional
+	// quality.
+	Exceptional() bool
+	// Crafter returns the name of the crafter that put their maker's mark on
+	// the item, or the empty string if the item bears no maker's mark.
+	Crafter() string
+	// SetCrafted sets the quality and maker's mark of a freshly crafted item.
+	SetCrafted(exceptional bool, crafter string)
 
 	//
 	// Flag accessors
@@ -160,6 +171,10 @@ type BaseItem struct {
 	uses int
 	// Loot type
 	lootType uo.LootType
+	// Exceptional quality flag
+	exceptional bool
+	// Name of the crafter from the maker's mark
+	crafter string
 
 	//
 	// Non-persistent values
@@ -202,11 +217,13 @@ func (i *BaseItem) ObjectType() marshal.ObjectType { return marshal.ObjectTypeIt
 // Marshal implements the marshal.Marshaler interface.
 func (i *BaseItem) Marshal(s *marshal.TagFileSegment) {
 	i.BaseObject.Marshal(s)
-	s.PutInt(0) // version
+	s.PutInt(1) // version
 	s.PutBool(i.flipped)
 	s.PutShort(uint16(i.amount))
 	s.PutShort(uint16(i.uses))
 	s.PutByte(byte(i.lootType))
+	s.PutBool(i.exceptional)
+	s.PutString(i.crafter)
 }
 
 // Deserialize implements the util.Serializeable interface.
@@ -232,7 +249,7 @@ func (i *BaseItem) Deserialize(t *template.Template, create bool) {
 // Unmarshal implements the marshal.Unmarshaler interface.
 func (i *BaseItem) Unmarshal(s *marshal.TagFileSegment) {
 	i.BaseObject.Unmarshal(s)
-	_ = s.Int() // version
+	version := s.Int()
 	i.flipped = s.Bool()
 	i.amount = int(s.Short())
 	if i.amount < 1 {
@@ -240,6 +257,10 @@ func (i *BaseItem) Unmarshal(s *marshal.TagFileSegment) {
 	}
 	i.uses = int(s.Short())
 	i.lootType = uo.LootType(s.Byte())
+	if version >= 1 {
+		i.exceptional = s.Bool()
+		i.crafter = s.String()
+	}
 	i.def = world.GetItemDefinition(i.graphic)
 	// Instead of storing the decay deadline we just refresh everything on a
 	// world load.
@@ -281,6 +302,7 @@ func (i *BaseItem) Flipped() bool { return i.flipped }
 
 // Flip implements the Item interface.
 func (i *BaseItem) Flip() {
+	preserve(i)
 	i.flipped = !i.flipped
 	world.Update(i)
 }
@@ -299,6 +321,7 @@ func (i *BaseItem) SetAmount(n int) {
 	if i.amount == n {
 		return
 	}
+	preserve(i)
 	i.InvalidateOPL()
 	if n < 1 {
 		i.amount = 1
@@ -322,6 +345,7 @@ func (i *BaseItem) Consume(n int) bool {
 	if n > i.amount {
 		return false
 	}
+	preserve(i)
 	i.amount -= n
 	if i.amount == 0 {
 		Remove(i)
@@ -361,7 +385,7 @@ func (i *BaseItem) Split(n int) Item {
 	// Remove this item from its parent
 	failed := false
 	if i.parent == nil {
-		failed = !world.Map().RemoveObject(i)
+		failed = !MapOf(i).RemoveObject(i)
 	} else {
 		failed = !i.parent.RemoveObject(i)
 	}
@@ -374,7 +398,7 @@ func (i *BaseItem) Split(n int) Item {
 	item.SetLocation(i.location)
 	item.SetDropLocation(i.location)
 	if i.parent == nil {
-		world.Map().ForceAddObject(item)
+		MapOf(i).ForceAddObject(item)
 	} else {
 		i.parent.ForceAddObject(item)
 	}
@@ -400,8 +424,8 @@ func (i *BaseItem) Combine(other Item) bool {
 	other.SetDropLocation(i.location)
 	iparent := i.parent
 	if iparent == nil {
-		world.Map().ForceRemoveObject(i)
-		world.Map().ForceAddObject(other)
+		MapOf(i).ForceRemoveObject(i)
+		MapOf(i).ForceAddObject(other)
 	} else {
 		iparent.ForceRemoveObject(i)
 		iparent.ForceAddObject(other)
@@ -497,6 +521,9 @@ func (i *BaseItem) DropSoundOverride(s uo.Sound) uo.Sound {
 	return s
 }
 
+// LootType implements the Item interface.
+func (i *BaseItem) LootType() uo.LootType { return i.lootType }
+
 // Uses implements the Item interface.
 func (i *BaseItem) Uses() int { return i.uses }
 
@@ -505,6 +532,7 @@ func (i *BaseItem) ConsumeUse() bool {
 	if i.uses < 1 {
 		return false
 	}
+	preserve(i)
 	i.uses--
 	i.InvalidateOPL()
 	return true
@@ -513,6 +541,12 @@ func (i *BaseItem) ConsumeUse() bool {
 // AppendOPLEntries implements the Object interface.
 func (i *BaseItem) AppendOPLEntires(r Object, p *serverpacket.OPLPacket) {
 	i.BaseObject.AppendOPLEntires(r, p)
+	if i.exceptional {
+		p.Append("exceptional", true)
+	}
+	if i.crafter != "" {
+		p.Append(fmt.Sprintf("crafted by %s", i.crafter), true)
+	}
 	if i.uses > 0 {
 		p.Append(fmt.Sprintf("Uses: %d", i.uses), true)
 	}
@@ -536,6 +570,20 @@ func (i *BaseItem) SetDefForGraphic(g uo.Graphic) {
 // NoRent implements the Object interface.
 func (i *BaseItem) NoRent() bool { return i.noRent }
 
+// Exceptional implements the Item interface.
+func (i *BaseItem) Exceptional() bool { return i.exceptional }
+
+// Crafter implements the Item interface.
+func (i *BaseItem) Crafter() string { return i.crafter }
+
+// SetCrafted implements the Item interface.
+func (i *BaseItem) SetCrafted(exceptional bool, crafter string) {
+	preserve(i)
+	i.exceptional = exceptional
+	i.crafter = crafter
+	i.InvalidateOPL()
+}
+
 // Flag accessors
 func (i *BaseItem) Background() bool   { return i.def.TileFlags&uo.TileFlagsBackground != 0 }
 func (i *BaseItem) Weapon() bool       { return i.def.TileFlags&uo.TileFlagsWeapon != 0 }
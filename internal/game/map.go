@@ -13,39 +13,49 @@ import (
 	"github.com/qbradq/sharduo/lib/uo/file"
 )
 
-// Map contains the tile matrix, static items, and all dynamic objects of a map.
+// Map contains the tile matrix, static items, and all dynamic objects of one
+// facet.
 type Map struct {
-	chunks      []*Chunk             // The chunks of the map
-	regions     []*Region            // A list of all of the regions of the map
-	deepStorage map[uo.Serial]Object // Deep storage for objects like stabled pets and logged out characters
+	facet        uo.Facet                        // Facet of the map
+	chunks       []*Chunk                        // The chunks of the map
+	regions      []*Region                       // A list of all of the regions of the map
+	deepStorage  map[uo.Serial]Object            // Deep storage for objects like stabled pets and logged out characters
+	pathfinder   *pathfinder                     // Path finding service for AI movement
+	harvestBanks map[harvestBankKey]*harvestBank // Harvest resource banks
 }
 
-// NewMap creates and returns a new Map
-func NewMap() *Map {
+// NewMap creates and returns a new Map for the facet
+func NewMap(f uo.Facet) *Map {
 	m := &Map{
-		chunks:      make([]*Chunk, uo.MapChunksWidth*uo.MapChunksHeight),
-		deepStorage: make(map[uo.Serial]Object),
+		facet:        f,
+		chunks:       make([]*Chunk, f.ChunksWidth()*f.ChunksHeight()),
+		deepStorage:  make(map[uo.Serial]Object),
+		pathfinder:   newPathfinder(),
+		harvestBanks: make(map[harvestBankKey]*harvestBank),
 	}
-	for cx := 0; cx < uo.MapChunksWidth; cx++ {
-		for cy := 0; cy < uo.MapChunksHeight; cy++ {
-			m.chunks[cy*uo.MapChunksWidth+cx] = newChunk(cx*uo.ChunkWidth, cy*uo.ChunkHeight)
+	for cx := 0; cx < f.ChunksWidth(); cx++ {
+		for cy := 0; cy < f.ChunksHeight(); cy++ {
+			m.chunks[cy*f.ChunksWidth()+cx] = newChunk(cx*uo.ChunkWidth, cy*uo.ChunkHeight)
 		}
 	}
 	return m
 }
 
+// Facet returns the facet of the map.
+func (m *Map) Facet() uo.Facet { return m.facet }
+
 // LoadFromMul reads in all of the segments of the given MapMul object and
 // updates the map
 func (m *Map) LoadFromMuls(mapmul *file.MapMul, staticsmul *file.StaticsMul) {
 	// Load the tiles
-	for iy := 0; iy < uo.MapHeight; iy++ {
-		for ix := 0; ix < uo.MapWidth; ix++ {
+	for iy := 0; iy < m.facet.Height(); iy++ {
+		for ix := 0; ix < m.facet.Width(); ix++ {
 			m.GetChunk(uo.Location{X: int16(ix), Y: int16(iy)}).setTile(ix, iy, mapmul.GetTile(ix, iy))
 		}
 	}
 	// Pre-calculate tile elevations
-	for iy := int16(0); iy < int16(uo.MapHeight); iy++ {
-		for ix := int16(0); ix < int16(uo.MapWidth); ix++ {
+	for iy := int16(0); iy < int16(m.facet.Height()); iy++ {
+		for ix := int16(0); ix < int16(m.facet.Width()); ix++ {
 			c := m.GetChunk(uo.Location{X: ix, Y: iy})
 			t := c.GetTile(ix, iy)
 			lowest, avg, height := m.getTerrainElevations(ix, iy)
@@ -59,8 +69,8 @@ func (m *Map) LoadFromMuls(mapmul *file.MapMul, staticsmul *file.StaticsMul) {
 		c.statics = append(c.statics, static)
 	}
 	// Sort statics by bottom Z
-	for iy := int16(0); iy < int16(uo.MapChunksHeight); iy++ {
-		for ix := int16(0); ix < int16(uo.MapChunksWidth); ix++ {
+	for iy := int16(0); iy < int16(m.facet.ChunksHeight()); iy++ {
+		for ix := int16(0); ix < int16(m.facet.ChunksWidth()); ix++ {
 			c := m.GetChunk(uo.Location{X: ix * int16(uo.ChunkWidth), Y: iy * int16(uo.ChunkHeight)})
 			sort.Slice(c.statics, func(i, j int) bool {
 				si := c.statics[i]
@@ -76,10 +86,10 @@ func (m *Map) LoadFromMuls(mapmul *file.MapMul, staticsmul *file.StaticsMul) {
 	}
 }
 
-// MarshalObjects writes out all objects that are directly on the map split into
-// pools to facilitate multi-goroutine saving.
-func (m *Map) MarshalObjects(wg *sync.WaitGroup, s *marshal.TagFileSegment, pool, pools int) {
-	defer wg.Done()
+// ObjectsToSave returns all objects that are directly on the map and need to
+// be saved split into pools to facilitate multi-goroutine saving.
+func (m *Map) ObjectsToSave(pool, pools int) []Object {
+	var ret []Object
 	l := len(m.chunks)
 	for i := pool; i < l; i += pools {
 		c := m.chunks[i]
@@ -87,26 +97,22 @@ func (m *Map) MarshalObjects(wg *sync.WaitGroup, s *marshal.TagFileSegment, pool
 			if o.Removed() || o.NoRent() || o.SpawnerRegion() != nil {
 				continue
 			}
-			s.PutObject(o)
-			s.IncrementRecordCount()
+			ret = append(ret, o)
 		}
 		for _, o := range c.mobiles {
 			if o.Removed() || o.NoRent() || o.SpawnerRegion() != nil {
 				continue
 			}
-			s.PutObject(o)
-			s.IncrementRecordCount()
+			ret = append(ret, o)
 		}
 	}
+	return ret
 }
 
 // Marshal writes out top-level map information
 func (m *Map) Marshal(wg *sync.WaitGroup, s *marshal.TagFileSegment) {
 	defer wg.Done()
-	// Ore map
-	for _, c := range m.chunks {
-		s.PutByte(byte(c.ore))
-	}
+	m.marshalHarvestBanks(s)
 }
 
 // UnmarshalObjects unmarshals all of the objects directly parented to the map.
@@ -118,6 +124,7 @@ func (m *Map) UnmarshalObjects(s *marshal.TagFileSegment) {
 		if !ok {
 			panic("map object did not implement the Object interface")
 		}
+		o.SetFacet(m.facet)
 		c := m.GetChunk(o.Location())
 		c.Add(o)
 	}
@@ -127,6 +134,13 @@ func (m *Map) UnmarshalObjects(s *marshal.TagFileSegment) {
 // this with a pre-compiled list of objects so that calls to
 // AfterUnmarshalOntoMap can call world.Remove() if needed.
 func (m *Map) AfterUnmarshal() {
+	for _, o := range m.Objects() {
+		o.AfterUnmarshalOntoMap()
+	}
+}
+
+// Objects returns all of the objects directly parented to the map.
+func (m *Map) Objects() []Object {
 	var objs []Object
 	for _, c := range m.chunks {
 		for _, item := range c.items {
@@ -136,26 +150,12 @@ func (m *Map) AfterUnmarshal() {
 			objs = append(objs, mobile)
 		}
 	}
-	for _, o := range objs {
-		o.AfterUnmarshalOntoMap()
-	}
+	return objs
 }
 
 // Unmarshal reads in top-level map information
 func (m *Map) Unmarshal(s *marshal.TagFileSegment) {
-	// Load the ore map data
-	for _, c := range m.chunks {
-		c.ore = uint8(s.Byte())
-	}
-}
-
-// MarshalDeepStorage writes out the off-map objects
-func (m *Map) MarshalDeepStorage(wg *sync.WaitGroup, s *marshal.TagFileSegment) {
-	defer wg.Done()
-	for _, o := range m.deepStorage {
-		s.PutObject(o)
-		s.IncrementRecordCount()
-	}
+	m.unmarshalHarvestBanks(s)
 }
 
 // UnmarshalDeepStorage unmarshals all of the objects in map deep storage.
@@ -166,34 +166,48 @@ func (m *Map) UnmarshalDeepStorage(s *marshal.TagFileSegment) {
 		if !ok {
 			panic("deep storage object did not implement the Object interface")
 		}
+		o.SetFacet(m.facet)
 		m.deepStorage[o.Serial()] = o
 	}
 }
 
 // GetChunk returns a pointer to the chunk for the given location.
 func (m *Map) GetChunk(l uo.Location) *Chunk {
-	l = l.Bound()
+	l = m.facet.WrapAndBound(l, l)
 	cx := int(l.X) / uo.ChunkWidth
 	cy := int(l.Y) / uo.ChunkHeight
-	return m.chunks[cy*uo.MapChunksWidth+cx]
+	return m.chunks[cy*m.facet.ChunksWidth()+cx]
 }
 
 // GetTile returns the Tile value for the location
 func (m *Map) GetTile(x, y int16) uo.Tile {
-	return m.GetChunk(uo.Location{
+	l := uo.Location{
 		X: x,
 		Y: y,
-	}).GetTile(x, y)
+	}
+	l = m.facet.WrapAndBound(l, l)
+	return m.GetChunk(l).GetTile(l.X, l.Y)
+}
+
+// chunkCoordinate returns the chunk coordinate containing the tile coordinate
+// v for chunks of the given size. This rounds towards negative infinity so
+// coordinates west or north of the map wrap to the correct chunk.
+func chunkCoordinate(v int16, size int) int {
+	if v < 0 {
+		return (int(v) - size + 1) / size
+	}
+	return int(v) / size
 }
 
 // SetNewParent sets the parent object of this object. It properly removes
 // the object from the old parent and adds the object to the new parent. Use
-// nil to represent the world. This function returns false if the operation
+// nil to represent this map. This function returns false if the operation
 // failed for any reason.
 func (m *Map) SetNewParent(o, p Object) bool {
 	oldParent := o.Parent()
+	oldMap := MapOf(o)
 	if oldParent == nil {
-		if !world.Map().RemoveObject(o) {
+		if !oldMap.RemoveObject(o) {
 			return false
 		}
 	} else {
@@ -203,7 +217,7 @@ func (m *Map) SetNewParent(o, p Object) bool {
 	}
 	addFailed := false
 	if p == nil {
-		if !world.Map().AddObject(o) {
+		if !m.AddObject(o) {
 			addFailed = true
 		}
 	} else {
@@ -214,7 +228,7 @@ func (m *Map) SetNewParent(o, p Object) bool {
 	if addFailed {
 		// Don't leak the object
 		if oldParent == nil {
-			world.Map().ForceAddObject(o)
+			oldMap.ForceAddObject(o)
 		} else {
 			oldParent.ForceAddObject(o)
 		}
@@ -233,6 +247,10 @@ func (m *Map) SetNewParent(o, p Object) bool {
 		// No drag packets if this is map-to-map.
 		return true
 	}
+	if oldMap != m && (p == nil || oldParent == nil) {
+		// No drag packets between facets
+		return true
+	}
 	if p != nil && oldParent != nil && p.Serial() == oldParent.Serial() {
 		// We don't need a drag packet if we are not changing parents. This
 		// is the case when lifting an item off the paper doll, dropping an
@@ -331,7 +349,9 @@ func (m *Map) ForceAddObject(o Object) {
 	if o == nil {
 		return
 	}
+	preserve(o)
 	o.SetParent(nil)
+	o.SetFacet(m.facet)
 	c := m.GetChunk(o.Location())
 	c.Add(o)
 	// Send the new object to all mobiles in range with an attached net state
@@ -351,15 +371,18 @@ func (m *Map) ForceAddObject(o Object) {
 		floor, _ := m.GetFloorAndCeiling(o.Location(), false, false)
 		mob.StandOn(floor)
 	}
-	// If this is an item we need to update the decay deadline.
+	// If this is an item we need to update the decay deadline and drop any
+	// paths that might be blocked or opened by it.
 	if item, ok := o.(Item); ok {
 		item.RefreshDecayDeadline()
+		m.pathfinder.invalidate(item.Location())
 	}
 }
 
 // ForceRemoveObject removes the object from the map and always succeeds.
 func (m *Map) ForceRemoveObject(o Object) {
-	c := m.GetChunk(o.Location().Bound())
+	preserve(o)
+	c := m.GetChunk(o.Location())
 	c.Remove(o)
 	// Tell other mobiles with net states in range about the object removal
 	for _, mob := range m.GetNetStatesInRange(o.Location(), uo.MaxViewRange) {
@@ -371,6 +394,10 @@ func (m *Map) ForceRemoveObject(o Object) {
 	if mob, ok := o.(Mobile); ok && mob.NetState() != nil {
 		m.RemoveEverything(mob)
 	}
+	// Items may have been blocking paths
+	if _, ok := o.(Item); ok {
+		m.pathfinder.invalidate(o.Location())
+	}
 }
 
 // canMoveTo returns true if the mobile can move from its current location in
@@ -383,9 +410,15 @@ func (m *Map) ForceRemoveObject(o Object) {
 // around dungeons - that would block monster movement if they were given
 // heights greater than this value.
 func (m *Map) canMoveTo(mob Mobile, d uo.Direction) (bool, uo.Location, uo.CommonObject) {
-	ol := mob.Location()
-	nl := ol.Forward(d.Bound()).WrapAndBound(ol)
-	nl.Z = mob.StandingOn().Highest()
+	return m.canStep(mob.Location(), mob.StandingOn(), d)
+}
+
+// canStep implements canMoveTo for a mobile standing at the given location on
+// the given floor object. This is used by the path finder to test steps
+// without moving the mobile.
+func (m *Map) canStep(ol uo.Location, oldFloor uo.CommonObject, d uo.Direction) (bool, uo.Location, uo.CommonObject) {
+	nl := m.facet.WrapAndBound(ol.Forward(d.Bound()), ol)
+	nl.Z = oldFloor.Highest()
 	floor, ceiling := m.GetFloorAndCeiling(nl, false, true)
 	// No floor to stand on, bail
 	if floor == nil {
@@ -415,7 +448,6 @@ func (m *Map) canMoveTo(mob Mobile, d uo.Direction) (bool, uo.Location, uo.Commo
 			return false, ol, floor
 		}
 		// Consider step height
-		oldFloor := mob.StandingOn()
 		oldTop := oldFloor.Highest()
 		if !floor.Bridge() && fz-oldTop > uo.StepHeight {
 			// Can't go up that much in one step
@@ -507,25 +539,50 @@ func (m *Map) MoveMobile(mob Mobile, dir uo.Direction) bool {
 	if oldChunk != newChunk {
 		newChunk.Add(mob)
 	}
+	// The client does not wrap around the edges of the overworld on its own,
+	// so redraw the player and its surroundings after crossing an edge
+	dx := newLocation.X - oldLocation.X
+	dy := newLocation.Y - oldLocation.Y
+	if mob.NetState() != nil && (dx < -1 || dx > 1 || dy < -1 || dy > 1) {
+		mob.NetState().DrawPlayer()
+		m.SendEverything(mob)
+	}
 	mob.AfterMove()
 	return true
 }
 
-// TeleportMobile moves a mobile from where it is now to the new location. This
-// returns false if there is not enough room at that location for the mobile.
-// This will also trigger all events as if the mobile left the tile normally,
-// and arrived at the new tile normally.
+// TeleportMobile moves a mobile from where it is now to the new location on
+// this map, which may be on another facet than the mobile. This returns false
+// if there is not enough room at that location for the mobile. This will also
+// trigger all events as if the mobile left the tile normally, and arrived at
+// the new tile normally.
 func (m *Map) TeleportMobile(mob Mobile, l uo.Location) bool {
 	oldLocation := mob.Location()
-	world.Map().RemoveObject(mob) // This triggers on leave events
+	oldMap := MapOf(mob)
+	oldMap.RemoveObject(mob) // This triggers on leave events
 	mob.SetLocation(l)
-	if !world.Map().AddObject(mob) {
+	// The client has to change facets before it receives the new objects
+	if oldMap != m && mob.NetState() != nil {
+		mob.NetState().Send(&serverpacket.SetMap{
+			Facet: m.facet,
+		})
+	}
+	if !m.AddObject(mob) {
 		// Map.AddObject() checks height
 		// Don't leak the mobile, just force it back where it came from.
 		mob.SetLocation(oldLocation)
-		world.Map().ForceAddObject(mob)
-		floor, _ := m.GetFloorAndCeiling(mob.Location(), false, false)
+		if oldMap != m && mob.NetState() != nil {
+			mob.NetState().Send(&serverpacket.SetMap{
+				Facet: oldMap.facet,
+			})
+		}
+		oldMap.ForceAddObject(mob)
+		floor, _ := oldMap.GetFloorAndCeiling(mob.Location(), false, false)
 		mob.StandOn(floor)
+		if oldMap != m && mob.NetState() != nil {
+			oldMap.SendEverything(mob)
+			mob.NetState().DrawPlayer()
+		}
 		return false
 	}
 	// Update standing
@@ -534,9 +591,11 @@ func (m *Map) TeleportMobile(mob Mobile, l uo.Location) bool {
 	// If this mobile has a net state attached we need to fully refresh the
 	// client's object collection.
 	if mob.NetState() != nil {
-		mob.SetLocation(oldLocation)
-		m.RemoveEverything(mob)
-		mob.SetLocation(l)
+		if oldMap == m {
+			mob.SetLocation(oldLocation)
+			m.RemoveEverything(mob)
+			mob.SetLocation(l)
+		}
 		m.SendEverything(mob)
 		mob.NetState().DrawPlayer()
 	}
@@ -556,19 +615,19 @@ func (m *Map) Query(center uo.Location, queryRange int16, set map[uo.Graphic]str
 		X: b.X,
 		Y: b.Y,
 	}
-	scx := int(b.X) / uo.ChunkWidth
-	scy := int(b.Y) / uo.ChunkHeight
-	ecx := int(b.X+b.W-1) / uo.ChunkWidth
-	ecy := int(b.Y+b.H-1) / uo.ChunkHeight
+	scx := chunkCoordinate(b.X, uo.ChunkWidth)
+	scy := chunkCoordinate(b.Y, uo.ChunkHeight)
+	ecx := chunkCoordinate(b.X+b.W-1, uo.ChunkWidth)
+	ecy := chunkCoordinate(b.Y+b.H-1, uo.ChunkHeight)
 	for cy := scy; cy <= ecy; cy++ {
 		for cx := scx; cx <= ecx; cx++ {
-			l := uo.Location{
+			l := m.facet.WrapAndBound(uo.Location{
 				X: int16(cx * uo.ChunkWidth),
 				Y: int16(cy * uo.ChunkHeight),
-			}.WrapAndBound(tl)
+			}, tl)
 			ccx := int(l.X) / uo.ChunkWidth
 			ccy := int(l.Y) / uo.ChunkHeight
-			c := m.chunks[ccy*uo.MapChunksWidth+ccx]
+			c := m.chunks[ccy*m.facet.ChunksWidth()+ccx]
 			for _, s := range c.statics {
 				if _, ok := set[s.BaseGraphic()]; ok && center.XYDistance(s.Location) <= queryRange {
 					return true
@@ -590,19 +649,19 @@ func (m *Map) getChunksInBounds(b uo.Bounds) []*Chunk {
 		X: b.X,
 		Y: b.Y,
 	}
-	scx := int(b.X) / uo.ChunkWidth
-	scy := int(b.Y) / uo.ChunkHeight
-	ecx := int(b.X+b.W-1) / uo.ChunkWidth
-	ecy := int(b.Y+b.H-1) / uo.ChunkHeight
+	scx := chunkCoordinate(b.X, uo.ChunkWidth)
+	scy := chunkCoordinate(b.Y, uo.ChunkHeight)
+	ecx := chunkCoordinate(b.X+b.W-1, uo.ChunkWidth)
+	ecy := chunkCoordinate(b.Y+b.H-1, uo.ChunkHeight)
 	for cy := scy; cy <= ecy; cy++ {
 		for cx := scx; cx <= ecx; cx++ {
-			l := uo.Location{
+			l := m.facet.WrapAndBound(uo.Location{
 				X: int16(cx * uo.ChunkWidth),
 				Y: int16(cy * uo.ChunkHeight),
-			}.WrapAndBound(tl)
+			}, tl)
 			ccx := int(l.X) / uo.ChunkWidth
 			ccy := int(l.Y) / uo.ChunkHeight
-			ret = append(ret, m.chunks[ccy*uo.MapChunksWidth+ccx])
+			ret = append(ret, m.chunks[ccy*m.facet.ChunksWidth()+ccx])
 		}
 	}
 	return ret
@@ -1125,10 +1184,20 @@ func (m *Map) SendSpeech(from Object, r int16, format string, args ...any) {
 	})
 	isAllCommand := len(text) >= 4 && strings.ToLower(text[:4]) == "all "
 	speechEventHandled := false
+	// Only other ghosts and staff can understand ghosts
+	ghostText := ""
+	if fm, ok := from.(Mobile); ok && fm.IsDead() {
+		ghostText = ghostSpeech(text)
+	}
 	for _, mob := range mobs {
 		if from.Location().XYDistance(mob.Location()) <= mob.ViewRange() {
 			if mob.NetState() != nil {
-				mob.NetState().Speech(from, text)
+				if ghostText != "" && !mob.IsDead() &&
+					!mob.NetState().Account().HasRole(RoleGameMaster) {
+					mob.NetState().Speech(from, ghostText)
+				} else {
+					mob.NetState().Speech(from, text)
+				}
 			}
 			// Make sure we don't trigger every listener in range, just the
 			// closest, unless it is an "all" command.
@@ -1139,6 +1208,21 @@ func (m *Map) SendSpeech(from Object, r int16, format string, args ...any) {
 	}
 }
 
+// ghostSpeech returns the text as heard by the living from a ghost.
+func ghostSpeech(text string) string {
+	ret := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r == ' ' {
+			ret = append(ret, ' ')
+		} else if world.Random().Random(0, 1) == 0 {
+			ret = append(ret, 'o')
+		} else {
+			ret = append(ret, 'O')
+		}
+	}
+	return string(ret)
+}
+
 // SendCliloc sends cliloc speech to all mobiles in range.
 func (m *Map) SendCliloc(from Object, r int16, c uo.Cliloc, args ...string) {
 	for _, mob := range m.GetMobilesInRange(from.Location(), r) {
@@ -1156,10 +1240,10 @@ var _mobBuffer = make([]Mobile, 1024*1024)
 // Update call once every real-world minute or twelve in-game minutes. It also
 // calls Update on a few regions every tick such that every region is updated
 // over fifteen real-world seconds or three in-game minutes. Finally Update
-// calls Update for every mobile on the map.
+// resets the path finding budget and calls Update for every mobile on the map.
 func (m *Map) Update(t uo.Time) {
 	// Interleaved chunk updates, updates every chunk over a minute
-	nChunks := uint64(uo.MapChunksWidth * uo.MapChunksHeight)
+	nChunks := uint64(len(m.chunks))
 	step := uint64(uo.DurationMinute)
 	start := uint64(t % uo.Time(step))
 	for idx := start; idx < nChunks; idx += step {
@@ -1172,6 +1256,8 @@ func (m *Map) Update(t uo.Time) {
 	for idx := start; idx < nRegions; idx += step {
 		m.regions[idx].Update(t)
 	}
+	// Path finding budget for this tick
+	m.pathfinder.update(t)
 	// Update all mobiles
 	idx := 0
 	for _, c := range m.chunks {
@@ -1185,26 +1271,6 @@ func (m *Map) Update(t uo.Time) {
 	}
 }
 
-// ConsumeOre attempts to consume the specified amount of ore from the chunk at
-// the specified location and returns the number of ore piles consumed.
-func (m *Map) ConsumeOre(l uo.Location, n int) int {
-	if n < 1 || n > 255 {
-		return 0
-	}
-	amount := uint8(n)
-	c := m.GetChunk(l)
-	if amount > c.ore {
-		amount = c.ore
-		c.ore = 0
-	} else {
-		c.ore -= amount
-	}
-	return int(amount)
-}
-
-// HasOre returns true if the chunk at the given location has any ore.
-func (m *Map) HasOre(l uo.Location) bool { return m.GetChunk(l).ore != 0 }
-
 // ItemQuery returns a slice of all of the items matching the given template
 // name. The second parameter may be the zero value, in which case the entire
 // map is searched. WARNING: This can be expensive and will hang the server.
@@ -1270,14 +1336,25 @@ func (m *Map) ItemBaseQuery(tn string, bounds uo.Bounds) []Item {
 
 // StoreObject places an object into deep storage.
 func (m *Map) StoreObject(o Object) {
+	preserve(o)
 	m.SetNewParent(o, TheVoid)
 	m.deepStorage[o.Serial()] = o
 }
 
+// StoredObjects returns all of the objects in deep storage.
+func (m *Map) StoredObjects() []Object {
+	ret := make([]Object, 0, len(m.deepStorage))
+	for _, o := range m.deepStorage {
+		ret = append(ret, o)
+	}
+	return ret
+}
+
 // RetrieveObject retrieves and object from deep storage.
 func (m *Map) RetrieveObject(s uo.Serial) Object {
 	o, found := m.deepStorage[s]
 	if found {
+		preserve(o)
 		delete(m.deepStorage, s)
 	}
 	return o
@@ -1449,6 +1526,11 @@ func (m *Map) RegionFeaturesAt(l uo.Location) RegionFeature {
 // The reference used for the Bresenham Line Algorithm was
 // https://www.baeldung.com/cs/bresenhams-line-algorithm#:~:text=3.-,Description,words%2C%20only%20very%20cheap%20operations.
 func (m *Map) LineOfSight(a, b uo.Location) bool {
+	// Walk the line the short way around the overworld
+	origin := a
+	ox, oy := a.Delta(b)
+	b.X = a.X + ox
+	b.Y = a.Y + oy
 	// Control variables for the line algorithm
 	dx := b.X - a.X
 	if dx < 0 {
@@ -1479,8 +1561,9 @@ func (m *Map) LineOfSight(a, b uo.Location) bool {
 		// Check point for sight blocking
 		bottom := a.Z
 		top := int8(float64(a.Z) + sz)
-		c := m.GetChunk(a)
-		t := c.GetTile(a.X, a.Y)
+		p := m.facet.WrapAndBound(a, origin)
+		c := m.GetChunk(p)
+		t := c.GetTile(p.X, p.Y)
 		if !t.Ignore() && t.Z() <= top && t.Highest() >= bottom {
 			// The tile matrix blocks line of site at this point
 			return false
@@ -1488,7 +1571,7 @@ func (m *Map) LineOfSight(a, b uo.Location) bool {
 		// Consider statics
 		for _, static := range c.statics {
 			// Ignore statics that are not at the location
-			if static.Location.X != a.X || static.Location.Y != a.Y {
+			if static.Location.X != p.X || static.Location.Y != p.Y {
 				continue
 			}
 			// Only care about vis-blocking statics
@@ -1511,7 +1594,7 @@ func (m *Map) LineOfSight(a, b uo.Location) bool {
 		for _, item := range c.items {
 			// Ignore items that are not at the location
 			l := item.Location()
-			if l.X != a.X || l.Y != a.Y {
+			if l.X != p.X || l.Y != p.Y {
 				continue
 			}
 			// Only care about vis-blocking items
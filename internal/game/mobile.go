@@ -45,6 +45,9 @@ type Mobile interface {
 	Dexterity() int
 	// Intelligence returns the current effective intelligence
 	Intelligence() int
+	// SetBaseStats sets the base strength, dexterity and intelligence and
+	// refills hit points, mana and stamina.
+	SetBaseStats(int, int, int)
 	// HitPoints returns the current hit points
 	HitPoints() int
 	// MaxHitPoints returns the current effective max hit points
@@ -53,6 +56,11 @@ type Mobile interface {
 	Mana() int
 	// MaxMana returns the current effective max mana
 	MaxMana() int
+	// ConsumeMana removes n mana from the mobile and returns true, or returns
+	// false if the mobile does not have that much mana.
+	ConsumeMana(int) bool
+	// Heal restores up to n hit points to the mobile
+	Heal(int)
 	// Stamina returns the current stamina
 	Stamina() int
 	// MaxStamina returns the current effective max stamina
@@ -74,9 +82,24 @@ type Mobile interface {
 	Skill(uo.Skill) int16
 	// Skills returns a slice of all raw skill values (range 0-1000)
 	Skills() []int16
+	// SetSkill sets the raw skill value (range 0-1000) of the named skill
+	SetSkill(uo.Skill, int16)
 	// SkillCheck returns true if the skill check succeeded. This function will
 	// also calculate skill and stat gains and send mobile updates for these.
 	SkillCheck(uo.Skill, int, int) bool
+	// SkillLock returns the lock state of the named skill
+	SkillLock(uo.Skill) uo.SkillLock
+	// SkillLocks returns a slice of all skill lock states
+	SkillLocks() []uo.SkillLock
+	// SetSkillLock sets the lock state of the named skill
+	SetSkillLock(uo.Skill, uo.SkillLock)
+	// StatLock returns the lock state of the named stat
+	StatLock(uo.Stat) uo.SkillLock
+	// SetStatLock sets the lock state of the named stat and sends the new lock
+	// states to the client
+	SetStatLock(uo.Stat, uo.SkillLock)
+	// SkillTotal returns the sum of all raw skill values
+	SkillTotal() int
 
 	//
 	// AI-related
@@ -127,6 +150,13 @@ type Mobile interface {
 	// HasLineOfSight returns true if the mobile has line of sight to the
 	// object.
 	HasLineOfSight(Object) bool
+	// IsHidden returns true if the mobile is hidden as with the hiding skill.
+	IsHidden() bool
+	// SetHidden hides or reveals the mobile and updates all clients in range.
+	SetHidden(bool)
+	// RevealingAction reveals the mobile if it is hidden. This is called for
+	// all actions that break hiding, such as moving, speaking and attacking.
+	RevealingAction()
 
 	//
 	// Graphics and display
@@ -141,6 +171,8 @@ type Mobile interface {
 	IsPlayerCharacter() bool
 	// IsFemale returns true if the mobile is female.
 	IsFemale() bool
+	// SetFemale sets the female flag of the mobile.
+	SetFemale(bool)
 	// IsHumanBody returns true if the body value is humanoid.
 	IsHumanBody() bool
 
@@ -223,13 +255,73 @@ type Mobile interface {
 	// IsMounted returns true if the mobile is riding a mount.
 	IsMounted() bool
 
+	//
+	// Combat
+	//
+
+	// IsInWarMode returns true if the mobile is in war mode.
+	IsInWarMode() bool
+	// SetWarMode sets the war mode state of the mobile. Leaving war mode ends
+	// combat.
+	SetWarMode(bool)
+	// Combatant returns the mobile this mobile is fighting, or nil.
+	Combatant() Mobile
+	// SetCombatant sets the mobile this mobile is fighting. Use
+	// SetCombatant(nil) to end combat.
+	SetCombatant(Mobile)
+	// Weapon returns the weapon the mobile is wielding, or nil if the mobile
+	// is fighting with its fists.
+	Weapon() Weapon
+	// Damage applies damage to the mobile. The source mobile may be nil.
+	Damage(Mobile, int)
+
+	//
+	// Death
+	//
+
+	// IsDead returns true if the mobile is a ghost.
+	IsDead() bool
+	// Kill kills the mobile, leaving a corpse behind. Player characters become
+	// ghosts, all other mobiles are removed. The killer may be nil.
+	Kill(Mobile)
+	// Resurrect brings a ghost back to life.
+	Resurrect()
+
 	//
 	// Notoriety system
 	//
 
+	// Notoriety returns the base notoriety of the mobile as set by its
+	// template, ignoring crimes and aggression.
+	Notoriety() uo.Notoriety
 	// GetNotorietyFor returns the notoriety value of the given mobile as
 	// observed from this mobile.
 	GetNotorietyFor(Mobile) uo.Notoriety
+	// IsCriminal returns true if the mobile is currently flagged criminal.
+	IsCriminal() bool
+	// CriminalAction flags the mobile criminal. Only player characters can be
+	// flagged criminal.
+	CriminalAction()
+	// MurderCount returns the number of murder counts of the mobile.
+	MurderCount() int
+	// IsMurderer returns true if the mobile has enough murder counts to be
+	// considered a murderer.
+	IsMurderer() bool
+	// AddMurder adds one murder count to the mobile.
+	AddMurder()
+	// IsAggressor returns true if the given mobile has recently attacked this
+	// mobile.
+	IsAggressor(Mobile) bool
+	// HasAggressed returns true if this mobile has recently attacked the
+	// given mobile.
+	HasAggressed(Mobile) bool
+	// AggressiveAction records this mobile attacking the target. Attacking a
+	// mobile that is innocent to the attacker is a criminal action.
+	AggressiveAction(Mobile)
+	// addAggressor records the given mobile attacking this mobile.
+	addAggressor(Mobile)
+	// CanHarm returns true if this mobile is allowed to harm the target.
+	CanHarm(Mobile) bool
 
 	//
 	// Callbacks
@@ -277,6 +369,26 @@ type BaseMobile struct {
 	notoriety         uo.Notoriety    // Notoriety of the mobile
 	lastStepTime      uo.Time         // Last time this mobile took a step
 
+	//
+	// Combat
+	//
+
+	warMode   bool    // War mode flag
+	combatant Mobile  // Mobile we are fighting, if any
+	nextSwing uo.Time // Earliest time we can swing at our combatant again
+	isDead    bool    // If true the mobile is a ghost
+	hidden    bool    // If true the mobile is hidden
+
+	//
+	// Notoriety
+	//
+
+	criminalDeadline    uo.Time               // When the criminal flag expires
+	murderCount         int                   // Number of murder counts
+	murderCountDeadline uo.Time               // When the next murder count decays
+	aggressors          map[uo.Serial]uo.Time // Mobiles that attacked us and when that expires
+	aggressed           map[uo.Serial]uo.Time // Mobiles we attacked and when that expires
+
 	//
 	// User interface stuff
 	//
@@ -302,6 +414,13 @@ type BaseMobile struct {
 	stamina          int     // Current stamina
 	skills           []int16 // Raw skill values
 
+	//
+	// Skill and stat locks
+	//
+
+	skillLocks []uo.SkillLock  // Lock states of all skills
+	statLocks  [3]uo.SkillLock // Lock states of strength, dexterity and intelligence
+
 	//
 	// Cache values
 	//
@@ -323,7 +442,7 @@ func (o *BaseMobile) SerialType() uo.SerialType {
 // Marshal implements the marshal.Marshaler interface.
 func (m *BaseMobile) Marshal(s *marshal.TagFileSegment) {
 	m.BaseObject.Marshal(s)
-	s.PutInt(0) // version
+	s.PutInt(5) // version
 	// Base stats
 	s.PutByte(byte(m.viewRange))
 	s.PutBool(m.isPlayerCharacter)
@@ -362,11 +481,39 @@ func (m *BaseMobile) Marshal(s *marshal.TagFileSegment) {
 	for _, pm := range m.stabledPets {
 		s.PutObject(pm)
 	}
+	// Death
+	s.PutBool(m.isDead)
+	// Skill and stat locks
+	s.PutByte(byte(len(m.skillLocks)))
+	for _, l := range m.skillLocks {
+		s.PutByte(byte(l))
+	}
+	for _, l := range m.statLocks {
+		s.PutByte(byte(l))
+	}
+	// Hiding
+	s.PutBool(m.hidden)
+	// Notoriety
+	s.PutLong(uint64(m.criminalDeadline))
+	s.PutShort(uint16(m.murderCount))
+	s.PutLong(uint64(m.murderCountDeadline))
+	// Trade containers are never saved, the items offered in open secure
+	// trades are saved with their owner instead
+	offers := secureTradeOffers(m)
+	if len(offers) > 255 {
+		log.Printf("warning: mobile %s is offering way too many items", m.serial.String())
+		offers = offers[:255]
+	}
+	s.PutByte(byte(len(offers)))
+	for _, item := range offers {
+		s.PutObject(item)
+	}
 }
 
 // Deserialize implements the util.Serializeable interface.
 func (m *BaseMobile) Deserialize(t *template.Template, create bool) {
 	m.skills = make([]int16, uo.SkillCount)
+	m.skillLocks = make([]uo.SkillLock, uo.SkillCount)
 	m.cursor = &Cursor{
 		m: m,
 	}
@@ -413,7 +560,7 @@ func (m *BaseMobile) Deserialize(t *template.Template, create bool) {
 // Unmarshal implements the marshal.Unmarshaler interface.
 func (m *BaseMobile) Unmarshal(s *marshal.TagFileSegment) {
 	m.BaseObject.Unmarshal(s)
-	_ = s.Int() // version
+	version := s.Int()
 	m.cursor = &Cursor{
 		m: m,
 	}
@@ -438,6 +585,7 @@ func (m *BaseMobile) Unmarshal(s *marshal.TagFileSegment) {
 	if len(m.skills) < int(uo.SkillCount) {
 		s := make([]int16, uo.SkillCount)
 		copy(s, m.skills)
+		m.skills = s
 	} else if len(m.skills) > int(uo.SkillCount) {
 		m.skills = m.skills[0:uo.SkillCount]
 	}
@@ -467,12 +615,57 @@ func (m *BaseMobile) Unmarshal(s *marshal.TagFileSegment) {
 	for i := 0; i < n; i++ {
 		m.stabledPets[i] = s.Object().(Mobile)
 	}
+	// Death
+	if version >= 1 {
+		m.isDead = s.Bool()
+	}
+	// Skill and stat locks
+	m.skillLocks = make([]uo.SkillLock, uo.SkillCount)
+	if version >= 2 {
+		n := int(s.Byte())
+		for i := 0; i < n; i++ {
+			l := uo.SkillLock(s.Byte())
+			if i < len(m.skillLocks) {
+				m.skillLocks[i] = l
+			}
+		}
+		for i := range m.statLocks {
+			m.statLocks[i] = uo.SkillLock(s.Byte())
+		}
+	}
+	// Hiding
+	if version >= 3 {
+		m.hidden = s.Bool()
+	}
+	// Notoriety
+	if version >= 4 {
+		m.criminalDeadline = uo.Time(s.Long())
+		m.murderCount = int(s.Short())
+		m.murderCountDeadline = uo.Time(s.Long())
+	}
+	// Items offered in secure trades are returned to the backpack
+	if version >= 5 {
+		n := int(s.Byte())
+		for i := 0; i < n; i++ {
+			item, ok := s.Object().(Item)
+			if !ok {
+				panic("secure trade offer does not implement the Item interface")
+			}
+			backpack, ok := m.equipment.GetItemInLayer(uo.LayerBackpack).(Container)
+			if !ok {
+				log.Printf("error: mobile %s leaked secure trade offer %s because the backpack was not found",
+					m.serial.String(), item.Serial().String())
+				continue
+			}
+			backpack.ForceAddObject(item)
+		}
+	}
 }
 
 // AfterUnmarshalOntoMap implements the Object interface.
 func (m *BaseMobile) AfterUnmarshalOntoMap() {
 	// Find what we are standing on.
-	floor, _ := world.Map().GetFloorAndCeiling(m.location, false, true)
+	floor, _ := MapOf(m).GetFloorAndCeiling(m.location, false, true)
 	if floor == nil {
 		// We are below the ground or in the void which is an invalid state
 		log.Printf("error: mobile %s below the world or in the void, removing", m.serial.String())
@@ -490,7 +683,7 @@ func (m *BaseMobile) AfterUnmarshalOntoMap() {
 	// we reload the save monsters may attack players. We also have no mechanism
 	// for logging out these players. So we force it here if needed.
 	if m.isPlayerCharacter {
-		world.Map().StoreObject(m)
+		MapOf(m).StoreObject(m)
 	}
 }
 
@@ -516,7 +709,10 @@ func (m *BaseMobile) SetNetState(n NetState) {
 func (m *BaseMobile) ViewRange() int16 { return m.viewRange }
 
 // SetViewRange implements the Mobile interface.
-func (m *BaseMobile) SetViewRange(r int16) { m.viewRange = uo.BoundViewRange(r) }
+func (m *BaseMobile) SetViewRange(r int16) {
+	preserve(m)
+	m.viewRange = uo.BoundViewRange(r)
+}
 
 // Body implements the Mobile interface.
 func (m *BaseMobile) Body() uo.Body { return m.body }
@@ -527,6 +723,12 @@ func (m *BaseMobile) IsPlayerCharacter() bool { return m.isPlayerCharacter }
 // IsFemale implements the Mobile interface.
 func (m *BaseMobile) IsFemale() bool { return m.isFemale }
 
+// SetFemale implements the Mobile interface.
+func (m *BaseMobile) SetFemale(v bool) {
+	preserve(m)
+	m.isFemale = v
+}
+
 // IsHumanBody implements the Mobile interface.
 func (m *BaseMobile) IsHumanBody() bool {
 	return m.body == uo.BodyHumanMale || m.body == uo.BodyHumanFemale
@@ -551,6 +753,7 @@ func (m *BaseMobile) Facing() uo.Direction { return m.facing }
 
 // SetFacing implements the Mobile interface.
 func (m *BaseMobile) SetFacing(f uo.Direction) {
+	preserve(m)
 	m.facing = f.StripRunningFlag()
 }
 
@@ -560,6 +763,12 @@ func (m *BaseMobile) MobileFlags() uo.MobileFlags {
 	if m.IsFemale() {
 		ret |= uo.MobileFlagFemale
 	}
+	if m.warMode {
+		ret |= uo.MobileFlagWarMode
+	}
+	if m.hidden {
+		ret |= uo.MobileFlagHidden
+	}
 	return ret
 }
 
@@ -572,6 +781,17 @@ func (m *BaseMobile) Dexterity() int { return m.baseDexterity }
 // Intelligence implements the Mobile interface.
 func (m *BaseMobile) Intelligence() int { return m.baseIntelligence }
 
+// SetBaseStats implements the Mobile interface.
+func (m *BaseMobile) SetBaseStats(str, dex, intel int) {
+	preserve(m)
+	m.baseStrength = str
+	m.baseDexterity = dex
+	m.baseIntelligence = intel
+	m.hitPoints = m.MaxHitPoints()
+	m.mana = m.MaxMana()
+	m.stamina = m.MaxStamina()
+}
+
 // HitPoints implements the Mobile interface.
 func (m *BaseMobile) HitPoints() int { return m.hitPoints }
 
@@ -584,6 +804,30 @@ func (m *BaseMobile) Mana() int { return m.mana }
 // MaxMana implements the Mobile interface.
 func (m *BaseMobile) MaxMana() int { return m.Intelligence() }
 
+// ConsumeMana implements the Mobile interface.
+func (m *BaseMobile) ConsumeMana(n int) bool {
+	if n > m.mana {
+		return false
+	}
+	preserve(m)
+	m.mana -= n
+	world.Update(m)
+	return true
+}
+
+// Heal implements the Mobile interface.
+func (m *BaseMobile) Heal(n int) {
+	if n < 1 || m.isDead {
+		return
+	}
+	preserve(m)
+	m.hitPoints += n
+	if m.hitPoints > m.MaxHitPoints() {
+		m.hitPoints = m.MaxHitPoints()
+	}
+	world.Update(m)
+}
+
 // Stamina implements the Mobile interface.
 func (m *BaseMobile) Stamina() int { return m.stamina }
 
@@ -653,10 +897,11 @@ func (m *BaseMobile) DropItemInCursor() {
 	if item == nil {
 		return
 	}
+	preserve(m)
 	m.cursor.PickUp(nil)
 	item.SetLocation(m.location)
 	item.SetParent(nil)
-	world.Map().ForceAddObject(item)
+	MapOf(m).ForceAddObject(item)
 	world.Update(m)
 }
 
@@ -730,7 +975,7 @@ func (m *BaseMobile) PickUp(o Object) bool {
 		if !m.cursor.PickUp(o) {
 			return false
 		}
-		if !world.Map().SetNewParent(o, m) {
+		if !MapOf(m).SetNewParent(o, m) {
 			m.cursor.PickUp(nil)
 			return false
 		}
@@ -745,6 +990,7 @@ func (m *BaseMobile) doAddObject(obj Object, force bool) bool {
 	if obj == nil {
 		return force
 	}
+	preserve(m)
 	// Handle items coming in from other sources
 	if m.cursor.item == nil || m.cursor.item.Serial() != obj.Serial() {
 		// Try to equip the item
@@ -859,8 +1105,8 @@ func (m *BaseMobile) DropToBackpack(o Object, force bool) bool {
 	oldParent := item.Parent()
 	if item.Parent() == nil {
 		if force {
-			world.Map().ForceRemoveObject(item)
-		} else if !world.Map().RemoveObject(item) {
+			MapOf(item).ForceRemoveObject(item)
+		} else if !MapOf(item).RemoveObject(item) {
 			return false
 		}
 	} else {
@@ -913,8 +1159,8 @@ func (m *BaseMobile) DropToBankBox(o Object, force bool) bool {
 	oldParent := item.Parent()
 	if item.Parent() == nil {
 		if force {
-			world.Map().ForceRemoveObject(item)
-		} else if !world.Map().RemoveObject(item) {
+			MapOf(item).ForceRemoveObject(item)
+		} else if !MapOf(item).RemoveObject(item) {
 			return false
 		}
 	} else {
@@ -940,10 +1186,10 @@ func (m *BaseMobile) DropToFeet(o Object) {
 	if o.Parent() != nil {
 		o.Parent().ForceRemoveObject(o)
 	} else {
-		world.Map().ForceRemoveObject(o)
+		MapOf(o).ForceRemoveObject(o)
 	}
 	o.SetLocation(m.location)
-	world.Map().ForceAddObject(o)
+	MapOf(m).ForceAddObject(o)
 }
 
 // doEquip equips a wearable to the mobile forcefully if requested
@@ -951,6 +1197,8 @@ func (m *BaseMobile) doEquip(w Wearable, force bool) bool {
 	if w == nil {
 		return force
 	}
+	preserve(m)
+	preserve(w)
 	if m.equipment == nil {
 		m.equipment = NewEquipmentCollection()
 	}
@@ -962,7 +1210,7 @@ func (m *BaseMobile) doEquip(w Wearable, force bool) bool {
 	}
 	w.SetParent(m)
 	// Send the WearItem packet to all netstates in range, including our own
-	for _, mob := range world.Map().GetNetStatesInRange(m.Location(), uo.MaxViewRange) {
+	for _, mob := range MapOf(m).GetNetStatesInRange(m.Location(), uo.MaxViewRange) {
 		if mob.Location().XYDistance(m.Location()) <= mob.ViewRange() {
 			mob.NetState().WornItem(w, m)
 		}
@@ -989,11 +1237,12 @@ func (m *BaseMobile) doUnequip(w Wearable, force bool) bool {
 	if worn == nil || worn.Serial() != w.Serial() {
 		return force
 	}
+	preserve(m)
 	if !m.equipment.Unequip(w) {
 		return force
 	}
 	// Send the remove item packet to everyone including ourselves
-	for _, mob := range world.Map().GetNetStatesInRange(m.Location(), uo.MaxViewRange) {
+	for _, mob := range MapOf(m).GetNetStatesInRange(m.Location(), uo.MaxViewRange) {
 		mob.NetState().RemoveObject(w)
 	}
 	return true
@@ -1045,16 +1294,6 @@ func (m *BaseMobile) MapEquipment(fn func(Wearable) error) []error {
 	return ret
 }
 
-// GetNotorietyFor implements the Mobile interface.
-func (m *BaseMobile) GetNotorietyFor(other Mobile) uo.Notoriety {
-	// TODO Guild system
-	// If this is a player's mobile return innocent
-	if other.IsPlayerCharacter() {
-		return uo.NotorietyInnocent
-	}
-	return m.notoriety
-}
-
 // AdjustWeight implements the Object interface
 func (m *BaseMobile) AdjustWeight(n float32) {
 	if m.equipment != nil {
@@ -1076,11 +1315,14 @@ func (m *BaseMobile) Weight() float32 {
 
 // AfterMove implements the Mobile interface.
 func (m *BaseMobile) AfterMove() {
+	// Walking about breaks hiding
+	m.RevealingAction()
 	// Max weight checks
 	w := int(m.Weight())
 	mw := m.MaxWeight()
 	if w > mw {
 		sc := w - mw
+		preserve(m)
 		m.stamina -= sc
 		if m.stamina < 0 {
 			m.stamina = 0
@@ -1091,6 +1333,13 @@ func (m *BaseMobile) AfterMove() {
 	if m.NetState() != nil {
 		m.NetState().ContainerRangeCheck()
 	}
+	// Cancel secure trades with mobiles that are now out of range
+	secureTradeRangeCheck(m)
+	// Red monsters wandering into guarded regions call the guards on themselves
+	if !m.isPlayerCharacter && m.controlMaster == nil &&
+		m.notoriety == uo.NotorietyMurderer && IsGuardTarget(m) {
+		sendGuard(m)
+	}
 }
 
 // InBank implements the Mobile interface.
@@ -1188,9 +1437,9 @@ func (m *BaseMobile) Mount(mount Mobile) {
 	}
 	mi.SetBaseGraphicForBody(mount.Body())
 	mi.SetHue(mount.Hue())
-	if !world.Map().SetNewParent(mount, mi) || !m.Equip(mi) {
+	if !MapOf(m).SetNewParent(mount, mi) || !m.Equip(mi) {
 		if mount.Parent() != nil {
-			world.Map().SetNewParent(mount, nil)
+			MapOf(m).SetNewParent(mount, nil)
 		}
 		return
 	}
@@ -1212,7 +1461,7 @@ func (m *BaseMobile) Dismount() {
 	}
 	mount.SetLocation(m.Location())
 	mount.SetFacing(m.Facing())
-	world.Map().SetNewParent(mount, nil)
+	MapOf(m).SetNewParent(mount, nil)
 	Remove(mi)
 }
 
@@ -1228,6 +1477,10 @@ func (m *BaseMobile) CanAccess(o Object) bool {
 		return false
 	}
 	for {
+		if c, ok := o.(*Corpse); ok {
+			// Corpses have their own looting rules
+			return c.CanLoot(m)
+		}
 		if o.Parent() == nil {
 			// Object is directly on the map
 			return true
@@ -1260,6 +1513,15 @@ func (m *BaseMobile) Skill(which uo.Skill) int16 { return m.skills[which] }
 // Skills implements the Mobile interface.
 func (m *BaseMobile) Skills() []int16 { return m.skills }
 
+// SetSkill implements the Mobile interface.
+func (m *BaseMobile) SetSkill(which uo.Skill, v int16) {
+	if which >= uo.SkillCount {
+		return
+	}
+	preserve(m)
+	m.skills[which] = v
+}
+
 // SkillCheck implements the Mobile interface.
 func (m *BaseMobile) SkillCheck(which uo.Skill, min, max int) bool {
 	if which > uo.SkillLast {
@@ -1283,8 +1545,7 @@ func (m *BaseMobile) SkillCheck(which uo.Skill, min, max int) bool {
 		success = true
 	}
 	// Calculate skill gain
-	tryGainSkill := v < 1000
-	// TODO Check skill lock
+	tryGainSkill := v < skillCap && m.skillLocks[which] == uo.SkillLockUp
 	if tryGainSkill {
 		gc := 1000
 		gc += 1000 - v
@@ -1314,10 +1575,7 @@ func (m *BaseMobile) SkillCheck(which uo.Skill, min, max int) bool {
 		}
 		if toGain > 0 {
 			// Execute skill gain
-			m.skills[which] = int16(v + toGain)
-			if m.n != nil {
-				m.n.UpdateSkill(which, uo.SkillLockUp, v+toGain)
-			}
+			m.gainSkill(which, toGain)
 		}
 	}
 	// Determine if we can gain a stat
@@ -1325,7 +1583,6 @@ func (m *BaseMobile) SkillCheck(which uo.Skill, min, max int) bool {
 		// 5% chance of stat gain on every skill use
 		return success
 	}
-	// TODO Consider total stat cap
 	info := uo.SkillInfo[which]
 	primaryStat := info.PrimaryStat
 	secondaryStat := info.SecondaryStat
@@ -1333,29 +1590,9 @@ func (m *BaseMobile) SkillCheck(which uo.Skill, min, max int) bool {
 	if world.Random().Random(0, 3) == 0 {
 		statToConsider = secondaryStat
 	}
-	// TODO Consider stat locks
-	sv := 0
-	switch statToConsider {
-	case uo.StatStrength:
-		sv = m.baseStrength
-	case uo.StatDexterity:
-		sv = m.baseDexterity
-	case uo.StatIntelligence:
-		sv = m.baseIntelligence
-	}
-	if sv >= 100 {
-		// Can't gain any more
+	if !m.gainStat(statToConsider) {
 		return success
 	}
-	// Apply stat gain
-	switch statToConsider {
-	case uo.StatStrength:
-		m.baseStrength++
-	case uo.StatDexterity:
-		m.baseDexterity++
-	case uo.StatIntelligence:
-		m.baseIntelligence++
-	}
 	// If we've gotten this far we need to send a status update for the new stat
 	world.Update(m)
 
@@ -1364,9 +1601,18 @@ func (m *BaseMobile) SkillCheck(which uo.Skill, min, max int) bool {
 
 // Update implements the Mobile interface.
 func (m *BaseMobile) Update(t uo.Time) {
+	// Notoriety timers run for ghosts too
+	if t%uo.DurationSecond == 0 {
+		m.updateNotoriety(t)
+	}
+	if m.isDead {
+		// Ghosts do not regenerate or fight
+		return
+	}
 	// HP regen, 1 per 3 seconds
 	if t%(uo.DurationSecond*3) == 0 {
 		if m.hitPoints < m.MaxHitPoints() {
+			preserve(m)
 			m.hitPoints++
 			world.Update(m)
 		}
@@ -1374,6 +1620,7 @@ func (m *BaseMobile) Update(t uo.Time) {
 	// SP regen, 1 per 2 seconds
 	if t%(uo.DurationSecond*2) == 0 {
 		if m.stamina < m.MaxStamina() {
+			preserve(m)
 			m.stamina++
 			world.Update(m)
 		}
@@ -1381,10 +1628,13 @@ func (m *BaseMobile) Update(t uo.Time) {
 	// MP regen, 1 per second
 	if t%(uo.DurationSecond) == 0 {
 		if m.mana < m.MaxMana() {
+			preserve(m)
 			m.mana++
 			world.Update(m)
 		}
 	}
+	// Combat handling
+	m.updateCombat(t)
 	// AI handling
 	if m.ai != nil {
 		// Interleaved target selection every 15 seconds
@@ -1399,25 +1649,82 @@ func (m *BaseMobile) Update(t uo.Time) {
 
 // CanSee implements the Mobile interface.
 func (m *BaseMobile) CanSee(o Object) bool {
+	if om, ok := o.(Mobile); ok && om.IsDead() && !m.isDead &&
+		om.Serial() != m.serial && !m.isStaff() {
+		// Only ghosts and staff can see ghosts
+		return false
+	}
+	if o.Serial() == m.serial {
+		// We always know where we are
+		return true
+	}
 	switch o.Visibility() {
 	case uo.VisibilityVisible:
 		return true
 	case uo.VisibilityInvisible:
 		return false
 	case uo.VisibilityHidden:
-		return false
+		return m.isStaff()
 	case uo.VisibilityStaff:
-		if m.NetState() == nil {
-			return false
-		}
-		a := m.NetState().Account()
-		return a.HasRole(RoleGameMaster)
+		return m.isStaff()
 	case uo.VisibilityNone:
 		return false
 	}
 	return false
 }
 
+// Visibility implements the Object interface.
+func (m *BaseMobile) Visibility() uo.Visibility {
+	if m.hidden {
+		return uo.VisibilityHidden
+	}
+	return m.BaseObject.Visibility()
+}
+
+// IsHidden implements the Mobile interface.
+func (m *BaseMobile) IsHidden() bool { return m.hidden }
+
+// SetHidden implements the Mobile interface.
+func (m *BaseMobile) SetHidden(v bool) {
+	if m.hidden == v {
+		return
+	}
+	preserve(m)
+	m.hidden = v
+	for _, om := range MapOf(m).GetNetStatesInRange(m.location, uo.MaxViewRange) {
+		if om.Serial() == m.serial {
+			continue
+		}
+		if om.CanSee(m) {
+			om.NetState().SendObject(m)
+		} else {
+			om.NetState().RemoveObject(m)
+		}
+	}
+	if m.n != nil {
+		m.n.DrawPlayer()
+	}
+}
+
+// RevealingAction implements the Mobile interface.
+func (m *BaseMobile) RevealingAction() {
+	if !m.hidden {
+		return
+	}
+	m.SetHidden(false)
+	if m.n != nil {
+		m.n.Cliloc(nil, 500814) // You have been revealed!
+	}
+}
+
+// isStaff returns true if the mobile is controlled by a game master.
+func (m *BaseMobile) isStaff() bool {
+	if m.NetState() == nil {
+		return false
+	}
+	return m.NetState().Account().HasRole(RoleGameMaster)
+}
+
 // NoRent implements the Object interface.
 func (m *BaseMobile) NoRent() bool {
 	if m.controlMaster != nil && m.controlMaster.IsPlayerCharacter() {
@@ -1440,12 +1747,14 @@ func (m *BaseMobile) ControlMaster() Mobile { return m.controlMaster }
 
 // SetControlMaster implements the Mobile interface.
 func (m *BaseMobile) SetControlMaster(cm Mobile) {
+	preserve(m)
 	m.controlMaster = cm
 	m.SetRunning(cm != nil)
 }
 
 // SetAI implements the Mobile interface.
 func (m *BaseMobile) SetAI(which string) {
+	preserve(m)
 	m.aiName = which
 	m.ai = aiGetter(which)
 }
@@ -1478,9 +1787,10 @@ func (m *BaseMobile) CanTakeStep() bool {
 
 // Step implements the Mobile interface.
 func (m *BaseMobile) Step(d uo.Direction) bool {
+	preserve(m)
 	f := m.facing
 	m.facing = d
-	ret := world.Map().MoveMobile(m, d)
+	ret := MapOf(m).MoveMobile(m, d)
 	if !ret {
 		m.facing = f
 	} else {
@@ -1495,7 +1805,7 @@ func (m *BaseMobile) AppendContextMenuEntries(c *ContextMenu, src Mobile) {
 	if m.controlMaster != nil && m.controlMaster == src {
 		// c.Append("CommandKill", 3006111)
 		c.Append("CommandFollow", 3006108)
-		// c.Append("CommandGuard", 3006107)
+		c.Append("CommandGuard", 3006107)
 		c.Append("CommandDrop", 3006109)
 		c.Append("CommandStop", 3006112)
 		c.Append("CommandRelease", 3006118)
@@ -1517,12 +1827,16 @@ func (m *BaseMobile) Stable(p Mobile) *Error {
 			String: "You have too many animals in the stables already!",
 		}
 	}
+	preserve(m)
+	preserve(p)
 	m.stabledPets = m.stabledPets.Append(p)
 	return nil
 }
 
 // Claim implements the Mobile interface.
 func (m *BaseMobile) Claim(p Mobile) *Error {
+	preserve(m)
+	preserve(p)
 	m.stabledPets = m.stabledPets.Remove(p)
 	return nil
 }
@@ -1534,6 +1848,7 @@ func (m *BaseMobile) StabledPets() []Mobile {
 
 // SetBody implements the Mobile interface.
 func (m *BaseMobile) SetBody(b uo.Body) {
+	preserve(m)
 	m.body = b
 	world.Update(m)
 }
@@ -1550,7 +1865,7 @@ func (m *BaseMobile) HasLineOfSight(o Object) bool {
 		// Look other mobiles in the eye
 		b.Z += uo.PlayerHeight
 	}
-	return world.Map().LineOfSight(a, b)
+	return MapOf(m).LineOfSight(a, b)
 }
 
 // SetAmount implements the Object interface.
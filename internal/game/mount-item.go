@@ -76,6 +76,8 @@ func (i *MountItem) doAdd(o Object, force bool) bool {
 	if o == nil {
 		return force
 	}
+	preserve(i)
+	preserve(o)
 	o.SetParent(i)
 	m, ok := o.(Mobile)
 	if !ok {
@@ -105,6 +107,7 @@ func (i *MountItem) ForceAddObject(o Object) {
 // ForceRemoveObject implements the Object interface. PLEASE NOTE that a call to
 // BaseObject.ForceRemoveObject() will leak the object!
 func (i *MountItem) ForceRemoveObject(o Object) {
+	preserve(i)
 	i.m = nil
 }
 
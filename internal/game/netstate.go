@@ -25,6 +25,9 @@ type NetState interface {
 	// equipping items. This method assumes that the action will be taken after
 	// this call and sets internal states to limit action speed.
 	TakeAction() bool
+	// TakeSkillAction returns true if a skill may be used at this time. If so
+	// further skill use is blocked for the given duration.
+	TakeSkillAction(uo.Time) bool
 	// Mobile returns the mobile associated with this state if any.
 	Mobile() Mobile
 
@@ -103,4 +106,18 @@ type NetState interface {
 	GetGUMPByID(uo.Serial) any
 	// OpenPaperDoll opens the paper doll of the given mobile
 	OpenPaperDoll(m Mobile)
+	// OpenSpellbook opens the spellbook
+	OpenSpellbook(*Spellbook)
+
+	//
+	// Secure trade
+	//
+
+	// SecureTradeOpen sends both trade containers and opens the secure trade
+	// window with the other mobile.
+	SecureTradeOpen(own, other Container, with Mobile)
+	// SecureTradeUpdate updates the accept check boxes of the trade window.
+	SecureTradeUpdate(own Container, accepted, otherAccepted bool)
+	// SecureTradeClose closes the secure trade window.
+	SecureTradeClose(own Container)
 }
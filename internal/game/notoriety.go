@@ -0,0 +1,249 @@
+package game
+
+import (
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// Notoriety rules all mobiles are subject to, see SetNotorietyRules
+var pvpEnabled bool = true
+var murderCountDecay uo.Time = uo.MurderCountDecayDefault
+
+// SetNotorietyRules sets whether player versus player combat is allowed and
+// how much time it takes for one murder count to decay.
+func SetNotorietyRules(pvp bool, decay uo.Time) {
+	pvpEnabled = pvp
+	murderCountDecay = decay
+}
+
+// PvPEnabled returns true if players may harm other players, their pets and
+// loot their corpses.
+func PvPEnabled() bool { return pvpEnabled }
+
+// playerOwned returns true if the mobile is a player character or controlled
+// by one.
+func playerOwned(m Mobile) bool { return responsibleFor(m) != nil }
+
+// Notoriety implements the Mobile interface.
+func (m *BaseMobile) Notoriety() uo.Notoriety { return m.notoriety }
+
+// GetNotorietyFor implements the Mobile interface.
+func (m *BaseMobile) GetNotorietyFor(other Mobile) uo.Notoriety {
+	// TODO Guild system
+	if other.Notoriety() == uo.NotorietyInvulnerable {
+		return uo.NotorietyInvulnerable
+	}
+	if other.IsMurderer() {
+		return uo.NotorietyMurderer
+	}
+	if other.IsCriminal() {
+		return uo.NotorietyCriminal
+	}
+	if other.Serial() != m.serial && m.IsAggressor(other) {
+		// Mobiles that attacked us may be attacked back freely
+		return uo.NotorietyAttackable
+	}
+	if other.IsPlayerCharacter() {
+		return uo.NotorietyInnocent
+	}
+	if cm := other.ControlMaster(); cm != nil && cm.IsPlayerCharacter() {
+		// Pets share the notoriety of their master
+		return m.GetNotorietyFor(cm)
+	}
+	return other.Notoriety()
+}
+
+// IsCriminal implements the Mobile interface.
+func (m *BaseMobile) IsCriminal() bool {
+	return m.criminalDeadline != uo.TimeZero && world.Time() < m.criminalDeadline
+}
+
+// CriminalAction implements the Mobile interface.
+func (m *BaseMobile) CriminalAction() {
+	if !m.isPlayerCharacter {
+		return
+	}
+	was := m.IsCriminal()
+	preserve(m)
+	m.criminalDeadline = world.Time() + uo.CriminalDuration
+	if !was {
+		if m.n != nil {
+			m.n.Cliloc(nil, 1005040) // You've committed a criminal act!!
+		}
+		m.refreshNotoriety()
+	}
+	// Crimes within guarded regions call the guards on their own
+	if IsGuarded(m) {
+		sendGuard(m)
+	}
+}
+
+// MurderCount implements the Mobile interface.
+func (m *BaseMobile) MurderCount() int { return m.murderCount }
+
+// IsMurderer implements the Mobile interface.
+func (m *BaseMobile) IsMurderer() bool {
+	return m.murderCount >= uo.MurderCountThreshold
+}
+
+// AddMurder implements the Mobile interface.
+func (m *BaseMobile) AddMurder() {
+	preserve(m)
+	if m.murderCount == 0 {
+		m.murderCountDeadline = world.Time() + murderCountDecay
+	}
+	m.murderCount++
+	if m.murderCount != uo.MurderCountThreshold {
+		return
+	}
+	if m.n != nil {
+		m.n.Speech(nil, "You are now known as a murderer!")
+	}
+	m.refreshNotoriety()
+}
+
+// IsAggressor implements the Mobile interface.
+func (m *BaseMobile) IsAggressor(other Mobile) bool {
+	d, found := m.aggressors[other.Serial()]
+	return found && world.Time() < d
+}
+
+// AggressiveAction implements the Mobile interface.
+func (m *BaseMobile) AggressiveAction(target Mobile) {
+	if target == nil || target.Serial() == m.serial {
+		return
+	}
+	// Pets commit their crimes on behalf of their masters
+	if rm := responsibleFor(m); rm != nil && rm.GetNotorietyFor(target) == uo.NotorietyInnocent {
+		rm.CriminalAction()
+	}
+	if m.aggressed == nil {
+		m.aggressed = make(map[uo.Serial]uo.Time)
+	}
+	m.aggressed[target.Serial()] = world.Time() + uo.AggressorDuration
+	target.addAggressor(m)
+}
+
+// HasAggressed implements the Mobile interface.
+func (m *BaseMobile) HasAggressed(other Mobile) bool {
+	d, found := m.aggressed[other.Serial()]
+	return found && world.Time() < d
+}
+
+// addAggressor implements the Mobile interface.
+func (m *BaseMobile) addAggressor(other Mobile) {
+	if m.aggressors == nil {
+		m.aggressors = make(map[uo.Serial]uo.Time)
+	}
+	was := m.IsAggressor(other)
+	m.aggressors[other.Serial()] = world.Time() + uo.AggressorDuration
+	if !was && m.n != nil {
+		// Highlight the aggressor as attackable
+		m.n.SendObject(other)
+	}
+}
+
+// CanHarm implements the Mobile interface.
+func (m *BaseMobile) CanHarm(target Mobile) bool {
+	if target == nil || target.Serial() == m.serial || target.IsDead() {
+		return false
+	}
+	if target.Notoriety() == uo.NotorietyInvulnerable {
+		return false
+	}
+	if !pvpEnabled && playerOwned(m) && playerOwned(target) {
+		return false
+	}
+	return true
+}
+
+// refreshNotoriety sends the mobile to everyone in range so its highlighting
+// is updated.
+func (m *BaseMobile) refreshNotoriety() {
+	for _, om := range MapOf(m).GetNetStatesInRange(m.location, uo.MaxViewRange) {
+		if m.location.XYDistance(om.Location()) <= om.ViewRange() && om.CanSee(m) {
+			om.NetState().SendObject(m)
+		}
+	}
+}
+
+// updateNotoriety expires criminal flags, aggressors and murder counts.
+func (m *BaseMobile) updateNotoriety(t uo.Time) {
+	if m.criminalDeadline != uo.TimeZero && t >= m.criminalDeadline {
+		preserve(m)
+		m.criminalDeadline = uo.TimeZero
+		if m.n != nil {
+			m.n.Speech(nil, "You are no longer a criminal.")
+		}
+		m.refreshNotoriety()
+	}
+	for s, d := range m.aggressors {
+		if t < d {
+			continue
+		}
+		delete(m.aggressors, s)
+		if m.n == nil {
+			continue
+		}
+		if om := Find[Mobile](s); om != nil && m.CanSee(om) {
+			m.n.SendObject(om)
+		}
+	}
+	for s, d := range m.aggressed {
+		if t >= d {
+			delete(m.aggressed, s)
+		}
+	}
+	if m.murderCount > 0 && t >= m.murderCountDeadline {
+		preserve(m)
+		m.murderCount--
+		m.murderCountDeadline = t + murderCountDecay
+		if m.murderCount == uo.MurderCountThreshold-1 {
+			if m.n != nil {
+				m.n.Speech(nil, "You are no longer known as a murderer.")
+			}
+			m.refreshNotoriety()
+		}
+	}
+}
+
+// creditMurders gives a murder count to the killer and every other player that
+// recently attacked the mobile if the mobile was innocent to them.
+func (m *BaseMobile) creditMurders(killer Mobile) {
+	if !m.isPlayerCharacter {
+		return
+	}
+	credited := make(map[uo.Serial]bool)
+	credit := func(a Mobile) {
+		rm := responsibleFor(a)
+		if rm == nil || rm.Serial() == m.serial || credited[rm.Serial()] {
+			return
+		}
+		credited[rm.Serial()] = true
+		if rm.GetNotorietyFor(m) == uo.NotorietyInnocent {
+			rm.AddMurder()
+		}
+	}
+	if killer != nil {
+		credit(killer)
+	}
+	for s := range m.aggressors {
+		if a := Find[Mobile](s); a != nil && m.IsAggressor(a) {
+			credit(a)
+		}
+	}
+}
+
+// responsibleFor returns the player character responsible for the actions of
+// the mobile, or nil if there is none.
+func responsibleFor(m Mobile) Mobile {
+	if m == nil {
+		return nil
+	}
+	if m.IsPlayerCharacter() {
+		return m
+	}
+	if cm := m.ControlMaster(); cm != nil && cm.IsPlayerCharacter() {
+		return cm
+	}
+	return nil
+}
@@ -152,6 +152,11 @@ type Object interface {
 	// SetLocation sets the absolute location of the object without regard to
 	// the map.
 	SetLocation(uo.Location)
+	// Facet returns the facet the object is on. Objects with a parent are on
+	// the facet of their parent.
+	Facet() uo.Facet
+	// SetFacet sets the facet of the object without regard to the map.
+	SetFacet(uo.Facet)
 	// Hue returns the hue of the item
 	Hue() uo.Hue
 	// SetHue sets the hue of the item. Remember to use hue.SetPartial() if a
@@ -213,6 +218,8 @@ type BaseObject struct {
 	hue uo.Hue
 	// Location of the object
 	location uo.Location
+	// Facet of the object when it has no parent
+	facet uo.Facet
 	// Facing is the direction the object is facing
 	facing uo.Direction
 	// Owner of the object if any
@@ -269,13 +276,14 @@ func (o *BaseObject) Marshal(s *marshal.TagFileSegment) {
 	if o.owner != nil {
 		os = o.owner.Serial()
 	}
-	s.PutInt(0) // version
+	s.PutInt(1) // version
 	s.PutInt(uint32(ps))
 	s.PutInt(uint32(os))
 	s.PutString(o.name)
 	s.PutShort(uint16(o.hue))
 	s.PutLocation(o.location)
 	s.PutByte(byte(o.facing))
+	s.PutByte(byte(o.facet))
 }
 
 // Deserialize implements the util.Serializeable interface.
@@ -326,7 +334,7 @@ func (o *BaseObject) Deserialize(t *template.Template, create bool) {
 
 // Unmarshal implements the marshal.Unmarshaler interface.
 func (o *BaseObject) Unmarshal(s *marshal.TagFileSegment) {
-	_ = s.Int() // version
+	version := s.Int()
 	// Parent object resolution
 	ps := uo.Serial(s.Int())
 	if ps == uo.SerialSystem {
@@ -357,6 +365,9 @@ func (o *BaseObject) Unmarshal(s *marshal.TagFileSegment) {
 	o.hue = uo.Hue(s.Short())
 	o.location = s.Location()
 	o.facing = uo.Direction(s.Byte())
+	if version >= 1 {
+		o.facet = uo.Facet(s.Byte())
+	}
 }
 
 // Parent implements the Object interface
@@ -377,7 +388,10 @@ func (o *BaseObject) HasParent(t Object) bool {
 }
 
 // SetParent implements the Object interface
-func (o *BaseObject) SetParent(p Object) { o.parent = p }
+func (o *BaseObject) SetParent(p Object) {
+	preserve(o)
+	o.parent = p
+}
 
 // TemplateName implements the Object interface
 func (o *BaseObject) TemplateName() string { return o.templateName }
@@ -478,14 +492,30 @@ func (o *BaseObject) Location() uo.Location { return o.location }
 
 // SetLocation implements the Object interface
 func (o *BaseObject) SetLocation(l uo.Location) {
+	preserve(o)
 	o.location = l
 }
 
+// Facet implements the Object interface
+func (o *BaseObject) Facet() uo.Facet {
+	if o.parent != nil && o.parent != Object(TheVoid) {
+		return o.parent.Facet()
+	}
+	return o.facet
+}
+
+// SetFacet implements the Object interface
+func (o *BaseObject) SetFacet(f uo.Facet) {
+	preserve(o)
+	o.facet = f
+}
+
 // Hue implements the Object interface
 func (o *BaseObject) Hue() uo.Hue { return o.hue }
 
 // SetHue implements the Object interface
 func (o *BaseObject) SetHue(hue uo.Hue) {
+	preserve(o)
 	o.hue = hue
 	world.Update(o)
 }
@@ -512,6 +542,7 @@ func (o *BaseObject) Facing() uo.Direction { return o.facing }
 
 // SetFacing implements the Object interface
 func (o *BaseObject) SetFacing(f uo.Direction) {
+	preserve(o)
 	o.facing = f.Bound()
 	world.Update(o)
 }
@@ -562,10 +593,14 @@ func (o *BaseObject) Update(t uo.Time) {}
 func (o *BaseObject) Owner() Object { return o.owner }
 
 // SetOwner implements the Object interface.
-func (o *BaseObject) SetOwner(owner Object) { o.owner = owner }
+func (o *BaseObject) SetOwner(owner Object) {
+	preserve(o)
+	o.owner = owner
+}
 
 // SetName implements the Object interface.
 func (o *BaseObject) SetName(name string) {
+	preserve(o)
 	o.name = name
 	o.articleA = false
 	o.articleAn = false
@@ -0,0 +1,336 @@
+package game
+
+import (
+	"github.com/qbradq/sharduo/lib/serverpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// MaxPartyMembers is the maximum number of members in a party, including the
+// leader.
+const MaxPartyMembers int = 10
+
+// Time a party invitation stays open
+const partyInvitationDuration uo.Time = uo.DurationSecond * 10
+
+// All parties by member serial
+var parties = make(map[uo.Serial]*Party)
+
+// All open party invitations by the serial of the invited mobile
+var partyInvitations = make(map[uo.Serial]*partyInvitation)
+
+// partyInvitation is an open invitation to join a party.
+type partyInvitation struct {
+	leader   Mobile  // The party leader that sent the invitation
+	deadline uo.Time // When the invitation expires
+}
+
+// Party is a group of players that share party chat, health bars and
+// optionally their loot. Parties only exist within the world goroutine and
+// are never persisted.
+type Party struct {
+	// All members of the party, the leader is always first
+	members []Mobile
+	// Members that allow other party members to loot their corpse
+	canLoot map[uo.Serial]bool
+}
+
+// PartyOf returns the party the mobile is a member of, or nil if it is not in
+// a party.
+func PartyOf(m Mobile) *Party {
+	if m == nil {
+		return nil
+	}
+	return parties[m.Serial()]
+}
+
+// Leader returns the party leader.
+func (p *Party) Leader() Mobile { return p.members[0] }
+
+// Members returns all members of the party, the leader first.
+func (p *Party) Members() []Mobile { return p.members }
+
+// IsMember returns true if the mobile is a member of the party.
+func (p *Party) IsMember(m Mobile) bool { return p.index(m) >= 0 }
+
+// CanLoot returns true if the member allows other party members to loot
+// their corpse.
+func (p *Party) CanLoot(m Mobile) bool { return p.canLoot[m.Serial()] }
+
+// SetCanLoot sets whether the member allows other party members to loot their
+// corpse.
+func (p *Party) SetCanLoot(m Mobile, v bool) {
+	if !p.IsMember(m) {
+		return
+	}
+	p.canLoot[m.Serial()] = v
+	if m.NetState() == nil {
+		return
+	}
+	if v {
+		m.NetState().Speech(nil, "You have chosen to allow your party to loot your corpse.")
+	} else {
+		m.NetState().Speech(nil, "You have chosen to prevent your party from looting your corpse.")
+	}
+}
+
+// index returns the index of the member or -1 if the mobile is not a member.
+func (p *Party) index(m Mobile) int {
+	for i, pm := range p.members {
+		if pm.Serial() == m.Serial() {
+			return i
+		}
+	}
+	return -1
+}
+
+// serials returns the serials of all party members.
+func (p *Party) serials() []uo.Serial {
+	ret := make([]uo.Serial, len(p.members))
+	for i, m := range p.members {
+		ret[i] = m.Serial()
+	}
+	return ret
+}
+
+// send sends the packet to all connected party members.
+func (p *Party) send(pkt serverpacket.Packet) {
+	for _, m := range p.members {
+		if m.NetState() != nil {
+			m.NetState().Send(pkt)
+		}
+	}
+}
+
+// notify sends a system message to all connected party members.
+func (p *Party) notify(format string, args ...any) {
+	for _, m := range p.members {
+		if m.NetState() != nil {
+			m.NetState().Speech(nil, format, args...)
+		}
+	}
+}
+
+// Message sends a party chat message from the member to the entire party.
+func (p *Party) Message(from Mobile, text string) {
+	if !p.IsMember(from) {
+		return
+	}
+	p.send(&serverpacket.PartyMessage{
+		From: from.Serial(),
+		Text: text,
+	})
+}
+
+// PrivateMessage sends a party chat message from one member to another. The
+// sender receives a copy of the message.
+func (p *Party) PrivateMessage(from, to Mobile, text string) {
+	if to == nil || !p.IsMember(from) || !p.IsMember(to) {
+		return
+	}
+	pkt := &serverpacket.PartyMessage{
+		Private: true,
+		From:    from.Serial(),
+		Text:    text,
+	}
+	if to.NetState() != nil {
+		to.NetState().Send(pkt)
+	}
+	if from.Serial() != to.Serial() && from.NetState() != nil {
+		from.NetState().Send(pkt)
+	}
+}
+
+// SendHealth sends the health of the member to all other party members that
+// are too far away to receive the normal status updates for the member.
+func (p *Party) SendHealth(m Mobile) {
+	pkt := &serverpacket.UpdateHealth{
+		Serial:  m.Serial(),
+		Hits:    m.HitPoints(),
+		MaxHits: m.MaxHitPoints(),
+	}
+	for _, pm := range p.members {
+		if pm.Serial() == m.Serial() || pm.NetState() == nil ||
+			pm.Location().XYDistance(m.Location()) <= pm.ViewRange() {
+			continue
+		}
+		pm.NetState().Send(pkt)
+	}
+}
+
+// InviteToParty sends a party invitation from the leader to the mobile. The
+// leader must either lead a party or not be in one at all.
+func InviteToParty(leader, m Mobile) {
+	n := leader.NetState()
+	if n == nil || m == nil {
+		return
+	}
+	if m.Serial() == leader.Serial() {
+		n.Speech(nil, "You cannot add yourself to a party.")
+		return
+	}
+	p := PartyOf(leader)
+	if p != nil && p.Leader().Serial() != leader.Serial() {
+		n.Speech(nil, "You may only add members to the party if you are the leader.")
+		return
+	}
+	if p != nil && len(p.members) >= MaxPartyMembers {
+		n.Speech(nil, "You may only have %d in your party.", MaxPartyMembers)
+		return
+	}
+	if !m.IsPlayerCharacter() || m.NetState() == nil {
+		n.Speech(nil, "The creature ignores your offer.")
+		return
+	}
+	if op := PartyOf(m); op != nil {
+		if op == p {
+			n.Speech(nil, "This person is already in your party!")
+		} else {
+			n.Speech(nil, "This person is already in a party!")
+		}
+		return
+	}
+	if pi := partyInvitations[m.Serial()]; pi != nil && world.Time() < pi.deadline {
+		n.Speech(nil, "This person has already been invited to a party.")
+		return
+	}
+	partyInvitations[m.Serial()] = &partyInvitation{
+		leader:   leader,
+		deadline: world.Time() + partyInvitationDuration,
+	}
+	m.NetState().Send(&serverpacket.PartyInvitation{
+		Leader: leader.Serial(),
+	})
+	m.NetState().Speech(nil, "%s has invited you to join a party. Type /accept "+
+		"to join or /decline to decline the offer.", leader.DisplayName())
+	n.Speech(nil, "You have invited %s to join the party.", m.DisplayName())
+}
+
+// takePartyInvitation removes and returns the open invitation of the mobile
+// sent by the leader, or nil if there is none.
+func takePartyInvitation(m Mobile, leader uo.Serial) *partyInvitation {
+	pi := partyInvitations[m.Serial()]
+	if pi == nil || pi.leader.Serial() != leader {
+		return nil
+	}
+	delete(partyInvitations, m.Serial())
+	if world.Time() >= pi.deadline || pi.leader.Removed() {
+		return nil
+	}
+	return pi
+}
+
+// AcceptPartyInvitation adds the mobile to the party of the leader that
+// invited it.
+func AcceptPartyInvitation(m Mobile, leader uo.Serial) {
+	pi := takePartyInvitation(m, leader)
+	if pi == nil || PartyOf(m) != nil {
+		if m.NetState() != nil {
+			m.NetState().Speech(nil, "That party invitation is no longer valid.")
+		}
+		return
+	}
+	p := PartyOf(pi.leader)
+	if p == nil {
+		p = &Party{
+			members: []Mobile{pi.leader},
+			canLoot: make(map[uo.Serial]bool),
+		}
+		parties[pi.leader.Serial()] = p
+	} else if p.Leader().Serial() != leader || len(p.members) >= MaxPartyMembers {
+		if m.NetState() != nil {
+			m.NetState().Speech(nil, "That party invitation is no longer valid.")
+		}
+		return
+	}
+	p.notify("%s has joined the party.", m.DisplayName())
+	p.members = append(p.members, m)
+	parties[m.Serial()] = p
+	if m.NetState() != nil {
+		m.NetState().Speech(nil, "You have been added to the party.")
+	}
+	p.send(&serverpacket.PartyMemberList{
+		Members: p.serials(),
+	})
+	for _, pm := range p.members {
+		p.SendHealth(pm)
+	}
+}
+
+// DeclinePartyInvitation declines the open party invitation of the mobile.
+func DeclinePartyInvitation(m Mobile, leader uo.Serial) {
+	pi := takePartyInvitation(m, leader)
+	if pi == nil {
+		return
+	}
+	if pi.leader.NetState() != nil {
+		pi.leader.NetState().Speech(nil, "%s does not wish to join the party.",
+			m.DisplayName())
+	}
+}
+
+// RemoveFromParty removes the member from the party. Party leaders may remove
+// anyone, all other members may only remove themselves. The party disbands
+// when the leader leaves or only the leader remains.
+func RemoveFromParty(by, m Mobile) {
+	p := PartyOf(m)
+	if p == nil || PartyOf(by) != p {
+		return
+	}
+	if by.Serial() != m.Serial() && p.Leader().Serial() != by.Serial() {
+		if by.NetState() != nil {
+			by.NetState().Speech(nil, "You may only remove yourself from a party if you are not the leader.")
+		}
+		return
+	}
+	if p.Leader().Serial() == m.Serial() {
+		p.disband()
+		return
+	}
+	p.leave(m)
+	if m.NetState() != nil {
+		m.NetState().Speech(nil, "You have been removed from the party.")
+	}
+	if len(p.members) < 2 {
+		p.disband()
+		return
+	}
+	p.send(&serverpacket.PartyRemoveMember{
+		Removed: m.Serial(),
+		Members: p.serials(),
+	})
+	p.notify("%s has left the party.", m.DisplayName())
+}
+
+// LeaveParty removes the mobile from its party and declines its open party
+// invitation, if any. This is used when the player leaves the world.
+func LeaveParty(m Mobile) {
+	if pi := partyInvitations[m.Serial()]; pi != nil {
+		DeclinePartyInvitation(m, pi.leader.Serial())
+	}
+	RemoveFromParty(m, m)
+}
+
+// leave removes the member from the party and resets its client's party
+// state.
+func (p *Party) leave(m Mobile) {
+	i := p.index(m)
+	if i < 0 {
+		return
+	}
+	p.members = append(p.members[:i], p.members[i+1:]...)
+	delete(parties, m.Serial())
+	delete(p.canLoot, m.Serial())
+	if m.NetState() != nil {
+		m.NetState().Send(&serverpacket.PartyRemoveMember{
+			Removed: m.Serial(),
+		})
+	}
+}
+
+// disband removes all members from the party.
+func (p *Party) disband() {
+	p.notify("Your party has disbanded.")
+	for len(p.members) > 0 {
+		p.leave(p.members[len(p.members)-1])
+	}
+}
@@ -0,0 +1,334 @@
+package game
+
+import (
+	"container/heap"
+
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// PathStatus describes the state of a path finding request.
+type PathStatus uint8
+
+const (
+	PathPending  PathStatus = 0 // The search has not completed yet, ask again next tick
+	PathFound    PathStatus = 1 // A path was found and the returned direction is valid
+	PathNotFound PathStatus = 2 // There is no path to the goal
+	PathArrived  PathStatus = 3 // The mobile is already within range of the goal
+)
+
+// DefaultPathfindingBudget is the default number of nodes the path finder may
+// expand every tick across all searches.
+const DefaultPathfindingBudget int = 2000
+
+// Maximum number of nodes a single search may expand before giving up
+const maxPathNodes int = 4096
+
+// Maximum distance from the start location a search will consider
+const maxPathDistance int16 = uo.MaxUpdateRange * 2
+
+// Time a failed search is remembered before searching again
+const pathRetryDelay uo.Time = uo.DurationSecond * 2
+
+// Time an unused search is kept in the cache
+const pathCacheDuration uo.Time = uo.DurationSecond * 10
+
+// pathNode is a single node of the A* search.
+type pathNode struct {
+	l      uo.Location     // Location of the node
+	floor  uo.CommonObject // Object the mobile would be standing on
+	g      int             // Cost to get to this node
+	f      int             // Estimated total cost through this node
+	parent *pathNode       // Node we came from
+	d      uo.Direction    // Direction of the step from the parent
+	index  int             // Index into the open list, -1 if closed
+}
+
+// pathNodeList implements heap.Interface for the open list.
+type pathNodeList []*pathNode
+
+func (l pathNodeList) Len() int { return len(l) }
+
+func (l pathNodeList) Less(i, j int) bool {
+	if l[i].f == l[j].f {
+		// Prefer nodes closer to the goal
+		return l[i].g > l[j].g
+	}
+	return l[i].f < l[j].f
+}
+
+func (l pathNodeList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+	l[i].index = i
+	l[j].index = j
+}
+
+func (l *pathNodeList) Push(x any) {
+	n := x.(*pathNode)
+	n.index = len(*l)
+	*l = append(*l, n)
+}
+
+func (l *pathNodeList) Pop() any {
+	old := *l
+	n := old[len(old)-1]
+	old[len(old)-1] = nil
+	n.index = -1
+	*l = old[:len(old)-1]
+	return n
+}
+
+// pathStep is one step of a found path.
+type pathStep struct {
+	d uo.Direction // Direction to step
+	l uo.Location  // Location after the step
+}
+
+// pathSearch is an A* search that may span several ticks, and the path it
+// found once completed.
+type pathSearch struct {
+	start    uo.Location               // Start location
+	goal     uo.Location               // Goal location
+	within   int16                     // Range to the goal that satisfies the search
+	status   PathStatus                // Status of the search
+	open     pathNodeList              // Open list
+	nodes    map[uo.Location]*pathNode // All nodes generated so far
+	expanded int                       // Number of nodes expanded so far
+	steps    []pathStep                // The path once found
+	next     int                       // Index of the next step to take
+	x1, y1   int16                     // Top-left of the area touched by the search
+	x2, y2   int16                     // Bottom-right of the area touched by the search
+	deadline uo.Time                   // Time at which a failed search may be retried
+	lastUsed uo.Time                   // Last time the search was requested
+}
+
+// pathfinder manages all path searches of a map.
+type pathfinder struct {
+	budget    int                       // Nodes that may be expanded every tick
+	remaining int                       // Nodes left to expand this tick
+	searches  map[uo.Serial]*pathSearch // Searches by mobile serial
+}
+
+// newPathfinder returns a new pathfinder with the default budget.
+func newPathfinder() *pathfinder {
+	return &pathfinder{
+		budget:    DefaultPathfindingBudget,
+		remaining: DefaultPathfindingBudget,
+		searches:  make(map[uo.Serial]*pathSearch),
+	}
+}
+
+// SetPathfindingBudget sets the number of path nodes that may be expanded
+// every tick across all searches.
+func (m *Map) SetPathfindingBudget(n int) {
+	if n < 1 {
+		n = 1
+	}
+	m.pathfinder.budget = n
+	m.pathfinder.remaining = n
+}
+
+// PathStep returns the direction the mobile should step in next to get within
+// the given range of the goal location. The search uses the same movement
+// rules as Map.MoveMobile, so closed doors and other dynamic items block the
+// path. Searches are spread over several ticks when the per-tick budget runs
+// out, in which case PathPending is returned and the caller should ask again
+// later. Found paths are cached until the goal moves out of range, the mobile
+// leaves the path, or an item within the searched area is added or removed.
+func (m *Map) PathStep(mob Mobile, goal uo.Location, within int16) (uo.Direction, PathStatus) {
+	pf := m.pathfinder
+	t := world.Time()
+	l := mob.Location()
+	if l.XYDistance(goal) <= within {
+		delete(pf.searches, mob.Serial())
+		return mob.Facing(), PathArrived
+	}
+	s := pf.searches[mob.Serial()]
+	if s != nil && s.goal.XYDistance(goal) > s.within {
+		// The goal has moved
+		s = nil
+	}
+	if s != nil && s.status == PathNotFound && t >= s.deadline {
+		// Try again
+		s = nil
+	}
+	if s != nil && s.status == PathFound {
+		// Skip the steps we have taken
+		for s.next < len(s.steps) && s.steps[s.next].l.X == l.X &&
+			s.steps[s.next].l.Y == l.Y {
+			s.next++
+		}
+		expected := s.start
+		if s.next > 0 {
+			expected = s.steps[s.next-1].l
+		}
+		if s.next >= len(s.steps) || expected.X != l.X || expected.Y != l.Y {
+			// We arrived or left the path
+			s = nil
+		}
+	}
+	if s == nil {
+		s = pf.newSearch(l, mob.StandingOn(), goal, within)
+		pf.searches[mob.Serial()] = s
+	}
+	s.lastUsed = t
+	if s.status == PathPending {
+		pf.run(m, s, t)
+	}
+	if s.status != PathFound {
+		return mob.Facing(), s.status
+	}
+	return s.steps[s.next].d, PathFound
+}
+
+// ClearPath discards any cached path of the mobile.
+func (m *Map) ClearPath(mob Mobile) {
+	delete(m.pathfinder.searches, mob.Serial())
+}
+
+// newSearch creates a new search.
+func (pf *pathfinder) newSearch(start uo.Location, floor uo.CommonObject, goal uo.Location, within int16) *pathSearch {
+	s := &pathSearch{
+		start:  start,
+		goal:   goal,
+		within: within,
+		status: PathPending,
+		nodes:  make(map[uo.Location]*pathNode),
+		x1:     start.X,
+		y1:     start.Y,
+		x2:     start.X,
+		y2:     start.Y,
+	}
+	if floor == nil {
+		// We don't know where we are standing, so we can't go anywhere
+		s.status = PathNotFound
+		return s
+	}
+	n := &pathNode{
+		l:     start,
+		floor: floor,
+		f:     int(start.XYDistance(goal)),
+	}
+	s.nodes[start] = n
+	heap.Push(&s.open, n)
+	return s
+}
+
+// run advances the search as far as the remaining budget for this tick
+// allows.
+func (pf *pathfinder) run(m *Map, s *pathSearch, t uo.Time) {
+	for pf.remaining > 0 {
+		if len(s.open) == 0 || s.expanded >= maxPathNodes {
+			s.fail(t)
+			return
+		}
+		pf.remaining--
+		s.expanded++
+		n := heap.Pop(&s.open).(*pathNode)
+		if n.l.XYDistance(s.goal) <= s.within {
+			s.succeed(n)
+			return
+		}
+		for d := uo.DirectionNorth; d <= uo.DirectionNorthWest; d++ {
+			ok, nl, floor := m.canStep(n.l, n.floor, d)
+			if !ok || nl.XYDistance(s.start) > maxPathDistance {
+				continue
+			}
+			if d.IsDiagonal() {
+				// Same rules as Map.MoveMobile
+				if ok, _, _ := m.canStep(n.l, n.floor, d.Left()); !ok {
+					continue
+				}
+				if ok, _, _ := m.canStep(n.l, n.floor, d.Right()); !ok {
+					continue
+				}
+			}
+			g := n.g + 1
+			c := s.nodes[nl]
+			if c == nil {
+				c = &pathNode{
+					l:     nl,
+					index: -1,
+				}
+				s.nodes[nl] = c
+				s.touch(nl)
+			} else if g >= c.g {
+				// Already have a better way to get there
+				continue
+			}
+			c.floor = floor
+			c.g = g
+			c.f = g + int(nl.XYDistance(s.goal))
+			c.parent = n
+			c.d = d
+			if c.index < 0 {
+				heap.Push(&s.open, c)
+			} else {
+				heap.Fix(&s.open, c.index)
+			}
+		}
+	}
+}
+
+// touch grows the area touched by the search to include the location.
+func (s *pathSearch) touch(l uo.Location) {
+	if l.X < s.x1 {
+		s.x1 = l.X
+	}
+	if l.Y < s.y1 {
+		s.y1 = l.Y
+	}
+	if l.X > s.x2 {
+		s.x2 = l.X
+	}
+	if l.Y > s.y2 {
+		s.y2 = l.Y
+	}
+}
+
+// succeed builds the path ending at the given node and releases the search
+// state.
+func (s *pathSearch) succeed(n *pathNode) {
+	for ; n.parent != nil; n = n.parent {
+		s.steps = append(s.steps, pathStep{
+			d: n.d,
+			l: n.l,
+		})
+	}
+	// Steps were collected from the goal back to the start
+	for i, j := 0, len(s.steps)-1; i < j; i, j = i+1, j-1 {
+		s.steps[i], s.steps[j] = s.steps[j], s.steps[i]
+	}
+	s.status = PathFound
+	s.open = nil
+	s.nodes = nil
+}
+
+// fail marks the search as failed and releases the search state.
+func (s *pathSearch) fail(t uo.Time) {
+	s.status = PathNotFound
+	s.deadline = t + pathRetryDelay
+	s.open = nil
+	s.nodes = nil
+}
+
+// update resets the budget for the tick and drops stale searches.
+func (pf *pathfinder) update(t uo.Time) {
+	pf.remaining = pf.budget
+	if t%uo.DurationSecond != 0 {
+		return
+	}
+	for k, s := range pf.searches {
+		if t-s.lastUsed > pathCacheDuration {
+			delete(pf.searches, k)
+		}
+	}
+}
+
+// invalidate drops all searches that touched the location.
+func (pf *pathfinder) invalidate(l uo.Location) {
+	for k, s := range pf.searches {
+		if l.X >= s.x1 && l.X <= s.x2 && l.Y >= s.y1 && l.Y <= s.y2 {
+			delete(pf.searches, k)
+		}
+	}
+}
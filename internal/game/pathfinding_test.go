@@ -0,0 +1,334 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qbradq/sharduo/lib/serverpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// Graphics of the test item definitions
+const (
+	testGraphicWall       uo.Graphic = 0x0001
+	testGraphicDoorClosed uo.Graphic = 0x0002
+	testGraphicDoorOpen   uo.Graphic = 0x0003
+)
+
+// pathTestWorld is a minimal World implementation with a single map of flat
+// terrain for path finding tests.
+type pathTestWorld struct {
+	m      *Map
+	defs   map[uo.Graphic]*uo.StaticDefinition
+	time   uo.Time
+	serial uo.Serial
+}
+
+func (w *pathTestWorld) Find(uo.Serial) Object   { return nil }
+func (w *pathTestWorld) Delete(Object)           {}
+func (w *pathTestWorld) Update(Object)           {}
+func (w *pathTestWorld) UpdateOPLInfo(Object)    {}
+func (w *pathTestWorld) Map(uo.Facet) *Map       { return w.m }
+func (w *pathTestWorld) Maps() []*Map            { return []*Map{w.m} }
+func (w *pathTestWorld) Random() uo.RandomSource { return nil }
+func (w *pathTestWorld) Time() uo.Time           { return w.time }
+func (w *pathTestWorld) ServerTime() time.Time   { return time.Time{} }
+func (w *pathTestWorld) Accounts() []*Account    { return nil }
+
+func (w *pathTestWorld) GetItemDefinition(g uo.Graphic) *uo.StaticDefinition {
+	return w.defs[g]
+}
+
+func (w *pathTestWorld) BroadcastPacket(serverpacket.Packet) {}
+
+func (w *pathTestWorld) BroadcastMessage(Object, string, ...interface{}) {}
+
+// newPathTestWorld registers and returns a new test world of flat terrain at
+// Z 0. The smallest facet is used to keep memory use down.
+func newPathTestWorld() *pathTestWorld {
+	w := &pathTestWorld{
+		m: NewMap(uo.FacetTokuno),
+		defs: map[uo.Graphic]*uo.StaticDefinition{
+			testGraphicWall: {
+				Graphic:   testGraphicWall,
+				TileFlags: uo.TileFlagsImpassable | uo.TileFlagsWall,
+				Height:    20,
+			},
+			testGraphicDoorClosed: {
+				Graphic:   testGraphicDoorClosed,
+				TileFlags: uo.TileFlagsImpassable | uo.TileFlagsDoor,
+				Height:    20,
+			},
+			testGraphicDoorOpen: {
+				Graphic:   testGraphicDoorOpen,
+				TileFlags: uo.TileFlagsImpassable | uo.TileFlagsDoor,
+				Height:    20,
+			},
+		},
+		time:   1,
+		serial: 0x40000000,
+	}
+	grass := uo.NewTile(0, &uo.TileDefinition{Graphic: 0x0003})
+	for _, c := range w.m.chunks {
+		for i := range c.tiles {
+			c.tiles[i] = grass
+		}
+	}
+	RegisterWorld(w)
+	return w
+}
+
+// surface returns the graphic of a surface item of the given height, creating
+// the definition as needed.
+func (w *pathTestWorld) surface(height int8) uo.Graphic {
+	g := uo.Graphic(0x0100 + int(height))
+	if w.defs[g] == nil {
+		w.defs[g] = &uo.StaticDefinition{
+			Graphic:   g,
+			TileFlags: uo.TileFlagsSurface,
+			Height:    height,
+		}
+	}
+	return g
+}
+
+// place puts a new item with the given graphic on the map.
+func (w *pathTestWorld) place(g uo.Graphic, l uo.Location) Item {
+	i := &BaseItem{}
+	w.serial++
+	i.SetSerial(w.serial)
+	i.SetBaseGraphic(g)
+	i.SetLocation(l)
+	w.m.ForceAddObject(i)
+	return i
+}
+
+// newPathTestMobile returns a new mobile standing on the terrain at the
+// location. The mobile is not placed on the map.
+func newPathTestMobile(w *pathTestWorld, s uo.Serial, l uo.Location) Mobile {
+	m := &BaseMobile{}
+	m.SetSerial(s)
+	m.SetLocation(l)
+	floor, _ := w.m.GetFloorAndCeiling(l, false, false)
+	m.StandOn(floor)
+	return m
+}
+
+// walkPath follows the path to the goal step by step, advancing time whenever
+// the search is pending. The locations walked through are returned along with
+// the final status and the number of ticks that passed.
+func walkPath(t *testing.T, w *pathTestWorld, mob Mobile, goal uo.Location, within int16) ([]uo.Location, PathStatus, int) {
+	var steps []uo.Location
+	ticks := 0
+	for i := 0; i < 1000; i++ {
+		d, status := w.m.PathStep(mob, goal, within)
+		switch status {
+		case PathPending:
+			w.time++
+			ticks++
+			w.m.pathfinder.update(w.time)
+			continue
+		case PathFound:
+			ok, nl, floor := w.m.canStep(mob.Location(), mob.StandingOn(), d)
+			if !ok {
+				t.Fatalf("path step %d from %v is blocked", d, mob.Location())
+			}
+			mob.SetLocation(nl)
+			mob.StandOn(floor)
+			steps = append(steps, nl)
+			continue
+		}
+		return steps, status, ticks
+	}
+	t.Fatal("path was never completed")
+	return nil, PathNotFound, ticks
+}
+
+// visits returns true if the path passes through the X and Y coordinates of
+// the location.
+func visits(steps []uo.Location, l uo.Location) bool {
+	for _, s := range steps {
+		if s.X == l.X && s.Y == l.Y {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPathfindingWalls(t *testing.T) {
+	w := newPathTestWorld()
+	start := uo.Location{X: 100, Y: 128}
+	goal := uo.Location{X: 110, Y: 128}
+	// Wall north to south between the start and the goal
+	for y := int16(120); y <= 136; y++ {
+		w.place(testGraphicWall, uo.Location{X: 105, Y: y})
+	}
+	steps, status, _ := walkPath(t, w, newPathTestMobile(w, 1, start), goal, 0)
+	if status != PathArrived {
+		t.Fatalf("path around the wall returned status %d", status)
+	}
+	if len(steps) <= 10 {
+		t.Errorf("path around the wall took %d steps", len(steps))
+	}
+	for _, s := range steps {
+		if s.X == 105 && s.Y >= 120 && s.Y <= 136 {
+			t.Errorf("path went through the wall at %v", s)
+		}
+	}
+	// Close the wall into a box around the goal
+	for y := int16(120); y <= 136; y++ {
+		w.place(testGraphicWall, uo.Location{X: 115, Y: y})
+	}
+	for x := int16(106); x <= 114; x++ {
+		w.place(testGraphicWall, uo.Location{X: x, Y: 120})
+		w.place(testGraphicWall, uo.Location{X: x, Y: 136})
+	}
+	if _, status, _ := walkPath(t, w, newPathTestMobile(w, 2, start), goal, 0); status != PathNotFound {
+		t.Errorf("path into a closed box returned status %d", status)
+	}
+}
+
+func TestPathfindingDoors(t *testing.T) {
+	w := newPathTestWorld()
+	start := uo.Location{X: 100, Y: 128}
+	goal := uo.Location{X: 110, Y: 128}
+	for y := int16(118); y <= 138; y++ {
+		if y != 128 {
+			w.place(testGraphicWall, uo.Location{X: 105, Y: y})
+		}
+	}
+	doorway := uo.Location{X: 105, Y: 128}
+	door := w.place(testGraphicDoorClosed, doorway)
+	steps, status, _ := walkPath(t, w, newPathTestMobile(w, 1, start), goal, 0)
+	if status != PathArrived {
+		t.Fatalf("path around the closed door returned status %d", status)
+	}
+	if len(steps) <= 10 || visits(steps, doorway) {
+		t.Errorf("path around the closed door took %d steps: %v", len(steps), steps)
+	}
+	// Open the door the same way the door events do
+	mob := newPathTestMobile(w, 2, start)
+	if _, status := w.m.PathStep(mob, goal, 0); status != PathFound {
+		t.Fatalf("path around the closed door returned status %d", status)
+	}
+	w.m.ForceRemoveObject(door)
+	door.SetBaseGraphic(testGraphicDoorOpen)
+	door.SetLocation(uo.Location{X: 106, Y: 127})
+	w.m.ForceAddObject(door)
+	steps, status, _ = walkPath(t, w, mob, goal, 0)
+	if status != PathArrived {
+		t.Fatalf("path through the open door returned status %d", status)
+	}
+	if len(steps) != 10 || !visits(steps, doorway) {
+		t.Errorf("path through the open door took %d steps: %v", len(steps), steps)
+	}
+}
+
+func TestPathfindingStepHeight(t *testing.T) {
+	w := newPathTestWorld()
+	start := uo.Location{X: 100, Y: 128}
+	goal := uo.Location{X: 112, Y: 128}
+	// Platform 20 units high around the goal
+	for x := int16(110); x <= 114; x++ {
+		for y := int16(126); y <= 130; y++ {
+			w.place(w.surface(20), uo.Location{X: x, Y: y})
+		}
+	}
+	if _, status, _ := walkPath(t, w, newPathTestMobile(w, 1, start), goal, 0); status != PathNotFound {
+		t.Fatalf("path onto the platform without stairs returned status %d", status)
+	}
+	// Stairs going up 2 units per step lead to the platform from the west
+	for i := int8(1); i < 10; i++ {
+		w.place(w.surface(i*2), uo.Location{X: 100 + int16(i), Y: 128})
+	}
+	mob := newPathTestMobile(w, 2, start)
+	steps, status, _ := walkPath(t, w, mob, goal, 0)
+	if status != PathArrived {
+		t.Fatalf("path up the stairs returned status %d", status)
+	}
+	if mob.Location().Z != 20 {
+		t.Errorf("mobile arrived at Z %d, expected 20", mob.Location().Z)
+	}
+	for i := 1; i < len(steps); i++ {
+		if steps[i].Z-steps[i-1].Z > uo.StepHeight {
+			t.Errorf("step from %v to %v is too high", steps[i-1], steps[i])
+		}
+	}
+}
+
+func TestPathfindingBudget(t *testing.T) {
+	w := newPathTestWorld()
+	start := uo.Location{X: 100, Y: 128}
+	goal := uo.Location{X: 130, Y: 128}
+	w.m.SetPathfindingBudget(10)
+	mob := newPathTestMobile(w, 1, start)
+	if _, status := w.m.PathStep(mob, goal, 0); status != PathPending {
+		t.Fatalf("search over budget returned status %d", status)
+	}
+	s := w.m.pathfinder.searches[mob.Serial()]
+	// Other searches wait for the next tick once the budget is spent
+	other := newPathTestMobile(w, 2, uo.Location{X: 100, Y: 140})
+	if _, status := w.m.PathStep(other, goal, 0); status != PathPending {
+		t.Errorf("second search in the same tick returned status %d", status)
+	}
+	if n := w.m.pathfinder.searches[other.Serial()].expanded; n != 0 {
+		t.Errorf("second search expanded %d nodes without budget", n)
+	}
+	w.m.ClearPath(other)
+	steps, status, ticks := walkPath(t, w, mob, goal, 0)
+	if status != PathArrived {
+		t.Fatalf("search over budget returned status %d", status)
+	}
+	if ticks < 2 {
+		t.Errorf("search completed in %d ticks", ticks)
+	}
+	if s.expanded > 10*(ticks+1) {
+		t.Errorf("search expanded %d nodes in %d ticks", s.expanded, ticks+1)
+	}
+	if len(steps) != 30 {
+		t.Errorf("path took %d steps, expected 30", len(steps))
+	}
+}
+
+func TestPathfindingInvalidation(t *testing.T) {
+	w := newPathTestWorld()
+	start := uo.Location{X: 100, Y: 128}
+	goal := uo.Location{X: 110, Y: 128}
+	blocked := uo.Location{X: 105, Y: 128}
+	mob := newPathTestMobile(w, 1, start)
+	if _, status := w.m.PathStep(mob, goal, 0); status != PathFound {
+		t.Fatalf("open path returned status %d", status)
+	}
+	s := w.m.pathfinder.searches[mob.Serial()]
+	// Items outside the searched area keep the cached path
+	item := w.place(testGraphicWall, uo.Location{X: 100, Y: 100})
+	if w.m.pathfinder.searches[mob.Serial()] != s {
+		t.Fatal("item outside the searched area dropped the path")
+	}
+	// Moving a blocking item into the searched area drops the path
+	w.m.ForceRemoveObject(item)
+	item.SetLocation(blocked)
+	w.m.ForceAddObject(item)
+	if w.m.pathfinder.searches[mob.Serial()] != nil {
+		t.Fatal("blocking item moved onto the path did not drop the path")
+	}
+	steps, status, _ := walkPath(t, w, mob, goal, 0)
+	if status != PathArrived {
+		t.Fatalf("blocked path returned status %d", status)
+	}
+	if visits(steps, blocked) {
+		t.Errorf("path went through the blocking item: %v", steps)
+	}
+	// Moving the item away again drops the detour
+	mob = newPathTestMobile(w, 2, start)
+	if _, status := w.m.PathStep(mob, goal, 0); status != PathFound {
+		t.Fatalf("blocked path returned status %d", status)
+	}
+	w.m.ForceRemoveObject(item)
+	if w.m.pathfinder.searches[mob.Serial()] != nil {
+		t.Fatal("blocking item moved off the path did not drop the path")
+	}
+	if steps, status, _ := walkPath(t, w, mob, goal, 0); status != PathArrived || len(steps) != 10 {
+		t.Errorf("reopened path returned status %d after %d steps", status, len(steps))
+	}
+}
@@ -0,0 +1,84 @@
+package game
+
+import (
+	"github.com/qbradq/sharduo/lib/marshal"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("RecallRune", marshal.ObjectTypeRecallRune, func() any { return &RecallRune{} })
+}
+
+// RecallRune is a rune that can be marked with a location to recall to.
+type RecallRune struct {
+	BaseItem
+	// If true the rune has been marked
+	marked bool
+	// Location the rune was marked at
+	destination uo.FacetLocation
+	// Name of the place the rune was marked at
+	description string
+}
+
+// ObjectType implements the Object interface.
+func (i *RecallRune) ObjectType() marshal.ObjectType { return marshal.ObjectTypeRecallRune }
+
+// Marshal implements the marshal.Marshaler interface.
+func (i *RecallRune) Marshal(s *marshal.TagFileSegment) {
+	i.BaseItem.Marshal(s)
+	s.PutInt(1) // version
+	s.PutBool(i.marked)
+	s.PutLocation(i.destination.Location)
+	s.PutString(i.description)
+	s.PutByte(byte(i.destination.Facet))
+}
+
+// Unmarshal implements the marshal.Unmarshaler interface.
+func (i *RecallRune) Unmarshal(s *marshal.TagFileSegment) {
+	i.BaseItem.Unmarshal(s)
+	version := s.Int()
+	i.marked = s.Bool()
+	i.destination.Location = s.Location()
+	i.description = s.String()
+	if version >= 1 {
+		i.destination.Facet = uo.Facet(s.Byte())
+	}
+}
+
+// DisplayName implements the Object interface.
+func (i *RecallRune) DisplayName() string {
+	if !i.marked {
+		return "a blank recall rune"
+	}
+	if i.description == "" {
+		return "a recall rune"
+	}
+	return "a recall rune for " + i.description
+}
+
+// Destination returns the marked location of the rune and true, or false if
+// the rune has not been marked.
+func (i *RecallRune) Destination() (uo.FacetLocation, bool) {
+	return i.destination, i.marked
+}
+
+// Mark marks the rune with the location. The description is taken from the
+// named region at that location, if any.
+func (i *RecallRune) Mark(l uo.FacetLocation) {
+	preserve(i)
+	i.marked = true
+	i.destination = l
+	i.description = ""
+	m := world.Map(l.Facet)
+	if m == nil {
+		i.InvalidateOPL()
+		return
+	}
+	for _, r := range m.RegionsAt(l.Location) {
+		if r.Name != "" {
+			i.description = r.Name
+			break
+		}
+	}
+	i.InvalidateOPL()
+}
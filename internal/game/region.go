@@ -34,6 +34,7 @@ type SpawnerEntry struct {
 // behaviors.
 type Region struct {
 	Name      string          // Name of the region
+	Facet     uo.Facet        // Facet the region is on
 	Bounds    uo.Bounds       // Bounds of all bounding rects for first-level inclusion detection
 	Rects     []uo.Bounds     // Bounds of all the rects region
 	Features  RegionFeature   // Feature flags for this region
@@ -137,6 +138,10 @@ func (r *Region) Spawn(which string) Object {
 	if len(tn) > 0 && tn[0] == '+' {
 		tn = template.RandomListMember(tn[1:])
 	}
+	m := world.Map(r.Facet)
+	if m == nil {
+		return nil
+	}
 	// Object creation
 	o := template.Create[Object](tn)
 	if o == nil {
@@ -151,7 +156,7 @@ func (r *Region) Spawn(which string) Object {
 			continue
 		}
 		l.Z = r.SpawnMinZ
-		s := world.Map().GetSpawnableSurface(l, r.SpawnMaxZ, o)
+		s := m.GetSpawnableSurface(l, r.SpawnMaxZ, o)
 		if s == nil {
 			// The location is not suitable for spawning, try again
 			continue
@@ -165,7 +170,7 @@ func (r *Region) Spawn(which string) Object {
 		}
 		l.Z = s.StandingHeight()
 		o.SetLocation(l)
-		if !world.Map().AddObject(o) {
+		if !m.AddObject(o) {
 			// Failed to add to the map for some reason, don't leak the object
 			// just remove it and try again
 			Remove(o)
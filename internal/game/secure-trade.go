@@ -0,0 +1,376 @@
+package game
+
+import (
+	"log"
+
+	"github.com/qbradq/sharduo/lib/marshal"
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("SecureTradeContainer", marshal.ObjectTypeSecureTradeContainer, func() any { return &SecureTradeContainer{} })
+}
+
+// All secure trades that are currently open
+var secureTrades = make(map[*SecureTrade]struct{})
+
+// SecureTradeContainer holds the items one party of a secure trade is offering.
+// The container is parented to the owning mobile without being equipped so
+// access checks work as they would for the backpack. Gold and checks offered
+// are held in escrow and do not count toward the owner's gold until the trade
+// closes. Trade containers are never persisted, the offered items are saved
+// with their owner and returned to the owner's backpack when the save is
+// loaded.
+type SecureTradeContainer struct {
+	BaseContainer
+	// The trade this container belongs to
+	trade *SecureTrade
+	// Amount of gold held in escrow
+	escrow int
+	// Total amount of gold offered, including gold within sub-containers
+	offered int
+}
+
+// ObjectType implements the Object interface.
+func (c *SecureTradeContainer) ObjectType() marshal.ObjectType {
+	return marshal.ObjectTypeSecureTradeContainer
+}
+
+// Trade returns the secure trade this container belongs to.
+func (c *SecureTradeContainer) Trade() *SecureTrade { return c.trade }
+
+// AddObject implements the Object interface.
+func (c *SecureTradeContainer) AddObject(o Object) bool {
+	if !c.BaseContainer.AddObject(o) {
+		return false
+	}
+	c.sync()
+	return true
+}
+
+// ForceAddObject implements the Object interface.
+func (c *SecureTradeContainer) ForceAddObject(o Object) {
+	c.BaseContainer.ForceAddObject(o)
+	c.sync()
+}
+
+// RemoveObject implements the Object interface.
+func (c *SecureTradeContainer) RemoveObject(o Object) bool {
+	if !c.BaseContainer.RemoveObject(o) {
+		return false
+	}
+	c.sync()
+	return true
+}
+
+// ForceRemoveObject implements the Object interface.
+func (c *SecureTradeContainer) ForceRemoveObject(o Object) {
+	c.BaseContainer.ForceRemoveObject(o)
+	c.sync()
+}
+
+// UpdateItem implements the Container interface.
+func (c *SecureTradeContainer) UpdateItem(i Item) {
+	c.BaseContainer.UpdateItem(i)
+	c.sync()
+}
+
+// sync must be called after every change to the contents of the container.
+// It makes sure all items report the trade container as their parent so
+// later changes come through the trade, moves offered gold and checks into
+// escrow and resets the accept states of the trade.
+func (c *SecureTradeContainer) sync() {
+	if c.trade == nil || c.trade.closed {
+		return
+	}
+	for _, item := range c.contents {
+		item.SetParent(c)
+	}
+	// The base container credits the owner with all gold and checks that are
+	// direct children, so exactly that amount is held in escrow.
+	gold := 0
+	for _, item := range c.contents {
+		if item.TemplateName() == "GoldCoin" {
+			gold += item.Amount()
+		} else if check, ok := item.(*Check); ok {
+			gold += check.CheckAmount()
+		}
+	}
+	owner, ok := c.Parent().(Mobile)
+	if !ok {
+		return
+	}
+	owner.AdjustGold(c.escrow - gold)
+	c.escrow = gold
+	if offered := c.CountGold(); offered != c.offered {
+		c.offered = offered
+		c.trade.goldOfferChanged(owner, offered)
+	}
+	c.trade.contentsChanged()
+}
+
+// releaseEscrow returns the gold held in escrow to the owner's gold count.
+func (c *SecureTradeContainer) releaseEscrow() {
+	if owner, ok := c.Parent().(Mobile); ok {
+		owner.AdjustGold(c.escrow)
+	}
+	c.escrow = 0
+}
+
+// SecureTrade is a secure trade session between two mobiles. Each party has a
+// trade container holding the items they offer. The items only change hands
+// once both parties have accepted, and any change to the offers resets both
+// accept states.
+type SecureTrade struct {
+	// The two parties of the trade
+	mobiles [2]Mobile
+	// Trade containers of the parties, with the same indexes as mobiles
+	containers [2]*SecureTradeContainer
+	// Accept states of the parties, with the same indexes as mobiles
+	accepted [2]bool
+	// If true the trade has been completed or canceled
+	closed bool
+}
+
+// OpenSecureTrade returns the open secure trade between the two mobiles,
+// opening a new trade if needed. Nil is returned if the trade could not be
+// opened.
+func OpenSecureTrade(from, to Mobile) *SecureTrade {
+	if from == nil || to == nil || from.Serial() == to.Serial() {
+		return nil
+	}
+	for t := range secureTrades {
+		if t.index(from) >= 0 && t.index(to) >= 0 {
+			return t
+		}
+	}
+	t := &SecureTrade{
+		mobiles: [2]Mobile{from, to},
+	}
+	for i, m := range t.mobiles {
+		c := template.Create[*SecureTradeContainer]("SecureTradeContainer")
+		if c == nil {
+			// Something is very wrong
+			if i > 0 {
+				t.containers[0].SetParent(TheVoid)
+				world.Delete(t.containers[0])
+			}
+			return nil
+		}
+		c.trade = t
+		c.SetParent(m)
+		t.containers[i] = c
+	}
+	// Both parties observe both containers so they see all changes. They are
+	// not registered as observers on the net state side, so CanAccess still
+	// only allows the owner to touch the contents.
+	for _, m := range t.mobiles {
+		if m.NetState() == nil {
+			continue
+		}
+		for _, c := range t.containers {
+			if c.observers == nil {
+				c.observers = make(map[ContainerObserver]struct{})
+			}
+			c.observers[m.NetState()] = struct{}{}
+		}
+	}
+	for i, m := range t.mobiles {
+		if m.NetState() != nil {
+			m.NetState().SecureTradeOpen(t.containers[i], t.containers[1-i], t.mobiles[1-i])
+		}
+	}
+	secureTrades[t] = struct{}{}
+	return t
+}
+
+// CancelSecureTrades cancels all open secure trades the mobile is a party to.
+func CancelSecureTrades(m Mobile) {
+	for _, t := range secureTradesOf(m) {
+		t.Cancel()
+	}
+}
+
+// CancelAllSecureTrades cancels every open secure trade, returning all offered
+// items to their owners. This is called before the final save on shutdown so
+// no trade is left open.
+func CancelAllSecureTrades() {
+	for t := range secureTrades {
+		t.Cancel()
+	}
+}
+
+// secureTradeRangeCheck cancels all open secure trades the mobile is a party
+// to where the other party is out of range.
+func secureTradeRangeCheck(m Mobile) {
+	for _, t := range secureTradesOf(m) {
+		if t.mobiles[0].Location().XYDistance(t.mobiles[1].Location()) > uo.MaxDropRange {
+			t.Cancel()
+		}
+	}
+}
+
+// canReceiveTrade returns true if the mobile has a backpack that can receive
+// the items of a completed trade.
+func canReceiveTrade(m Mobile) bool {
+	if m.Removed() {
+		return false
+	}
+	_, ok := m.EquipmentInSlot(uo.LayerBackpack).(Container)
+	return ok
+}
+
+// secureTradeOffers returns all items the mobile is offering in open secure
+// trades.
+func secureTradeOffers(m Mobile) []Item {
+	var ret []Item
+	for _, t := range secureTradesOf(m) {
+		ret = append(ret, t.Container(m).contents...)
+	}
+	return ret
+}
+
+// secureTradesOf returns a copy of the list of all open secure trades the
+// mobile is a party to.
+func secureTradesOf(m Mobile) []*SecureTrade {
+	var ret []*SecureTrade
+	for t := range secureTrades {
+		if t.index(m) >= 0 {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// index returns the index of the mobile within the trade, or -1 if the mobile
+// is not a party to the trade.
+func (t *SecureTrade) index(m Mobile) int {
+	for i, tm := range t.mobiles {
+		if tm.Serial() == m.Serial() {
+			return i
+		}
+	}
+	return -1
+}
+
+// Container returns the trade container of the given mobile, or nil if the
+// mobile is not a party to the trade.
+func (t *SecureTrade) Container(m Mobile) *SecureTradeContainer {
+	i := t.index(m)
+	if i < 0 {
+		return nil
+	}
+	return t.containers[i]
+}
+
+// Accept sets the accept state of the given mobile. The trade is completed
+// once both parties have accepted.
+func (t *SecureTrade) Accept(m Mobile, accepted bool) {
+	i := t.index(m)
+	if i < 0 || t.closed {
+		return
+	}
+	t.accepted[i] = accepted
+	if t.accepted[0] && t.accepted[1] {
+		t.complete()
+		return
+	}
+	t.sendUpdate()
+}
+
+// Cancel cancels the trade and returns all offered items to their owners.
+func (t *SecureTrade) Cancel() {
+	if t.closed {
+		return
+	}
+	t.close(false)
+}
+
+// complete exchanges the offered items and closes the trade.
+func (t *SecureTrade) complete() {
+	t.close(true)
+}
+
+// close closes the trade windows and removes the trade containers. If
+// exchange is true the contents of each container are given to the other
+// party, otherwise they are returned to their owners. The exchange is all or
+// nothing: if either party is unable to receive items the trade is canceled
+// instead.
+func (t *SecureTrade) close(exchange bool) {
+	// The offers are saved with the parties, so write both before the trade
+	// is forgotten
+	for _, m := range t.mobiles {
+		preserve(m)
+	}
+	t.closed = true
+	delete(secureTrades, t)
+	if exchange && !(canReceiveTrade(t.mobiles[0]) && canReceiveTrade(t.mobiles[1])) {
+		log.Printf("warning: secure trade between %s and %s canceled because a backpack was not found",
+			t.mobiles[0].Serial().String(), t.mobiles[1].Serial().String())
+		for _, m := range t.mobiles {
+			if m.NetState() != nil {
+				m.NetState().Speech(nil, "The trade could not be completed.")
+			}
+		}
+		exchange = false
+	}
+	// Release the escrow and collect the offers of both parties before moving
+	// anything. Copies are needed as DropToBackpack modifies the containers.
+	var offers [2][]Item
+	for i, c := range t.containers {
+		c.releaseEscrow()
+		offers[i] = make([]Item, len(c.contents))
+		copy(offers[i], c.contents)
+	}
+	for i, items := range offers {
+		to := t.mobiles[i]
+		if exchange {
+			to = t.mobiles[1-i]
+		}
+		for _, item := range items {
+			to.DropToBackpack(item, true)
+		}
+	}
+	for i, m := range t.mobiles {
+		if m.NetState() != nil {
+			m.NetState().SecureTradeClose(t.containers[i])
+			for _, c := range t.containers {
+				m.NetState().RemoveObject(c)
+			}
+		}
+	}
+	for _, c := range t.containers {
+		c.observers = nil
+		Remove(c)
+	}
+}
+
+// contentsChanged must be called every time the offers change. It resets
+// the accept states of both parties.
+func (t *SecureTrade) contentsChanged() {
+	if t == nil || t.closed {
+		return
+	}
+	t.accepted = [2]bool{}
+	t.sendUpdate()
+}
+
+// goldOfferChanged tells both parties the new total amount of gold the mobile
+// is offering.
+func (t *SecureTrade) goldOfferChanged(m Mobile, offered int) {
+	for _, tm := range t.mobiles {
+		if tm.NetState() != nil {
+			tm.NetState().Speech(nil, "%s is offering %d gold.", m.DisplayName(), offered)
+		}
+	}
+}
+
+// sendUpdate sends the current accept states to both parties.
+func (t *SecureTrade) sendUpdate() {
+	for i, m := range t.mobiles {
+		if m.NetState() != nil {
+			m.NetState().SecureTradeUpdate(t.containers[i], t.accepted[i], t.accepted[1-i])
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package game
+
+import (
+	"github.com/qbradq/sharduo/lib/serverpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// Skill and stat caps all mobiles are subject to, see SetCaps
+var skillCap int = uo.SkillCapDefault
+var totalSkillCap int = uo.TotalSkillCapDefault
+var totalStatCap int = uo.StatsCapDefault
+
+// SetCaps sets the individual skill cap, the total skill cap and the total
+// stat cap. Skill values are raw values in the range 0-1000.
+func SetCaps(skill, totalSkill, totalStat int) {
+	skillCap = skill
+	totalSkillCap = totalSkill
+	totalStatCap = totalStat
+}
+
+// SkillCap returns the raw individual skill cap.
+func SkillCap() int { return skillCap }
+
+// TotalSkillCap returns the raw total skill cap.
+func TotalSkillCap() int { return totalSkillCap }
+
+// TotalStatCap returns the total stat cap.
+func TotalStatCap() int { return totalStatCap }
+
+// SkillLock implements the Mobile interface.
+func (m *BaseMobile) SkillLock(which uo.Skill) uo.SkillLock {
+	if which > uo.SkillLast {
+		return uo.SkillLockLocked
+	}
+	return m.skillLocks[which]
+}
+
+// SkillLocks implements the Mobile interface.
+func (m *BaseMobile) SkillLocks() []uo.SkillLock { return m.skillLocks }
+
+// SetSkillLock implements the Mobile interface.
+func (m *BaseMobile) SetSkillLock(which uo.Skill, l uo.SkillLock) {
+	if which > uo.SkillLast || l > uo.SkillLockLocked {
+		return
+	}
+	preserve(m)
+	m.skillLocks[which] = l
+}
+
+// StatLock implements the Mobile interface.
+func (m *BaseMobile) StatLock(which uo.Stat) uo.SkillLock {
+	if which > uo.StatIntelligence {
+		return uo.SkillLockLocked
+	}
+	return m.statLocks[which]
+}
+
+// SetStatLock implements the Mobile interface.
+func (m *BaseMobile) SetStatLock(which uo.Stat, l uo.SkillLock) {
+	if which > uo.StatIntelligence || l > uo.SkillLockLocked {
+		return
+	}
+	preserve(m)
+	m.statLocks[which] = l
+	if m.n != nil {
+		m.n.Send(&serverpacket.StatLocks{
+			Mobile:       m.serial,
+			Strength:     m.statLocks[uo.StatStrength],
+			Dexterity:    m.statLocks[uo.StatDexterity],
+			Intelligence: m.statLocks[uo.StatIntelligence],
+		})
+	}
+}
+
+// SkillTotal returns the sum of all raw skill values of the mobile.
+func (m *BaseMobile) SkillTotal() int {
+	ret := 0
+	for _, v := range m.skills {
+		ret += int(v)
+	}
+	return ret
+}
+
+// gainSkill attempts to raise the skill by up to n points while respecting the
+// skill lock, the individual skill cap and the total skill cap. When the total
+// skill cap is reached, points are taken from skills locked down.
+func (m *BaseMobile) gainSkill(which uo.Skill, n int) {
+	if m.skillLocks[which] != uo.SkillLockUp {
+		return
+	}
+	v := int(m.skills[which])
+	if v+n > skillCap {
+		n = skillCap - v
+	}
+	if n < 1 {
+		return
+	}
+	preserve(m)
+	// Atrophy down-locked skills to make room under the total cap
+	over := m.SkillTotal() + n - totalSkillCap
+	for over > 0 {
+		var down []uo.Skill
+		for s := uo.SkillFirst; s <= uo.SkillLast; s++ {
+			if s != which && m.skillLocks[s] == uo.SkillLockDown && m.skills[s] > 0 {
+				down = append(down, s)
+			}
+		}
+		if len(down) == 0 {
+			// Nothing left to lose, only gain what fits under the cap
+			n -= over
+			break
+		}
+		s := down[world.Random().Random(0, len(down)-1)]
+		loss := over
+		if loss > int(m.skills[s]) {
+			loss = int(m.skills[s])
+		}
+		m.skills[s] -= int16(loss)
+		over -= loss
+		if m.n != nil {
+			m.n.UpdateSkill(s, m.skillLocks[s], int(m.skills[s]))
+		}
+	}
+	if n < 1 {
+		return
+	}
+	m.skills[which] = int16(v + n)
+	if m.n != nil {
+		m.n.UpdateSkill(which, m.skillLocks[which], v+n)
+	}
+}
+
+// statPointer returns a pointer to the named base stat.
+func (m *BaseMobile) statPointer(which uo.Stat) *int {
+	switch which {
+	case uo.StatStrength:
+		return &m.baseStrength
+	case uo.StatDexterity:
+		return &m.baseDexterity
+	}
+	return &m.baseIntelligence
+}
+
+// gainStat attempts to raise the stat by one point while respecting the stat
+// lock, the individual stat maximum and the total stat cap. When the total stat
+// cap is reached, a point is taken from a stat locked down. Returns true if the
+// stat was raised.
+func (m *BaseMobile) gainStat(which uo.Stat) bool {
+	if m.statLocks[which] != uo.SkillLockUp {
+		return false
+	}
+	sv := m.statPointer(which)
+	if *sv >= uo.StatMaximum {
+		// Can't gain any more
+		return false
+	}
+	preserve(m)
+	if m.baseStrength+m.baseDexterity+m.baseIntelligence >= totalStatCap {
+		var down []uo.Stat
+		for s := uo.StatStrength; s <= uo.StatIntelligence; s++ {
+			if s != which && m.statLocks[s] == uo.SkillLockDown &&
+				*m.statPointer(s) > uo.StatMinimum {
+				down = append(down, s)
+			}
+		}
+		if len(down) == 0 {
+			return false
+		}
+		*m.statPointer(down[world.Random().Random(0, len(down)-1)])--
+	}
+	*sv++
+	return true
+}
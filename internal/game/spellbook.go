@@ -0,0 +1,119 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/qbradq/sharduo/lib/marshal"
+	"github.com/qbradq/sharduo/lib/serverpacket"
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("Spellbook", marshal.ObjectTypeSpellbook, func() any { return &Spellbook{} })
+}
+
+// Spellbook is a book of magery spells. The spells in the book are kept in a
+// bitmask with one bit per spell.
+type Spellbook struct {
+	BaseWearable
+	// Bitmask of the spells in the book, bit 0 is the first spell
+	content uint64
+}
+
+// ObjectType implements the Object interface.
+func (i *Spellbook) ObjectType() marshal.ObjectType { return marshal.ObjectTypeSpellbook }
+
+// Marshal implements the marshal.Marshaler interface.
+func (i *Spellbook) Marshal(s *marshal.TagFileSegment) {
+	i.BaseWearable.Marshal(s)
+	s.PutInt(0) // version
+	s.PutLong(i.content)
+}
+
+// Deserialize implements the util.Serializeable interface.
+func (i *Spellbook) Deserialize(t *template.Template, create bool) {
+	i.BaseWearable.Deserialize(t, create)
+	i.content = t.GetULong("Content", 0)
+}
+
+// Unmarshal implements the marshal.Unmarshaler interface.
+func (i *Spellbook) Unmarshal(s *marshal.TagFileSegment) {
+	i.BaseWearable.Unmarshal(s)
+	_ = s.Int() // version
+	i.content = s.Long()
+}
+
+// AppendOPLEntries implements the Object interface.
+func (i *Spellbook) AppendOPLEntires(r Object, p *serverpacket.OPLPacket) {
+	i.BaseWearable.AppendOPLEntires(r, p)
+	p.Append(fmt.Sprintf("%d spells", i.SpellCount()), true)
+}
+
+// Content returns the bitmask of the spells in the book.
+func (i *Spellbook) Content() uint64 { return i.content }
+
+// HasSpell returns true if the spell is in the book.
+func (i *Spellbook) HasSpell(s uo.Spell) bool {
+	return s <= uo.SpellLast && i.content&(1<<s) != 0
+}
+
+// AddSpell adds the spell to the book. Returns false if the spell was already
+// in the book.
+func (i *Spellbook) AddSpell(s uo.Spell) bool {
+	if s > uo.SpellLast || i.HasSpell(s) {
+		return false
+	}
+	preserve(i)
+	i.content |= 1 << s
+	i.InvalidateOPL()
+	return true
+}
+
+// SpellCount returns the number of spells in the book.
+func (i *Spellbook) SpellCount() int {
+	ret := 0
+	for v := i.content; v != 0; v &= v - 1 {
+		ret++
+	}
+	return ret
+}
+
+// FindSpellbook returns the first spellbook the mobile has equipped or carries
+// in its backpack that contains the spell, or nil if there is none.
+func FindSpellbook(m Mobile, s uo.Spell) *Spellbook {
+	return findSpellbook(m, func(sb *Spellbook) bool { return sb.HasSpell(s) })
+}
+
+// FirstSpellbook returns the first spellbook the mobile has equipped or carries
+// in its backpack, or nil if there is none.
+func FirstSpellbook(m Mobile) *Spellbook {
+	return findSpellbook(m, func(sb *Spellbook) bool { return true })
+}
+
+// findSpellbook returns the first spellbook the mobile has equipped or carries
+// in its backpack that matches the predicate.
+func findSpellbook(m Mobile, fn func(*Spellbook) bool) *Spellbook {
+	if sb, ok := m.EquipmentInSlot(uo.LayerWeapon).(*Spellbook); ok && fn(sb) {
+		return sb
+	}
+	bp, ok := m.EquipmentInSlot(uo.LayerBackpack).(Container)
+	if !ok {
+		return nil
+	}
+	var find func(Container) *Spellbook
+	find = func(c Container) *Spellbook {
+		for _, i := range c.Contents() {
+			if sb, ok := i.(*Spellbook); ok && fn(sb) {
+				return sb
+			}
+			if sub, ok := i.(Container); ok {
+				if sb := find(sub); sb != nil {
+					return sb
+				}
+			}
+		}
+		return nil
+	}
+	return find(bp)
+}
@@ -22,6 +22,8 @@ type StaticItem struct {
 	def *uo.StaticDefinition
 	// Location
 	location uo.Location
+	// Facet
+	facet uo.Facet
 	// Hue
 	hue uo.Hue
 	// If true this static was removed from the datastore
@@ -135,6 +137,10 @@ func (i *StaticItem) LiftSound() uo.Sound                   { return uo.SoundDef
 func (i *StaticItem) DropSoundOverride(s uo.Sound) uo.Sound { return s }
 func (i *StaticItem) Uses() int                             { return 0 }
 func (i *StaticItem) ConsumeUse() bool                      { return false }
+func (i *StaticItem) LootType() uo.LootType                 { return uo.LootTypeSystem }
+func (i *StaticItem) Exceptional() bool                     { return false }
+func (i *StaticItem) Crafter() string                       { return "" }
+func (i *StaticItem) SetCrafted(bool, string)               {}
 
 // Object interface
 func (i *StaticItem) SingleClick(m Mobile) {
@@ -166,6 +172,8 @@ func (i *StaticItem) InsertObject(o any)
 func (i *StaticItem) ForceRemoveObject(o Object)                                {}
 func (i *StaticItem) Location() uo.Location                                     { return i.location }
 func (i *StaticItem) SetLocation(l uo.Location)                                 { i.location = l }
+func (i *StaticItem) Facet() uo.Facet                                           { return i.facet }
+func (i *StaticItem) SetFacet(f uo.Facet)                                       { i.facet = f }
 func (i *StaticItem) Hue() uo.Hue                                               { return i.hue }
 func (i *StaticItem) SetHue(hue uo.Hue) {
 	i.hue = hue
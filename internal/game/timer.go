@@ -135,8 +135,15 @@ func MarshalTimers(s *marshal.TagFileSegment) {
 	}
 }
 
-// UnmarshalTimers reads all timers from the segment.
+// UnmarshalTimers reads all timers from the segment, replacing all existing
+// timers.
 func UnmarshalTimers(s *marshal.TagFileSegment) {
+	for _, timers := range timerPools {
+		for serial := range timers {
+			delete(timers, serial)
+		}
+	}
+	timerSerials = map[uo.Serial]*Timer{}
 	for i := uint32(0); i < s.RecordCount(); i++ {
 		serial := uo.Serial(s.Int())
 		pool := int(s.Short())
@@ -18,6 +18,14 @@ type Weapon interface {
 	Skill() uo.Skill
 	// AnimationAction returns the animation action code used for an attack
 	AnimationAction() uo.AnimationAction
+	// MinDamage returns the minimum damage of one hit
+	MinDamage() int
+	// MaxDamage returns the maximum damage of one hit
+	MaxDamage() int
+	// Speed returns the speed rating of the weapon, higher is faster
+	Speed() int
+	// Range returns the maximum distance to the defender in tiles
+	Range() int16
 }
 
 // BaseWeapon is the base implementatino of Weapon
@@ -27,6 +35,14 @@ type BaseWeapon struct {
 	skill uo.Skill
 	// Animation action code to use on attack
 	animation uo.AnimationAction
+	// Minimum damage of one hit
+	minDamage int
+	// Maximum damage of one hit
+	maxDamage int
+	// Speed rating
+	speed int
+	// Maximum range in tiles
+	weaponRange int16
 }
 
 // ObjectType implements the Object interface.
@@ -39,6 +55,10 @@ func (w *BaseWeapon) Deserialize(t *template.Template, create bool) {
 	w.BaseWearable.Deserialize(t, create)
 	w.skill = uo.Skill(t.GetNumber("Skill", int(uo.SkillWrestling)))
 	w.animation = uo.AnimationAction(t.GetNumber("Animation", int(uo.AnimationActionSlash1H)))
+	w.minDamage = t.GetNumber("MinDamage", uo.FistsMinDamage)
+	w.maxDamage = t.GetNumber("MaxDamage", uo.FistsMaxDamage)
+	w.speed = t.GetNumber("Speed", uo.FistsSpeed)
+	w.weaponRange = int16(t.GetNumber("Range", int(uo.MaxMeleeRange)))
 }
 
 // Skill implements the Weapon interface.
@@ -46,3 +66,15 @@ func (w *BaseWeapon) Skill() uo.Skill { return w.skill }
 
 // AnimationAction implements the Weapon interface.
 func (w *BaseWeapon) AnimationAction() uo.AnimationAction { return w.animation }
+
+// MinDamage implements the Weapon interface.
+func (w *BaseWeapon) MinDamage() int { return w.minDamage }
+
+// MaxDamage implements the Weapon interface.
+func (w *BaseWeapon) MaxDamage() int { return w.maxDamage }
+
+// Speed implements the Weapon interface.
+func (w *BaseWeapon) Speed() int { return w.speed }
+
+// Range implements the Weapon interface.
+func (w *BaseWeapon) Range() int16 { return w.weaponRange }
@@ -28,8 +28,18 @@ type Wearable interface {
 	// DamageDurability handles durability loss of a wearable. The first
 	// parameter must be an object back-reference.
 	DamageDurability(Object, float64)
+	// Durability returns the current durability of the wearable
+	Durability() float64
+	// MaxDurability returns the maximum durability of the wearable
+	MaxDurability() float64
+	// Repair restores durability of the wearable up to its maximum. The first
+	// parameter must be an object back-reference.
+	Repair(Object, float64)
 }
 
+// Durability multiplier of exceptional wearables
+const exceptionalDurabilityBonus float64 = 1.2
+
 // BaseWearableImplementation provides the most common implementation of the
 // Wearable interface and associated functionality to be mixed into other
 // structs.
@@ -55,6 +65,12 @@ func (i *BaseWearableImplementation) Deserialize(t *template.Template, create bo
 // Layer implements the Wearable interface.
 func (i *BaseWearableImplementation) Layer() uo.Layer { return i.layer }
 
+// Durability implements the Wearable interface.
+func (i *BaseWearableImplementation) Durability() float64 { return i.durability }
+
+// MaxDurability implements the Wearable interface.
+func (i *BaseWearableImplementation) MaxDurability() float64 { return i.maxDurability }
+
 // Marshal implements the marshal.Marshaler interface.
 func (i *BaseWearableImplementation) Marshal(s *marshal.TagFileSegment) {
 	s.PutInt(0) // version
@@ -71,6 +87,7 @@ func (i *BaseWearableImplementation) Unmarshal(s *marshal.TagFileSegment) {
 
 // DamageDurability implements the Wearable interface.
 func (i *BaseWearableImplementation) DamageDurability(r Object, v float64) {
+	preserve(r)
 	i.durability -= v
 	if i.durability < 0 {
 		i.maxDurability += i.durability
@@ -83,6 +100,16 @@ func (i *BaseWearableImplementation) DamageDurability(r Object, v float64) {
 	}
 }
 
+// Repair implements the Wearable interface.
+func (i *BaseWearableImplementation) Repair(r Object, v float64) {
+	preserve(r)
+	i.durability += v
+	if i.durability > i.maxDurability {
+		i.durability = i.maxDurability
+	}
+	r.InvalidateOPL()
+}
+
 // BaseWearable provides the most common implementation of Wearable
 type BaseWearable struct {
 	BaseItem
@@ -112,6 +139,17 @@ func (i *BaseWearable) Unmarshal(s *marshal.TagFileSegment) {
 	_ = s.Int() // version
 }
 
+// SetCrafted implements the Item interface. Exceptional wearables are more
+// durable.
+func (i *BaseWearable) SetCrafted(exceptional bool, crafter string) {
+	if exceptional && !i.exceptional {
+		preserve(i)
+		i.maxDurability *= exceptionalDurabilityBonus
+		i.durability = i.maxDurability
+	}
+	i.BaseItem.SetCrafted(exceptional, crafter)
+}
+
 // AppendOPLEntries implements the Object interface.
 func (i *BaseWearable) AppendOPLEntires(r Object, p *serverpacket.OPLPacket) {
 	i.BaseItem.AppendOPLEntires(r, p)
@@ -23,8 +23,10 @@ type World interface {
 	// have OPLInfo packets sent for. It is safe to call this method in rapid
 	// succession. No duplicate packets will be sent.
 	UpdateOPLInfo(Object)
-	// Map returns the map the world is using.
-	Map() *Map
+	// Map returns the map of the facet, or nil if the facet is not loaded.
+	Map(uo.Facet) *Map
+	// Maps returns the maps of all loaded facets.
+	Maps() []*Map
 	// GetItemDefinition returns the uo.StaticDefinition that holds the static
 	// data for a given item graphic.
 	GetItemDefinition(uo.Graphic) *uo.StaticDefinition
@@ -59,6 +61,11 @@ func GetWorld() World {
 	return world
 }
 
+// MapOf returns the map of the facet the object is on.
+func MapOf(o Object) *Map {
+	return world.Map(o.Facet())
+}
+
 // Find returns the given object cast to the given interface, or the zero value
 // if any of this fails.
 func Find[I Object](s uo.Serial) I {
@@ -65,10 +65,12 @@ func (g *account) Layout(target, param game.Object) {
 // HandleReply implements the GUMP interface.
 func (g *account) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 	fn := func() {
-		m := game.Find[game.Mobile](g.Account.Player())
-		if m != nil && m.NetState() != nil {
-			m.NetState().Disconnect()
-			m.SetNetState(nil)
+		for _, s := range g.Account.Characters() {
+			m := game.Find[game.Mobile](s)
+			if m != nil && m.NetState() != nil {
+				m.NetState().Disconnect()
+				m.SetNetState(nil)
+			}
 		}
 	}
 	// Data
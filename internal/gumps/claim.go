@@ -46,11 +46,11 @@ func (g *claim) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 	if err := n.Mobile().Claim(pm); err != nil {
 		err.SendTo(n, n.Mobile())
 	} else {
-		game.GetWorld().Map().RetrieveObject(pm.Serial())
+		game.MapOf(pm).RetrieveObject(pm.Serial())
 		pm.SetLocation(g.tm.Location())
 		pm.SetControlMaster(n.Mobile())
 		pm.SetAI("Follow")
 		pm.SetAIGoal(n.Mobile())
-		game.GetWorld().Map().ForceAddObject(pm)
+		game.MapOf(g.tm).ForceAddObject(pm)
 	}
 }
@@ -0,0 +1,154 @@
+package gumps
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qbradq/sharduo/internal/crafting"
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("craft", 0, func() GUMP {
+		return &craft{}
+	})
+}
+
+// Number of recipes listed on one page of the craft GUMP
+const craftRecipesPerPage int = 10
+
+// craft implements the crafting menu of all crafts. The target is the crafter
+// and the parameter is the crafting tool. Categories are listed on the left
+// and the recipes of the selected category on the right.
+type craft struct {
+	StandardGUMP
+	m        game.Mobile        // Crafter
+	tool     game.Item          // Crafting tool
+	c        *crafting.Craft    // Craft of the tool
+	category int                // Index of the selected category
+	recipes  []*crafting.Recipe // Recipes of the selected category
+	last     *crafting.Recipe   // Last recipe crafted
+	material int                // Index of the selected material
+	mark     bool               // If true exceptional items get the maker's mark
+}
+
+// Layout implements the game.GUMP interface.
+func (g *craft) Layout(target, param game.Object) {
+	m, ok := target.(game.Mobile)
+	if !ok {
+		return
+	}
+	tool, ok := param.(game.Item)
+	if !ok {
+		return
+	}
+	g.m = m
+	g.tool = tool
+	g.c = crafting.ForTool(tool)
+	if g.c == nil {
+		return
+	}
+	if g.category >= len(g.c.Categories) {
+		g.category = 0
+	}
+	materials := g.c.Materials()
+	if g.material >= len(materials) {
+		g.material = 0
+	}
+	g.recipes = g.c.RecipesFor(tool, g.c.Categories[g.category])
+	pages := len(g.recipes) / craftRecipesPerPage
+	if len(g.recipes)%craftRecipesPerPage != 0 {
+		pages++
+	}
+	h := 12
+	if len(materials) > 1 {
+		// Room for the material button
+		h++
+	}
+	g.Window(18, h, g.c.Name, 0, uint32(pages))
+	// Category buttons start at 100
+	for i, cat := range g.c.Categories {
+		if i == g.category {
+			g.CheckedReplyButton(0, i, 5, 1, uo.HueDefault, cat, 100+uint32(i))
+		} else {
+			g.ReplyButton(0, i, 5, 1, uo.HueDefault, cat, 100+uint32(i))
+		}
+	}
+	// Recipe buttons start at 1000
+	for i := (int(g.currentPage) - 1) * craftRecipesPerPage; i < len(g.recipes) && i < int(g.currentPage)*craftRecipesPerPage; i++ {
+		r := g.recipes[i]
+		y := i % craftRecipesPerPage
+		c := crafting.Chance(m, r)
+		g.ReplyButton(5, y, 6, 1, uo.HueDefault, fmt.Sprintf("%s %d.%d%%", r.Name, c/10, c%10), 1000+uint32(i))
+		var res []string
+		resources, _ := r.ResourcesFor(g.selectedMaterial())
+		for _, rr := range resources {
+			res = append(res, fmt.Sprintf("%d %s", rr.Amount, rr.Name))
+		}
+		g.Text(11, y, 7, uo.HueDefault, strings.Join(res, ", "))
+	}
+	g.HorizontalBar(0, 10, 18)
+	// Other buttons
+	if g.last != nil {
+		g.ReplyButton(0, 11, 6, 1, uo.HueDefault, "Make Last", 1)
+	}
+	if g.mark {
+		g.CheckedReplyButton(6, 11, 6, 1, uo.HueDefault, "Mark Item", 2)
+	} else {
+		g.ReplyButton(6, 11, 6, 1, uo.HueDefault, "Mark Item", 2)
+	}
+	if g.c.Repair {
+		g.ReplyButton(12, 11, 6, 1, uo.HueDefault, "Repair Item", 3)
+	}
+	if len(materials) > 1 {
+		g.ReplyButton(0, 12, 12, 1, uo.HueDefault, "Material: "+materials[g.material].Name, 4)
+	}
+}
+
+// selectedMaterial returns the selected material or nil if the craft has no
+// materials.
+func (g *craft) selectedMaterial() *crafting.Material {
+	materials := g.c.Materials()
+	if g.material >= len(materials) {
+		return nil
+	}
+	return materials[g.material]
+}
+
+// HandleReply implements the GUMP interface.
+func (g *craft) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
+	if g.StandardReplyHandler(p) {
+		return
+	}
+	if g.m == nil || g.tool == nil || g.c == nil {
+		return
+	}
+	switch {
+	case p.Button == 1:
+		if g.last != nil {
+			crafting.Make(g.m, g.tool, g.last, g.selectedMaterial(), g.mark)
+		}
+	case p.Button == 2:
+		g.mark = !g.mark
+	case p.Button == 3:
+		crafting.Repair(g.m, g.tool)
+	case p.Button == 4:
+		if n := len(g.c.Materials()); n > 0 {
+			g.material = (g.material + 1) % n
+		}
+	case p.Button >= 100 && p.Button < 1000:
+		idx := int(p.Button - 100)
+		if idx < len(g.c.Categories) {
+			g.category = idx
+			g.currentPage = 1
+		}
+	case p.Button >= 1000:
+		idx := int(p.Button - 1000)
+		if idx < len(g.recipes) {
+			g.last = g.recipes[idx]
+			crafting.Make(g.m, g.tool, g.last, g.selectedMaterial(), g.mark)
+		}
+	}
+}
@@ -145,8 +145,9 @@ func (g *decorate) targetVolume(n game.NetState, fn func(uo.Bounds)) {
 	})
 }
 
-// place places a single static with regard to a reference item, if any.
-func (g *decorate) place(l uo.Location, exp string, ref game.Item) bool {
+// place places a single static on the map with regard to a reference item, if
+// any.
+func (g *decorate) place(m *game.Map, l uo.Location, exp string, ref game.Item) bool {
 	item := template.Create[*game.StaticItem]("StaticItem")
 	if item == nil {
 		// Something very wrong
@@ -171,6 +172,6 @@ func (g *decorate) place(l uo.Location, exp string, ref game.Item) bool {
 		}
 	}
 	item.SetLocation(l)
-	game.GetWorld().Map().ForceAddObject(item)
+	m.ForceAddObject(item)
 	return true
 }
@@ -149,6 +149,6 @@ func (g *doors) singlePlacement(n game.NetState) {
 		door.SetFlippedGraphic(door.FlippedGraphic() + uo.Graphic(g.facing*2))
 		door.SetLocation(tr.Location)
 		door.SetFacing(uo.Direction(g.facing))
-		game.GetWorld().Map().ForceAddObject(door)
+		game.MapOf(n.Mobile()).ForceAddObject(door)
 	})
 }
@@ -165,7 +165,7 @@ func (g *floors) placeFloor(n game.NetState) {
 						}
 					}
 				}
-				d.place(l, exp, nil)
+				d.place(game.MapOf(n.Mobile()), l, exp, nil)
 			}
 		}
 	})
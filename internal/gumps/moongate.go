@@ -0,0 +1,180 @@
+package gumps
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/qbradq/sharduo/data"
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+func init() {
+	reg("moongate", 0, func() GUMP {
+		return &moongate{
+			facet: -1,
+		}
+	})
+}
+
+// MoongateDestination is one destination of the public moongate network.
+type MoongateDestination struct {
+	// Name of the destination
+	Name string
+	// Location of the moongate at the destination
+	Destination uo.FacetLocation
+}
+
+// All moongate destinations
+var moongateDestinations []*MoongateDestination
+
+// MoongateDestinations returns all moongate destinations on loaded facets as
+// defined in data/misc/moongates.ini.
+func MoongateDestinations() []*MoongateDestination {
+	if moongateDestinations == nil {
+		loadMoongateDestinations()
+	}
+	var ret []*MoongateDestination
+	for _, d := range moongateDestinations {
+		if game.GetWorld().Map(d.Destination.Facet) != nil {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+// loadMoongateDestinations loads all of the entries in
+// data/misc/moongates.ini. The segment names are the facet names.
+func loadMoongateDestinations() {
+	r := util.ListFileReader{}
+	f, err := data.FS.Open("misc/moongates.ini")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer f.Close()
+	r.StartReading(f)
+	for s := r.ReadNextSegment(); s != nil; s = r.ReadNextSegment() {
+		facet, found := uo.FacetByName(s.Name)
+		if !found {
+			log.Printf("error: unknown facet %s in misc/moongates.ini", s.Name)
+			continue
+		}
+		for _, line := range s.Contents {
+			name, lstr, err := util.ParseTagLine(line)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			l, err := util.ParseLocation(lstr)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			moongateDestinations = append(moongateDestinations, &MoongateDestination{
+				Name: name,
+				Destination: uo.FacetLocation{
+					Facet:    facet,
+					Location: l,
+				},
+			})
+		}
+	}
+	for _, err := range r.Errors() {
+		log.Println(err)
+	}
+}
+
+// moongate implements the destination menu of public moongates.
+type moongate struct {
+	StandardGUMP
+	gate  game.Object // The moongate used
+	facet int         // Index of the selected facet, -1 for the facet list
+}
+
+// facets returns the facets that have moongate destinations.
+func (g *moongate) facets() []uo.Facet {
+	var ret []uo.Facet
+	seen := map[uo.Facet]bool{}
+	for _, d := range MoongateDestinations() {
+		if !seen[d.Destination.Facet] {
+			seen[d.Destination.Facet] = true
+			ret = append(ret, d.Destination.Facet)
+		}
+	}
+	return ret
+}
+
+// destinations returns the destinations on the selected facet.
+func (g *moongate) destinations() []*MoongateDestination {
+	facets := g.facets()
+	if g.facet < 0 || g.facet >= len(facets) {
+		return nil
+	}
+	var ret []*MoongateDestination
+	for _, d := range MoongateDestinations() {
+		if d.Destination.Facet == facets[g.facet] {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+// Layout implements the GUMP interface.
+func (g *moongate) Layout(target, param game.Object) {
+	g.gate = param
+	facets := g.facets()
+	if g.facet < 0 || g.facet >= len(facets) {
+		g.facet = -1
+		g.Window(8, len(facets), "Select a Facet", 0, 1)
+		for i, f := range facets {
+			g.ReplyButton(0, i, 8, 1, uo.HueDefault, f.String(), 1001+uint32(i))
+		}
+		return
+	}
+	dests := g.destinations()
+	g.Window(8, len(dests)+1, fmt.Sprintf("Moongates of %s", facets[g.facet]), 0, 1)
+	// Back to facets button is ID 1000
+	g.ReplyButton(0, 0, 8, 1, uo.HueDefault, "Back to Facets", 1000)
+	for i, d := range dests {
+		g.ReplyButton(0, i+1, 8, 1, uo.HueDefault, d.Name, 2001+uint32(i))
+	}
+}
+
+// HandleReply implements the GUMP interface.
+func (g *moongate) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
+	if g.StandardReplyHandler(p) {
+		return
+	}
+	if p.Button == 1000 {
+		g.facet = -1
+		return
+	}
+	if p.Button > 1000 && p.Button < 2000 {
+		g.facet = int(p.Button - 1001)
+		return
+	}
+	dests := g.destinations()
+	idx := int(p.Button - 2001)
+	if idx < 0 || idx >= len(dests) {
+		return
+	}
+	m := n.Mobile()
+	if m == nil || g.gate == nil {
+		return
+	}
+	if m.Facet() != g.gate.Facet() || m.Location().XYDistance(g.gate.Location()) > 1 {
+		n.Cliloc(nil, 500446) // That is too far away.
+		return
+	}
+	dest := dests[idx].Destination
+	game.MapOf(m).PlaySound(0x1FE, m.Location())
+	dm := game.GetWorld().Map(dest.Facet)
+	if !dm.TeleportMobile(m, dest.Location) {
+		n.Cliloc(nil, 501942) // That location is blocked.
+		return
+	}
+	dm.PlaySound(0x1FE, m.Location())
+}
@@ -135,16 +135,16 @@ func (g *objects) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 
 func (g *objects) placementTarget(n game.NetState) {
 	n.TargetSendCursor(uo.TargetTypeLocation, func(tr *clientpacket.TargetResponse) {
-		g.place(tr.Location)
+		g.place(game.MapOf(n.Mobile()), tr.Location)
 		g.placementTarget(n)
 	})
 }
 
-func (g *objects) place(l uo.Location) {
+func (g *objects) place(m *game.Map, l uo.Location) {
 	if g.useFixedZ {
 		l.Z = g.fixedZ
 	} else {
-		f, _ := game.GetWorld().Map().GetFloorAndCeiling(l, false, false)
+		f, _ := m.GetFloorAndCeiling(l, false, false)
 		if f != nil {
 			l.Z = f.Highest()
 		}
@@ -159,7 +159,7 @@ func (g *objects) place(l uo.Location) {
 		nl.Z += t.Offset.Z
 		s.SetBaseGraphic(t.Graphic)
 		s.SetLocation(nl)
-		game.GetWorld().Map().ForceAddObject(s)
+		m.ForceAddObject(s)
 		g.lastPlacements = append(g.lastPlacements, s)
 	}
 }
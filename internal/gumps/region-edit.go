@@ -87,17 +87,17 @@ func (g *regionEdit) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 			Y: y,
 			Z: uo.MapMaxZ,
 		}
-		f, _ := game.GetWorld().Map().GetFloorAndCeiling(l, false, true)
+		f, _ := game.GetWorld().Map(g.Region.Facet).GetFloorAndCeiling(l, false, true)
 		if f == nil {
 			return
 		}
 		l.Z = f.StandingHeight()
-		game.GetWorld().Map().TeleportMobile(n.Mobile(), l)
+		game.GetWorld().Map(g.Region.Facet).TeleportMobile(n.Mobile(), l)
 	}
 	// Data
 	g.Region.Name = p.Text(1)
 	g.Region.Music = p.Text(2)
-	game.GetWorld().Map().RemoveRegion(g.Region)
+	game.GetWorld().Map(g.Region.Facet).RemoveRegion(g.Region)
 	for i := range g.Region.Rects {
 		s := p.Text(uint16(2001 + i))
 		if s == "" {
@@ -113,7 +113,7 @@ func (g *regionEdit) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 			Z: uo.MapMaxZ,
 		})
 	}
-	game.GetWorld().Map().AddRegion(g.Region)
+	game.GetWorld().Map(g.Region.Facet).AddRegion(g.Region)
 	defer n.RefreshGUMP(n.GetGUMPByID(GUMPIDRegions))
 	// Standard reply
 	if g.StandardReplyHandler(p) {
@@ -140,7 +140,7 @@ func (g *regionEdit) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 					continue
 				}
 				l.Z = m.Location().Z
-				f, _ := game.GetWorld().Map().GetFloorAndCeiling(l, false, false)
+				f, _ := game.GetWorld().Map(g.Region.Facet).GetFloorAndCeiling(l, false, false)
 				if f == nil {
 					continue
 				}
@@ -163,9 +163,9 @@ func (g *regionEdit) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 		if m == nil {
 			break
 		}
-		game.GetWorld().Map().RemoveRegion(g.Region)
+		game.GetWorld().Map(g.Region.Facet).RemoveRegion(g.Region)
 		g.Region.AddRect(m.Location().BoundsByRadius(0))
-		game.GetWorld().Map().AddRegion(g.Region)
+		game.GetWorld().Map(g.Region.Facet).AddRegion(g.Region)
 		return
 	case 6: // Spawn button
 		a := New("spawn")
@@ -180,10 +180,10 @@ func (g *regionEdit) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 		if i >= len(g.Region.Rects) {
 			return
 		}
-		game.GetWorld().Map().RemoveRegion(g.Region)
+		game.GetWorld().Map(g.Region.Facet).RemoveRegion(g.Region)
 		g.Region.Rects = append(g.Region.Rects[:i], g.Region.Rects[i+1:]...)
 		g.Region.ForceRecalculateBounds()
-		game.GetWorld().Map().AddRegion(g.Region)
+		game.GetWorld().Map(g.Region.Facet).AddRegion(g.Region)
 		return
 	}
 	// Flag buttons
@@ -222,14 +222,14 @@ func (g *regionEdit) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 		n.TargetSendCursor(uo.TargetTypeLocation, func(tr *clientpacket.TargetResponse) {
 			l := tr.Location
 			l.Z = uo.MapMaxZ
-			game.GetWorld().Map().RemoveRegion(g.Region)
+			game.GetWorld().Map(g.Region.Facet).RemoveRegion(g.Region)
 			g.Region.Rects[i] = uo.BoundsOf(uo.Location{
 				X: r.X,
 				Y: r.Y,
 				Z: uo.MapMinZ,
 			}, l)
 			g.Region.ForceRecalculateBounds()
-			game.GetWorld().Map().AddRegion(g.Region)
+			game.GetWorld().Map(g.Region.Facet).AddRegion(g.Region)
 			n.RefreshGUMP(g)
 		})
 		return
@@ -254,14 +254,14 @@ func (g *regionEdit) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 		n.TargetSendCursor(uo.TargetTypeLocation, func(tr *clientpacket.TargetResponse) {
 			l := tr.Location
 			l.Z = uo.MapMinZ
-			game.GetWorld().Map().RemoveRegion(g.Region)
+			game.GetWorld().Map(g.Region.Facet).RemoveRegion(g.Region)
 			g.Region.Rects[i] = uo.BoundsOf(l, uo.Location{
 				X: r.East(),
 				Y: r.South(),
 				Z: uo.MapMaxZ,
 			})
 			g.Region.ForceRecalculateBounds()
-			game.GetWorld().Map().AddRegion(g.Region)
+			game.GetWorld().Map(g.Region.Facet).AddRegion(g.Region)
 			n.RefreshGUMP(g)
 		})
 		return
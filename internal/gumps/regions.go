@@ -23,7 +23,7 @@ type regions struct {
 // Layout implements the game.GUMP interface.
 func (g *regions) Layout(target, param game.Object) {
 	g.tr = target.(game.Mobile)
-	g.regions = game.GetWorld().Map().RegionsAt(g.tr.Location())
+	g.regions = game.MapOf(g.tr).RegionsAt(g.tr.Location())
 	pages := len(g.regions) / 5
 	if len(g.regions)%5 != 0 {
 		pages++
@@ -58,11 +58,12 @@ func (g *regions) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 		re := a.(*regionEdit)
 		re.Region = &game.Region{
 			Name:      "New Region",
+			Facet:     n.Mobile().Facet(),
 			SpawnMinZ: uo.MapMinZ,
 			SpawnMaxZ: uo.MapMaxZ,
 		}
 		re.Region.AddRect(n.Mobile().Location().BoundsByRadius(0))
-		game.GetWorld().Map().AddRegion(re.Region)
+		game.MapOf(n.Mobile()).AddRegion(re.Region)
 		n.GUMP(a, nil, nil)
 		n.RefreshGUMP(g)
 		return
@@ -71,7 +72,8 @@ func (g *regions) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 		return
 	case 3: // Show all regions button
 		b := g.tr.Location().BoundsByRadius(int(g.tr.ViewRange()))
-		for _, r := range game.GetWorld().Map().RegionsWithin(b) {
+		m := game.MapOf(g.tr)
+		for _, r := range m.RegionsWithin(b) {
 			hue := uo.Hue(game.GetWorld().Random().Random(0, 199)*5 + 1)
 			l := g.tr.Location()
 			for l.Y = b.Y; l.Y <= b.South(); l.Y++ {
@@ -80,7 +82,7 @@ func (g *regions) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 						continue
 					}
 					l.Z = g.tr.Location().Z
-					f, _ := game.GetWorld().Map().GetFloorAndCeiling(l, false, false)
+					f, _ := m.GetFloorAndCeiling(l, false, false)
 					if f == nil {
 						continue
 					}
@@ -112,6 +114,7 @@ func (g *regions) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 		r := g.regions[i]
 		nr := &game.Region{
 			Name:     r.Name + " - Copy",
+			Facet:    r.Facet,
 			Rects:    make([]uo.Bounds, len(r.Rects)),
 			Features: r.Features,
 			Music:    r.Music,
@@ -120,7 +123,7 @@ func (g *regions) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 		a := New("region-edit")
 		re := a.(*regionEdit)
 		re.Region = nr
-		game.GetWorld().Map().AddRegion(nr)
+		game.GetWorld().Map(nr.Facet).AddRegion(nr)
 		n.GUMP(a, nil, nil)
 		n.RefreshGUMP(g)
 	}
@@ -131,7 +134,7 @@ func (g *regions) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 			return
 		}
 		r := g.regions[i]
-		game.GetWorld().Map().RemoveRegion(r)
+		game.GetWorld().Map(r.Facet).RemoveRegion(r)
 		return
 	}
 	// Region button
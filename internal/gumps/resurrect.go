@@ -0,0 +1,54 @@
+package gumps
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg("resurrect", 0, func() GUMP {
+		return &resurrect{}
+	})
+}
+
+// resurrect implements the resurrection offer GUMP. The target is the ghost to
+// resurrect and the optional parameter is the healer making the offer.
+type resurrect struct {
+	StandardGUMP
+	tm     game.Mobile // Target mobile
+	healer game.Mobile // Healer making the offer, if any
+}
+
+// Layout implements the game.GUMP interface.
+func (g *resurrect) Layout(target, param game.Object) {
+	tm, ok := target.(game.Mobile)
+	if !ok {
+		return
+	}
+	g.tm = tm
+	if hm, ok := param.(game.Mobile); ok {
+		g.healer = hm
+	}
+	g.Window(12, 5, "Resurrection", 0, 1)
+	g.Text(0, 0, 12, uo.HueDefault, "It is possible for you to be resurrected here by")
+	g.Text(0, 1, 12, uo.HueDefault, "this healer. Do you wish to try?")
+	g.GemButton(0, 3, SGGemButtonOK, 1001)
+	g.GemButton(10, 3, SGGemButtonCancel, 1002)
+}
+
+// HandleReply implements the GUMP interface.
+func (g *resurrect) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
+	if g.StandardReplyHandler(p) {
+		return
+	}
+	if p.Button != 1001 || g.tm == nil || !g.tm.IsDead() {
+		return
+	}
+	if g.healer != nil && (g.healer.Removed() ||
+		g.healer.Location().XYDistance(g.tm.Location()) > uo.MaxUseRange) {
+		n.Cliloc(nil, 500312) // You cannot reach that.
+		return
+	}
+	g.tm.Resurrect()
+}
@@ -101,7 +101,8 @@ func (g *signs) placeSingle(n game.NetState) {
 			return
 		}
 		l := tr.Location
-		for _, s := range game.GetWorld().Map().StaticsAt(l) {
+		m := game.MapOf(n.Mobile())
+		for _, s := range m.StaticsAt(l) {
 			for i, p := range signpostGraphics {
 				if s.BaseGraphic() != p {
 					continue
@@ -111,7 +112,7 @@ func (g *signs) placeSingle(n game.NetState) {
 				if i%2 != 0 {
 					sg++
 				}
-				d.place(l, strconv.FormatInt(int64(sg), 10), nil)
+				d.place(m, l, strconv.FormatInt(int64(sg), 10), nil)
 				return
 			}
 		}
@@ -252,7 +252,7 @@ func (g *statics) eraseArea(n game.NetState) {
 		return
 	}
 	d.targetVolume(n, func(b uo.Bounds) {
-		items := game.GetWorld().Map().ItemQuery("StaticItem", b)
+		items := game.MapOf(n.Mobile()).ItemQuery("StaticItem", b)
 		for _, item := range items {
 			game.Remove(item)
 		}
@@ -287,7 +287,7 @@ func (g *statics) placeSingle(n game.NetState) {
 		if !ok {
 			return
 		}
-		d.place(tr.Location, g.item.expression, game.Find[game.Item](tr.TargetObject))
+		d.place(game.MapOf(n.Mobile()), tr.Location, g.item.expression, game.Find[game.Item](tr.TargetObject))
 		g.placeSingle(n)
 	})
 }
@@ -305,7 +305,7 @@ func (g *statics) areaFill(n game.NetState) {
 		l := uo.Location{Z: b.Z}
 		for l.Y = b.Y; l.Y <= b.South(); l.Y++ {
 			for l.X = b.X; l.X <= b.East(); l.X++ {
-				d.place(l, g.item.expression, nil)
+				d.place(game.MapOf(n.Mobile()), l, g.item.expression, nil)
 			}
 		}
 		g.areaFill(n)
@@ -21,7 +21,7 @@ func init() {
 
 type teleportDestination struct {
 	Name        string
-	Destination uo.Location
+	Destination uo.FacetLocation
 }
 
 type teleportGroup struct {
@@ -97,7 +97,12 @@ func (g *teleport) HandleReply(n game.NetState, p *clientpacket.GUMPReply) {
 			if n == nil || n.Mobile() == nil {
 				return
 			}
-			game.GetWorld().Map().TeleportMobile(n.Mobile(), dest.Destination)
+			m := game.GetWorld().Map(dest.Destination.Facet)
+			if m == nil {
+				n.Speech(nil, "%s is not loaded.", dest.Destination.Facet)
+				return
+			}
+			m.TeleportMobile(n.Mobile(), dest.Destination.Location)
 		} else {
 			log.Printf("error: bad teleport group %d", g.currentGroup)
 		}
@@ -134,7 +139,7 @@ func (g *teleport) LoadTeleportData() {
 				log.Println(err)
 				continue
 			}
-			l, err := util.ParseLocation(lstr)
+			l, err := util.ParseFacetLocation(lstr)
 			if err != nil {
 				log.Println(err)
 				continue
@@ -0,0 +1,302 @@
+package harvest
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/qbradq/sharduo/data"
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+// Result is one possible result of a harvest.
+type Result struct {
+	// Template name of the item harvested
+	Template string
+	// Relative chance of this result being selected
+	Weight int
+	// Raw skill value required to harvest this result
+	MinSkill int
+}
+
+// Definition describes one kind of harvest like mining or fishing. Harvests
+// are defined by [Harvest] objects within the harvest data files.
+type Definition struct {
+	// Name of the harvest, this is also the name of its resource bank
+	Name string
+	// Skill checked when harvesting
+	Skill uo.Skill
+	// Raw skill value below which harvesting always fails
+	MinSkill int
+	// Raw skill value above which harvesting always succeeds
+	MaxSkill int
+	// Template names of the tools used to harvest
+	Tools []string
+	// Maximum distance to the harvested tile
+	Range int16
+	// Land tiles that may be harvested
+	LandTiles map[uo.Graphic]struct{}
+	// Static tiles that may be harvested
+	StaticTiles map[uo.Graphic]struct{}
+	// Resource bank of the harvest
+	Bank *game.HarvestBankDefinition
+	// Amount of resources harvested at once
+	PerHarvest int
+	// All possible results, these are also the results of veins when the
+	// harvester lacks the skill to harvest the vein
+	Results []Result
+	// All veins of the harvest by name
+	Veins map[string]*Vein
+	// Vein distributions by region name, the empty name is the default
+	Distributions map[string][]VeinWeight
+	// Sounds played while harvesting, one is selected at random
+	Sounds []uo.Sound
+	// Animation played while harvesting
+	Animation uo.AnimationAction
+	// Message sent when targeting something that cannot be harvested
+	InvalidMessage uo.Cliloc
+	// Message sent when targeting something that is out of range
+	RangeMessage uo.Cliloc
+	// Message sent when harvesting while mounted, harvesting while mounted is
+	// allowed if this is zero
+	MountedMessage uo.Cliloc
+	// Message sent when the resource bank is depleted
+	DepletedMessage uo.Cliloc
+	// Message sent when failing the skill check
+	FailMessage uo.Cliloc
+	// Message sent when the harvest was placed in the backpack
+	SuccessMessage uo.Cliloc
+	// Message sent when the harvest was dropped to the feet
+	PackFullMessage uo.Cliloc
+}
+
+// All harvest definitions by name
+var definitions = map[string]*Definition{}
+
+// All harvest definitions by the template name of the tools used with them
+var tools = map[string]*Definition{}
+
+// Load loads all harvest definitions, veins and vein distributions from the
+// harvest data files within the given internal data directory. This must be
+// called after templates are loaded.
+func Load(dir string) []error {
+	var veins, distributions []*util.TagFileObject
+	errs := data.Walk(dir, func(path string, d []byte) []error {
+		var errs []error
+		tfr := &util.TagFileReader{}
+		tfr.StartReading(bytes.NewReader(d))
+		for {
+			tfo := tfr.ReadObject()
+			if tfo == nil {
+				break
+			}
+			switch tfo.TypeName() {
+			case "Harvest":
+				def, err := newDefinition(tfo)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", path, err))
+					continue
+				}
+				if _, duplicate := definitions[def.Name]; duplicate {
+					errs = append(errs, fmt.Errorf("%s: duplicate harvest %s", path, def.Name))
+					continue
+				}
+				definitions[def.Name] = def
+				for _, t := range def.Tools {
+					if od := tools[t]; od != nil {
+						errs = append(errs, fmt.Errorf("%s: tool %s already used by harvest %s",
+							path, t, od.Name))
+						continue
+					}
+					tools[t] = def
+				}
+				game.RegisterHarvestBank(def.Bank)
+			case "Vein":
+				veins = append(veins, tfo)
+			case "VeinDistribution":
+				distributions = append(distributions, tfo)
+			default:
+				errs = append(errs, fmt.Errorf("unexpected object type %s in %s",
+					tfo.TypeName(), path))
+			}
+		}
+		return append(errs, tfr.Errors()...)
+	})
+	// Veins are resolved after all harvests are known, and distributions after
+	// all veins are known
+	for _, tfo := range veins {
+		if err := addVein(tfo); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, tfo := range distributions {
+		if err := addDistribution(tfo); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// newDefinition creates a harvest definition from its data file object.
+func newDefinition(tfo *util.TagFileObject) (*Definition, error) {
+	d := &Definition{
+		Name:            tfo.GetString("Name", ""),
+		MinSkill:        tfo.GetNumber("MinSkill", 0),
+		MaxSkill:        tfo.GetNumber("MaxSkill", 1000),
+		Veins:           map[string]*Vein{},
+		Distributions:   map[string][]VeinWeight{},
+		Range:           int16(tfo.GetNumber("Range", 2)),
+		PerHarvest:      tfo.GetNumber("PerHarvest", 1),
+		Animation:       uo.AnimationAction(tfo.GetNumber("Animation", int(uo.AnimationActionSlash1H))),
+		InvalidMessage:  uo.Cliloc(tfo.GetNumber("InvalidMessage", 0)),
+		RangeMessage:    uo.Cliloc(tfo.GetNumber("RangeMessage", 500251)), // That location is too far away.
+		MountedMessage:  uo.Cliloc(tfo.GetNumber("MountedMessage", 0)),
+		DepletedMessage: uo.Cliloc(tfo.GetNumber("DepletedMessage", 0)),
+		FailMessage:     uo.Cliloc(tfo.GetNumber("FailMessage", 0)),
+		SuccessMessage:  uo.Cliloc(tfo.GetNumber("SuccessMessage", 0)),
+		PackFullMessage: uo.Cliloc(tfo.GetNumber("PackFullMessage", 0)),
+	}
+	if d.Name == "" {
+		return nil, fmt.Errorf("harvest without a name")
+	}
+	skill, found := uo.SkillByName(tfo.GetString("Skill", ""))
+	if !found {
+		return nil, fmt.Errorf("harvest %s: unknown skill %s", d.Name, tfo.GetString("Skill", ""))
+	}
+	d.Skill = skill
+	if d.MinSkill >= d.MaxSkill {
+		return nil, fmt.Errorf("harvest %s: minimum skill must be less than maximum skill", d.Name)
+	}
+	for _, t := range strings.Split(tfo.GetString("Tools", ""), ",") {
+		t = strings.TrimSpace(t)
+		if template.FindTemplate(t) == nil {
+			return nil, fmt.Errorf("harvest %s: unknown tool template %s", d.Name, t)
+		}
+		d.Tools = append(d.Tools, t)
+	}
+	var err error
+	if d.LandTiles, err = parseGraphicSet(tfo.GetString("LandTiles", "")); err != nil {
+		return nil, fmt.Errorf("harvest %s: %w", d.Name, err)
+	}
+	if d.StaticTiles, err = parseGraphicSet(tfo.GetString("StaticTiles", "")); err != nil {
+		return nil, fmt.Errorf("harvest %s: %w", d.Name, err)
+	}
+	d.Bank = &game.HarvestBankDefinition{
+		Name: d.Name,
+		Size: int16(tfo.GetNumber("BankSize", uo.ChunkWidth)),
+	}
+	if d.Bank.MinAmount, d.Bank.MaxAmount, err = parseRange(tfo.GetString("BankAmount", "")); err != nil {
+		return nil, fmt.Errorf("harvest %s: %w", d.Name, err)
+	}
+	min, max, err := parseRange(tfo.GetString("BankRespawn", ""))
+	if err != nil {
+		return nil, fmt.Errorf("harvest %s: %w", d.Name, err)
+	}
+	d.Bank.MinRespawn = uo.DurationMinute * uo.Time(min)
+	d.Bank.MaxRespawn = uo.DurationMinute * uo.Time(max)
+	if d.Results, err = parseResults(tfo.GetString("Results", "")); err != nil {
+		return nil, fmt.Errorf("harvest %s: %w", d.Name, err)
+	}
+	for _, s := range strings.Split(tfo.GetString("Sounds", ""), ",") {
+		if s == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("harvest %s: malformed sound %s", d.Name, s)
+		}
+		d.Sounds = append(d.Sounds, uo.Sound(n))
+	}
+	if tfo.HasErrors() {
+		return nil, tfo.Errors()[0]
+	}
+	return d, nil
+}
+
+// parseRange parses a range in the form Min-Max. A single number is both the
+// minimum and maximum.
+func parseRange(s string) (int, int, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("malformed range %s", s)
+	}
+	min, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 0, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed range %s", s)
+	}
+	max := min
+	if len(parts) == 2 {
+		if max, err = strconv.ParseInt(strings.TrimSpace(parts[1]), 0, 32); err != nil {
+			return 0, 0, fmt.Errorf("malformed range %s", s)
+		}
+	}
+	if max < min {
+		return 0, 0, fmt.Errorf("malformed range %s", s)
+	}
+	return int(min), int(max), nil
+}
+
+// parseGraphicSet parses a comma-separated list of graphics and graphic ranges
+// in the form First-Last.
+func parseGraphicSet(s string) (map[uo.Graphic]struct{}, error) {
+	ret := map[uo.Graphic]struct{}{}
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		first, last, err := parseRange(part)
+		if err != nil {
+			return nil, err
+		}
+		for g := first; g <= last; g++ {
+			ret[uo.Graphic(g)] = struct{}{}
+		}
+	}
+	return ret, nil
+}
+
+// parseResults parses a comma-separated list of results.
+func parseResults(s string) ([]Result, error) {
+	var ret []Result
+	for _, part := range strings.Split(s, ",") {
+		r, err := parseResult(part)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+// parseResult parses a result in the form TemplateName:Weight:MinSkill. The
+// minimum skill is optional.
+func parseResult(s string) (Result, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Result{}, fmt.Errorf("malformed result %s", s)
+	}
+	r := Result{
+		Template: parts[0],
+	}
+	if template.FindTemplate(r.Template) == nil {
+		return Result{}, fmt.Errorf("unknown result template %s", r.Template)
+	}
+	var err error
+	if r.Weight, err = strconv.Atoi(parts[1]); err != nil || r.Weight < 1 {
+		return Result{}, fmt.Errorf("malformed result weight %s", s)
+	}
+	if len(parts) == 3 {
+		if r.MinSkill, err = strconv.Atoi(parts[2]); err != nil {
+			return Result{}, fmt.Errorf("malformed result skill %s", s)
+		}
+	}
+	return r, nil
+}
+
+// ForTool returns the harvest definition the tool is used with, or nil if the
+// item is not a harvesting tool.
+func ForTool(tool game.Item) *Definition { return tools[tool.TemplateName()] }
@@ -0,0 +1,164 @@
+package harvest
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// CanUse returns true if the mobile is able to use the harvesting tool right
+// now, sending the reason to the mobile if not. Tools that can be worn must be
+// equipped.
+func CanUse(m game.Mobile, tool game.Item) bool {
+	n := m.NetState()
+	d := ForTool(tool)
+	if n == nil || d == nil || tool.Removed() || m.IsDead() {
+		return false
+	}
+	if _, ok := tool.(game.Wearable); ok && !m.IsEquipped(tool) {
+		n.Cliloc(nil, 1149764) // You must have that equipped to use it.
+		return false
+	}
+	if root := game.RootParent(tool); root == nil || root.Serial() != m.Serial() {
+		n.Cliloc(nil, 1044263) // The tool must be on your person to use.
+		return false
+	}
+	if d.MountedMessage != 0 && m.IsMounted() {
+		n.Cliloc(nil, d.MountedMessage)
+		return false
+	}
+	return true
+}
+
+// CanHarvestAt returns true if the mobile is able to harvest the target with
+// the tool, sending the reason to the mobile if not.
+func CanHarvestAt(m game.Mobile, tool game.Item, tr *clientpacket.TargetResponse) bool {
+	n := m.NetState()
+	d := ForTool(tool)
+	if n == nil || d == nil {
+		return false
+	}
+	if m.Location().XYDistance(tr.Location) > d.Range {
+		n.Cliloc(nil, d.RangeMessage)
+		return false
+	}
+	if !d.validTarget(game.MapOf(m), tr) {
+		n.Cliloc(nil, d.InvalidMessage)
+		return false
+	}
+	if game.MapOf(m).HarvestBankAmount(d.Name, tr.Location) < 1 {
+		n.Cliloc(nil, d.DepletedMessage)
+		return false
+	}
+	return true
+}
+
+// validTarget returns true if the target is a tile of the harvest on the map.
+func (d *Definition) validTarget(m *game.Map, tr *clientpacket.TargetResponse) bool {
+	if tr.Graphic != uo.GraphicNone {
+		if _, found := d.StaticTiles[tr.Graphic]; found {
+			for _, s := range m.StaticsAt(tr.Location) {
+				if s.BaseGraphic() == tr.Graphic {
+					return true
+				}
+			}
+		}
+	}
+	_, found := d.LandTiles[m.GetTile(tr.Location.X, tr.Location.Y).BaseGraphic()]
+	return found
+}
+
+// PlayEffects plays the animation and a sound of the harvest.
+func PlayEffects(m game.Mobile, tool game.Item, l uo.Location) {
+	d := ForTool(tool)
+	if d == nil {
+		return
+	}
+	w := game.GetWorld()
+	game.MapOf(m).PlayAnimation(m, uo.AnimationTypeAttack, d.Animation)
+	if len(d.Sounds) > 0 {
+		game.MapOf(m).PlaySound(d.Sounds[w.Random().Random(0, len(d.Sounds)-1)], l)
+	}
+}
+
+// Harvest completes one harvest attempt at the target and returns true if the
+// mobile may continue harvesting there.
+func Harvest(m game.Mobile, tool game.Item, tr *clientpacket.TargetResponse) bool {
+	d := ForTool(tool)
+	if d == nil || !CanUse(m, tool) || !CanHarvestAt(m, tool, tr) {
+		return false
+	}
+	n := m.NetState()
+	w := game.GetWorld()
+	if len(d.Sounds) > 0 {
+		game.MapOf(m).PlaySound(d.Sounds[w.Random().Random(0, len(d.Sounds)-1)], tr.Location)
+	}
+	if m.SkillCheck(d.Skill, d.MinSkill, d.MaxSkill) {
+		if r := d.selectResult(m, tr.Location); r != nil {
+			item := template.Create[game.Item](r.Template)
+			if item == nil {
+				// Something is very wrong
+				return false
+			}
+			item.SetAmount(game.MapOf(m).ConsumeHarvestBank(d.Name, tr.Location, d.PerHarvest))
+			if !m.DropToBackpack(item, false) {
+				m.DropToFeet(item)
+				n.Cliloc(nil, d.PackFullMessage)
+			} else if d.SuccessMessage != 0 {
+				n.Cliloc(nil, d.SuccessMessage)
+			}
+		}
+	} else {
+		n.Cliloc(nil, d.FailMessage)
+	}
+	// Tool wear
+	if !tool.ConsumeUse() {
+		return false
+	}
+	if tool.Uses() < 1 {
+		game.Remove(tool)
+		n.Cliloc(nil, 1044038) // You have worn out your tool!
+		return false
+	}
+	return true
+}
+
+// selectResult returns a random result the mobile has the skill to harvest at
+// the location, or nil if there is none. When the mobile lacks the skill to
+// harvest the vein at the location the results of the harvest are used.
+func (d *Definition) selectResult(m game.Mobile, l uo.Location) *Result {
+	v := int(m.Skill(d.Skill))
+	if vein := d.VeinAt(uo.FacetLocation{Facet: m.Facet(), Location: l}); vein != nil {
+		if r := selectFrom(vein.Results, v); r != nil {
+			return r
+		}
+	}
+	return selectFrom(d.Results, v)
+}
+
+// selectFrom returns a random result from the slice that requires at most the
+// raw skill value, or nil if there is none.
+func selectFrom(results []Result, v int) *Result {
+	total := 0
+	for _, r := range results {
+		if v >= r.MinSkill {
+			total += r.Weight
+		}
+	}
+	if total < 1 {
+		return nil
+	}
+	roll := game.GetWorld().Random().Random(0, total-1)
+	for i := range results {
+		r := &results[i]
+		if v < r.MinSkill {
+			continue
+		}
+		if roll < r.Weight {
+			return r
+		}
+		roll -= r.Weight
+	}
+	return nil
+}
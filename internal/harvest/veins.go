@@ -0,0 +1,170 @@
+package harvest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+// Vein is a named set of results that replaces the results of a harvest within
+// the banks it is chosen for, like a vein of gold ore. Veins are defined by
+// [Vein] objects within the harvest data files.
+type Vein struct {
+	// Name of the vein
+	Name string
+	// All possible results of the vein
+	Results []Result
+}
+
+// VeinWeight is one entry of a vein distribution.
+type VeinWeight struct {
+	// Vein that may be chosen
+	Vein *Vein
+	// Relative chance of the vein being chosen
+	Weight int
+}
+
+// Seed mixed into the choice of veins, changing it reshuffles all veins
+var veinSeed uint32
+
+// SetVeinSeed sets the seed mixed into the choice of veins. Veins are derived
+// from the seed and the bank location so they are stable across restarts
+// without being saved.
+func SetVeinSeed(seed uint32) {
+	veinSeed = seed
+}
+
+// addVein creates a vein from its data file object and adds it to its
+// harvest.
+func addVein(tfo *util.TagFileObject) error {
+	v := &Vein{
+		Name: tfo.GetString("Name", ""),
+	}
+	d := definitions[tfo.GetString("Harvest", "")]
+	if d == nil {
+		return fmt.Errorf("vein %s: unknown harvest %s", v.Name,
+			tfo.GetString("Harvest", ""))
+	}
+	if v.Name == "" {
+		return fmt.Errorf("vein in harvest %s without a name", d.Name)
+	}
+	if _, duplicate := d.Veins[v.Name]; duplicate {
+		return fmt.Errorf("duplicate vein %s in harvest %s", v.Name, d.Name)
+	}
+	var err error
+	if v.Results, err = parseResults(tfo.GetString("Results", "")); err != nil {
+		return fmt.Errorf("vein %s: %w", v.Name, err)
+	}
+	if tfo.HasErrors() {
+		return tfo.Errors()[0]
+	}
+	d.Veins[v.Name] = v
+	return nil
+}
+
+// addDistribution creates a vein distribution from its data file object and
+// adds it to its harvest. Distributions without a region are the default.
+func addDistribution(tfo *util.TagFileObject) error {
+	region := tfo.GetString("Region", "")
+	d := definitions[tfo.GetString("Harvest", "")]
+	if d == nil {
+		return fmt.Errorf("vein distribution %s: unknown harvest %s", region,
+			tfo.GetString("Harvest", ""))
+	}
+	if _, duplicate := d.Distributions[region]; duplicate {
+		return fmt.Errorf("duplicate vein distribution %s in harvest %s", region,
+			d.Name)
+	}
+	var dist []VeinWeight
+	for _, s := range strings.Split(tfo.GetString("Veins", ""), ",") {
+		parts := strings.Split(strings.TrimSpace(s), ":")
+		if len(parts) != 2 {
+			return fmt.Errorf("vein distribution %s: malformed vein %s", region, s)
+		}
+		v := d.Veins[parts[0]]
+		if v == nil {
+			return fmt.Errorf("vein distribution %s: unknown vein %s", region, parts[0])
+		}
+		w, err := strconv.Atoi(parts[1])
+		if err != nil || w < 1 {
+			return fmt.Errorf("vein distribution %s: malformed vein weight %s", region, s)
+		}
+		dist = append(dist, VeinWeight{
+			Vein:   v,
+			Weight: w,
+		})
+	}
+	if tfo.HasErrors() {
+		return tfo.Errors()[0]
+	}
+	d.Distributions[region] = dist
+	return nil
+}
+
+// VeinAt returns the vein of the bank covering the location, or nil if the
+// harvest has no veins there.
+func (d *Definition) VeinAt(l uo.FacetLocation) *Vein {
+	if len(d.Distributions) == 0 || d.Bank.Size < 1 {
+		return nil
+	}
+	bx := l.X / d.Bank.Size
+	by := l.Y / d.Bank.Size
+	dist := d.distributionAt(l.Facet, uo.Location{
+		X: bx*d.Bank.Size + d.Bank.Size/2,
+		Y: by*d.Bank.Size + d.Bank.Size/2,
+		Z: l.Z,
+	})
+	total := 0
+	for _, vw := range dist {
+		total += vw.Weight
+	}
+	if total < 1 {
+		return nil
+	}
+	// The vein is derived from the seed and bank so it never changes
+	h := fnv.New32a()
+	var buf [9]byte
+	binary.LittleEndian.PutUint32(buf[0:4], veinSeed)
+	binary.LittleEndian.PutUint16(buf[4:6], uint16(bx))
+	binary.LittleEndian.PutUint16(buf[6:8], uint16(by))
+	buf[8] = byte(l.Facet)
+	h.Write([]byte(d.Name))
+	h.Write(buf[:])
+	roll := int(h.Sum32() % uint32(total))
+	for _, vw := range dist {
+		if roll < vw.Weight {
+			return vw.Vein
+		}
+		roll -= vw.Weight
+	}
+	return nil
+}
+
+// distributionAt returns the vein distribution of the smallest region at the
+// location that has one, or the default distribution.
+func (d *Definition) distributionAt(f uo.Facet, l uo.Location) []VeinWeight {
+	ret := d.Distributions[""]
+	m := game.GetWorld().Map(f)
+	if m == nil {
+		return ret
+	}
+	area := -1
+	for _, r := range m.RegionsAt(l) {
+		dist, found := d.Distributions[r.Name]
+		if !found {
+			continue
+		}
+		a := int(r.Bounds.W) * int(r.Bounds.H)
+		if area < 0 || a < area {
+			ret = dist
+			area = a
+		}
+	}
+	return ret
+}
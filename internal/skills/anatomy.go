@@ -0,0 +1,74 @@
+package skills
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(uo.SkillAnatomy, defaultDelay, anatomy)
+}
+
+// Descriptions of strength
+var anatomyStrength = []string{
+	"rather feeble",
+	"somewhat weak",
+	"to be of normal strength",
+	"somewhat strong",
+	"very strong",
+	"extremely strong",
+	"extraordinarily strong",
+	"as strong as an ox",
+	"like one of the strongest people you have ever seen",
+	"superhumanly strong",
+}
+
+// Descriptions of dexterity
+var anatomyDexterity = []string{
+	"very clumsy",
+	"somewhat uncoordinated",
+	"moderately dexterous",
+	"somewhat agile",
+	"very agile",
+	"extremely agile",
+	"extraordinarily agile",
+	"like they move like quicksilver",
+	"like one of the fastest people you have ever seen",
+	"superhumanly agile",
+}
+
+// Descriptions of stamina as a percentage of maximum stamina
+var anatomyStamina = []string{
+	"They look almost completely exhausted.",
+	"They look very tired.",
+	"They look somewhat tired.",
+	"They look rather rested.",
+	"They look fully rested.",
+}
+
+// anatomy describes the physical characteristics of the targeted mobile.
+func anatomy(m game.Mobile) {
+	n := m.NetState()
+	n.Speech(nil, "Whom shall I examine?")
+	target(m, func(o game.Object) {
+		tm, ok := o.(game.Mobile)
+		if !ok {
+			n.Speech(nil, "Only living things have anatomies!")
+			return
+		}
+		if tm.IsDead() {
+			n.Speech(nil, "That cannot be examined.")
+			return
+		}
+		if !m.SkillCheck(uo.SkillAnatomy, 0, 1000) {
+			n.Speech(tm, "You cannot quite get a sense of their physical characteristics.")
+			return
+		}
+		n.Speech(tm, "%s looks %s and %s.", tm.DisplayName(),
+			describe(tm.Strength(), 100, anatomyStrength),
+			describe(tm.Dexterity(), 100, anatomyDexterity))
+		if m.Skill(uo.SkillAnatomy) >= 650 && tm.MaxStamina() > 0 {
+			n.Speech(tm, describe(tm.Stamina()*100/tm.MaxStamina(), 100, anatomyStamina))
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package skills
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(uo.SkillAnimalLore, defaultDelay, animalLore)
+}
+
+// animalLore reports the vital statistics of the targeted creature.
+func animalLore(m game.Mobile) {
+	n := m.NetState()
+	n.Speech(nil, "What animal should I look at?")
+	target(m, func(o game.Object) {
+		tm, ok := o.(game.Mobile)
+		if !ok || tm.IsHumanBody() || tm.IsPlayerCharacter() {
+			n.Speech(nil, "That's not an animal!")
+			return
+		}
+		if tm.IsDead() {
+			n.Speech(nil, "That creature is dead.")
+			return
+		}
+		if !m.SkillCheck(uo.SkillAnimalLore, 0, 1000) {
+			n.Speech(tm, "You can't think of anything you know offhand.")
+			return
+		}
+		n.Speech(tm, "Hits %d/%d Stamina %d/%d Mana %d/%d",
+			tm.HitPoints(), tm.MaxHitPoints(),
+			tm.Stamina(), tm.MaxStamina(),
+			tm.Mana(), tm.MaxMana())
+		n.Speech(tm, "Strength %d Dexterity %d Intelligence %d",
+			tm.Strength(), tm.Dexterity(), tm.Intelligence())
+		if cm := tm.ControlMaster(); cm != nil {
+			n.Speech(tm, "It is loyal to %s.", cm.DisplayName())
+		} else {
+			n.Speech(tm, "It is wild.")
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package skills
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(uo.SkillArmsLore, defaultDelay, armsLore)
+}
+
+// Descriptions of condition as a percentage of maximum durability
+var armsLoreCondition = []string{
+	"It is in danger of breaking.",
+	"It is badly damaged.",
+	"It is fairly damaged.",
+	"It is somewhat worn.",
+	"It is in full repair.",
+}
+
+// Descriptions of weapon damage by average damage per hit
+var armsLoreDamage = []string{
+	"It might scratch your opponent slightly.",
+	"It would do minimal damage.",
+	"It would do some damage.",
+	"It would probably hurt your opponent a fair amount.",
+	"It would inflict quite a lot of damage and pain.",
+	"It would be an extraordinarily deadly weapon.",
+}
+
+// armsLore describes the condition of the targeted weapon or armor.
+func armsLore(m game.Mobile) {
+	n := m.NetState()
+	n.Speech(nil, "What item do you wish to get information about?")
+	target(m, func(o game.Object) {
+		w, ok := o.(game.Wearable)
+		if !ok {
+			n.Speech(nil, "That is not a weapon or armor.")
+			return
+		}
+		if !w.Weapon() && !w.Armor() {
+			n.Speech(nil, "That is not a weapon or armor.")
+			return
+		}
+		if !m.SkillCheck(uo.SkillArmsLore, 0, 1000) {
+			n.Speech(w, "You are not certain...")
+			return
+		}
+		if w.MaxDurability() > 0 {
+			n.Speech(w, describe(int(w.Durability()*100/w.MaxDurability()), 100,
+				armsLoreCondition))
+		}
+		if wp, ok := w.(game.Weapon); ok {
+			n.Speech(w, describe((wp.MinDamage()+wp.MaxDamage())/2, 30,
+				armsLoreDamage))
+		}
+	})
+}
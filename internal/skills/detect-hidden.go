@@ -0,0 +1,50 @@
+package skills
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(uo.SkillDetectHidden, uo.DurationSecond*10, detectHidden)
+}
+
+// detectHidden reveals hidden mobiles around a targeted location. The search
+// radius grows with the skill, and a mobile is only found if its hiding skill
+// does not exceed the detect hidden skill of the searcher.
+func detectHidden(m game.Mobile) {
+	n := m.NetState()
+	n.TargetSendCursor(uo.TargetTypeLocation, func(tr *clientpacket.TargetResponse) {
+		if m.IsDead() {
+			return
+		}
+		l := tr.Location
+		if m.Location().XYDistance(l) > targetRange {
+			n.Cliloc(nil, 500446) // That is too far away.
+			return
+		}
+		found := false
+		if m.SkillCheck(uo.SkillDetectHidden, 0, 1000) {
+			r := int16(m.Skill(uo.SkillDetectHidden) / 100)
+			for _, om := range game.MapOf(m).GetMobilesInRange(l, r) {
+				if om.Serial() == m.Serial() || !om.IsHidden() {
+					continue
+				}
+				if om.NetState() != nil &&
+					om.NetState().Account().HasRole(game.RoleGameMaster) {
+					// Staff can not be found this way
+					continue
+				}
+				if om.Skill(uo.SkillHiding) > m.Skill(uo.SkillDetectHidden) {
+					continue
+				}
+				om.RevealingAction()
+				found = true
+			}
+		}
+		if !found {
+			n.Speech(nil, "You can see nothing hidden there.")
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package skills
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(uo.SkillEvaluateIntelligence, defaultDelay, evaluateIntelligence)
+}
+
+// Descriptions of intelligence
+var evalIntIntelligence = []string{
+	"slightly less intelligent than a rock",
+	"fairly stupid",
+	"not the brightest",
+	"about average",
+	"moderately intelligent",
+	"very intelligent",
+	"extraordinarily intelligent",
+	"like a formidable intellect, well beyond even the extraordinary",
+	"like a definite genius",
+	"superhumanly intelligent in a manner you cannot comprehend",
+}
+
+// Descriptions of mana as a percentage of maximum mana
+var evalIntMana = []string{
+	"Their mental strength appears to be almost entirely drained.",
+	"Their mental strength appears to be very low.",
+	"Their mental strength appears to be somewhat low.",
+	"Their mental strength appears to be fairly high.",
+	"Their mental strength appears to be at its peak.",
+}
+
+// evaluateIntelligence describes the intelligence of the targeted mobile.
+func evaluateIntelligence(m game.Mobile) {
+	n := m.NetState()
+	n.Speech(nil, "What would you like to evaluate?")
+	target(m, func(o game.Object) {
+		tm, ok := o.(game.Mobile)
+		if !ok {
+			n.Speech(nil, "It looks smarter than a rock, but dumber than a piece of wood.")
+			return
+		}
+		if tm.IsDead() {
+			n.Speech(nil, "That cannot be evaluated.")
+			return
+		}
+		if !m.SkillCheck(uo.SkillEvaluateIntelligence, 0, 1000) {
+			n.Speech(tm, "You cannot judge their mental abilities.")
+			return
+		}
+		n.Speech(tm, "%s looks %s.", tm.DisplayName(),
+			describe(tm.Intelligence(), 100, evalIntIntelligence))
+		if m.Skill(uo.SkillEvaluateIntelligence) >= 760 && tm.MaxMana() > 0 {
+			n.Speech(tm, describe(tm.Mana()*100/tm.MaxMana(), 100, evalIntMana))
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package skills
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(uo.SkillHiding, uo.DurationSecond*10, hiding)
+}
+
+// hiding attempts to hide the mobile.
+func hiding(m game.Mobile) {
+	n := m.NetState()
+	if m.IsHidden() {
+		n.Cliloc(nil, 501240) // You have hidden yourself well.
+		return
+	}
+	if m.IsInWarMode() && m.Combatant() != nil {
+		n.Cliloc(nil, 501238) // You are preoccupied with thoughts of battle.
+		return
+	}
+	if !m.SkillCheck(uo.SkillHiding, 0, 1000) {
+		n.Cliloc(nil, 501241) // You can't seem to hide here.
+		return
+	}
+	m.SetHidden(true)
+	n.Cliloc(nil, 501240) // You have hidden yourself well.
+}
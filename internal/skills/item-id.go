@@ -0,0 +1,33 @@
+package skills
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(uo.SkillItemID, defaultDelay, itemID)
+}
+
+// itemID appraises the targeted item.
+func itemID(m game.Mobile) {
+	n := m.NetState()
+	n.Speech(nil, "What should I try to identify?")
+	target(m, func(o game.Object) {
+		item, ok := o.(game.Item)
+		if !ok {
+			n.Speech(nil, "That is not an item.")
+			return
+		}
+		if !m.SkillCheck(uo.SkillItemID, 0, 1000) {
+			n.Speech(item, "You have no idea what that item is worth.")
+			return
+		}
+		if item.Value() < 1 {
+			n.Speech(item, "It appears to be %s and is worthless.", item.DisplayName())
+			return
+		}
+		n.Speech(item, "It appears to be %s worth about %d gold.",
+			item.DisplayName(), item.Value()*item.Amount())
+	})
+}
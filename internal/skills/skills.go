@@ -0,0 +1,95 @@
+package skills
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+// Default time between uses of active skills
+const defaultDelay uo.Time = uo.DurationSecond
+
+// Range of targeted skills
+const targetRange int16 = 12
+
+// handler describes an active skill.
+type handler struct {
+	delay uo.Time           // Time before another skill may be used
+	fn    func(game.Mobile) // Function that uses the skill
+}
+
+// Registry of active skill handlers
+var handlers = util.NewRegistry[uo.Skill, *handler]("skills")
+
+func reg(which uo.Skill, delay uo.Time, fn func(game.Mobile)) {
+	handlers.Add(which, &handler{
+		delay: delay,
+		fn:    fn,
+	})
+}
+
+// Use attempts to use the active skill for the player mobile attached to the
+// net state. Skills that can not be used directly, dead players and players
+// that have used a skill too recently are refused with a message.
+func Use(n game.NetState, which uo.Skill) {
+	m := n.Mobile()
+	if m == nil {
+		return
+	}
+	h, found := handlers.Get(which)
+	if !found {
+		n.Cliloc(nil, 500014) // That skill cannot be used directly.
+		return
+	}
+	if m.IsDead() {
+		n.Cliloc(nil, 1019048) // I am dead and cannot do that.
+		return
+	}
+	if !n.TakeSkillAction(h.delay) {
+		n.Cliloc(nil, 500118) // You must wait a few moments to use another skill.
+		return
+	}
+	h.fn(m)
+}
+
+// target sends a target cursor to the mobile and calls fn with the targeted
+// object if the mobile can still see and reach it.
+func target(m game.Mobile, fn func(game.Object)) {
+	n := m.NetState()
+	if n == nil {
+		return
+	}
+	n.TargetSendCursor(uo.TargetTypeObject, func(tr *clientpacket.TargetResponse) {
+		if m.IsDead() {
+			return
+		}
+		o := game.Find[game.Object](tr.TargetObject)
+		if o == nil || !m.CanSee(o) {
+			n.Cliloc(nil, 500237) // Target can not be seen.
+			return
+		}
+		if m.Location().XYDistance(game.RootParent(o).Location()) > targetRange {
+			n.Cliloc(nil, 500446) // That is too far away.
+			return
+		}
+		if !m.HasLineOfSight(o) {
+			n.Cliloc(nil, 500237) // Target can not be seen.
+			return
+		}
+		fn(o)
+	})
+}
+
+// describe returns the description from the list that matches the value. The
+// list is spread evenly over the range 0-max.
+func describe(v, max int, ds []string) string {
+	i := v * len(ds) / (max + 1)
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(ds) {
+		i = len(ds) - 1
+	}
+	return ds[i]
+}
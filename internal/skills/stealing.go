@@ -0,0 +1,92 @@
+package skills
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(uo.SkillStealing, uo.DurationSecond*10, stealing)
+}
+
+// Heaviest item that may be stolen in stones
+const maxStealWeight float32 = 10
+
+// stealing attempts to take an item from the backpack of another mobile. A
+// mobile may be targeted to steal a random item from its backpack.
+func stealing(m game.Mobile) {
+	n := m.NetState()
+	n.Cliloc(nil, 502698) // Which item do you want to steal?
+	target(m, func(o game.Object) {
+		var item game.Item
+		victim, ok := o.(game.Mobile)
+		if ok {
+			item = randomStealable(victim)
+			if item == nil {
+				n.Speech(nil, "You reach into their backpack but find nothing to steal.")
+				return
+			}
+		} else {
+			item, ok = o.(game.Item)
+			if !ok {
+				n.Cliloc(nil, 502710) // You can't steal that!
+				return
+			}
+			victim, ok = game.RootParent(item).(game.Mobile)
+			if !ok || !victim.InBackpack(item) {
+				n.Cliloc(nil, 502710) // You can't steal that!
+				return
+			}
+		}
+		if victim.Serial() == m.Serial() {
+			n.Cliloc(nil, 502704) // You catch yourself red-handed.
+			return
+		}
+		if m.Location().XYDistance(victim.Location()) > uo.MaxMeleeRange {
+			n.Cliloc(nil, 502703) // You must be standing next to an item to steal it.
+			return
+		}
+		if !m.CanHarm(victim) || !item.Movable() {
+			n.Cliloc(nil, 502710) // You can't steal that!
+			return
+		}
+		w := item.Weight()
+		if w > maxStealWeight {
+			n.Cliloc(nil, 502722) // That is too heavy to steal.
+			return
+		}
+		min := int(w * 100)
+		bp, ok := m.EquipmentInSlot(uo.LayerBackpack).(game.Container)
+		if ok && m.SkillCheck(uo.SkillStealing, min, min+1000) &&
+			game.MapOf(item).SetNewParent(item, bp) {
+			n.Cliloc(nil, 502724) // You successfully steal the item.
+		} else {
+			n.Cliloc(nil, 502723) // You fail to steal the item.
+		}
+		// Getting caught stealing from an innocent is a crime
+		if int(m.Skill(uo.SkillStealing)) >= game.GetWorld().Random().Random(0, 1500) {
+			return
+		}
+		m.RevealingAction()
+		m.AggressiveAction(victim)
+		if victim.NetState() != nil {
+			victim.NetState().Speech(m, "You notice %s trying to steal from you!",
+				m.DisplayName())
+		}
+		n.Speech(nil, "You have been noticed!")
+	})
+}
+
+// randomStealable returns a random item from the top level of the mobile's
+// backpack, or nil if there is none.
+func randomStealable(m game.Mobile) game.Item {
+	bp, ok := m.EquipmentInSlot(uo.LayerBackpack).(game.Container)
+	if !ok {
+		return nil
+	}
+	items := bp.Contents()
+	if len(items) == 0 {
+		return nil
+	}
+	return items[game.GetWorld().Random().Random(0, len(items)-1)]
+}
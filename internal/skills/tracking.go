@@ -0,0 +1,61 @@
+package skills
+
+import (
+	"sort"
+
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(uo.SkillTracking, uo.DurationSecond*10, tracking)
+}
+
+// Maximum number of creatures reported by the tracking skill
+const trackingMaxResults int = 5
+
+// Names of all directions
+var directionNames = []string{
+	"north",
+	"north east",
+	"east",
+	"south east",
+	"south",
+	"south west",
+	"west",
+	"north west",
+}
+
+// tracking reports the direction and distance of the nearest creatures. The
+// search radius grows with the skill.
+func tracking(m game.Mobile) {
+	n := m.NetState()
+	if !m.SkillCheck(uo.SkillTracking, 0, 1000) {
+		n.Speech(nil, "You see no evidence of those in the area.")
+		return
+	}
+	r := 10 + int16(m.Skill(uo.SkillTracking)/10)
+	var mobs []game.Mobile
+	for _, om := range game.MapOf(m).GetMobilesInRange(m.Location(), r) {
+		if om.Serial() == m.Serial() || om.IsDead() || !m.CanSee(om) {
+			continue
+		}
+		mobs = append(mobs, om)
+	}
+	if len(mobs) == 0 {
+		n.Speech(nil, "You see no evidence of those in the area.")
+		return
+	}
+	sort.Slice(mobs, func(i, j int) bool {
+		return m.Location().XYDistance(mobs[i].Location()) <
+			m.Location().XYDistance(mobs[j].Location())
+	})
+	if len(mobs) > trackingMaxResults {
+		mobs = mobs[:trackingMaxResults]
+	}
+	for _, om := range mobs {
+		d := m.Location().DirectionTo(om.Location()).Bound().StripRunningFlag()
+		n.Speech(nil, "%s: %d tiles to the %s", om.DisplayName(),
+			m.Location().XYDistance(om.Location()), directionNames[d])
+	}
+}
@@ -0,0 +1,49 @@
+package spells
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(&spell{
+		id:       uo.SpellHarm,
+		words:    "An Mani",
+		reagents: []string{"Nightshade", "SpidersSilk"},
+		scroll:   "HarmScroll",
+		onObject: harm,
+	})
+}
+
+// harm damages the target mobile.
+func harm(caster game.Mobile, o game.Object) {
+	tm := harmful(caster, o)
+	if tm == nil {
+		return
+	}
+	w := game.GetWorld()
+	damage := w.Random().Random(1, 15) + int(caster.Skill(uo.SkillMagery))/200
+	if resisted(tm, uo.SpellHarm.Circle()) {
+		damage /= 2
+	}
+	game.MapOf(caster).PlayEffect(uo.GFXTypeFixed, tm, tm, 0x374A, 10, 15, true, false,
+		uo.HueDefault, uo.GFXBlendModeNormal)
+	game.MapOf(caster).PlaySound(0x1F1, tm.Location())
+	tm.Damage(caster, damage)
+}
+
+// harmful returns the target of a harmful spell, or nil if the object may not
+// be harmed by the caster.
+func harmful(caster game.Mobile, o game.Object) game.Mobile {
+	n := caster.NetState()
+	tm, ok := o.(game.Mobile)
+	if !ok || tm.IsDead() {
+		n.Speech(nil, "That is not a valid target.")
+		return nil
+	}
+	if !caster.CanHarm(tm) {
+		n.Speech(nil, "You cannot harm them.")
+		return nil
+	}
+	return tm
+}
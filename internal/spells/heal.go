@@ -0,0 +1,35 @@
+package spells
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(&spell{
+		id:       uo.SpellHeal,
+		words:    "In Mani",
+		reagents: []string{"Garlic", "Ginseng", "SpidersSilk"},
+		scroll:   "HealScroll",
+		onObject: heal,
+	})
+}
+
+// heal restores a few hit points of the target mobile.
+func heal(caster game.Mobile, o game.Object) {
+	n := caster.NetState()
+	tm, ok := o.(game.Mobile)
+	if !ok {
+		n.Speech(nil, "That is not a living thing.")
+		return
+	}
+	if tm.IsDead() {
+		n.Speech(nil, "You cannot heal that which is not alive.")
+		return
+	}
+	w := game.GetWorld()
+	tm.Heal(int(caster.Skill(uo.SkillMagery))/120 + w.Random().Random(1, 4))
+	game.MapOf(caster).PlayEffect(uo.GFXTypeFixed, tm, tm, 0x376A, 9, 32, true, false,
+		uo.HueDefault, uo.GFXBlendModeNormal)
+	game.MapOf(caster).PlaySound(0x1F2, tm.Location())
+}
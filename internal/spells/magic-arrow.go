@@ -0,0 +1,33 @@
+package spells
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(&spell{
+		id:       uo.SpellMagicArrow,
+		words:    "In Por Ylem",
+		reagents: []string{"SulfurousAsh"},
+		scroll:   "MagicArrowScroll",
+		onObject: magicArrow,
+	})
+}
+
+// magicArrow shoots a bolt of fire at the target mobile.
+func magicArrow(caster game.Mobile, o game.Object) {
+	tm := harmful(caster, o)
+	if tm == nil {
+		return
+	}
+	w := game.GetWorld()
+	damage := w.Random().Random(4, 7)
+	if resisted(tm, uo.SpellMagicArrow.Circle()) {
+		damage /= 2
+	}
+	game.MapOf(caster).PlayEffect(uo.GFXTypeMoving, caster, tm, 0x36E4, 5, 0, false, true,
+		uo.HueDefault, uo.GFXBlendModeNormal)
+	game.MapOf(caster).PlaySound(0x1E5, caster.Location())
+	tm.Damage(caster, damage)
+}
@@ -0,0 +1,43 @@
+package spells
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(&spell{
+		id:       uo.SpellMark,
+		words:    "Kal Por Ylem",
+		reagents: []string{"BlackPearl", "Bloodmoss", "MandrakeRoot"},
+		scroll:   "MarkScroll",
+		onObject: mark,
+	})
+}
+
+// mark marks the target rune with the location of the caster.
+func mark(caster game.Mobile, o game.Object) {
+	n := caster.NetState()
+	rune, ok := o.(*game.RecallRune)
+	if !ok {
+		n.Cliloc(nil, 501797) // I cannot mark that object.
+		return
+	}
+	if !caster.CanAccess(rune) {
+		n.Cliloc(nil, 500447) // That is not accessible.
+		return
+	}
+	l := uo.FacetLocation{
+		Facet:    caster.Facet(),
+		Location: caster.Location(),
+	}
+	if !canTeleport(caster, l) {
+		n.Cliloc(nil, 501802) // Thy spell doth not appear to work...
+		return
+	}
+	rune.Mark(l)
+	m := game.MapOf(caster)
+	m.PlayEffect(uo.GFXTypeFixed, caster, caster, 0x3779, 10, 16, true, false,
+		uo.HueDefault, uo.GFXBlendModeNormal)
+	m.PlaySound(0x1FA, caster.Location())
+}
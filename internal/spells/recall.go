@@ -0,0 +1,50 @@
+package spells
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(&spell{
+		id:       uo.SpellRecall,
+		words:    "Kal Ort Por",
+		reagents: []string{"BlackPearl", "Bloodmoss", "MandrakeRoot"},
+		scroll:   "RecallScroll",
+		onObject: recall,
+	})
+}
+
+// recall moves the caster to the location marked on the target rune.
+func recall(caster game.Mobile, o game.Object) {
+	n := caster.NetState()
+	rune, ok := o.(*game.RecallRune)
+	if !ok {
+		n.Cliloc(nil, 502357) // I can not recall from that object.
+		return
+	}
+	if !caster.CanAccess(rune) {
+		n.Cliloc(nil, 500447) // That is not accessible.
+		return
+	}
+	l, marked := rune.Destination()
+	if !marked {
+		n.Cliloc(nil, 502354) // Target is not marked.
+		return
+	}
+	if !canTeleport(caster, l) {
+		n.Cliloc(nil, 501802) // Thy spell doth not appear to work...
+		return
+	}
+	if caster.Weight() > float32(caster.MaxWeight()) {
+		n.Cliloc(nil, 502359) // Thou art too encumbered to move.
+		return
+	}
+	game.MapOf(caster).PlaySound(0x1FC, caster.Location())
+	m := game.GetWorld().Map(l.Facet)
+	if !m.TeleportMobile(caster, l.Location) {
+		n.Cliloc(nil, 501942) // That location is blocked.
+		return
+	}
+	m.PlaySound(0x1FC, caster.Location())
+}
@@ -0,0 +1,280 @@
+package spells
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/clientpacket"
+	"github.com/qbradq/sharduo/lib/uo"
+	"github.com/qbradq/sharduo/lib/util"
+)
+
+// Mana cost of spells by circle
+var manaCosts = []int{4, 6, 9, 11, 14, 20, 40, 50}
+
+// Time the casting of every spell takes in addition to the circle delay
+const castDelayBase uo.Time = uo.DurationSecond / 2
+
+// Time the casting of a spell takes per circle
+const castDelayPerCircle uo.Time = uo.DurationSecond / 4
+
+// spell describes a magery spell. Exactly one of onObject and onLocation is
+// set, this selects the kind of target cursor sent.
+type spell struct {
+	id         uo.Spell                       // Spell
+	words      string                         // Words of power spoken when casting
+	reagents   []string                       // Template names of the reagents consumed, one each
+	scroll     string                         // Template name of the scroll of the spell
+	onObject   func(game.Mobile, game.Object) // Effect of object-targeted spells
+	onLocation func(game.Mobile, uo.Location) // Effect of location-targeted spells
+}
+
+// Registry of spells
+var spells = util.NewRegistry[uo.Spell, *spell]("spells")
+
+// Spells by scroll template name
+var scrolls = map[string]uo.Spell{}
+
+func reg(s *spell) {
+	spells.Add(s.id, s)
+	scrolls[s.scroll] = s.id
+}
+
+// Spells currently being cast by mobile serial
+var casting = map[uo.Serial]*cast{}
+
+// cast is a spell being cast.
+type cast struct {
+	spell *spell    // The spell being cast
+	timer uo.Serial // Timer that finishes casting
+}
+
+// mana returns the mana cost of the spell.
+func (s *spell) mana() int { return manaCosts[s.id.Circle()-1] }
+
+// skillRange returns the minimum and maximum raw magery skill values of the
+// skill check for the spell. The check is centered on a value spread evenly
+// over the circles.
+func (s *spell) skillRange() (int, int) {
+	avg := (s.id.Circle() - 1) * 1000 / 7
+	return avg - 200, avg + 200
+}
+
+// hasReagents returns true if the mobile carries all of the reagents of the
+// spell in its backpack.
+func (s *spell) hasReagents(m game.Mobile) bool {
+	bp, ok := m.EquipmentInSlot(uo.LayerBackpack).(game.Container)
+	if !ok {
+		return len(s.reagents) == 0
+	}
+	for _, r := range s.reagents {
+		if bp.CountTemplate(r) < 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// consumeReagents removes the reagents of the spell from the backpack of the
+// mobile and returns true, or returns false if any of them are missing.
+func (s *spell) consumeReagents(m game.Mobile) bool {
+	if !s.hasReagents(m) {
+		return false
+	}
+	bp, ok := m.EquipmentInSlot(uo.LayerBackpack).(game.Container)
+	if !ok {
+		return true
+	}
+	for _, r := range s.reagents {
+		bp.ConsumeTemplate(r, 1)
+	}
+	return true
+}
+
+// ForScroll returns the spell the scroll template inscribes and true, or false
+// if the template is not a spell scroll.
+func ForScroll(templateName string) (uo.Spell, bool) {
+	s, found := scrolls[templateName]
+	return s, found
+}
+
+// IsCasting returns true if the mobile is currently casting a spell.
+func IsCasting(m game.Mobile) bool { return casting[m.Serial()] != nil }
+
+// Cast begins casting the spell for the player mobile attached to the net
+// state. The caster must have the spell in a spellbook and enough mana and
+// reagents for it. Casting finishes after a delay depending on the circle of
+// the spell, see FinishCasting.
+func Cast(n game.NetState, which uo.Spell) {
+	m := n.Mobile()
+	if m == nil {
+		return
+	}
+	s, found := spells.Get(which)
+	if !found {
+		n.Speech(nil, "That spell is not yet known to this world.")
+		return
+	}
+	if m.IsDead() {
+		n.Cliloc(nil, 1019048) // I am dead and cannot do that.
+		return
+	}
+	if IsCasting(m) {
+		n.Cliloc(nil, 502642) // You are already casting a spell.
+		return
+	}
+	if game.FindSpellbook(m, which) == nil {
+		n.Speech(nil, "You do not have that spell.")
+		return
+	}
+	if m.Mana() < s.mana() {
+		n.Cliloc(nil, 502625) // Insufficient mana for this spell.
+		return
+	}
+	if !s.hasReagents(m) {
+		n.Cliloc(nil, 502630) // More reagents are needed for this spell.
+		return
+	}
+	m.RevealingAction()
+	w := game.MapOf(m)
+	w.SendSpeech(m, uo.SpeechNormalRange, s.words)
+	w.PlayAnimation(m, uo.AnimationTypeSpell, 0)
+	delay := castDelayBase + castDelayPerCircle*uo.Time(which.Circle())
+	casting[m.Serial()] = &cast{
+		spell: s,
+		timer: game.NewTimer(delay, "FinishCasting", m, nil, true, nil),
+	}
+}
+
+// FinishCasting sends the target cursor of the spell the mobile has finished
+// casting. The spell takes effect once a valid target is selected.
+func FinishCasting(m game.Mobile) {
+	c := casting[m.Serial()]
+	if c == nil {
+		return
+	}
+	delete(casting, m.Serial())
+	n := m.NetState()
+	if n == nil || m.IsDead() {
+		return
+	}
+	s := c.spell
+	if s.onLocation != nil {
+		n.TargetSendCursor(uo.TargetTypeLocation, func(tr *clientpacket.TargetResponse) {
+			if !checkLocation(m, tr.Location) || !checkSequence(m, s) {
+				return
+			}
+			s.onLocation(m, tr.Location)
+		})
+		return
+	}
+	n.TargetSendCursor(uo.TargetTypeObject, func(tr *clientpacket.TargetResponse) {
+		o := game.Find[game.Object](tr.TargetObject)
+		if !checkObject(m, o) || !checkSequence(m, s) {
+			return
+		}
+		s.onObject(m, o)
+	})
+}
+
+// Disturb interrupts the spell the mobile is casting, if any.
+func Disturb(m game.Mobile) {
+	c := casting[m.Serial()]
+	if c == nil {
+		return
+	}
+	delete(casting, m.Serial())
+	game.CancelTimer(c.timer)
+	if m.NetState() != nil {
+		m.NetState().Cliloc(nil, 500641) // Your concentration is disturbed, thus ruining thy spell.
+	}
+	fizzle(m)
+}
+
+// checkObject returns true if the mobile can still cast at the object.
+func checkObject(m game.Mobile, o game.Object) bool {
+	n := m.NetState()
+	if m.IsDead() {
+		return false
+	}
+	if o == nil || !m.CanSee(o) {
+		n.Cliloc(nil, 500237) // Target can not be seen.
+		return false
+	}
+	if m.Location().XYDistance(game.RootParent(o).Location()) > uo.MaxSpellRange {
+		n.Cliloc(nil, 500446) // That is too far away.
+		return false
+	}
+	if !m.HasLineOfSight(o) {
+		n.Cliloc(nil, 500237) // Target can not be seen.
+		return false
+	}
+	return true
+}
+
+// checkLocation returns true if the mobile can still cast at the location.
+func checkLocation(m game.Mobile, l uo.Location) bool {
+	n := m.NetState()
+	if m.IsDead() {
+		return false
+	}
+	if m.Location().XYDistance(l) > uo.MaxSpellRange {
+		n.Cliloc(nil, 500446) // That is too far away.
+		return false
+	}
+	return true
+}
+
+// checkSequence consumes the reagents and mana of the spell and checks the
+// magery skill of the caster. It returns true if the spell should take effect.
+// Reagents are lost when the spell fizzles, mana is not.
+func checkSequence(m game.Mobile, s *spell) bool {
+	n := m.NetState()
+	if m.Mana() < s.mana() {
+		n.Cliloc(nil, 502625) // Insufficient mana for this spell.
+		return false
+	}
+	if !s.consumeReagents(m) {
+		n.Cliloc(nil, 502630) // More reagents are needed for this spell.
+		return false
+	}
+	min, max := s.skillRange()
+	if !m.SkillCheck(uo.SkillMagery, min, max) {
+		n.Cliloc(nil, 502632) // The spell fizzles.
+		fizzle(m)
+		return false
+	}
+	m.ConsumeMana(s.mana())
+	return true
+}
+
+// fizzle plays the fizzle effect on the mobile.
+func fizzle(m game.Mobile) {
+	w := game.MapOf(m)
+	w.PlayEffect(uo.GFXTypeFixed, m, m, 0x3735, 10, 30, true, false,
+		uo.HueDefault, uo.GFXBlendModeNormal)
+	w.PlaySound(0x5C, m.Location())
+}
+
+// canTeleport returns true if teleporting the mobile out of its location and
+// into the destination is allowed.
+func canTeleport(mob game.Mobile, to uo.FacetLocation) bool {
+	from := game.MapOf(mob)
+	dest := game.GetWorld().Map(to.Facet)
+	if from == nil || dest == nil {
+		return false
+	}
+	return from.RegionFeaturesAt(mob.Location())&game.RegionFeatureNoTeleport == 0 &&
+		dest.RegionFeaturesAt(to.Location)&game.RegionFeatureNoTeleport == 0
+}
+
+// resisted returns true if the target resists a spell of the given circle.
+// Resisting a spell halves its damage.
+func resisted(target game.Mobile, circle int) bool {
+	min := (circle - 1) * 100
+	if !target.SkillCheck(uo.SkillMagicResistance, min, min+1000) {
+		return false
+	}
+	if target.NetState() != nil {
+		target.NetState().Cliloc(nil, 501783) // You feel yourself resisting magical energy.
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package spells
+
+import (
+	"github.com/qbradq/sharduo/internal/game"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+func init() {
+	reg(&spell{
+		id:         uo.SpellTeleport,
+		words:      "Rel Por",
+		reagents:   []string{"Bloodmoss", "MandrakeRoot"},
+		scroll:     "TeleportScroll",
+		onLocation: teleport,
+	})
+}
+
+// teleport moves the caster to the target location.
+func teleport(caster game.Mobile, l uo.Location) {
+	n := caster.NetState()
+	m := game.MapOf(caster)
+	if !canTeleport(caster, uo.FacetLocation{Facet: caster.Facet(), Location: l}) {
+		n.Cliloc(nil, 501802) // Thy spell doth not appear to work...
+		return
+	}
+	s := m.GetSpawnableSurface(l, l.Z+uo.PlayerHeight, caster)
+	if s == nil {
+		n.Cliloc(nil, 501942) // That location is blocked.
+		return
+	}
+	l.Z = s.StandingHeight()
+	a := caster.Location()
+	a.Z += uo.PlayerHeight
+	b := l
+	b.Z += uo.PlayerHeight
+	if !m.LineOfSight(a, b) {
+		n.Cliloc(nil, 500237) // Target can not be seen.
+		return
+	}
+	m.PlayEffect(uo.GFXTypeFixed, caster, caster, 0x3728, 10, 10, true, false,
+		uo.HueDefault, uo.GFXBlendModeNormal)
+	if !m.TeleportMobile(caster, l) {
+		n.Cliloc(nil, 501942) // That location is blocked.
+		return
+	}
+	m.PlayEffect(uo.GFXTypeFixed, caster, caster, 0x3728, 10, 10, true, false,
+		uo.HueDefault, uo.GFXBlendModeNormal)
+	m.PlaySound(0x1FE, caster.Location())
+}
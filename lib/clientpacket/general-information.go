@@ -7,11 +7,14 @@ import (
 
 func init() {
 	giFactory.Ignore(0x05) // Client screen dimensions
+	giFactory.Add(0x06, newPartyCommand)
 	giFactory.Ignore(0x0B) // Client language
 	giFactory.Ignore(0x0C) // Status closed
 	giFactory.Ignore(0x0F) // Client flags
 	giFactory.Add(0x13, newContextMenuRequest)
 	giFactory.Add(0x15, newContextMenuSelection)
+	giFactory.Add(0x1C, newCastSpell)
+	giFactory.Add(0x1A, newStatLockChange)
 }
 
 var giFactory = &packetFactory{}
@@ -68,3 +71,154 @@ func newContextMenuSelection(in []byte) Packet {
 		EntryID:      dc.GetUint16(in[4:6]),
 	}
 }
+
+// StatLockChange is sent by the client to change the lock state of a stat.
+type StatLockChange struct {
+	baseGIPacket
+	// Stat to change
+	Stat uo.Stat
+	// New lock state
+	Lock uo.SkillLock
+}
+
+func newStatLockChange(in []byte) Packet {
+	return &StatLockChange{
+		baseGIPacket: baseGIPacket{id: 0xBF, sc: 0x1A},
+		Stat:         uo.Stat(in[0]),
+		Lock:         uo.SkillLock(in[1]),
+	}
+}
+
+// newPartyCommand creates the party packet for the party subcommand.
+func newPartyCommand(in []byte) Packet {
+	if len(in) < 1 {
+		return nil
+	}
+	base := baseGIPacket{id: 0xBF, sc: 0x06}
+	switch in[0] {
+	case 0x01:
+		if len(in) < 5 {
+			return nil
+		}
+		return &PartyAddMember{
+			baseGIPacket: base,
+			Serial:       uo.Serial(dc.GetUint32(in[1:5])),
+		}
+	case 0x02:
+		if len(in) < 5 {
+			return nil
+		}
+		return &PartyRemoveMember{
+			baseGIPacket: base,
+			Serial:       uo.Serial(dc.GetUint32(in[1:5])),
+		}
+	case 0x03:
+		if len(in) < 5 {
+			return nil
+		}
+		return &PartyMessage{
+			baseGIPacket: base,
+			To:           uo.Serial(dc.GetUint32(in[1:5])),
+			Text:         dc.UTF16String(in[5:]),
+		}
+	case 0x04:
+		return &PartyMessage{
+			baseGIPacket: base,
+			Text:         dc.UTF16String(in[1:]),
+		}
+	case 0x06:
+		if len(in) < 2 {
+			return nil
+		}
+		return &PartyCanLoot{
+			baseGIPacket: base,
+			CanLoot:      in[1] != 0,
+		}
+	case 0x08, 0x09:
+		if len(in) < 5 {
+			return nil
+		}
+		return &PartyInvitationReply{
+			baseGIPacket: base,
+			Leader:       uo.Serial(dc.GetUint32(in[1:5])),
+			Accepted:     in[0] == 0x08,
+		}
+	}
+	return nil
+}
+
+// PartyAddMember is sent by the client to invite a player to the party.
+type PartyAddMember struct {
+	baseGIPacket
+	// Serial of the player to invite, or uo.SerialZero to request a target
+	// cursor
+	Serial uo.Serial
+}
+
+// PartyRemoveMember is sent by the client to remove a member from the party.
+type PartyRemoveMember struct {
+	baseGIPacket
+	// Serial of the member to remove, or uo.SerialZero to request a target
+	// cursor
+	Serial uo.Serial
+}
+
+// PartyMessage is sent by the client to send a message to the party or to a
+// single member of the party.
+type PartyMessage struct {
+	baseGIPacket
+	// Serial of the member the message is for, or uo.SerialZero for messages
+	// to the entire party
+	To uo.Serial
+	// Text of the message
+	Text string
+}
+
+// PartyCanLoot is sent by the client to allow or deny party members looting
+// the player's corpse.
+type PartyCanLoot struct {
+	baseGIPacket
+	// If true party members may loot the player's corpse
+	CanLoot bool
+}
+
+// PartyInvitationReply is sent by the client to accept or decline a party
+// invitation.
+type PartyInvitationReply struct {
+	baseGIPacket
+	// Serial of the party leader that sent the invitation
+	Leader uo.Serial
+	// If true the invitation was accepted
+	Accepted bool
+}
+
+// CastSpell is sent by the client when the player casts a spell from the
+// spellbook or with a spell icon.
+type CastSpell struct {
+	baseGIPacket
+	// Serial of the spellbook the spell was cast from, or uo.SerialZero if no
+	// spellbook was given
+	Spellbook uo.Serial
+	// Spell to cast
+	Spell uo.Spell
+}
+
+func newCastSpell(in []byte) Packet {
+	if len(in) < 4 {
+		return nil
+	}
+	p := &CastSpell{
+		baseGIPacket: baseGIPacket{id: 0xBF, sc: 0x1C},
+	}
+	ofs := 2
+	if dc.GetUint16(in[0:2]) == 1 {
+		if len(in) < 8 {
+			return nil
+		}
+		p.Spellbook = uo.Serial(dc.GetUint32(in[2:6]))
+		ofs = 6
+	}
+	// The client counts spells from one
+	p.Spell = uo.Spell(dc.GetUint16(in[ofs:ofs+2]) - 1)
+	return p
+}
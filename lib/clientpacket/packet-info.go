@@ -1,5 +1,7 @@
 package clientpacket
 
+import "github.com/qbradq/sharduo/lib/uo"
+
 // Info contains the ID and length of a client packet.
 type Info struct {
 	// Packet ID
@@ -259,7 +261,7 @@ var InfoTable = [256]Info{
 	{0xf5, 0},
 	{0xf6, 0},
 	{0xf7, 0},
-	{0xf8, 0},
+	{0xf8, 106},
 	{0xf9, 0},
 	{0xfa, 0},
 	{0xfb, 0},
@@ -268,3 +270,27 @@ var InfoTable = [256]Info{
 	{0xfe, 0},
 	{0xff, 0},
 }
+
+// versionedInfo lists the packets that have a different length for older
+// clients.
+var versionedInfo = []struct {
+	// Packet ID
+	ID byte
+	// Clients before this version use Length
+	Before uo.ClientVersion
+	// Length of the packet for older clients
+	Length int
+}{
+	{0x08, uo.ClientVersionGridIndex, 14},
+}
+
+// PacketLength returns the length of the packet for the given client version.
+// The return value has the same meaning as Info.Length.
+func PacketLength(id byte, v uo.ClientVersion) int {
+	for _, vi := range versionedInfo {
+		if vi.ID == id && v.Before(vi.Before) {
+			return vi.Length
+		}
+	}
+	return InfoTable[id].Length
+}
@@ -12,7 +12,9 @@ import (
 var pf = &packetFactory{}
 
 func init() {
+	pf.Add(0x00, newCharacterCreate)
 	pf.Add(0x02, newWalkRequest)
+	pf.Add(0x05, newAttackRequest)
 	pf.Add(0x06, newDoubleClick)
 	pf.Add(0x07, newLiftRequest)
 	pf.Add(0x08, newDropRequest)
@@ -20,12 +22,16 @@ func init() {
 	pf.Add(0x12, newMacroRequest)
 	pf.Add(0x13, newWearItemRequest)
 	pf.Add(0x34, newPlayerStatusRequest)
+	pf.Add(0x3A, newSkillLockChange)
 	pf.Add(0x3B, newBuyItems)
 	pf.Add(0x5D, newCharacterLogin)
 	pf.Add(0x6C, newTargetResponse)
+	pf.Add(0x6F, newSecureTrade)
+	pf.Add(0x72, newWarMode)
 	pf.Add(0x73, newPing)
 	pf.Add(0x75, newRenameRequest)
 	pf.Add(0x80, newAccountLogin)
+	pf.Add(0x83, newCharacterDelete)
 	pf.Add(0x91, newGameServerLogin)
 	pf.Add(0x98, newNameRequest)
 	pf.Add(0x9F, newSellResponse)
@@ -41,6 +47,7 @@ func init() {
 	pf.Add(0xEF, newLoginSeed)
 	// pf.Add(0xF0, newProtocolExtension)
 	pf.Ignore(0xF0) // New protocol extensions, used by world map programs
+	pf.Add(0xF8, newCharacterCreate)
 }
 
 // Packet is the interface all client packets implement.
@@ -59,6 +66,10 @@ func New(data []byte) Packet {
 	} else if length == 0 {
 		return newUnknownPacket("client-packets", data[0])
 	} else {
+		// Older clients send shorter versions of some fixed-length packets
+		if len(data) < length {
+			length = len(data)
+		}
 		pdat = data[1:length]
 	}
 	return pf.New(data[0], pdat)
@@ -194,7 +205,7 @@ type GameServerLogin struct {
 
 func newGameServerLogin(in []byte) Packet {
 	return &GameServerLogin{
-		basePacket: basePacket{id: 91},
+		basePacket: basePacket{id: 0x91},
 		Key:        uo.Serial(dc.GetUint32(in[:4])),
 		Username:   dc.NullString(in[4:34]),
 		Password:   dc.NullString(in[34:64]),
@@ -216,6 +227,123 @@ func newCharacterLogin(in []byte) Packet {
 	return p
 }
 
+// StartingSkill is one of the skills chosen during character creation.
+type StartingSkill struct {
+	// Skill chosen
+	Skill uo.Skill
+	// Value of the skill in whole points
+	Value int
+}
+
+// CharacterCreate is sent to create a new character. Packet 0xF8 is used by
+// newer clients and adds a fourth starting skill.
+type CharacterCreate struct {
+	basePacket
+	// Name of the character
+	Name string
+	// Profession chosen, 0 for advanced
+	Profession int
+	// If true the character is female
+	Female bool
+	// Body of the character as determined by sex and race
+	Body uo.Body
+	// Starting stats
+	Strength, Dexterity, Intelligence int
+	// Starting skills
+	Skills []StartingSkill
+	// Skin hue
+	SkinHue uo.Hue
+	// Hair style graphic, 0 for bald
+	HairStyle uo.Graphic
+	// Hair hue
+	HairHue uo.Hue
+	// Facial hair style graphic, 0 for none
+	BeardStyle uo.Graphic
+	// Facial hair hue
+	BeardHue uo.Hue
+	// Index of the starting city chosen
+	City int
+	// Character slot the client wants to use
+	Slot int
+	// Shirt hue
+	ShirtHue uo.Hue
+	// Pants hue
+	PantsHue uo.Hue
+}
+
+func newCharacterCreate(in []byte) Packet {
+	// Packet 0xF8 has a fourth skill, all fields after the skills are shifted
+	// by two bytes
+	nSkills := 3
+	id := byte(0x00)
+	if len(in) >= 105 {
+		nSkills = 4
+		id = 0xF8
+	} else if len(in) < 103 {
+		return newMalformedPacket(0x00)
+	}
+	o := nSkills*2 + 73
+	p := &CharacterCreate{
+		basePacket:   basePacket{id: id},
+		Name:         dc.NullString(in[9:39]),
+		Profession:   int(in[53]),
+		Female:       in[69]%2 != 0,
+		Strength:     int(in[70]),
+		Dexterity:    int(in[71]),
+		Intelligence: int(in[72]),
+		SkinHue:      uo.Hue(dc.GetUint16(in[o : o+2])),
+		HairStyle:    uo.Graphic(dc.GetUint16(in[o+2 : o+4])),
+		HairHue:      uo.Hue(dc.GetUint16(in[o+4 : o+6])),
+		BeardStyle:   uo.Graphic(dc.GetUint16(in[o+6 : o+8])),
+		BeardHue:     uo.Hue(dc.GetUint16(in[o+8 : o+10])),
+		City:         int(in[o+11]),
+		Slot:         int(dc.GetUint32(in[o+12 : o+16])),
+		ShirtHue:     uo.Hue(dc.GetUint16(in[o+20 : o+22])),
+		PantsHue:     uo.Hue(dc.GetUint16(in[o+22 : o+24])),
+	}
+	for i := 0; i < nSkills; i++ {
+		p.Skills = append(p.Skills, StartingSkill{
+			Skill: uo.Skill(in[73+i*2]),
+			Value: int(in[74+i*2]),
+		})
+	}
+	// Sex and race
+	race := int(in[69]) / 2
+	if id == 0xF8 && race > 0 {
+		// Packet 0xF8 uses values 2 and 3 for humans
+		race--
+	}
+	switch race {
+	case 1:
+		p.Body = uo.BodyElfMale
+	case 2:
+		p.Body = uo.BodyGargoyleMale
+	default:
+		p.Body = uo.BodyHumanMale
+	}
+	if p.Female {
+		p.Body++
+	}
+	return p
+}
+
+// CharacterDelete is sent to request the deletion of a character.
+type CharacterDelete struct {
+	basePacket
+	// Account password in plain-text
+	Password string
+	// Character slot to delete
+	Slot int
+}
+
+func newCharacterDelete(in []byte) Packet {
+	return &CharacterDelete{
+		basePacket: basePacket{id: 0x83},
+		Password:   dc.NullString(in[0:30]),
+		Slot:       int(dc.GetUint32(in[30:34])),
+	}
+}
+
 // Version is used to communicate to the server the client's version string.
 type Version struct {
 	basePacket
@@ -303,6 +431,21 @@ func newSingleClick(in []byte) Packet {
 	return p
 }
 
+// AttackRequest is sent by the client when the player wants to attack a
+// mobile.
+type AttackRequest struct {
+	basePacket
+	// Serial of the mobile to attack
+	Defender uo.Serial
+}
+
+func newAttackRequest(in []byte) Packet {
+	return &AttackRequest{
+		basePacket: basePacket{id: 0x05},
+		Defender:   uo.Serial(dc.GetUint32(in[0:4])),
+	}
+}
+
 // DoubleClick is sent by the client when the player double-clicks an object
 type DoubleClick struct {
 	basePacket
@@ -461,8 +604,13 @@ func newDropRequest(in []byte) Packet {
 			Y: int16(dc.GetUint16(in[6:8])),
 			Z: int8(in[8]),
 		},
+	}
+	if len(in) < 14 {
+		// Clients before 6.0.1.7 do not send the grid index
+		p.Container = uo.Serial(dc.GetUint32(in[9:13]))
+	} else {
 		// Skip one byte for the grid index
-		Container: uo.Serial(dc.GetUint32(in[10:14])),
+		p.Container = uo.Serial(dc.GetUint32(in[10:14]))
 	}
 	return p
 }
@@ -709,15 +857,30 @@ func newMacroRequest(in []byte) Packet {
 			p.MacroType = uo.MacroTypeInvalid
 			break
 		}
-		p.Offset = int(v) - 1
-	case 'V':
+		p.Offset = int(v)
+	case 'V', '\'':
+		// The client counts spells from one. Older clients send the serial of
+		// the spellbook after the spell number when casting from the book.
 		p.MacroType = uo.MacroTypeSpell
-		v, err := strconv.ParseInt(string(in[1:]), 0, 32)
+		parts := strings.Split(dc.NullString(in[1:]), " ")
+		v, err := strconv.ParseInt(parts[0], 0, 32)
 		if err != nil {
 			p.MacroType = uo.MacroTypeInvalid
 			break
 		}
-		p.Offset = int(v) - 2
+		p.Offset = int(v) - 1
+	case 'C':
+		// Older clients send no book type when opening the magery book
+		p.MacroType = uo.MacroTypeOpenSpellbook
+		p.Offset = 1
+		if s := dc.NullString(in[1:]); s != "" {
+			v, err := strconv.ParseInt(s, 0, 32)
+			if err != nil {
+				p.MacroType = uo.MacroTypeInvalid
+				break
+			}
+			p.Offset = int(v)
+		}
 	case 'X':
 		p.MacroType = uo.MacroTypeOpenDoor
 	case rune(0xC7):
@@ -748,3 +911,55 @@ func newTextGUMPReply(in []byte) Packet {
 		Text:       string(in[9 : len(in)-1]),
 	}
 }
+
+// SecureTrade is sent by the client to cancel a secure trade or change the
+// state of the accept check box.
+type SecureTrade struct {
+	basePacket
+	Action    uo.SecureTradeAction // Requested action
+	Container uo.Serial            // Serial of the client's trade container
+	Accepted  bool                 // New accept state for the update action
+}
+
+func newSecureTrade(in []byte) Packet {
+	if len(in) < 9 {
+		return nil
+	}
+	return &SecureTrade{
+		basePacket: basePacket{id: 0x6F},
+		Action:     uo.SecureTradeAction(in[0]),
+		Container:  uo.Serial(dc.GetUint32(in[1:5])),
+		Accepted:   dc.GetUint32(in[5:9]) != 0,
+	}
+}
+
+// WarMode is sent by the client to request entering or leaving war mode.
+type WarMode struct {
+	basePacket
+	WarMode bool // If true the player wants to enter war mode
+}
+
+func newWarMode(in []byte) Packet {
+	return &WarMode{
+		basePacket: basePacket{id: 0x72},
+		WarMode:    in[0] != 0,
+	}
+}
+
+// SkillLockChange is sent by the client to change the lock state of a skill.
+type SkillLockChange struct {
+	basePacket
+	Skill uo.Skill     // Skill to change
+	Lock  uo.SkillLock // New lock state
+}
+
+func newSkillLockChange(in []byte) Packet {
+	if len(in) < 3 {
+		return newMalformedPacket(0x3A)
+	}
+	return &SkillLockChange{
+		basePacket: basePacket{id: 0x3A},
+		Skill:      uo.Skill(dc.GetUint16(in[0:2])),
+		Lock:       uo.SkillLock(in[2]),
+	}
+}
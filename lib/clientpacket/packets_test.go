@@ -1,7 +1,10 @@
 package clientpacket
 
 import (
+	"encoding/binary"
 	"testing"
+
+	"github.com/qbradq/sharduo/lib/uo"
 )
 
 func TestPackets(t *testing.T) {
@@ -40,6 +43,134 @@ func TestPackets(t *testing.T) {
 				}
 			}},
 		{0xBD, []byte{0xbd, 0x0, 0xb, 0x35, 0x2e, 0x30, 0x2e, 0x39, 0x2e, 0x31, 0x0}, nil},
+		// Drop request with grid index, 6.0.1.7 and later
+		{0x08, []byte{0x08, 0x40, 0x0, 0x0, 0x01, 0x0, 0x10, 0x0, 0x20, 0x0, 0x05, 0x40, 0x0, 0x0, 0x02},
+			func(t *testing.T, p Packet) {
+				dp := p.(*DropRequest)
+				if dp.Item != 0x40000001 || dp.Container != 0x40000002 || dp.Location.X != 0x10 || dp.Location.Y != 0x20 {
+					t.Fatal("Failed to decode drop request")
+				}
+			}},
+		// Drop request without grid index, before 6.0.1.7
+		{0x08, []byte{0x08, 0x40, 0x0, 0x0, 0x01, 0x0, 0x10, 0x0, 0x20, 0x0, 0x40, 0x0, 0x0, 0x02},
+			func(t *testing.T, p Packet) {
+				dp := p.(*DropRequest)
+				if dp.Item != 0x40000001 || dp.Container != 0x40000002 || dp.Location.X != 0x10 || dp.Location.Y != 0x20 {
+					t.Fatal("Failed to decode legacy drop request")
+				}
+			}},
+		// Character creation, three skills
+		{0x00, characterCreateData(0x00, 1),
+			func(t *testing.T, p Packet) {
+				cp := p.(*CharacterCreate)
+				if cp.Name != "Dolly" || !cp.Female || cp.Body != uo.BodyHumanFemale ||
+					cp.Strength != 60 || cp.Dexterity != 10 || cp.Intelligence != 10 ||
+					len(cp.Skills) != 3 || cp.Skills[2].Skill != uo.SkillMining || cp.Skills[2].Value != 20 ||
+					cp.SkinHue != 0x83EA || cp.HairStyle != 0x203C || cp.HairHue != 0x044E ||
+					cp.City != 3 || cp.Slot != 1 || cp.ShirtHue != 0x0123 || cp.PantsHue != 0x0456 {
+					t.Fatalf("Failed to decode character creation %+v", cp)
+				}
+			}},
+		// Character creation, four skills
+		{0xF8, characterCreateData(0xF8, 4),
+			func(t *testing.T, p Packet) {
+				cp := p.(*CharacterCreate)
+				if cp.Name != "Dolly" || cp.Female || cp.Body != uo.BodyElfMale ||
+					len(cp.Skills) != 4 || cp.Skills[3].Skill != uo.SkillTinkering || cp.Skills[3].Value != 30 ||
+					cp.SkinHue != 0x83EA || cp.BeardStyle != 0x203E || cp.BeardHue != 0x0455 ||
+					cp.City != 3 || cp.Slot != 1 || cp.ShirtHue != 0x0123 || cp.PantsHue != 0x0456 {
+					t.Fatalf("Failed to decode character creation %+v", cp)
+				}
+			}},
+		{0x83, []byte{0x83, 0x61, 0x73, 0x64, 0x66, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x02, 0x7f, 0x0, 0x0, 0x1},
+			func(t *testing.T, p Packet) {
+				dp := p.(*CharacterDelete)
+				if dp.Password != "asdf" || dp.Slot != 2 {
+					t.Fatal("Failed to decode character deletion")
+				}
+			}},
+		{0x05, []byte{0x05, 0x00, 0x00, 0x01, 0x02},
+			func(t *testing.T, p Packet) {
+				if p.(*AttackRequest).Defender != 0x00000102 {
+					t.Fatal("Failed to decode attack request")
+				}
+			}},
+		{0x72, []byte{0x72, 0x01, 0x00, 0x32, 0x00},
+			func(t *testing.T, p Packet) {
+				if !p.(*WarMode).WarMode {
+					t.Fatal("Failed to decode war mode request")
+				}
+			}},
+		{0x6F, []byte{0x6f, 0x0, 0x11, 0x2, 0x40, 0x0, 0x0, 0x2a, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0},
+			func(t *testing.T, p Packet) {
+				sp := p.(*SecureTrade)
+				if sp.Action != uo.SecureTradeActionUpdate || sp.Container != 0x4000002A || !sp.Accepted {
+					t.Fatal("Failed to decode secure trade")
+				}
+			}},
+		{0x3A, []byte{0x3a, 0x0, 0x6, 0x0, 0x2d, 0x2},
+			func(t *testing.T, p Packet) {
+				sp := p.(*SkillLockChange)
+				if sp.Skill != uo.SkillMining || sp.Lock != uo.SkillLockLocked {
+					t.Fatal("Failed to decode skill lock change")
+				}
+			}},
+		{0xBF, []byte{0xbf, 0x0, 0x7, 0x0, 0x1a, 0x1, 0x1},
+			func(t *testing.T, p Packet) {
+				sp := p.(*StatLockChange)
+				if sp.Stat != uo.StatDexterity || sp.Lock != uo.SkillLockDown {
+					t.Fatal("Failed to decode stat lock change")
+				}
+			}},
+		{0x12, []byte{0x12, 0x0, 0x9, 0x24, 0x32, 0x31, 0x20, 0x30, 0x0},
+			func(t *testing.T, p Packet) {
+				sp := p.(*MacroRequest)
+				if sp.MacroType != uo.MacroTypeSkill || sp.Offset != int(uo.SkillHiding) {
+					t.Fatal("Failed to decode skill use macro")
+				}
+			}},
+		{0x12, []byte{0x12, 0x0, 0x7, 0x56, 0x32, 0x32, 0x0},
+			func(t *testing.T, p Packet) {
+				sp := p.(*MacroRequest)
+				if sp.MacroType != uo.MacroTypeSpell || sp.Offset != int(uo.SpellTeleport) {
+					t.Fatal("Failed to decode spell cast macro")
+				}
+			}},
+		{0x12, []byte{0x12, 0x0, 0x6, 0x43, 0x31, 0x0},
+			func(t *testing.T, p Packet) {
+				sp := p.(*MacroRequest)
+				if sp.MacroType != uo.MacroTypeOpenSpellbook || sp.Offset != 1 {
+					t.Fatal("Failed to decode open spellbook macro")
+				}
+			}},
+		{0xBF, []byte{0xbf, 0x0, 0xd, 0x0, 0x1c, 0x0, 0x1, 0x40, 0x0, 0x0, 0x1, 0x0, 0x4},
+			func(t *testing.T, p Packet) {
+				sp := p.(*CastSpell)
+				if sp.Spellbook != 0x40000001 || sp.Spell != uo.SpellHeal {
+					t.Fatal("Failed to decode cast spell")
+				}
+			}},
+		{0xBF, []byte{0xbf, 0x0, 0xa, 0x0, 0x6, 0x1, 0x0, 0x0, 0x1, 0x2},
+			func(t *testing.T, p Packet) {
+				sp := p.(*PartyAddMember)
+				if sp.Serial != 0x00000102 {
+					t.Fatal("Failed to decode party add member")
+				}
+			}},
+		{0xBF, []byte{0xbf, 0x0, 0xe, 0x0, 0x6, 0x3, 0x0, 0x0, 0x1, 0x2, 0x0, 0x68, 0x0, 0x69, 0x0, 0x0},
+			func(t *testing.T, p Packet) {
+				sp := p.(*PartyMessage)
+				if sp.To != 0x00000102 || sp.Text != "hi" {
+					t.Fatal("Failed to decode party private message")
+				}
+			}},
+		{0xBF, []byte{0xbf, 0x0, 0xa, 0x0, 0x6, 0x9, 0x0, 0x0, 0x1, 0x2},
+			func(t *testing.T, p Packet) {
+				sp := p.(*PartyInvitationReply)
+				if sp.Leader != 0x00000102 || sp.Accepted {
+					t.Fatal("Failed to decode party invitation reply")
+				}
+			}},
 	}
 
 	for _, test := range tests {
@@ -55,3 +186,50 @@ func TestPackets(t *testing.T) {
 		}
 	}
 }
+
+func TestPacketLengthPerVersion(t *testing.T) {
+	var tests = []struct {
+		id     byte
+		v      uo.ClientVersion
+		length int
+	}{
+		{0x08, uo.NewClientVersion(5, 0, 9, 1), 14},
+		{0x08, uo.NewClientVersion(6, 0, 1, 7), 15},
+		{0x08, uo.NewClientVersion(7, 0, 15, 1), 15},
+		{0x08, uo.ClientVersionUnknown, 15},
+		{0x02, uo.NewClientVersion(5, 0, 9, 1), 7},
+	}
+	for _, test := range tests {
+		if l := PacketLength(test.id, test.v); l != test.length {
+			t.Fatalf("Packet 0x%02X version %s length %d wanted %d", test.id, test.v, l, test.length)
+		}
+	}
+}
+
+// characterCreateData returns the data of a character creation packet for
+// packet 0x00 or 0xF8.
+func characterCreateData(id byte, genderRace byte) []byte {
+	nSkills := 3
+	if id == 0xF8 {
+		nSkills = 4
+	}
+	d := make([]byte, InfoTable[id].Length)
+	d[0] = id
+	copy(d[10:40], "Dolly")
+	d[70] = genderRace
+	d[71] = 60
+	d[72] = 10
+	d[73] = 10
+	skills := []byte{byte(uo.SkillBlacksmithing), 50, byte(uo.SkillTactics), 30,
+		byte(uo.SkillMining), 20, byte(uo.SkillTinkering), 30}
+	copy(d[74:], skills[:nSkills*2])
+	o := 74 + nSkills*2
+	for i, v := range []uint16{0x83EA, 0x203C, 0x044E, 0x203E, 0x0455} {
+		binary.BigEndian.PutUint16(d[o+i*2:], v)
+	}
+	d[o+11] = 3
+	binary.BigEndian.PutUint32(d[o+12:], 1)
+	binary.BigEndian.PutUint16(d[o+20:], 0x0123)
+	binary.BigEndian.PutUint16(d[o+22:], 0x0456)
+	return d
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+
+	"github.com/qbradq/sharduo/lib/uo"
 )
 
 const maxInputBuffer = 64 * 1024
@@ -14,6 +16,8 @@ type Reader struct {
 	r     io.Reader
 	// Header is the connection header, or nil if it has not been read.
 	Header []byte
+	// Version of the client, used to determine packet lengths
+	Version uo.ClientVersion
 }
 
 // NewReader creates a new Reader for use.
@@ -42,8 +46,7 @@ func (r *Reader) Read() ([]byte, error) {
 		return nil, err
 	}
 
-	info := InfoTable[r.inbuf[0]]
-	length := info.Length
+	length := PacketLength(r.inbuf[0], r.Version)
 
 	// Packet body
 	if length == 0 { // Bad packet
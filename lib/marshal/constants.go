@@ -1,34 +1,74 @@
 package marshal
 
+import "fmt"
+
 // Segment represents a segment name within a file
 type Segment byte
 
 // Segment values
 const (
-	SegmentAccounts     Segment = 0
-	SegmentMap          Segment = 1
-	SegmentTimers       Segment = 2
-	SegmentWorld        Segment = 3
-	SegmentObjectList   Segment = 4
-	SegmentDeepStorage  Segment = 5
-	SegmentObjectsStart Segment = 0x7F // THIS MUST BE THE LAST ENTRY!
+	SegmentAccounts          Segment = 0
+	SegmentMap               Segment = 1 // Legacy ore map, no longer written
+	SegmentTimers            Segment = 2
+	SegmentWorld             Segment = 3
+	SegmentObjectList        Segment = 4
+	SegmentDeepStorage       Segment = 5
+	SegmentHarvestBanks      Segment = 6
+	SegmentFacets            Segment = 7
+	SegmentManifest          Segment = 8    // Directory saves only, see DirectorySaveBackend
+	SegmentFacetDeepStorage  Segment = 0x10 // Plus the facet number, Felucca uses SegmentDeepStorage
+	SegmentFacetHarvestBanks Segment = 0x20 // Plus the facet number, Felucca uses SegmentHarvestBanks
+	SegmentObjectsStart      Segment = 0x7F // THIS MUST BE THE LAST ENTRY!
 )
 
+// Names of the fixed segments
+var segmentNames = map[Segment]string{
+	SegmentAccounts:     "Accounts",
+	SegmentMap:          "Map",
+	SegmentTimers:       "Timers",
+	SegmentWorld:        "World",
+	SegmentObjectList:   "ObjectList",
+	SegmentDeepStorage:  "DeepStorage",
+	SegmentHarvestBanks: "HarvestBanks",
+	SegmentFacets:       "Facets",
+	SegmentManifest:     "Manifest",
+}
+
+// String returns the human-readable name of the segment.
+func (s Segment) String() string {
+	if n, found := segmentNames[s]; found {
+		return n
+	}
+	switch {
+	case s >= SegmentObjectsStart:
+		return fmt.Sprintf("Objects+%d", s-SegmentObjectsStart)
+	case s >= SegmentFacetHarvestBanks && s < SegmentFacetHarvestBanks+0x10:
+		return fmt.Sprintf("FacetHarvestBanks+%d", s-SegmentFacetHarvestBanks)
+	case s >= SegmentFacetDeepStorage && s < SegmentFacetDeepStorage+0x10:
+		return fmt.Sprintf("FacetDeepStorage+%d", s-SegmentFacetDeepStorage)
+	}
+	return fmt.Sprintf("Unknown(0x%02X)", byte(s))
+}
+
 // ObjectType are the concrete Go types in the game package.
 type ObjectType byte
 
 const (
-	ObjectTypeObject            ObjectType = 0  // BaseObject
-	ObjectTypeStatic            ObjectType = 1  // StaticItem
-	ObjectTypeItem              ObjectType = 2  // BaseItem
-	ObjectTypeWearable          ObjectType = 3  // BaseWearable
-	ObjectTypeWearableContainer ObjectType = 4  // WearableContainer
-	ObjectTypeWeapon            ObjectType = 5  // BaseWeapon
-	ObjectTypeContainer         ObjectType = 6  // BaseContainer
-	ObjectTypeMountItem         ObjectType = 7  // MountItem
-	ObjectTypeMobile            ObjectType = 8  // BaseMobile
-	ObjectTypeAccount           ObjectType = 9  // Account
-	ObjectTypeSpawner           ObjectType = 10 // Spawner
-	ObjectTypeDoor              ObjectType = 11 // Door
-	ObjectTypeCheck             ObjectType = 12 // Check
+	ObjectTypeObject               ObjectType = 0  // BaseObject
+	ObjectTypeStatic               ObjectType = 1  // StaticItem
+	ObjectTypeItem                 ObjectType = 2  // BaseItem
+	ObjectTypeWearable             ObjectType = 3  // BaseWearable
+	ObjectTypeWearableContainer    ObjectType = 4  // WearableContainer
+	ObjectTypeWeapon               ObjectType = 5  // BaseWeapon
+	ObjectTypeContainer            ObjectType = 6  // BaseContainer
+	ObjectTypeMountItem            ObjectType = 7  // MountItem
+	ObjectTypeMobile               ObjectType = 8  // BaseMobile
+	ObjectTypeAccount              ObjectType = 9  // Account
+	ObjectTypeSpawner              ObjectType = 10 // Spawner
+	ObjectTypeDoor                 ObjectType = 11 // Door
+	ObjectTypeCheck                ObjectType = 12 // Check
+	ObjectTypeSecureTradeContainer ObjectType = 13 // SecureTradeContainer
+	ObjectTypeCorpse               ObjectType = 14 // Corpse
+	ObjectTypeSpellbook            ObjectType = 15 // Spellbook
+	ObjectTypeRecallRune           ObjectType = 16 // RecallRune
 )
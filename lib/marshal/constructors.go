@@ -17,3 +17,34 @@ func RegisterCtor(ot ObjectType, ctor func() interface{}) {
 func Constructor(ot ObjectType) func() interface{} {
 	return ctors[ot]
 }
+
+// Upgrade upgrades an object that was just unmarshaled from the previous
+// version of its object type.
+type Upgrade func(Unmarshaler)
+
+// Collection of object upgrades by object type, the upgrade to version N is at
+// index N-1
+var upgrades = make(map[ObjectType][]Upgrade)
+
+// RegisterUpgrade registers the upgrade of an object type to the given version.
+// The current version of an object type is the highest version registered, or
+// 0 if none are. Versions must be registered in order starting with 1.
+//
+// The object version is written before every object. When an object of an
+// older version is unmarshaled all of the upgrades to the current version run
+// in order right after the object's Unmarshal method returns. Objects that
+// follow the object in the save have not been unmarshaled yet at that point.
+// Unmarshal methods must still use the version numbers they write for
+// themselves to decide which fields to read, the upgrades only adjust the
+// values read.
+func RegisterUpgrade(ot ObjectType, version uint32, fn Upgrade) {
+	if version != uint32(len(upgrades[ot]))+1 {
+		panic(fmt.Sprintf("upgrade of object type %d to version %d registered out of order", ot, version))
+	}
+	upgrades[ot] = append(upgrades[ot], fn)
+}
+
+// ObjectVersion returns the current version of an object type.
+func ObjectVersion(ot ObjectType) uint32 {
+	return uint32(len(upgrades[ot]))
+}
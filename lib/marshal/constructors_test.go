@@ -0,0 +1,97 @@
+package marshal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qbradq/sharduo/lib/template"
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// Object type of testObject
+const objectTypeTest ObjectType = 0xFE
+
+// testObject is a minimal object that records the upgrades applied to it.
+type testObject struct {
+	serial   uo.Serial
+	value    uint32
+	upgrades []uint32
+}
+
+func (o *testObject) Serial() uo.Serial                    { return o.serial }
+func (o *testObject) SetSerial(s uo.Serial)                { o.serial = s }
+func (o *testObject) ObjectType() ObjectType               { return objectTypeTest }
+func (o *testObject) TemplateName() string                 { return "TestObject" }
+func (o *testObject) Deserialize(*template.Template, bool) {}
+func (o *testObject) Marshal(s *TagFileSegment)            { s.PutInt(o.value) }
+func (o *testObject) Unmarshal(s *TagFileSegment)          { o.value = s.Int() }
+
+// readTestObject reads the first object of the world segment of the file.
+func readTestObject(t *testing.T, d []byte) *testObject {
+	tf, err := NewTagFile(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tf.Segment(SegmentWorld).Object().(*testObject)
+}
+
+// writeTestObject returns the output of a tag file holding the object within
+// the world segment. Format version 0 files have no object versions.
+func writeTestObject(t *testing.T, o *testObject, format uint32) []byte {
+	tf := &TagFile{format: format}
+	s := tf.Segment(SegmentWorld)
+	if format < 1 {
+		s.PutByte(byte(o.ObjectType()))
+		s.PutInt(uint32(o.Serial()))
+		s.PutString(o.TemplateName())
+		o.Marshal(s)
+	} else {
+		s.PutObject(o)
+	}
+	var buf bytes.Buffer
+	if err := tf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestObjectUpgrades(t *testing.T) {
+	// Only the template registry is needed
+	template.Initialize("none", "none", "none", nil, nil)
+	SetInsertFunction(func(interface{}) {})
+	RegisterCtor(objectTypeTest, func() interface{} { return &testObject{} })
+	defer delete(upgrades, objectTypeTest)
+	upgrade := func(v uint32) Upgrade {
+		return func(um Unmarshaler) {
+			o := um.(*testObject)
+			o.upgrades = append(o.upgrades, v)
+		}
+	}
+	v0 := writeTestObject(t, &testObject{serial: 1, value: 42}, FormatVersion)
+	unversioned := writeTestObject(t, &testObject{serial: 1, value: 42}, 0)
+	if o := readTestObject(t, v0); o.serial != 1 || o.value != 42 || len(o.upgrades) != 0 {
+		t.Fatalf("version 0 object read as %v", o)
+	}
+	RegisterUpgrade(objectTypeTest, 1, upgrade(1))
+	RegisterUpgrade(objectTypeTest, 2, upgrade(2))
+	if ObjectVersion(objectTypeTest) != 2 {
+		t.Fatalf("object version %d, expected 2", ObjectVersion(objectTypeTest))
+	}
+	for _, d := range [][]byte{v0, unversioned} {
+		o := readTestObject(t, d)
+		if o.value != 42 || len(o.upgrades) != 2 || o.upgrades[0] != 1 || o.upgrades[1] != 2 {
+			t.Fatalf("old object upgraded as %v", o)
+		}
+	}
+	v2 := writeTestObject(t, &testObject{serial: 2, value: 7}, FormatVersion)
+	if o := readTestObject(t, v2); o.value != 7 || len(o.upgrades) != 0 {
+		t.Fatalf("current object upgraded as %v", o)
+	}
+	// Upgrades must be registered in order
+	defer func() {
+		if recover() == nil {
+			t.Fatal("out of order upgrade registered")
+		}
+	}()
+	RegisterUpgrade(objectTypeTest, 4, upgrade(4))
+}
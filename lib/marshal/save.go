@@ -0,0 +1,457 @@
+package marshal
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SaveBackend persists tag files as saves on disk.
+type SaveBackend interface {
+	// Name returns the name of the backend as used in the configuration.
+	Name() string
+	// Extension returns the suffix of the paths of all saves of the backend.
+	Extension() string
+	// Write writes the tag file as the save at the path. The save is written
+	// atomically, the path never holds a partial save.
+	Write(p string, tf *TagFile, modTime time.Time) error
+	// Read reads and verifies the save at the path.
+	Read(p string) (*TagFile, error)
+}
+
+// All save backends
+var saveBackends = []SaveBackend{
+	&FlatSaveBackend{},
+	&DirectorySaveBackend{},
+}
+
+// NewSaveBackend returns the save backend with the given name, ignoring case.
+func NewSaveBackend(name string) (SaveBackend, error) {
+	for _, b := range saveBackends {
+		if strings.EqualFold(b.Name(), strings.TrimSpace(name)) {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown save type %s", name)
+}
+
+// SaveBackendFor returns the save backend that wrote the save at the path, or
+// nil if the path is not a save.
+func SaveBackendFor(p string) SaveBackend {
+	for _, b := range saveBackends {
+		if strings.HasSuffix(p, b.Extension()) {
+			return b
+		}
+	}
+	return nil
+}
+
+// ReadSave reads and verifies the save at the path with the backend that
+// wrote it.
+func ReadSave(p string) (*TagFile, error) {
+	b := SaveBackendFor(p)
+	if b == nil {
+		return nil, fmt.Errorf("%s is not a save", p)
+	}
+	return b.Read(p)
+}
+
+// ListSaves returns the paths of all saves of all backends within the
+// directory, newest first.
+func ListSaves(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type save struct {
+		p string
+		t time.Time
+	}
+	var saves []save
+	for _, e := range entries {
+		b := SaveBackendFor(e.Name())
+		if b == nil {
+			continue
+		}
+		if _, isDir := b.(*DirectorySaveBackend); isDir != e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		saves = append(saves, save{
+			p: filepath.Join(dir, e.Name()),
+			t: info.ModTime(),
+		})
+	}
+	sort.Slice(saves, func(i, j int) bool {
+		if saves[i].t.Equal(saves[j].t) {
+			return saves[i].p > saves[j].p
+		}
+		return saves[i].t.After(saves[j].t)
+	})
+	ret := make([]string, len(saves))
+	for i, s := range saves {
+		ret[i] = s.p
+	}
+	return ret
+}
+
+// CopySave copies the save at src to dst byte for byte, dst must end with the
+// extension of the backend of src. The save is neither read nor verified so
+// this is only limited by the speed of the disk. Any save already at dst is
+// replaced.
+func CopySave(src, dst string) error {
+	b := SaveBackendFor(src)
+	if b == nil {
+		return fmt.Errorf("%s is not a save", src)
+	}
+	if SaveBackendFor(dst) != b {
+		return fmt.Errorf("%s must end with %s", dst, b.Extension())
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	os.RemoveAll(tmp)
+	if info.IsDir() {
+		err = copyDir(src, tmp)
+	} else {
+		err = copyFile(src, tmp)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	os.RemoveAll(dst)
+	if err := os.Rename(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	os.Chtimes(dst, info.ModTime(), info.ModTime())
+	syncDir(filepath.Dir(dst))
+	return nil
+}
+
+// copyDir copies every file of the directory at src into a new directory at
+// dst, one segment file at a time.
+func copyDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0777); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if err := copyFile(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+			return err
+		}
+	}
+	syncDir(dst)
+	return nil
+}
+
+// copyFile copies the file at src to dst, syncing it to disk before returning.
+func copyFile(src, dst string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Sync(); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// syncDir syncs the directory so renames within it survive a crash. Not all
+// platforms support this so errors are ignored.
+func syncDir(p string) {
+	if d, err := os.Open(p); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// writeCompressed writes the tag file to the path as a gzip file, syncing it
+// to disk before returning.
+func writeCompressed(p string, tf *TagFile, modTime time.Time) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	zw.Name = filepath.Base(p)
+	zw.ModTime = modTime
+	zw.Comment = "ShardUO save file"
+	err = tf.Output(zw)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readCompressed reads and verifies the gzip'd tag file at the path.
+func readCompressed(p string) (*TagFile, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTagFileCorrupt, err)
+	}
+	// Reading to the end verifies the gzip checksum and length
+	d, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTagFileCorrupt, err)
+	}
+	return NewTagFile(d)
+}
+
+// FlatSaveBackend stores each save as a single gzip'd tag file.
+type FlatSaveBackend struct{}
+
+// Name implements the SaveBackend interface.
+func (b *FlatSaveBackend) Name() string { return "Flat" }
+
+// Extension implements the SaveBackend interface.
+func (b *FlatSaveBackend) Extension() string { return ".sav.gz" }
+
+// Write implements the SaveBackend interface. The file is written to a
+// temporary file first which is synced to disk and then renamed over the path.
+func (b *FlatSaveBackend) Write(p string, tf *TagFile, modTime time.Time) error {
+	tmp := p + ".tmp"
+	if err := writeCompressed(tmp, tf, modTime); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(p))
+	return nil
+}
+
+// Read implements the SaveBackend interface.
+func (b *FlatSaveBackend) Read(p string) (*TagFile, error) {
+	return readCompressed(p)
+}
+
+// Extension of the segment files within a directory save
+const segmentFileExtension = ".seg.gz"
+
+// DirectorySaveBackend stores each save as a directory holding one gzip'd tag
+// file per segment. Segments are written and read in parallel. Segment files
+// are named after the hexadecimal segment ID, like 03.seg.gz for the world
+// segment, and may be inspected on their own. The manifest segment lists the
+// ID, record count, length and checksum of every other segment so missing or
+// mismatched segment files are detected on read. Saves written before tag file
+// version 3 have no manifest.
+type DirectorySaveBackend struct{}
+
+// Length of one entry of the manifest segment
+const manifestEntryLength = 17
+
+// manifestEntry describes one segment of a directory save.
+type manifestEntry struct {
+	records  uint32 // Number of records
+	length   uint64 // Length of the segment data
+	checksum uint32 // CRC-32 (IEEE) of the segment data
+}
+
+// newManifest returns a new manifest segment describing the segments.
+func newManifest(segs []*TagFileSegment) *TagFileSegment {
+	m := NewTagFileSegment(SegmentManifest, nil)
+	for _, s := range segs {
+		m.PutByte(byte(s.id))
+		m.PutInt(s.records)
+		m.PutLong(uint64(s.buf.Len()))
+		m.PutInt(s.checksum())
+		m.IncrementRecordCount()
+	}
+	return m
+}
+
+// readManifest returns the segment descriptions of the manifest segment.
+func readManifest(m *TagFileSegment) (map[Segment]manifestEntry, error) {
+	if m.Length() != int(m.records)*manifestEntryLength {
+		return nil, fmt.Errorf("%w: manifest truncated", ErrTagFileCorrupt)
+	}
+	ret := make(map[Segment]manifestEntry, m.records)
+	for i := uint32(0); i < m.records; i++ {
+		id := Segment(m.Byte())
+		ret[id] = manifestEntry{
+			records:  m.Int(),
+			length:   m.Long(),
+			checksum: m.Int(),
+		}
+	}
+	return ret, nil
+}
+
+// Name implements the SaveBackend interface.
+func (b *DirectorySaveBackend) Name() string { return "Directory" }
+
+// Extension implements the SaveBackend interface.
+func (b *DirectorySaveBackend) Extension() string { return ".sav" }
+
+// Write implements the SaveBackend interface. The segments are written to a
+// temporary directory first which is renamed over the path once all segment
+// files are synced to disk.
+func (b *DirectorySaveBackend) Write(p string, tf *TagFile, modTime time.Time) error {
+	tmp := p + ".tmp"
+	os.RemoveAll(tmp)
+	if err := os.MkdirAll(tmp, 0777); err != nil {
+		return err
+	}
+	segs := append([]*TagFileSegment{newManifest(tf.segs)}, tf.segs...)
+	var wg sync.WaitGroup
+	errs := make([]error, len(segs))
+	for i, s := range segs {
+		wg.Add(1)
+		go func(i int, s *TagFileSegment) {
+			defer wg.Done()
+			stf := &TagFile{
+				segs:   []*TagFileSegment{s},
+				format: tf.format,
+			}
+			errs[i] = writeCompressed(filepath.Join(tmp,
+				fmt.Sprintf("%02X%s", byte(s.id), segmentFileExtension)), stf, modTime)
+		}(i, s)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			os.RemoveAll(tmp)
+			return err
+		}
+	}
+	syncDir(tmp)
+	// Directories can not be renamed over each other, the old save is only
+	// removed once the new one is complete
+	old := p + ".old"
+	if _, err := os.Stat(p); err == nil {
+		os.RemoveAll(old)
+		if err := os.Rename(p, old); err != nil {
+			os.RemoveAll(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	os.RemoveAll(old)
+	syncDir(filepath.Dir(p))
+	return nil
+}
+
+// Read implements the SaveBackend interface.
+func (b *DirectorySaveBackend) Read(p string) (*TagFile, error) {
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), segmentFileExtension) {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: %s holds no segments", ErrTagFileCorrupt, p)
+	}
+	sort.Strings(names)
+	var wg sync.WaitGroup
+	files := make([]*TagFile, len(names))
+	errs := make([]error, len(names))
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			files[i], errs[i] = readCompressed(filepath.Join(p, name))
+		}(i, name)
+	}
+	wg.Wait()
+	ret := &TagFile{}
+	var manifest *TagFileSegment
+	for i, name := range names {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("segment file %s: %w", name, errs[i])
+		}
+		if i == 0 {
+			ret.format = files[i].format
+			ret.version = files[i].version
+		} else if files[i].format != ret.format {
+			return nil, fmt.Errorf("%w: segment file %s has save format version %d, expected %d",
+				ErrTagFileCorrupt, name, files[i].format, ret.format)
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentFileExtension), 16, 8)
+		if err != nil || len(files[i].segs) != 1 || files[i].segs[0].id != Segment(id) {
+			return nil, fmt.Errorf("%w: segment file %s does not hold segment %s",
+				ErrTagFileCorrupt, name, strings.TrimSuffix(name, segmentFileExtension))
+		}
+		s := files[i].segs[0]
+		if s.id == SegmentManifest {
+			manifest = s
+			continue
+		}
+		s.parent = ret
+		ret.segs = append(ret.segs, s)
+	}
+	if manifest == nil {
+		if ret.version >= 3 {
+			return nil, fmt.Errorf("%w: %s has no manifest", ErrTagFileCorrupt, p)
+		}
+		return ret, nil
+	}
+	listed, err := readManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range ret.segs {
+		e, found := listed[s.id]
+		if !found {
+			return nil, fmt.Errorf("%w: segment %s is not in the manifest", ErrTagFileCorrupt, s.id)
+		}
+		if e.records != s.records || e.length != uint64(s.buf.Len()) || e.checksum != s.checksum() {
+			return nil, fmt.Errorf("%w: segment %s does not match the manifest", ErrTagFileCorrupt, s.id)
+		}
+		delete(listed, s.id)
+	}
+	for id := range listed {
+		return nil, fmt.Errorf("%w: segment %s is missing", ErrTagFileCorrupt, id)
+	}
+	return ret, nil
+}
@@ -0,0 +1,145 @@
+package marshal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveBackends(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"Flat", "directory"} {
+		b, err := NewSaveBackend(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tf, _ := NewTagFile(buildTagFile(t))
+		p := filepath.Join(dir, "save"+b.Extension())
+		// Writing twice replaces the first save
+		for j := 0; j < 2; j++ {
+			if err := b.Write(p, tf, time.Now()); err != nil {
+				t.Fatalf("%s: %s", b.Name(), err)
+			}
+		}
+		// Move the save back in time so the saves are ordered
+		old := time.Now().Add(time.Duration(i-10) * time.Hour)
+		os.Chtimes(p, old, old)
+		rtf, err := ReadSave(p)
+		if err != nil {
+			t.Fatalf("%s: %s", b.Name(), err)
+		}
+		s := rtf.Segment(SegmentWorld)
+		if s.RecordCount() != 1 || s.Int() != 42 || s.String() != "hello" {
+			t.Fatalf("%s: world segment read incorrectly", b.Name())
+		}
+		if s := rtf.Segment(SegmentAccounts); s.RecordCount() != 2 || s.Long() != 7 {
+			t.Fatalf("%s: accounts segment read incorrectly", b.Name())
+		}
+	}
+	// Temporary files are never listed
+	os.WriteFile(filepath.Join(dir, "partial.sav.gz.tmp"), nil, 0666)
+	os.MkdirAll(filepath.Join(dir, "partial.sav.tmp"), 0777)
+	saves := ListSaves(dir)
+	if len(saves) != 2 || filepath.Base(saves[0]) != "save.sav" ||
+		filepath.Base(saves[1]) != "save.sav.gz" {
+		t.Fatalf("saves listed as %v", saves)
+	}
+	// A damaged segment file fails the whole directory save
+	seg := filepath.Join(dir, "save.sav", "00"+segmentFileExtension)
+	d, _ := os.ReadFile(seg)
+	os.WriteFile(seg, d[:len(d)-4], 0666)
+	if _, err := ReadSave(saves[0]); !errors.Is(err, ErrTagFileCorrupt) {
+		t.Fatalf("damaged segment file returned %v", err)
+	}
+	if err := CopySave(saves[1], filepath.Join(dir, "copy.sav.gz")); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := os.ReadFile(saves[1])
+	b, _ := os.ReadFile(filepath.Join(dir, "copy.sav.gz"))
+	if len(a) == 0 || !bytes.Equal(a, b) {
+		t.Fatal("save not copied byte for byte")
+	}
+	// Saves are copied without being verified, the damage is copied as well
+	if err := CopySave(saves[0], filepath.Join(dir, "copy.sav")); err != nil {
+		t.Fatal(err)
+	}
+	a, _ = os.ReadFile(seg)
+	b, _ = os.ReadFile(filepath.Join(dir, "copy.sav", "00"+segmentFileExtension))
+	if len(a) == 0 || !bytes.Equal(a, b) {
+		t.Fatal("segment file not copied byte for byte")
+	}
+	if _, err := ReadSave(filepath.Join(dir, "copy.sav")); !errors.Is(err, ErrTagFileCorrupt) {
+		t.Fatalf("copy of a damaged save returned %v", err)
+	}
+	if err := CopySave(saves[1], filepath.Join(dir, "copy2.sav")); err == nil {
+		t.Fatal("save copied to the extension of another backend")
+	}
+	if _, err := NewSaveBackend("Database"); err == nil {
+		t.Fatal("unknown save backend created")
+	}
+}
+
+func TestDirectorySaveManifest(t *testing.T) {
+	dir := t.TempDir()
+	b := &DirectorySaveBackend{}
+	write := func(name string, d []byte) string {
+		tf, _ := NewTagFile(d)
+		p := filepath.Join(dir, name+b.Extension())
+		if err := b.Write(p, tf, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	p := write("save", buildTagFile(t))
+	manifest := filepath.Join(p, fmt.Sprintf("%02X%s", byte(SegmentManifest), segmentFileExtension))
+	if _, err := os.Stat(manifest); err != nil {
+		t.Fatal(err)
+	}
+	tf, err := b.Read(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range tf.Segments() {
+		if s.ID() == SegmentManifest {
+			t.Fatal("manifest segment returned as save data")
+		}
+	}
+	// Segment files from another save do not match the manifest
+	other, _ := NewTagFile(nil)
+	other.Segment(SegmentWorld).PutInt(43)
+	other.Segment(SegmentWorld).IncrementRecordCount()
+	other.Segment(SegmentAccounts).PutLong(7)
+	var buf bytes.Buffer
+	other.Output(&buf)
+	op := write("other", buf.Bytes())
+	world := filepath.Join(p, "03"+segmentFileExtension)
+	good, _ := os.ReadFile(world)
+	bad, _ := os.ReadFile(filepath.Join(op, "03"+segmentFileExtension))
+	os.WriteFile(world, bad, 0666)
+	if _, err := b.Read(p); !errors.Is(err, ErrTagFileCorrupt) {
+		t.Errorf("mismatched segment file returned %v", err)
+	}
+	os.WriteFile(world, good, 0666)
+	// Missing segment files
+	os.Remove(world)
+	if _, err := b.Read(p); !errors.Is(err, ErrTagFileCorrupt) {
+		t.Errorf("missing segment file returned %v", err)
+	}
+	os.WriteFile(world, good, 0666)
+	// Extra segment files
+	extra := filepath.Join(p, "07"+segmentFileExtension)
+	os.WriteFile(extra, good, 0666)
+	if _, err := b.Read(p); !errors.Is(err, ErrTagFileCorrupt) {
+		t.Errorf("extra segment file returned %v", err)
+	}
+	os.Remove(extra)
+	// Missing manifest
+	os.Remove(manifest)
+	if _, err := b.Read(p); !errors.Is(err, ErrTagFileCorrupt) {
+		t.Errorf("missing manifest returned %v", err)
+	}
+}
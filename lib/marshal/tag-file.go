@@ -3,7 +3,9 @@ package marshal
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"math"
 
@@ -21,6 +23,8 @@ import (
 //
 // File Format:
 // MagicString            uint64          A fixed value used to identify this as a ShardUO TagFile: 0x6BB50D00B87E33A4
+// Version                uint32          Version of the file format, see tagFileVersion
+// FormatVersion          uint32          Version of the save format of the segment data, version 2 and later
 // SegmentCount           uint8           Number of segments in the file
 // Headers                []SegmentHeader Segment headers
 // Blob                   []byte          Raw segment data
@@ -30,27 +34,100 @@ import (
 // Offset                 uint64          Offset from the beginning of the file where the raw segment data starts
 // Length                 uint64          Length of the raw segment data
 // Records                uint32          Number of records in the segment
+// Checksum               uint32          CRC-32 (IEEE) of the raw segment data, version 1 and later
 type TagFile struct {
-	segs []*TagFileSegment // Segments
+	segs    []*TagFileSegment // Segments
+	format  uint32            // Save format version of the segment data
+	version uint32            // Version of the file format the file was read from
+}
+
+// Magic number at the start of every tag file
+const tagFileMagic uint64 = 0x6BB50D00B87E33A4
+
+// Current version of the tag file format
+//
+// File Versions:
+// 0                      Initial version
+// 1                      Segment checksums
+// 2                      Save format version
+// 3                      No layout change, directory saves hold a manifest
+const tagFileVersion uint32 = 3
+
+// FormatVersion is the current version of the save format, the layout of the
+// data within the segments. Files written before the save format was
+// versioned are format version 0. This must be incremented whenever the layout
+// of segments changes in a way that object versions can not describe, see
+// RegisterUpgrade.
+//
+// Format Versions:
+// 0                      Objects have no object version
+// 1                      Objects are preceded by their object version
+const FormatVersion uint32 = 1
+
+// ErrTagFileCorrupt is wrapped by all errors returned for tag files that are
+// truncated or otherwise damaged.
+var ErrTagFileCorrupt = errors.New("tag file corrupt")
+
+// tagFileFileHeaderLength returns the length of the file header for the file
+// format version.
+func tagFileFileHeaderLength(version uint32) int {
+	if version < 2 {
+		return 13
+	}
+	return 17
+}
+
+// tagFileHeaderLength returns the length of one segment header for the file
+// format version.
+func tagFileHeaderLength(version uint32) int {
+	if version < 1 {
+		return 21
+	}
+	return 25
 }
 
 // NewTagFile creates a new TagFile object from the data given. The data slice
-// may be nil, in which case an empty TagFile object is returned ready for
-// write operations.
-func NewTagFile(d []byte) *TagFile {
-	t := &TagFile{}
+// may be nil, in which case an empty TagFile object of the current save format
+// is returned ready for write operations. An error wrapping ErrTagFileCorrupt
+// is returned if the data is truncated or a segment checksum does not match.
+func NewTagFile(d []byte) (*TagFile, error) {
+	t := &TagFile{
+		format:  FormatVersion,
+		version: tagFileVersion,
+	}
 	if d == nil {
-		return t
+		return t, nil
 	}
 	// Header data
+	if len(d) < 13 {
+		return nil, fmt.Errorf("%w: header truncated", ErrTagFileCorrupt)
+	}
 	magic := binary.LittleEndian.Uint64(d[0:8])
-	if magic != 0x6BB50D00B87E33A4 {
-		panic("tag file does not have correct magic number")
+	if magic != tagFileMagic {
+		return nil, fmt.Errorf("%w: incorrect magic number", ErrTagFileCorrupt)
+	}
+	version := binary.LittleEndian.Uint32(d[8:12])
+	if version > tagFileVersion {
+		return nil, fmt.Errorf("unsupported tag file version %d", version)
+	}
+	ofs := tagFileFileHeaderLength(version)
+	if len(d) < ofs {
+		return nil, fmt.Errorf("%w: header truncated", ErrTagFileCorrupt)
+	}
+	t.version = version
+	t.format = 0
+	if version >= 2 {
+		t.format = binary.LittleEndian.Uint32(d[12:16])
+	}
+	if t.format > FormatVersion {
+		return nil, fmt.Errorf("unsupported save format version %d", t.format)
+	}
+	nSegments := int(d[ofs-1])
+	hl := tagFileHeaderLength(version)
+	if len(d) < ofs+nSegments*hl {
+		return nil, fmt.Errorf("%w: segment headers truncated", ErrTagFileCorrupt)
 	}
-	_ = int(binary.LittleEndian.Uint32(d[8:12]))
-	nSegments := int(d[12])
 	// Load segments
-	ofs := 13
 	t.segs = make([]*TagFileSegment, nSegments)
 	for i := range t.segs {
 		s := NewTagFileSegment(0, t)
@@ -59,10 +136,17 @@ func NewTagFile(d []byte) *TagFile {
 		segmentOffset := binary.LittleEndian.Uint64(d[ofs+1 : ofs+9])
 		segmentLength := binary.LittleEndian.Uint64(d[ofs+9 : ofs+17])
 		s.records = binary.LittleEndian.Uint32(d[ofs+17 : ofs+21])
-		s.buf = bytes.NewBuffer(d[segmentOffset : segmentOffset+segmentLength])
-		ofs += 21
+		if segmentOffset > uint64(len(d)) || segmentLength > uint64(len(d))-segmentOffset {
+			return nil, fmt.Errorf("%w: segment %d truncated", ErrTagFileCorrupt, s.id)
+		}
+		sd := d[segmentOffset : segmentOffset+segmentLength]
+		if version >= 1 && crc32.ChecksumIEEE(sd) != binary.LittleEndian.Uint32(d[ofs+21:ofs+25]) {
+			return nil, fmt.Errorf("%w: segment %d checksum mismatch", ErrTagFileCorrupt, s.id)
+		}
+		s.buf = bytes.NewBuffer(sd)
+		ofs += hl
 	}
-	return t
+	return t, nil
 }
 
 // Close releases internal memory and MUST be called!
@@ -73,33 +157,46 @@ func (f *TagFile) Close() {
 	}
 }
 
-// Output writes the file to the writer in TagFile format.
-func (f *TagFile) Output(w io.Writer) {
-	buf := make([]byte, 21)
+// Output writes the file to the writer in TagFile format. The first write
+// error encountered is returned.
+func (f *TagFile) Output(w io.Writer) error {
+	hl := tagFileHeaderLength(tagFileVersion)
+	buf := make([]byte, hl)
+	var err error
+	write := func(d []byte) {
+		if err == nil {
+			_, err = w.Write(d)
+		}
+	}
 	// Write file header
-	binary.LittleEndian.PutUint64(buf[0:8], 0x6BB50D00B87E33A4) // Magic string
-	w.Write(buf[0:8])
+	binary.LittleEndian.PutUint64(buf[0:8], tagFileMagic) // Magic string
+	write(buf[0:8])
 	// Write file version
-	binary.LittleEndian.PutUint32(buf[0:4], 0) // Version
-	w.Write(buf[0:4])
+	binary.LittleEndian.PutUint32(buf[0:4], tagFileVersion) // Version
+	write(buf[0:4])
+	// Write save format version
+	binary.LittleEndian.PutUint32(buf[0:4], f.format) // Format version
+	write(buf[0:4])
 	// Number of segments
 	buf[0] = byte(len(f.segs))
-	w.Write(buf[0:1])
+	write(buf[0:1])
 	// Write segment headers
-	var ofs uint64 = 13 + 21*(uint64(len(f.segs)))
+	var ofs uint64 = uint64(tagFileFileHeaderLength(tagFileVersion) + hl*len(f.segs))
 	// Output segments
 	for _, seg := range f.segs {
-		buf[0] = byte(seg.id)                                           // Segment ID
-		binary.LittleEndian.PutUint64(buf[1:9], ofs)                    // File offset
-		binary.LittleEndian.PutUint64(buf[9:17], uint64(seg.buf.Len())) // Length
-		binary.LittleEndian.PutUint32(buf[17:21], seg.records)          // Record count
-		w.Write(buf[0:21])
+		buf[0] = byte(seg.id)                                                          // Segment ID
+		binary.LittleEndian.PutUint64(buf[1:9], ofs)                                   // File offset
+		binary.LittleEndian.PutUint64(buf[9:17], uint64(seg.buf.Len()))                // Length
+		binary.LittleEndian.PutUint32(buf[17:21], seg.records)                         // Record count
+		binary.LittleEndian.PutUint32(buf[21:25], crc32.ChecksumIEEE(seg.buf.Bytes())) // Checksum
+		write(buf[0:hl])
 		ofs += uint64(seg.buf.Len())
 	}
 	// Segment data
 	for _, seg := range f.segs {
-		w.Write(seg.buf.Bytes())
+		write(seg.buf.Bytes())
 	}
+	return err
 }
 
 // Segment returns the named segment. A new, empty segment is created if needed.
@@ -114,6 +211,14 @@ func (f *TagFile) Segment(which Segment) *TagFileSegment {
 	return s
 }
 
+// FormatVersion returns the save format version of the segment data.
+func (f *TagFile) FormatVersion() uint32 { return f.format }
+
+// Segments returns all segments of the file in the order they are stored.
+func (f *TagFile) Segments() []*TagFileSegment {
+	return f.segs
+}
+
 // TagFileSegment manages a single segment in the file.
 type TagFileSegment struct {
 	parent  *TagFile      // Parent tag file
@@ -133,6 +238,24 @@ func NewTagFileSegment(id Segment, parent *TagFile) *TagFileSegment {
 	}
 }
 
+// ID returns the ID of the segment.
+func (s *TagFileSegment) ID() Segment { return s.id }
+
+// Length returns the number of bytes of the segment that remain unread.
+func (s *TagFileSegment) Length() int { return s.buf.Len() }
+
+// checksum returns the CRC-32 (IEEE) of the bytes of the segment that remain
+// unread.
+func (s *TagFileSegment) checksum() uint32 { return crc32.ChecksumIEEE(s.buf.Bytes()) }
+
+// Grow grows the buffer of the segment to hold at least n more bytes without
+// another allocation.
+func (s *TagFileSegment) Grow(n int) {
+	if n > 0 {
+		s.buf.Grow(n)
+	}
+}
+
 // IsEmpty returns true if the segment contains no data.
 func (s *TagFileSegment) IsEmpty() bool { return s.buf.Len() == 0 }
 
@@ -276,6 +399,7 @@ func (s *TagFileSegment) PutBounds(b uo.Bounds) {
 // PutObject writes an object to the segment.
 func (s *TagFileSegment) PutObject(o Marshaler) {
 	s.PutByte(byte(o.ObjectType()))
+	s.PutInt(ObjectVersion(o.ObjectType()))
 	s.PutInt(uint32(o.Serial()))
 	s.PutString(o.TemplateName())
 	o.Marshal(s)
@@ -415,10 +539,18 @@ func (s *TagFileSegment) Bounds() uo.Bounds {
 }
 
 // Object returns the next object encoded into the segment which must support
-// the Unmarshaler interface. The object will be fully unmarshaled upon return.
+// the Unmarshaler interface. The object will be fully unmarshaled and upgraded
+// to the current version of its object type upon return.
 func (s *TagFileSegment) Object() Unmarshaler {
 	// Object construction
 	ot := ObjectType(s.Byte())
+	var version uint32
+	if s.parent.format >= 1 {
+		version = s.Int()
+	}
+	if version > ObjectVersion(ot) {
+		panic(fmt.Sprintf("object type code 0x%02X version %d is newer than version %d", ot, version, ObjectVersion(ot)))
+	}
 	ctor := Constructor(ot)
 	if ctor == nil {
 		panic(fmt.Sprintf("no constructor found for object type code 0x%02X", ot))
@@ -438,5 +570,9 @@ func (s *TagFileSegment) Object() Unmarshaler {
 	um.Deserialize(t, false)
 	// Data unmarshaling
 	um.Unmarshal(s)
+	// Object upgrades
+	for _, fn := range upgrades[ot][version:] {
+		fn(um)
+	}
 	return um
 }
@@ -0,0 +1,142 @@
+package marshal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// buildTagFile returns the output of a small tag file with two segments.
+func buildTagFile(t *testing.T) []byte {
+	tf, _ := NewTagFile(nil)
+	s := tf.Segment(SegmentWorld)
+	s.PutInt(42)
+	s.PutString("hello")
+	s.IncrementRecordCount()
+	s = tf.Segment(SegmentAccounts)
+	s.PutLong(7)
+	s.IncrementRecordCount()
+	s.IncrementRecordCount()
+	var buf bytes.Buffer
+	if err := tf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTagFileRoundTrip(t *testing.T) {
+	tf, err := NewTagFile(buildTagFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tf.Segment(SegmentWorld)
+	if s.RecordCount() != 1 || s.Int() != 42 || s.String() != "hello" {
+		t.Fatal("world segment read incorrectly")
+	}
+	s = tf.Segment(SegmentAccounts)
+	if s.RecordCount() != 2 || s.Long() != 7 {
+		t.Fatal("accounts segment read incorrectly")
+	}
+}
+
+func TestTagFileCorruption(t *testing.T) {
+	d := buildTagFile(t)
+	// Flipped bit within the segment data
+	bad := append([]byte(nil), d...)
+	bad[len(bad)-1] ^= 0x10
+	if _, err := NewTagFile(bad); !errors.Is(err, ErrTagFileCorrupt) {
+		t.Fatalf("corrupt segment data returned %v", err)
+	}
+	// Truncation anywhere in the file
+	for _, l := range []int{0, 5, 13, 20, len(d) - 1} {
+		if _, err := NewTagFile(d[:l]); !errors.Is(err, ErrTagFileCorrupt) {
+			t.Fatalf("file truncated to %d bytes returned %v", l, err)
+		}
+	}
+	// Wrong magic number
+	bad = append([]byte(nil), d...)
+	bad[0] = 0
+	if _, err := NewTagFile(bad); !errors.Is(err, ErrTagFileCorrupt) {
+		t.Fatalf("wrong magic number returned %v", err)
+	}
+}
+
+func TestTagFileVersion0(t *testing.T) {
+	// Version 0 files have no segment checksums
+	data := []byte{42, 0, 0, 0}
+	d := make([]byte, 13+21)
+	binary.LittleEndian.PutUint64(d[0:8], tagFileMagic)
+	d[12] = 1
+	d[13] = byte(SegmentWorld)
+	binary.LittleEndian.PutUint64(d[14:22], uint64(len(d)))
+	binary.LittleEndian.PutUint64(d[22:30], uint64(len(data)))
+	binary.LittleEndian.PutUint32(d[30:34], 1)
+	d = append(d, data...)
+	tf, err := NewTagFile(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := tf.Segment(SegmentWorld).Int(); v != 42 {
+		t.Fatalf("version 0 segment read as %d", v)
+	}
+}
+
+func TestTagFileSegments(t *testing.T) {
+	tf, err := NewTagFile(buildTagFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	segs := tf.Segments()
+	if len(segs) != 2 || segs[0].ID() != SegmentWorld || segs[1].ID() != SegmentAccounts {
+		t.Fatal("segments listed incorrectly")
+	}
+	if segs[0].Length() != 4+1+5 || segs[1].Length() != 8 {
+		t.Fatal("segment lengths incorrect")
+	}
+}
+
+func TestSegmentString(t *testing.T) {
+	for s, n := range map[Segment]string{
+		SegmentWorld:                 "World",
+		SegmentFacetDeepStorage + 2:  "FacetDeepStorage+2",
+		SegmentFacetHarvestBanks + 1: "FacetHarvestBanks+1",
+		SegmentObjectsStart + 12:     "Objects+12",
+		0x50:                         "Unknown(0x50)",
+	} {
+		if s.String() != n {
+			t.Errorf("segment 0x%02X named %s, expected %s", byte(s), s.String(), n)
+		}
+	}
+}
+
+func TestTagFileFormatVersion(t *testing.T) {
+	d := buildTagFile(t)
+	tf, err := NewTagFile(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tf.FormatVersion() != FormatVersion {
+		t.Fatalf("format version %d, expected %d", tf.FormatVersion(), FormatVersion)
+	}
+	// Newer save formats can not be read
+	bad := append([]byte(nil), d...)
+	binary.LittleEndian.PutUint32(bad[12:16], FormatVersion+1)
+	if _, err := NewTagFile(bad); err == nil {
+		t.Fatal("newer save format read")
+	}
+	// Version 1 files have no format version
+	v1 := append([]byte(nil), d[:12]...)
+	binary.LittleEndian.PutUint32(v1[8:12], 1)
+	v1 = append(v1, d[16:]...)
+	for i := 0; i < int(v1[12]); i++ {
+		h := v1[13+i*25:]
+		binary.LittleEndian.PutUint64(h[1:9], binary.LittleEndian.Uint64(h[1:9])-4)
+	}
+	if tf, err = NewTagFile(v1); err != nil {
+		t.Fatal(err)
+	}
+	if tf.FormatVersion() != 0 || tf.Segment(SegmentWorld).Int() != 42 {
+		t.Fatal("version 1 file read incorrectly")
+	}
+}
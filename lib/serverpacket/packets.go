@@ -11,6 +11,7 @@ import (
 	"net"
 	"strings"
 	"time"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	dc "github.com/qbradq/sharduo/lib/dataconv"
@@ -32,6 +33,15 @@ func putHue(w io.Writer, hue uo.Hue) {
 	}
 }
 
+// Utility function to write a boolean value as a 32-bit integer.
+func putBool32(w io.Writer, v bool) {
+	if v {
+		dc.PutUint32(w, 1)
+	} else {
+		dc.PutUint32(w, 0)
+	}
+}
+
 // ServerListEntry represents one entry in the server list.
 type ServerListEntry struct {
 	// Name of the server
@@ -90,16 +100,40 @@ func (p *ConnectToGameServer) Write(w io.Writer) {
 	dc.PutUint32(w, uint32(p.Key))
 }
 
+// StartingCity describes one of the cities new characters may start in.
+type StartingCity struct {
+	// Name of the city
+	Name string
+	// Name of the building the character starts in
+	Building string
+	// Location the character starts at
+	Location uo.Location
+	// Facet the character starts on
+	Facet uo.Facet
+	// Cliloc of the description of the city
+	Description uo.Cliloc
+}
+
 // CharacterList is sent on game server login and lists all characters on the
 // account as well as the new character starting locations.
 type CharacterList struct {
 	// Names of all of the characters, empty string for open slots.
 	Names []string
+	// Starting cities available to new characters
+	Cities []*StartingCity
+	// Client version the packet is being sent to
+	Version uo.ClientVersion
 }
 
 // Write implements the Packet interface.
 func (p *CharacterList) Write(w io.Writer) {
+	extended := !p.Version.Before(uo.ClientVersionExtendedCities)
 	length := 4 + len(p.Names)*60 + 1 + 4
+	if extended {
+		length += len(p.Cities)*89 + 2
+	} else {
+		length += len(p.Cities) * 63
+	}
 	dc.PutByte(w, 0xa9)               // ID
 	dc.PutUint16(w, uint16(length))   // Length
 	dc.PutByte(w, byte(len(p.Names))) // Number of character slots
@@ -107,9 +141,67 @@ func (p *CharacterList) Write(w io.Writer) {
 		dc.PutStringN(w, name, 30)
 		dc.Pad(w, 30)
 	}
-	dc.PutByte(w, 0)
-	// Flags
-	dc.PutUint32(w, 0x0000003c)
+	dc.PutByte(w, byte(len(p.Cities))) // Number of starting cities
+	for i, c := range p.Cities {
+		dc.PutByte(w, byte(i))
+		if extended {
+			dc.PutStringN(w, c.Name, 32)
+			dc.PutStringN(w, c.Building, 32)
+			dc.PutUint32(w, uint32(c.Location.X))
+			dc.PutUint32(w, uint32(c.Location.Y))
+			dc.PutUint32(w, uint32(int32(c.Location.Z)))
+			dc.PutUint32(w, uint32(c.Facet))
+			dc.PutUint32(w, uint32(c.Description))
+			dc.PutUint32(w, 0)
+		} else {
+			dc.PutStringN(w, c.Name, 31)
+			dc.PutStringN(w, c.Building, 31)
+		}
+	}
+	// Flags, context menus and AOS features are always enabled
+	flags := uint32(0x00000028)
+	switch {
+	case len(p.Names) <= 1:
+		flags |= 0x00000014 // One character slot
+	case len(p.Names) == 6:
+		flags |= 0x00000040 // Sixth character slot
+	case len(p.Names) >= 7:
+		flags |= 0x00001040 // Sixth and seventh character slots
+	}
+	dc.PutUint32(w, flags)
+	if extended {
+		dc.PutUint16(w, 0xFFFF) // Last character slot played, none
+	}
+}
+
+// CharacterListUpdate is sent after a character is deleted to update the
+// list of characters on the account.
+type CharacterListUpdate struct {
+	// Names of all of the characters, empty string for open slots.
+	Names []string
+}
+
+// Write implements the Packet interface.
+func (p *CharacterListUpdate) Write(w io.Writer) {
+	dc.PutByte(w, 0x86)                        // ID
+	dc.PutUint16(w, uint16(4+len(p.Names)*60)) // Length
+	dc.PutByte(w, byte(len(p.Names)))          // Number of character slots
+	for _, name := range p.Names {
+		dc.PutStringN(w, name, 30)
+		dc.Pad(w, 30)
+	}
+}
+
+// CharacterDeleteDenied is sent when a character deletion request is refused.
+type CharacterDeleteDenied struct {
+	// The reason for the denial
+	Reason uo.CharacterDeleteDeniedReason
+}
+
+// Write implements the Packet interface.
+func (p *CharacterDeleteDenied) Write(w io.Writer) {
+	dc.PutByte(w, 0x85) // ID
+	dc.PutByte(w, byte(p.Reason))
 }
 
 // LoginComplete is sent after character login is successful.
@@ -418,10 +510,17 @@ type ObjectInfo struct {
 	// when this is false the client may still treat the object as movable
 	// depending on the contents of the tile definition for the graphic.
 	Movable bool
+	// Version of the receiving client, clients older than 7.0.0.0 get packet
+	// 0x1A instead of 0xF3
+	Version uo.ClientVersion
 }
 
 // Write implements the Packet interface.
 func (p *ObjectInfo) Write(w io.Writer) {
+	if p.Version.Before(uo.ClientVersionWorldObjectSA) {
+		p.writeLegacy(w)
+		return
+	}
 	dc.PutByte(w, 0xF3)     // Packet ID
 	dc.PutUint16(w, 0x0001) // Always 0x0001 on OSI according to POL
 	// Data type
@@ -467,6 +566,88 @@ func (p *ObjectInfo) Write(w io.Writer) {
 	dc.Pad(w, 2)
 }
 
+// writeLegacy writes the pre-7.0.0.0 packet 0x1A with all of its optional
+// fields.
+func (p *ObjectInfo) writeLegacy(w io.Writer) {
+	var n int
+	if p.Amount < int(uo.MinStackAmount) {
+		n = int(uo.MinStackAmount)
+	} else if p.Amount > int(uo.MaxStackAmount) {
+		n = int(uo.MaxStackAmount)
+	} else {
+		n = p.Amount
+	}
+	hasAmount := n > 1 && !p.IsMulti
+	hasIncrement := p.GraphicIncrement != 0 && !p.IsMulti
+	hasFacing := p.Facing != 0 && !p.IsMulti
+	hasHue := p.Hue != uo.HueDefault && !p.IsMulti
+	hasFlags := p.Movable && !p.IsMulti
+	// Length calculation
+	length := 14
+	if hasAmount {
+		length += 2
+	}
+	if hasIncrement {
+		length++
+	}
+	if hasFacing {
+		length++
+	}
+	if hasHue {
+		length += 2
+	}
+	if hasFlags {
+		length++
+	}
+	dc.PutByte(w, 0x1A) // Packet ID
+	dc.PutUint16(w, uint16(length))
+	// Serial with the amount flag
+	if hasAmount {
+		dc.PutUint32(w, uint32(p.Serial)|0x80000000)
+	} else {
+		dc.PutUint32(w, uint32(p.Serial))
+	}
+	// Graphic with the multi and increment flags
+	g := uint16(p.Graphic) & 0x3FFF
+	if p.IsMulti {
+		g |= 0x4000
+	}
+	if hasIncrement {
+		g |= 0x8000
+	}
+	dc.PutUint16(w, g)
+	if hasAmount {
+		dc.PutUint16(w, uint16(n))
+	}
+	if hasIncrement {
+		dc.PutByte(w, byte(p.GraphicIncrement))
+	}
+	// Location with the facing, hue and flags flags
+	x := uint16(p.Location.X) & 0x7FFF
+	if hasFacing {
+		x |= 0x8000
+	}
+	y := uint16(p.Location.Y) & 0x3FFF
+	if hasHue {
+		y |= 0x8000
+	}
+	if hasFlags {
+		y |= 0x4000
+	}
+	dc.PutUint16(w, x)
+	dc.PutUint16(w, y)
+	if hasFacing {
+		dc.PutByte(w, byte(p.Facing))
+	}
+	dc.PutByte(w, byte(p.Location.Z))
+	if hasHue {
+		putHue(w, p.Hue)
+	}
+	if hasFlags {
+		dc.PutByte(w, 0x20) // Movable if normally not
+	}
+}
+
 // DeleteObject tells the client to forget about an object
 type DeleteObject struct {
 	// Serial of the object to remove
@@ -683,6 +864,8 @@ type OpenContainerGump struct {
 	GumpSerial uo.Serial
 	// The gump graphic
 	Gump uo.GUMP
+	// Version of the receiving client
+	Version uo.ClientVersion
 }
 
 // Write implements the Packet interface.
@@ -690,7 +873,9 @@ func (p *OpenContainerGump) Write(w io.Writer) {
 	dc.PutByte(w, 0x24) // Packet ID
 	dc.PutUint32(w, uint32(p.GumpSerial))
 	dc.PutUint16(w, uint16(p.Gump))
-	dc.PutUint16(w, uint16(0x007D)) // No idea what this does but it's required > 7.0.9.x
+	if !p.Version.Before(uo.ClientVersionContainerGumpSA) {
+		dc.PutUint16(w, uint16(0x007D)) // No idea what this does but it's required > 7.0.9.x
+	}
 }
 
 // AddItemToContainer adds an item to an already-open container gump.
@@ -709,6 +894,9 @@ type AddItemToContainer struct {
 	Container uo.Serial
 	// Hue of the item
 	Hue uo.Hue
+	// Version of the receiving client, clients older than 6.0.1.7 do not
+	// expect the grid index
+	Version uo.ClientVersion
 }
 
 // Write implements the Packet interface.
@@ -720,7 +908,9 @@ func (p *AddItemToContainer) Write(w io.Writer) {
 	dc.PutUint16(w, uint16(p.Amount))
 	dc.PutUint16(w, uint16(p.Location.X))
 	dc.PutUint16(w, uint16(p.Location.Y))
-	dc.Pad(w, 1) // Grid index
+	if !p.Version.Before(uo.ClientVersionGridIndex) {
+		dc.Pad(w, 1) // Grid index
+	}
 	dc.PutUint32(w, uint32(p.Container))
 	putHue(w, p.Hue)
 }
@@ -753,37 +943,41 @@ type Contents struct {
 	Items []ContentsItem
 	// If true items are listed in reverse order
 	ReverseOrder bool
+	// Version of the receiving client, clients older than 6.0.1.7 do not
+	// expect the grid index
+	Version uo.ClientVersion
 }
 
 // Write implements the Packet interface.
 func (p *Contents) Write(w io.Writer) {
-	dc.PutByte(w, 0x3C)                        // Packet ID
-	dc.PutUint16(w, uint16(5+len(p.Items)*20)) // Packet length
+	grid := !p.Version.Before(uo.ClientVersionGridIndex)
+	itemLength := 19
+	if grid {
+		itemLength++
+	}
+	dc.PutByte(w, 0x3C)                                // Packet ID
+	dc.PutUint16(w, uint16(5+len(p.Items)*itemLength)) // Packet length
 	dc.PutUint16(w, uint16(len(p.Items)))
+	fn := func(item *ContentsItem) {
+		dc.PutUint32(w, uint32(item.Serial))
+		dc.PutUint16(w, uint16(item.Graphic))
+		dc.PutByte(w, byte(item.GraphicOffset))
+		dc.PutUint16(w, uint16(item.Amount))
+		dc.PutUint16(w, uint16(item.Location.X))
+		dc.PutUint16(w, uint16(item.Location.Y))
+		if grid {
+			dc.Pad(w, 1) // Grid index
+		}
+		dc.PutUint32(w, uint32(item.Container))
+		putHue(w, item.Hue)
+	}
 	if p.ReverseOrder {
 		for i := len(p.Items) - 1; i >= 0; i-- {
-			item := p.Items[i]
-			dc.PutUint32(w, uint32(item.Serial))
-			dc.PutUint16(w, uint16(item.Graphic))
-			dc.PutByte(w, byte(item.GraphicOffset))
-			dc.PutUint16(w, uint16(item.Amount))
-			dc.PutUint16(w, uint16(item.Location.X))
-			dc.PutUint16(w, uint16(item.Location.Y))
-			dc.Pad(w, 1) // Grid index
-			dc.PutUint32(w, uint32(item.Container))
-			putHue(w, item.Hue)
+			fn(&p.Items[i])
 		}
 	} else {
-		for _, item := range p.Items {
-			dc.PutUint32(w, uint32(item.Serial))
-			dc.PutUint16(w, uint16(item.Graphic))
-			dc.PutByte(w, byte(item.GraphicOffset))
-			dc.PutUint16(w, uint16(item.Amount))
-			dc.PutUint16(w, uint16(item.Location.X))
-			dc.PutUint16(w, uint16(item.Location.Y))
-			dc.Pad(w, 1) // Grid index
-			dc.PutUint32(w, uint32(item.Container))
-			putHue(w, item.Hue)
+		for i := range p.Items {
+			fn(&p.Items[i])
 		}
 	}
 }
@@ -834,6 +1028,8 @@ type SingleSkillUpdate struct {
 	Value int
 	// Lock state
 	Lock uo.SkillLock
+	// Skill cap (0-1000)
+	Cap int
 }
 
 // Write implements the Packet interface.
@@ -845,13 +1041,17 @@ func (p *SingleSkillUpdate) Write(w io.Writer) {
 	dc.PutUint16(w, uint16(p.Value))          // Display value
 	dc.PutUint16(w, uint16(p.Value))          // Base value
 	dc.PutByte(w, byte(p.Lock))               // Lock code
-	dc.PutUint16(w, 1000)                     // Skill cap
+	dc.PutUint16(w, uint16(p.Cap))            // Skill cap
 }
 
 // FullSkillUpdate sends an update for all skills.
 type FullSkillUpdate struct {
 	// Slice of all skill values
 	SkillValues []int16
+	// Slice of all skill lock states, indexes must match SkillValues
+	Locks []uo.SkillLock
+	// Skill cap (0-1000)
+	Cap int
 }
 
 // Write implements the Packet interface.
@@ -860,14 +1060,43 @@ func (p *FullSkillUpdate) Write(w io.Writer) {
 	dc.PutUint16(w, uint16(4+len(p.SkillValues)*9)) // Packet length
 	dc.PutByte(w, byte(uo.SkillUpdateAll))          // Update type
 	for id, value := range p.SkillValues {
-		dc.PutUint16(w, uint16(id+1))       // Skill ID - Not sure why this is 1-based in this one packet, but oh well
-		dc.PutUint16(w, uint16(value))      // Displayed value
-		dc.PutUint16(w, uint16(value))      // Base value
-		dc.PutByte(w, byte(uo.SkillLockUp)) // Skill lock
-		dc.PutUint16(w, 1000)               // Skill cap
+		lock := uo.SkillLockUp
+		if id < len(p.Locks) {
+			lock = p.Locks[id]
+		}
+		dc.PutUint16(w, uint16(id+1))  // Skill ID - Not sure why this is 1-based in this one packet, but oh well
+		dc.PutUint16(w, uint16(value)) // Displayed value
+		dc.PutUint16(w, uint16(value)) // Base value
+		dc.PutByte(w, byte(lock))      // Skill lock
+		dc.PutUint16(w, uint16(p.Cap)) // Skill cap
 	}
 }
 
+// StatLocks sends the lock states of the strength, dexterity and intelligence
+// of a mobile to the client.
+type StatLocks struct {
+	// Serial of the mobile
+	Mobile uo.Serial
+	// Strength lock state
+	Strength uo.SkillLock
+	// Dexterity lock state
+	Dexterity uo.SkillLock
+	// Intelligence lock state
+	Intelligence uo.SkillLock
+}
+
+// Write implements the Packet interface.
+func (p *StatLocks) Write(w io.Writer) {
+	dc.PutByte(w, 0xBF)     // General information packet ID
+	dc.PutUint16(w, 12)     // Length
+	dc.PutUint16(w, 0x0019) // Extended stats subcommand
+	dc.PutByte(w, 0x02)     // Stat locks
+	dc.PutUint32(w, uint32(p.Mobile))
+	dc.PutByte(w, 0x00) // Unused
+	dc.PutByte(w, byte(p.Strength&0x03)<<4|byte(p.Dexterity&0x03)<<2|
+		byte(p.Intelligence&0x03))
+}
+
 // ClilocMessage sends a localized message to the client.
 type ClilocMessage struct {
 	// Serial of the speaker
@@ -1432,3 +1661,230 @@ func (p *TextEntryGUMP) Write(w io.Writer) {
 	dc.PutUint16(w, uint16(len(p.Description)+1)) // Description text
 	dc.PutString(w, p.Description)
 }
+
+// SecureTrade is sent to open, update and close the secure trade window.
+type SecureTrade struct {
+	// Action to take
+	Action uo.SecureTradeAction
+	// Serial of the other mobile in the trade, only used by the display action
+	Mobile uo.Serial
+	// Serial of the receiving client's trade container
+	Container uo.Serial
+	// Serial of the other party's trade container, only used by the display
+	// action
+	OtherContainer uo.Serial
+	// Name of the other party, only used by the display action
+	Name string
+	// Accept state of the receiving client, only used by the update action
+	Accepted bool
+	// Accept state of the other party, only used by the update action
+	OtherAccepted bool
+}
+
+// Write implements the Packet interface.
+func (p *SecureTrade) Write(w io.Writer) {
+	l := 17
+	if p.Action == uo.SecureTradeActionDisplay {
+		l = 47
+	}
+	dc.PutByte(w, 0x6F)        // Packet ID
+	dc.PutUint16(w, uint16(l)) // Packet length
+	dc.PutByte(w, byte(p.Action))
+	switch p.Action {
+	case uo.SecureTradeActionDisplay:
+		dc.PutUint32(w, uint32(p.Mobile))
+		dc.PutUint32(w, uint32(p.Container))
+		dc.PutUint32(w, uint32(p.OtherContainer))
+		dc.PutByte(w, 1) // Name follows
+		dc.PutStringN(w, p.Name, 30)
+	case uo.SecureTradeActionUpdate:
+		dc.PutUint32(w, uint32(p.Container))
+		putBool32(w, p.Accepted)
+		putBool32(w, p.OtherAccepted)
+		dc.Pad(w, 1)
+	default:
+		dc.PutUint32(w, uint32(p.Container))
+		dc.Pad(w, 9)
+	}
+}
+
+// WarMode is sent to the client to set the war mode state of the player.
+type WarMode struct {
+	WarMode bool // If true the player is in war mode
+}
+
+// Write implements the Packet interface.
+func (p *WarMode) Write(w io.Writer) {
+	dc.PutByte(w, 0x72) // Packet ID
+	if p.WarMode {
+		dc.PutByte(w, 1)
+	} else {
+		dc.PutByte(w, 0)
+	}
+	dc.PutByte(w, 0x00) // Unknown
+	dc.PutByte(w, 0x32) // Unknown
+	dc.PutByte(w, 0x00) // Unknown
+}
+
+// ChangeCombatant is sent to the client to set or clear the player's current
+// combatant.
+type ChangeCombatant struct {
+	Combatant uo.Serial // Serial of the new combatant, uo.SerialZero clears
+}
+
+// Write implements the Packet interface.
+func (p *ChangeCombatant) Write(w io.Writer) {
+	dc.PutByte(w, 0xAA) // Packet ID
+	dc.PutUint32(w, uint32(p.Combatant))
+}
+
+// DeathStatus is sent to the client to toggle the death screen and ghost
+// movement mode of the player.
+type DeathStatus struct {
+	Dead bool // If true the player has died
+}
+
+// Write implements the Packet interface.
+func (p *DeathStatus) Write(w io.Writer) {
+	dc.PutByte(w, 0x2C) // Packet ID
+	if p.Dead {
+		dc.PutByte(w, 0)
+	} else {
+		dc.PutByte(w, 2)
+	}
+}
+
+// DisplayDeath is sent to the client to play the death animation of a mobile
+// and link the mobile to its corpse.
+type DisplayDeath struct {
+	Mobile uo.Serial // Serial of the mobile that died
+	Corpse uo.Serial // Serial of the corpse of the mobile
+}
+
+// Write implements the Packet interface.
+func (p *DisplayDeath) Write(w io.Writer) {
+	dc.PutByte(w, 0xAF) // Packet ID
+	dc.PutUint32(w, uint32(p.Mobile))
+	dc.PutUint32(w, uint32(p.Corpse))
+	dc.PutUint32(w, 0) // Unknown
+}
+
+// PartyMemberList sends the full list of party members to the client.
+type PartyMemberList struct {
+	// Serials of all party members, the leader first
+	Members []uo.Serial
+}
+
+// Write implements the Packet interface.
+func (p *PartyMemberList) Write(w io.Writer) {
+	dc.PutByte(w, 0xBF)                         // General information packet ID
+	dc.PutUint16(w, uint16(7+len(p.Members)*4)) // Length
+	dc.PutUint16(w, 0x0006)                     // Party subcommand
+	dc.PutByte(w, 0x01)                         // Member list
+	dc.PutByte(w, byte(len(p.Members)))
+	for _, s := range p.Members {
+		dc.PutUint32(w, uint32(s))
+	}
+}
+
+// PartyRemoveMember notifies the client that a member has left the party. An
+// empty member list tells the client that it is no longer in a party.
+type PartyRemoveMember struct {
+	// Serial of the member that left
+	Removed uo.Serial
+	// Serials of the remaining party members
+	Members []uo.Serial
+}
+
+// Write implements the Packet interface.
+func (p *PartyRemoveMember) Write(w io.Writer) {
+	dc.PutByte(w, 0xBF)                          // General information packet ID
+	dc.PutUint16(w, uint16(11+len(p.Members)*4)) // Length
+	dc.PutUint16(w, 0x0006)                      // Party subcommand
+	dc.PutByte(w, 0x02)                          // Remove member
+	dc.PutByte(w, byte(len(p.Members)))
+	dc.PutUint32(w, uint32(p.Removed))
+	for _, s := range p.Members {
+		dc.PutUint32(w, uint32(s))
+	}
+}
+
+// PartyMessage sends a party chat message to the client.
+type PartyMessage struct {
+	// If true this is a private message to the client
+	Private bool
+	// Serial of the member who sent the message
+	From uo.Serial
+	// Text of the message
+	Text string
+}
+
+// Write implements the Packet interface.
+func (p *PartyMessage) Write(w io.Writer) {
+	text := utf16.Encode([]rune(p.Text))
+	dc.PutByte(w, 0xBF)                     // General information packet ID
+	dc.PutUint16(w, uint16(12+len(text)*2)) // Length
+	dc.PutUint16(w, 0x0006)                 // Party subcommand
+	if p.Private {
+		dc.PutByte(w, 0x03) // Private message
+	} else {
+		dc.PutByte(w, 0x04) // Party message
+	}
+	dc.PutUint32(w, uint32(p.From))
+	dc.PutUTF16String(w, p.Text)
+}
+
+// PartyInvitation sends a party invitation to the client.
+type PartyInvitation struct {
+	// Serial of the party leader
+	Leader uo.Serial
+}
+
+// Write implements the Packet interface.
+func (p *PartyInvitation) Write(w io.Writer) {
+	dc.PutByte(w, 0xBF)     // General information packet ID
+	dc.PutUint16(w, 10)     // Length
+	dc.PutUint16(w, 0x0006) // Party subcommand
+	dc.PutByte(w, 0x07)     // Invitation
+	dc.PutUint32(w, uint32(p.Leader))
+}
+
+// SpellbookContent sends the spells contained in a spellbook to the client.
+type SpellbookContent struct {
+	// Serial of the spellbook
+	Spellbook uo.Serial
+	// Graphic of the spellbook
+	Graphic uo.Graphic
+	// Client-side number of the first spell of the book, 1 for magery
+	Offset int
+	// Bitmask of the spells in the book, bit 0 is the first spell
+	Content uint64
+}
+
+// Write implements the Packet interface.
+func (p *SpellbookContent) Write(w io.Writer) {
+	dc.PutByte(w, 0xBF)     // General information packet ID
+	dc.PutUint16(w, 23)     // Length
+	dc.PutUint16(w, 0x001B) // New spellbook content subcommand
+	dc.PutUint16(w, 0x0001) // Unknown, always 1
+	dc.PutUint32(w, uint32(p.Spellbook))
+	dc.PutUint16(w, uint16(p.Graphic))
+	dc.PutUint16(w, uint16(p.Offset))
+	for i := 0; i < 8; i++ {
+		dc.PutByte(w, byte(p.Content>>(i*8)))
+	}
+}
+
+// SetMap tells the client which facet the player is on.
+type SetMap struct {
+	// Facet the player is on
+	Facet uo.Facet
+}
+
+// Write implements the Packet interface.
+func (p *SetMap) Write(w io.Writer) {
+	dc.PutByte(w, 0xBF)     // General information packet ID
+	dc.PutUint16(w, 6)      // Length
+	dc.PutUint16(w, 0x0008) // Set map subcommand
+	dc.PutByte(w, byte(p.Facet))
+}
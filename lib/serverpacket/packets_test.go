@@ -0,0 +1,169 @@
+package serverpacket
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+var (
+	testVersionLegacy = uo.NewClientVersion(5, 0, 9, 1)
+	testVersionGrid   = uo.NewClientVersion(6, 0, 14, 2)
+	testVersionSA     = uo.NewClientVersion(7, 0, 15, 1)
+)
+
+func TestPacketsPerVersion(t *testing.T) {
+	var tests = []struct {
+		name    string
+		p       Packet
+		id      byte
+		length  int
+		verify  func(t *testing.T, d []byte)
+		dynamic bool
+	}{
+		{"0x25 legacy", &AddItemToContainer{Version: testVersionLegacy}, 0x25, 20, nil, false},
+		{"0x25 grid", &AddItemToContainer{Version: testVersionGrid}, 0x25, 21, nil, false},
+		{"0x25 unknown", &AddItemToContainer{}, 0x25, 21, nil, false},
+		{"0x3C legacy", &Contents{Version: testVersionLegacy, Items: make([]ContentsItem, 2)}, 0x3C, 5 + 2*19, nil, true},
+		{"0x3C grid", &Contents{Version: testVersionGrid, Items: make([]ContentsItem, 2)}, 0x3C, 5 + 2*20, nil, true},
+		{"0x24 legacy", &OpenContainerGump{Version: testVersionGrid}, 0x24, 7, nil, false},
+		{"0x24 SA", &OpenContainerGump{Version: testVersionSA}, 0x24, 9, nil, false},
+		{"0xF3 SA", &ObjectInfo{Version: testVersionSA, Amount: 5}, 0xF3, 26, nil, false},
+		{"0x1A minimal", &ObjectInfo{Version: testVersionGrid, Serial: 0x40000001, Amount: 1}, 0x1A, 14, func(t *testing.T, d []byte) {
+			if d[3]&0x80 != 0 {
+				t.Fatal("amount flag set without an amount")
+			}
+		}, true},
+		{"0x1A full", &ObjectInfo{
+			Version:          testVersionGrid,
+			Serial:           0x40000001,
+			Graphic:          0x0EED,
+			GraphicIncrement: 1,
+			Amount:           100,
+			Facing:           uo.DirectionEast,
+			Hue:              0x0021,
+			Movable:          true,
+		}, 0x1A, 21, func(t *testing.T, d []byte) {
+			if d[3]&0x80 == 0 {
+				t.Fatal("amount flag not set")
+			}
+			if d[7]&0x80 == 0 {
+				t.Fatal("graphic increment flag not set")
+			}
+			if d[12]&0x80 == 0 {
+				t.Fatal("facing flag not set")
+			}
+			if d[14]&0xC0 != 0xC0 {
+				t.Fatal("hue and flags flags not set")
+			}
+		}, true},
+		{"0xA9 legacy", &CharacterList{Version: testVersionGrid, Names: make([]string, 5), Cities: []*StartingCity{{}, {}}}, 0xA9, 4 + 5*60 + 1 + 2*63 + 4, nil, true},
+		{"0xA9 extended", &CharacterList{Version: testVersionSA, Names: make([]string, 7), Cities: []*StartingCity{{Name: "Britain", Facet: uo.FacetTrammel}}}, 0xA9, 4 + 7*60 + 1 + 89 + 4 + 2, func(t *testing.T, d []byte) {
+			if string(d[4+7*60+2:4+7*60+9]) != "Britain" {
+				t.Fatal("city name not written")
+			}
+			if d[4+7*60+2+64+15] != byte(uo.FacetTrammel) {
+				t.Fatal("city facet not written")
+			}
+			if d[4+7*60+1+89+2]&0x10 != 0x10 {
+				t.Fatal("seventh slot flag not set")
+			}
+		}, true},
+		{"0x6F display", &SecureTrade{Action: uo.SecureTradeActionDisplay, Name: "Trader"}, 0x6F, 47, func(t *testing.T, d []byte) {
+			if d[16] != 1 || string(d[17:23]) != "Trader" {
+				t.Fatal("name not written")
+			}
+		}, true},
+		{"0x6F update", &SecureTrade{Action: uo.SecureTradeActionUpdate, OtherAccepted: true}, 0x6F, 17, func(t *testing.T, d []byte) {
+			if d[11] != 0 || d[15] != 1 {
+				t.Fatal("accept states not written")
+			}
+		}, true},
+		{"0x72", &WarMode{WarMode: true}, 0x72, 5, func(t *testing.T, d []byte) {
+			if d[1] != 1 {
+				t.Fatal("war mode flag not written")
+			}
+		}, false},
+		{"0xAA", &ChangeCombatant{Combatant: 0x00000102}, 0xAA, 5, nil, false},
+		{"0x6F close", &SecureTrade{Action: uo.SecureTradeActionClose}, 0x6F, 17, nil, true},
+		{"0x2C dead", &DeathStatus{Dead: true}, 0x2C, 2, func(t *testing.T, d []byte) {
+			if d[1] != 0 {
+				t.Fatal("dead status not written")
+			}
+		}, false},
+		{"0x2C alive", &DeathStatus{}, 0x2C, 2, func(t *testing.T, d []byte) {
+			if d[1] != 2 {
+				t.Fatal("alive status not written")
+			}
+		}, false},
+		{"0xAF", &DisplayDeath{Mobile: 0x00000102, Corpse: 0x40000010}, 0xAF, 13, func(t *testing.T, d []byte) {
+			if d[5] != 0x40 || d[8] != 0x10 {
+				t.Fatal("corpse serial not written")
+			}
+		}, false},
+		{"0x3A single", &SingleSkillUpdate{Skill: uo.SkillMining, Value: 505, Lock: uo.SkillLockLocked, Cap: 1000}, 0x3A, 13, func(t *testing.T, d []byte) {
+			if d[10] != byte(uo.SkillLockLocked) || int(d[11])<<8|int(d[12]) != 1000 {
+				t.Fatal("lock and cap not written")
+			}
+		}, true},
+		{"0x3A full", &FullSkillUpdate{SkillValues: make([]int16, 3), Locks: []uo.SkillLock{uo.SkillLockUp, uo.SkillLockDown}, Cap: 1000}, 0x3A, 4 + 3*9, func(t *testing.T, d []byte) {
+			if d[4+9+6] != byte(uo.SkillLockDown) || d[4+18+6] != byte(uo.SkillLockUp) {
+				t.Fatal("skill locks not written")
+			}
+		}, true},
+		{"0xBF 0x19", &StatLocks{Mobile: 0x00000102, Strength: uo.SkillLockDown, Dexterity: uo.SkillLockLocked, Intelligence: uo.SkillLockUp}, 0xBF, 12, func(t *testing.T, d []byte) {
+			if d[4] != 0x19 || d[5] != 0x02 || d[11] != 0x18 {
+				t.Fatal("stat locks not written")
+			}
+		}, true},
+		{"0xBF 0x1B", &SpellbookContent{Spellbook: 0x40000001, Graphic: 0x0EFA, Offset: 1, Content: 0x0000010000000809}, 0xBF, 23, func(t *testing.T, d []byte) {
+			if d[4] != 0x1B || d[12] != 0xFA || d[14] != 1 || d[15] != 0x09 || d[16] != 0x08 || d[20] != 0x01 {
+				t.Fatal("spellbook content not written")
+			}
+		}, true},
+		{"0xBF 0x06 list", &PartyMemberList{Members: []uo.Serial{0x00000102, 0x00000103}}, 0xBF, 15, func(t *testing.T, d []byte) {
+			if d[5] != 0x01 || d[6] != 2 || d[14] != 0x03 {
+				t.Fatal("party member list not written")
+			}
+		}, true},
+		{"0xBF 0x06 remove", &PartyRemoveMember{Removed: 0x00000104, Members: []uo.Serial{0x00000102}}, 0xBF, 15, func(t *testing.T, d []byte) {
+			if d[5] != 0x02 || d[6] != 1 || d[10] != 0x04 || d[14] != 0x02 {
+				t.Fatal("party remove member not written")
+			}
+		}, true},
+		{"0xBF 0x06 message", &PartyMessage{Private: true, From: 0x00000102, Text: "hi"}, 0xBF, 16, func(t *testing.T, d []byte) {
+			if d[5] != 0x03 || d[9] != 0x02 || d[11] != 'h' || d[13] != 'i' || d[15] != 0 {
+				t.Fatal("party message not written")
+			}
+		}, true},
+		{"0xBF 0x06 invitation", &PartyInvitation{Leader: 0x00000102}, 0xBF, 10, func(t *testing.T, d []byte) {
+			if d[5] != 0x07 || d[9] != 0x02 {
+				t.Fatal("party invitation not written")
+			}
+		}, true},
+		{"0xBF 0x08", &SetMap{Facet: uo.FacetMalas}, 0xBF, 6, func(t *testing.T, d []byte) {
+			if d[4] != 0x08 || d[5] != 0x03 {
+				t.Fatal("facet not written")
+			}
+		}, true},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		test.p.Write(&buf)
+		d := buf.Bytes()
+		if d[0] != test.id {
+			t.Fatalf("%s: wrong packet ID 0x%02X", test.name, d[0])
+		}
+		if len(d) != test.length {
+			t.Fatalf("%s: wrong length %d, wanted %d", test.name, len(d), test.length)
+		}
+		if test.dynamic && int(d[1])<<8|int(d[2]) != test.length {
+			t.Fatalf("%s: length field does not match packet length", test.name)
+		}
+		if test.verify != nil {
+			test.verify(t, d)
+		}
+	}
+}
@@ -99,13 +99,44 @@ func (b Bounds) RandomLocation(rng RandomSource) Location {
 }
 
 // Contains returns true if the location is contained within these bounds.
+// Bounds within the overworld wrap around its edges.
 func (b Bounds) Contains(l Location) bool {
-	return l.X >= b.X && l.X <= b.East() && l.Y >= b.Y && l.Y <= b.South() && l.Z >= b.Z && l.Z <= b.Top()
+	if l.Z < b.Z || l.Z > b.Top() {
+		return false
+	}
+	xw, yw := b.wrap(l.X)
+	return spansOverlap(b.X, b.East(), l.X, l.X, xw) &&
+		spansOverlap(b.Y, b.South(), l.Y, l.Y, yw)
 }
 
-// Overlaps returns true if the two bound values overlap
+// Overlaps returns true if the two bound values overlap. Bounds within the
+// overworld wrap around its edges.
 func (b Bounds) Overlaps(a Bounds) bool {
-	return !(a.South() < b.Y || b.South() < a.Y || a.East() < b.X || b.East() < a.X)
+	xw, yw := b.wrap(a.X)
+	return spansOverlap(b.X, b.East(), a.X, a.East(), xw) &&
+		spansOverlap(b.Y, b.South(), a.Y, a.South(), yw)
+}
+
+// wrap returns the distances at which the X and Y axes wrap when comparing
+// these bounds with something starting at X coordinate x, or zero if the axes
+// do not wrap. Only comparisons within the overworld wrap.
+func (b Bounds) wrap(x int16) (int16, int16) {
+	if b.X < int16(MapOverworldWidth) && x < int16(MapOverworldWidth) {
+		return int16(MapOverworldWidth), int16(MapHeight)
+	}
+	return 0, 0
+}
+
+// spansOverlap returns true if the span [as, ae] overlaps the span [bs, be] on
+// an axis that wraps every n units, or does not wrap if n is zero.
+func spansOverlap(as, ae, bs, be, n int16) bool {
+	if !(be < as || ae < bs) {
+		return true
+	}
+	if n == 0 {
+		return false
+	}
+	return !(be-n < as || ae < bs-n) || !(be+n < as || ae < bs+n)
 }
 
 // East returns the east-most point within these bounds.
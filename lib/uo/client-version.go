@@ -0,0 +1,157 @@
+package uo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ClientVersion is a packed representation of a client version number that
+// can be compared directly. The zero value means the version is not known.
+type ClientVersion uint32
+
+// Client versions that changed the layout of one or more packets
+const (
+	ClientVersionUnknown         ClientVersion = 0
+	ClientVersionGridIndex       ClientVersion = 0x06000107 // 6.0.1.7 added the grid index byte to packets 0x08, 0x25 and 0x3C
+	ClientVersionWorldObjectSA   ClientVersion = 0x07000000 // 7.0.0.0 replaced packet 0x1A with 0xF3
+	ClientVersionContainerGumpSA ClientVersion = 0x07000900 // 7.0.9.0 added the gump type to packet 0x24
+	ClientVersionExtendedCities  ClientVersion = 0x07000D00 // 7.0.13.0 added locations to the starting cities in packet 0xA9
+	ClientVersionDefault         ClientVersion = 0x07000F01 // 7.0.15.1 is the version the server is developed against
+)
+
+// NewClientVersion returns the ClientVersion value for the given parts.
+func NewClientVersion(major, minor, patch, extra int) ClientVersion {
+	return ClientVersion(uint32(major&0xFF)<<24 | uint32(minor&0xFF)<<16 |
+		uint32(patch&0xFF)<<8 | uint32(extra&0xFF))
+}
+
+// ParseClientVersion parses a client version string in the form
+// major.minor.patch.extra. Trailing parts may be omitted and default to 0.
+// Client version strings that end in a letter, such as 5.0.9a, are also
+// accepted and the letter is treated as the extra part.
+func ParseClientVersion(s string) (ClientVersion, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ClientVersionUnknown, errors.New("empty client version string")
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 4 {
+		return ClientVersionUnknown, fmt.Errorf("client version %s has too many parts", s)
+	}
+	var v [4]int
+	for i, p := range parts {
+		// Legacy letter suffix, a=1, b=2, etc.
+		if i == len(parts)-1 && i < 3 && len(p) > 1 {
+			c := p[len(p)-1]
+			if c >= 'a' && c <= 'z' {
+				v[3] = int(c-'a') + 1
+				p = p[:len(p)-1]
+			}
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || n > 255 {
+			return ClientVersionUnknown, fmt.Errorf("client version %s has invalid part %s", s, p)
+		}
+		v[i] = n
+	}
+	return NewClientVersion(v[0], v[1], v[2], v[3]), nil
+}
+
+// Major returns the major part of the version.
+func (v ClientVersion) Major() int { return int(v>>24) & 0xFF }
+
+// Minor returns the minor part of the version.
+func (v ClientVersion) Minor() int { return int(v>>16) & 0xFF }
+
+// Patch returns the patch part of the version.
+func (v ClientVersion) Patch() int { return int(v>>8) & 0xFF }
+
+// Extra returns the extra part of the version.
+func (v ClientVersion) Extra() int { return int(v) & 0xFF }
+
+// String returns the version in the dotted form.
+func (v ClientVersion) String() string {
+	if v == ClientVersionUnknown {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major(), v.Minor(), v.Patch(), v.Extra())
+}
+
+// Before returns true if the version is known and older than o. Unknown
+// versions are assumed to be new enough for every packet layout.
+func (v ClientVersion) Before(o ClientVersion) bool {
+	return v != ClientVersionUnknown && v < o
+}
+
+// ClientVersionRange is an inclusive range of client versions.
+type ClientVersionRange struct {
+	Min ClientVersion // Oldest version in the range
+	Max ClientVersion // Newest version in the range
+}
+
+// Contains returns true if the version is within the range.
+func (r ClientVersionRange) Contains(v ClientVersion) bool {
+	return v >= r.Min && v <= r.Max
+}
+
+// ClientVersionSet is a set of client version ranges.
+type ClientVersionSet []ClientVersionRange
+
+// ParseClientVersionSet parses a comma-separated list of client versions and
+// inclusive version ranges, such as "6.0.14.2,7.0.10.0-7.0.15.1".
+func ParseClientVersionSet(s string) (ClientVersionSet, error) {
+	var ret ClientVersionSet
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		parts := strings.Split(e, "-")
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("client version range %s is malformed", e)
+		}
+		min, err := ParseClientVersion(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		max := min
+		if len(parts) == 2 {
+			if max, err = ParseClientVersion(parts[1]); err != nil {
+				return nil, err
+			}
+		}
+		if max < min {
+			min, max = max, min
+		}
+		ret = append(ret, ClientVersionRange{Min: min, Max: max})
+	}
+	if len(ret) == 0 {
+		return nil, errors.New("no client versions given")
+	}
+	return ret, nil
+}
+
+// Contains returns true if the version is within any of the ranges of the set.
+func (s ClientVersionSet) Contains(v ClientVersion) bool {
+	for _, r := range s {
+		if r.Contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the set in the form accepted by ParseClientVersionSet.
+func (s ClientVersionSet) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		if r.Min == r.Max {
+			parts[i] = r.Min.String()
+		} else {
+			parts[i] = r.Min.String() + "-" + r.Max.String()
+		}
+	}
+	return strings.Join(parts, ",")
+}
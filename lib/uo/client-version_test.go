@@ -0,0 +1,63 @@
+package uo
+
+import "testing"
+
+func TestParseClientVersion(t *testing.T) {
+	var tests = []struct {
+		s string
+		v ClientVersion
+	}{
+		{"7.0.15.1", NewClientVersion(7, 0, 15, 1)},
+		{"6.0.14.2", NewClientVersion(6, 0, 14, 2)},
+		{"5.0.9a", NewClientVersion(5, 0, 9, 1)},
+		{"7", NewClientVersion(7, 0, 0, 0)},
+	}
+	for _, test := range tests {
+		v, err := ParseClientVersion(test.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != test.v {
+			t.Fatalf("%s parsed as %s", test.s, v)
+		}
+	}
+	for _, s := range []string{"", "7.0.x.1", "7.0.15.1.1", "7.0.256.0"} {
+		if _, err := ParseClientVersion(s); err == nil {
+			t.Fatalf("%s parsed without error", s)
+		}
+	}
+}
+
+func TestClientVersionSet(t *testing.T) {
+	s, err := ParseClientVersionSet("6.0.14.2, 7.0.10.0-7.0.15.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != "6.0.14.2,7.0.10.0-7.0.15.1" {
+		t.Fatalf("set string %s", s)
+	}
+	for _, v := range []ClientVersion{
+		NewClientVersion(6, 0, 14, 2),
+		NewClientVersion(7, 0, 10, 0),
+		NewClientVersion(7, 0, 15, 1),
+	} {
+		if !s.Contains(v) {
+			t.Fatalf("set does not contain %s", v)
+		}
+	}
+	for _, v := range []ClientVersion{
+		ClientVersionUnknown,
+		NewClientVersion(6, 0, 14, 3),
+		NewClientVersion(7, 0, 16, 0),
+	} {
+		if s.Contains(v) {
+			t.Fatalf("set contains %s", v)
+		}
+	}
+	if !NewClientVersion(6, 0, 1, 6).Before(ClientVersionGridIndex) {
+		t.Fatal("6.0.1.6 not before grid index version")
+	}
+	if ClientVersionUnknown.Before(ClientVersionGridIndex) {
+		t.Fatal("unknown version treated as legacy")
+	}
+}
@@ -0,0 +1,126 @@
+package uo
+
+import "strings"
+
+// Facet identifies one of the maps of the universe.
+type Facet uint8
+
+// Facet values, these match the map file numbers of the client
+const (
+	FacetFelucca  Facet = 0
+	FacetTrammel  Facet = 1
+	FacetIlshenar Facet = 2
+	FacetMalas    Facet = 3
+	FacetTokuno   Facet = 4
+	FacetCount    int   = 5
+)
+
+// facetInfo describes the dimensions of one facet.
+type facetInfo struct {
+	name           string // Display name of the facet
+	width          int    // Width of the facet in tiles
+	height         int    // Height of the facet in tiles
+	overworldWidth int    // Width of the portion of the facet that wraps as the overworld
+}
+
+// Facet dimensions by facet
+var facetInfos = [FacetCount]facetInfo{
+	{"Felucca", MapWidth, MapHeight, MapOverworldWidth},
+	{"Trammel", MapWidth, MapHeight, MapOverworldWidth},
+	{"Ilshenar", 2304, 1600, 2304},
+	{"Malas", 2560, 2048, 2560},
+	{"Tokuno", 1448, 1448, 1448},
+}
+
+// FacetByName returns the facet with the given name, ignoring case.
+func FacetByName(name string) (Facet, bool) {
+	for i, fi := range facetInfos {
+		if strings.EqualFold(fi.name, strings.TrimSpace(name)) {
+			return Facet(i), true
+		}
+	}
+	return FacetFelucca, false
+}
+
+// Valid returns true if the facet is one of the known facets.
+func (f Facet) Valid() bool { return int(f) < FacetCount }
+
+// String returns the name of the facet.
+func (f Facet) String() string {
+	if !f.Valid() {
+		return "Unknown"
+	}
+	return facetInfos[f].name
+}
+
+// Width returns the width of the facet in tiles.
+func (f Facet) Width() int { return facetInfos[f].width }
+
+// Height returns the height of the facet in tiles.
+func (f Facet) Height() int { return facetInfos[f].height }
+
+// ChunksWidth returns the width of the facet in chunks.
+func (f Facet) ChunksWidth() int { return facetInfos[f].width / ChunkWidth }
+
+// ChunksHeight returns the height of the facet in chunks.
+func (f Facet) ChunksHeight() int { return facetInfos[f].height / ChunkHeight }
+
+// Bounds returns the bounds of the entire facet.
+func (f Facet) Bounds() Bounds {
+	return Bounds{
+		Z: MapMinZ,
+		W: int16(f.Width()),
+		H: int16(f.Height()),
+		D: int16(MapMaxZ) - int16(MapMinZ),
+	}
+}
+
+// Bound bounds the spacial portion of the location to the dimensions of the
+// facet.
+func (f Facet) Bound(l Location) Location {
+	w := int16(f.Width())
+	h := int16(f.Height())
+	for l.X < 0 {
+		l.X += w
+	}
+	for l.X >= w {
+		l.X -= w
+	}
+	for l.Y < 0 {
+		l.Y += h
+	}
+	for l.Y >= h {
+		l.Y -= h
+	}
+	if l.Z < MapMinZ {
+		l.Z = MapMinZ
+	}
+	if l.Z > MapMaxZ {
+		l.Z = MapMaxZ
+	}
+	return l
+}
+
+// WrapAndBound wraps and bounds the spacial portion of the location to the
+// facet relative to a reference point. Facets with a dungeon server section
+// wrap the overworld and dungeon server sections separately. Locations that
+// were wrapped around the overworld by Location math are unwrapped relative
+// to the reference point first on all other facets.
+func (f Facet) WrapAndBound(l, ref Location) Location {
+	fi := facetInfos[f]
+	if fi.overworldWidth == fi.width {
+		dx, dy := ref.Delta(l)
+		l.X = ref.X + dx
+		l.Y = ref.Y + dy
+		return f.Bound(l)
+	}
+	return l.WrapAndBound(ref)
+}
+
+// FacetLocation identifies an absolute location on one facet of the universe.
+type FacetLocation struct {
+	// Facet of the location
+	Facet Facet
+	// Location on the facet
+	Location
+}
@@ -0,0 +1,39 @@
+package uo
+
+import "testing"
+
+func TestFacetByName(t *testing.T) {
+	for i := 0; i < FacetCount; i++ {
+		f := Facet(i)
+		got, found := FacetByName(f.String())
+		if !found || got != f {
+			t.Fatalf("facet %s not found by name", f)
+		}
+	}
+	if f, found := FacetByName(" tokuno "); !found || f != FacetTokuno {
+		t.Fatal("facet names must ignore case and spaces")
+	}
+	if _, found := FacetByName("Sosaria"); found {
+		t.Fatal("unknown facet found by name")
+	}
+}
+
+func TestFacetBound(t *testing.T) {
+	var tests = []struct {
+		f    Facet
+		l    Location
+		ref  Location
+		want Location
+	}{
+		{FacetIlshenar, Location{X: -1, Y: 1600}, Location{}, Location{X: 2303, Y: 0}},
+		{FacetTokuno, Location{X: 1448, Y: -2, Z: 5}, Location{}, Location{X: 0, Y: 1446, Z: 5}},
+		{FacetMalas, Location{}.Forward(DirectionNorthWest), Location{}, Location{X: 2559, Y: 2047}},
+		{FacetFelucca, Location{X: -1, Y: 10}, Location{X: 10, Y: 10}, Location{X: 5119, Y: 10}},
+		{FacetTrammel, Location{X: 5119, Y: 10}, Location{X: 5125, Y: 10}, Location{X: 7167, Y: 10}},
+	}
+	for _, test := range tests {
+		if got := test.f.WrapAndBound(test.l, test.ref); got != test.want {
+			t.Fatalf("%s: %v wrapped to %v, expected %v", test.f, test.l, got, test.want)
+		}
+	}
+}
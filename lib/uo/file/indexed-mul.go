@@ -19,6 +19,9 @@ func NewIndexedMulFromFile(indexFileName, mulFileName string) *IndexedMul {
 	m := &IndexedMul{
 		index: NewIndexFrom(indexFileName),
 	}
+	if m.index == nil {
+		return nil
+	}
 	d, err := os.ReadFile(mulFileName)
 	if err != nil {
 		log.Println(err)
@@ -2,10 +2,18 @@ package file
 
 import (
 	"encoding/binary"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/qbradq/sharduo/lib/uo"
 )
 
+// Length of one block of map data
+const mapBlockLength = 196
+
 // MapMulChunk represents one chunk of the map from map0.mul
 type MapMulChunk struct {
 	Tiles []uo.Tile
@@ -14,56 +22,137 @@ type MapMulChunk struct {
 // MapMul represents the Map0.mul file
 type MapMul struct {
 	Chunks []MapMulChunk
+	// Dimensions of the map in chunks
+	width, height int
 }
 
-// NewMapMulFromFile loads map0.mul from the given path. Not that this ONLY
-// works for map0.mul/map1.mul without diffs. The tile data mul must be loaded
-// prior to this and passed in so we can perform tile linkage.
-func NewMapMulFromFile(fname string, tdmul *TileDataMul) *MapMul {
+// NewMapMulFromFile loads the map file of the facet from the given path. This
+// may be a classic map file like map0.mul or a UOP archive like
+// map0LegacyMUL.uop. Diff files are applied separately with ApplyDiffs. The
+// tile data mul must be loaded prior to this and passed in so we can perform
+// tile linkage.
+func NewMapMulFromFile(fname string, f uo.Facet, tdmul *TileDataMul) *MapMul {
+	var d []byte
+	if strings.EqualFold(filepath.Ext(fname), ".uop") {
+		u := NewUOPFromFile(fname)
+		if u == nil {
+			return nil
+		}
+		d = u.Contents(fmt.Sprintf("build/map%dlegacymul/%%08d.dat", f))
+	} else {
+		var err error
+		if d, err = os.ReadFile(fname); err != nil {
+			log.Println(err)
+			return nil
+		}
+	}
+	m := newMapMul(d, f.ChunksWidth(), f.ChunksHeight(), tdmul)
+	if m == nil {
+		log.Printf("error: %s does not match the dimensions of %s", fname, f)
+	}
+	return m
+}
+
+// newMapMul returns a new MapMul of the given dimensions in chunks loaded
+// from the map data, or nil if the data does not match the dimensions.
+func newMapMul(d []byte, width, height int, tdmul *TileDataMul) *MapMul {
 	// Initialize map storage
 	m := &MapMul{
-		Chunks: make([]MapMulChunk, uo.MapChunksWidth*uo.MapChunksHeight),
+		width:  width,
+		height: height,
 	}
+	m.Chunks = make([]MapMulChunk, m.width*m.height)
 	for i := range m.Chunks {
 		m.Chunks[i] = MapMulChunk{
 			Tiles: make([]uo.Tile, uo.ChunkWidth*uo.ChunkHeight),
 		}
 	}
-	// Load the mul and do sanity checks
-	sm := NewStaticMulFromFile(fname, 196, 0)
-	if sm.NumberOfSegments() != uo.MapChunksWidth*uo.MapChunksHeight {
+	// Sanity checks
+	sm := &StaticMul{
+		pitch: mapBlockLength,
+		d:     d,
+	}
+	if len(d)%mapBlockLength != 0 || sm.NumberOfSegments() != m.width*m.height {
 		return nil
 	}
-	// Load all chunks
+	// Load all chunks, blocks are stored in column-major order
 	iseg := 0
-	for cx := 0; cx < uo.MapChunksWidth; cx++ {
-		for cy := 0; cy < uo.MapChunksHeight; cy++ {
-			seg := sm.GetSegment(iseg)
+	for cx := 0; cx < m.width; cx++ {
+		for cy := 0; cy < m.height; cy++ {
+			m.loadBlock(cx, cy, sm.GetSegment(iseg), tdmul)
 			iseg++
-			chunk := m.Chunks[cy*uo.MapChunksWidth+cx]
-			// Load all tiles in the chunk
-			sofs := 4 // Each map chunk has a 4-byte header of unknown use
-			for ty := 0; ty < uo.ChunkHeight; ty++ {
-				for tx := 0; tx < uo.ChunkWidth; tx++ {
-					tileIdx := binary.LittleEndian.Uint16(seg[sofs : sofs+2])
-					z := int8(seg[sofs+2])
-					sofs += 3
-					chunk.Tiles[ty*uo.ChunkWidth+tx] = uo.NewTile(z, tdmul.GetTileDefinition(int(tileIdx)))
-				}
-			}
 		}
 	}
 	return m
 }
 
+// loadBlock loads the tiles of the chunk from one block of map data.
+func (m *MapMul) loadBlock(cx, cy int, seg []byte, tdmul *TileDataMul) {
+	chunk := m.Chunks[cy*m.width+cx]
+	sofs := 4 // Each map chunk has a 4-byte header of unknown use
+	for ty := 0; ty < uo.ChunkHeight; ty++ {
+		for tx := 0; tx < uo.ChunkWidth; tx++ {
+			tileIdx := binary.LittleEndian.Uint16(seg[sofs : sofs+2])
+			z := int8(seg[sofs+2])
+			sofs += 3
+			chunk.Tiles[ty*uo.ChunkWidth+tx] = uo.NewTile(z, tdmul.GetTileDefinition(int(tileIdx)))
+		}
+	}
+}
+
+// ApplyDiffs replaces the blocks of the map listed in the map diff list file
+// (mapdifl0.mul) with the blocks of the map diff file (mapdif0.mul), returning
+// the number of blocks replaced.
+func (m *MapMul) ApplyDiffs(diflPath, difPath string, tdmul *TileDataMul) (int, error) {
+	blocks, err := readDiffList(diflPath)
+	if err != nil {
+		return 0, err
+	}
+	d, err := os.ReadFile(difPath)
+	if err != nil {
+		return 0, err
+	}
+	sm := &StaticMul{
+		pitch: mapBlockLength,
+		d:     d,
+	}
+	if sm.NumberOfSegments() < len(blocks) {
+		return 0, fmt.Errorf("%s holds %d blocks but %s lists %d", difPath,
+			sm.NumberOfSegments(), diflPath, len(blocks))
+	}
+	for i, block := range blocks {
+		if block >= m.width*m.height {
+			return i, fmt.Errorf("%s: block %d out of range", diflPath, block)
+		}
+		m.loadBlock(block/m.height, block%m.height, sm.GetSegment(i), tdmul)
+	}
+	return len(blocks), nil
+}
+
+// readDiffList returns the block numbers of a diff list file.
+func readDiffList(fname string) ([]int, error) {
+	d, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	if len(d)%4 != 0 {
+		return nil, fmt.Errorf("%s: length is not a multiple of 4", fname)
+	}
+	ret := make([]int, len(d)/4)
+	for i := range ret {
+		ret[i] = int(binary.LittleEndian.Uint32(d[i*4 : i*4+4]))
+	}
+	return ret, nil
+}
+
 // GetChunk returns a pointer to the given MapMulChunk, or nil if the
 // chunk coordinates are out of bounds.
 func (m *MapMul) GetChunk(x, y int) MapMulChunk {
 	var zero MapMulChunk
-	if x < 0 || x >= uo.MapChunksWidth || y < 0 || y >= uo.MapChunksHeight {
+	if x < 0 || x >= m.width || y < 0 || y >= m.height {
 		return zero
 	}
-	return m.Chunks[y*uo.MapChunksWidth+x]
+	return m.Chunks[y*m.width+x]
 }
 
 // GetTile returns the tile at the given coordinates, or the zero value if the
@@ -0,0 +1,106 @@
+package file
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// buildMapBlock returns one block of map data where every tile uses the
+// graphic and tile i of the block has elevation i.
+func buildMapBlock(g uint16) []byte {
+	d := make([]byte, mapBlockLength)
+	for i := 0; i < uo.ChunkWidth*uo.ChunkHeight; i++ {
+		binary.LittleEndian.PutUint16(d[4+i*3:], g)
+		d[4+i*3+2] = byte(i)
+	}
+	return d
+}
+
+// putDiffList writes a diff list file of the block numbers.
+func putDiffList(t *testing.T, fname string, blocks ...uint32) {
+	d := make([]byte, len(blocks)*4)
+	for i, b := range blocks {
+		binary.LittleEndian.PutUint32(d[i*4:], b)
+	}
+	if err := os.WriteFile(fname, d, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMapMul(t *testing.T) {
+	tdmul := newTileDataMul(buildTileData(8))
+	// A map two chunks wide and three chunks high, blocks are stored in
+	// column-major order and block b uses graphic b
+	var d []byte
+	for b := 0; b < 6; b++ {
+		d = append(d, buildMapBlock(uint16(b))...)
+	}
+	if newMapMul(d, 3, 3, tdmul) != nil {
+		t.Fatal("map data of the wrong dimensions loaded")
+	}
+	m := newMapMul(d, 2, 3, tdmul)
+	if m == nil {
+		t.Fatal("map data not loaded")
+	}
+	check := func(x, y int, g uo.Graphic) {
+		tile := m.GetTile(x, y)
+		z := int8((y%uo.ChunkHeight)*uo.ChunkWidth + x%uo.ChunkWidth)
+		if tile.BaseGraphic() != g || tile.RawZ() != z {
+			t.Fatalf("tile %d,%d read as graphic %d z %d, expected %d z %d", x, y,
+				tile.BaseGraphic(), tile.RawZ(), g, z)
+		}
+	}
+	check(0, 0, 0)
+	check(7, 17, 2)
+	check(9, 3, 3)
+	check(15, 23, 5)
+	// Replace blocks 1 and 3 with diffs
+	dir := t.TempDir()
+	putDiffList(t, filepath.Join(dir, "mapdifl0.mul"), 1, 3)
+	difd := append(buildMapBlock(100), buildMapBlock(101)...)
+	if err := os.WriteFile(filepath.Join(dir, "mapdif0.mul"), difd, 0644); err != nil {
+		t.Fatal(err)
+	}
+	n, err := m.ApplyDiffs(filepath.Join(dir, "mapdifl0.mul"),
+		filepath.Join(dir, "mapdif0.mul"), tdmul)
+	if err != nil || n != 2 {
+		t.Fatalf("applied %d diff blocks: %v", n, err)
+	}
+	check(0, 0, 0)
+	check(3, 12, 100)
+	check(8, 0, 101)
+	check(15, 23, 5)
+	// Diff blocks out of range
+	putDiffList(t, filepath.Join(dir, "mapdifl0.mul"), 6)
+	if _, err := m.ApplyDiffs(filepath.Join(dir, "mapdifl0.mul"),
+		filepath.Join(dir, "mapdif0.mul"), tdmul); err == nil {
+		t.Fatal("diff block out of range applied")
+	}
+}
+
+func TestMapMulFromUOP(t *testing.T) {
+	tdmul := newTileDataMul(buildTileData(8))
+	// The map is split across two files in the archive
+	var d []byte
+	for b := 0; b < 4; b++ {
+		d = append(d, buildMapBlock(uint16(b))...)
+	}
+	u, err := NewUOP(buildUOP(t, []uopTestFile{
+		{"build/map4legacymul/00000000.dat", d[:mapBlockLength*3], false},
+		{"build/map4legacymul/00000001.dat", d[mapBlockLength*3:], true},
+	}, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newMapMul(u.Contents("build/map4legacymul/%08d.dat"), 2, 2, tdmul)
+	if m == nil {
+		t.Fatal("map data not loaded from uop")
+	}
+	if g := m.GetTile(8, 8).BaseGraphic(); g != 3 {
+		t.Fatalf("tile 8,8 read as graphic %d", g)
+	}
+}
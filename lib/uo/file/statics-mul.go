@@ -2,54 +2,104 @@ package file
 
 import (
 	"encoding/binary"
+	"fmt"
 
 	"github.com/qbradq/sharduo/lib/uo"
 )
 
+// Length of one static within a block of statics
+const staticEntryLength = 7
+
 // StaticsMul holds the data for "statics0.mul"
 type StaticsMul struct {
-	mul     *IndexedMul
 	statics []uo.Static
+	// Dimensions of the map in chunks
+	width, height int
 }
 
-// NewStaticsMulFromFile loads "statics0.mul" and "staidx0.mul", or nil on error
-// tiledata.mul must be loaded first and passed in so we can link static
-// definition pointers
-func NewStaticsMulFromFile(staidxPath, staticsPath string, tdmul *TileDataMul) *StaticsMul {
-	m := &StaticsMul{}
-	m.mul = NewIndexedMulFromFile(staidxPath, staticsPath)
-	if m.mul == nil {
+// NewStaticsMulFromFile loads the statics and static index files of the facet,
+// or nil on error. tiledata.mul must be loaded first and passed in so we can
+// link static definition pointers
+func NewStaticsMulFromFile(staidxPath, staticsPath string, f uo.Facet, tdmul *TileDataMul) *StaticsMul {
+	mul := NewIndexedMulFromFile(staidxPath, staticsPath)
+	if mul == nil {
 		return nil
 	}
+	return newStaticsMul(mul, f.ChunksWidth(), f.ChunksHeight(), tdmul)
+}
+
+// newStaticsMul returns a new StaticsMul of the given dimensions in chunks
+// loaded from the indexed statics data.
+func newStaticsMul(mul *IndexedMul, width, height int, tdmul *TileDataMul) *StaticsMul {
+	m := &StaticsMul{
+		width:  width,
+		height: height,
+	}
 	chunkIdx := 0
-	for cx := 0; cx < uo.MapChunksWidth; cx++ {
-		for cy := 0; cy < uo.MapChunksHeight; cy++ {
-			cd := m.mul.GetSegment(chunkIdx)
+	for cx := 0; cx < width; cx++ {
+		for cy := 0; cy < height; cy++ {
+			m.statics = appendStatics(m.statics, cx, cy, mul.GetSegment(chunkIdx), tdmul)
 			chunkIdx++
-			if cd == nil {
-				continue
-			}
-			staticOfs := 0
-			for {
-				if staticOfs >= len(cd) {
-					break
-				}
-
-				e := uo.NewStatic(
-					uo.Location{
-						X: int16(cx*uo.ChunkWidth) + int16(cd[staticOfs+2]),
-						Y: int16(cy*uo.ChunkHeight) + int16(cd[staticOfs+3]),
-						Z: int8(cd[staticOfs+4]),
-					},
-					&tdmul.staticDefinitions[binary.LittleEndian.Uint16(cd[staticOfs+0:staticOfs+2])])
-				staticOfs += 7
-				m.statics = append(m.statics, e)
-			}
 		}
 	}
 	return m
 }
 
+// appendStatics appends the statics of one block of statics data to the
+// slice.
+func appendStatics(statics []uo.Static, cx, cy int, cd []byte, tdmul *TileDataMul) []uo.Static {
+	for staticOfs := 0; staticOfs+staticEntryLength <= len(cd); staticOfs += staticEntryLength {
+		statics = append(statics, uo.NewStatic(
+			uo.Location{
+				X: int16(cx*uo.ChunkWidth) + int16(cd[staticOfs+2]),
+				Y: int16(cy*uo.ChunkHeight) + int16(cd[staticOfs+3]),
+				Z: int8(cd[staticOfs+4]),
+			},
+			tdmul.GetStaticDefinition(int(binary.LittleEndian.Uint16(cd[staticOfs+0:staticOfs+2])))))
+	}
+	return statics
+}
+
+// ApplyDiffs replaces the statics of the blocks listed in the statics diff
+// list file (stadifl0.mul) with the statics of the statics diff index and data
+// files (stadifi0.mul and stadif0.mul), returning the number of blocks
+// replaced.
+func (m *StaticsMul) ApplyDiffs(diflPath, difiPath, difPath string, tdmul *TileDataMul) (int, error) {
+	blocks, err := readDiffList(diflPath)
+	if err != nil {
+		return 0, err
+	}
+	mul := NewIndexedMulFromFile(difiPath, difPath)
+	if mul == nil {
+		return 0, fmt.Errorf("failed to load %s", difPath)
+	}
+	if mul.NumberOfSegments() < len(blocks) {
+		return 0, fmt.Errorf("%s holds %d blocks but %s lists %d", difiPath,
+			mul.NumberOfSegments(), diflPath, len(blocks))
+	}
+	replaced := make(map[int]struct{}, len(blocks))
+	for _, block := range blocks {
+		if block >= m.width*m.height {
+			return 0, fmt.Errorf("%s: block %d out of range", diflPath, block)
+		}
+		replaced[block] = struct{}{}
+	}
+	// Drop the original statics of all replaced blocks
+	statics := m.statics[:0]
+	for _, s := range m.statics {
+		block := int(s.Location.X)/uo.ChunkWidth*m.height + int(s.Location.Y)/uo.ChunkHeight
+		if _, found := replaced[block]; !found {
+			statics = append(statics, s)
+		}
+	}
+	m.statics = statics
+	for i, block := range blocks {
+		m.statics = appendStatics(m.statics, block/m.height, block%m.height,
+			mul.GetSegment(i), tdmul)
+	}
+	return len(blocks), nil
+}
+
 // Statics returns the internal slice of static definitions
 func (m *StaticsMul) Statics() []uo.Static {
 	return m.statics
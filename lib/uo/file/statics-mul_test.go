@@ -0,0 +1,112 @@
+package file
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// putIndexedMul writes an index file and data file holding the segments. nil
+// segments are written as empty index entries.
+func putIndexedMul(t *testing.T, idxPath, mulPath string, segs [][]byte) {
+	var idx, d []byte
+	for _, seg := range segs {
+		e := make([]byte, 12)
+		if seg == nil {
+			binary.LittleEndian.PutUint32(e[0:4], 0xFFFFFFFF)
+			binary.LittleEndian.PutUint32(e[4:8], 0xFFFFFFFF)
+		} else {
+			binary.LittleEndian.PutUint32(e[0:4], uint32(len(d)))
+			binary.LittleEndian.PutUint32(e[4:8], uint32(len(seg)))
+		}
+		idx = append(idx, e...)
+		d = append(d, seg...)
+	}
+	if err := os.WriteFile(idxPath, idx, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mulPath, d, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// buildStatic returns the data of one static.
+func buildStatic(g uint16, x, y byte, z int8) []byte {
+	d := make([]byte, staticEntryLength)
+	binary.LittleEndian.PutUint16(d[0:2], g)
+	d[2] = x
+	d[3] = y
+	d[4] = byte(z)
+	return d
+}
+
+// sortedStatics returns a copy of the statics of the mul sorted by graphic.
+func sortedStatics(m *StaticsMul) []uo.Static {
+	ret := append([]uo.Static(nil), m.Statics()...)
+	sort.Slice(ret, func(i, j int) bool { return ret[i].BaseGraphic() < ret[j].BaseGraphic() })
+	return ret
+}
+
+func TestStaticsMul(t *testing.T) {
+	tdmul := newTileDataMul(buildTileData(8))
+	dir := t.TempDir()
+	p := func(name string) string { return filepath.Join(dir, name) }
+	// A map two chunks wide and two chunks high, block b holds one static with
+	// graphic b, block 2 is empty
+	putIndexedMul(t, p("staidx0.mul"), p("statics0.mul"), [][]byte{
+		buildStatic(0, 1, 2, 3),
+		buildStatic(1, 4, 5, 6),
+		nil,
+		buildStatic(3, 7, 7, -5),
+	})
+	mul := NewIndexedMulFromFile(p("staidx0.mul"), p("statics0.mul"))
+	if mul == nil {
+		t.Fatal("statics index not loaded")
+	}
+	m := newStaticsMul(mul, 2, 2, tdmul)
+	want := []uo.Location{
+		{X: 1, Y: 2, Z: 3},
+		{X: 4, Y: 13, Z: 6},
+		{X: 15, Y: 15, Z: -5},
+	}
+	statics := sortedStatics(m)
+	if len(statics) != len(want) {
+		t.Fatalf("%d statics read, expected %d", len(statics), len(want))
+	}
+	for i, s := range statics {
+		if s.Location != want[i] {
+			t.Fatalf("static %d read at %v, expected %v", i, s.Location, want[i])
+		}
+	}
+	// Replace block 1 with two statics and clear block 3
+	putDiffList(t, p("stadifl0.mul"), 1, 3)
+	putIndexedMul(t, p("stadifi0.mul"), p("stadif0.mul"), [][]byte{
+		append(buildStatic(20, 0, 0, 0), buildStatic(21, 1, 1, 1)...),
+		nil,
+	})
+	n, err := m.ApplyDiffs(p("stadifl0.mul"), p("stadifi0.mul"), p("stadif0.mul"), tdmul)
+	if err != nil || n != 2 {
+		t.Fatalf("applied %d diff blocks: %v", n, err)
+	}
+	statics = sortedStatics(m)
+	want = []uo.Location{
+		{X: 1, Y: 2, Z: 3},
+		{X: 0, Y: 8, Z: 0},
+		{X: 1, Y: 9, Z: 1},
+	}
+	if len(statics) != len(want) {
+		t.Fatalf("%d statics after diffs, expected %d", len(statics), len(want))
+	}
+	for i, s := range statics {
+		if s.Location != want[i] {
+			t.Fatalf("static %d read at %v after diffs, expected %v", i, s.Location, want[i])
+		}
+	}
+	if g := statics[2].BaseGraphic(); g != 21 {
+		t.Fatalf("diff static read as graphic %d", g)
+	}
+}
@@ -3,75 +3,128 @@ package file
 import (
 	"bytes"
 	"encoding/binary"
+	"log"
 	"os"
 
 	"github.com/qbradq/sharduo/lib/uo"
 )
 
+// Number of blocks of land tile definitions in tiledata.mul
+const tileDataLandBlocks = 512
+
+// Number of definitions in one block of tiledata.mul
+const tileDataBlockEntries = 32
+
+// tileDataLandBlockLength returns the length of one block of land tile
+// definitions with flags of the given size in bytes.
+func tileDataLandBlockLength(flagSize int) int {
+	return 4 + tileDataBlockEntries*(flagSize+22)
+}
+
+// tileDataStaticBlockLength returns the length of one block of static
+// definitions with flags of the given size in bytes.
+func tileDataStaticBlockLength(flagSize int) int {
+	return 4 + tileDataBlockEntries*(flagSize+33)
+}
+
+// tileDataFlagSize returns the size of the tile flags of tiledata.mul in bytes
+// based on the length of the file. Clients starting with 7.0.9.0 use 64-bit
+// flags, older clients use 32-bit flags. Zero is returned if the length
+// matches neither layout.
+func tileDataFlagSize(length int) int {
+	for _, fs := range []int{8, 4} {
+		rest := length - tileDataLandBlocks*tileDataLandBlockLength(fs)
+		if rest >= 0 && rest%tileDataStaticBlockLength(fs) == 0 {
+			return fs
+		}
+	}
+	return 0
+}
+
 // TileDataMul loads "tiledata.mul"
 type TileDataMul struct {
 	tileDefinitions   []uo.TileDefinition
 	staticDefinitions []uo.StaticDefinition
 }
 
-// NewTileDataMul creates a new TileDataMul and loads it from the path
+// NewTileDataMul creates a new TileDataMul and loads it from the path. Both
+// the 32-bit and 64-bit flag layouts of the file are supported.
 func NewTileDataMul(fname string) *TileDataMul {
 	// Load the file
 	d, err := os.ReadFile(fname)
 	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	ret := newTileDataMul(d)
+	if ret == nil {
+		log.Printf("error: %s has an unknown layout", fname)
+	}
+	return ret
+}
+
+// newTileDataMul returns a new TileDataMul loaded from the data, or nil if the
+// layout of the data is unknown.
+func newTileDataMul(d []byte) *TileDataMul {
+	fs := tileDataFlagSize(len(d))
+	if fs == 0 {
 		return nil
 	}
 	ret := &TileDataMul{
 		tileDefinitions:   make([]uo.TileDefinition, 0x4000),
 		staticDefinitions: make([]uo.StaticDefinition, 0x10000),
 	}
+	flags := func(d []byte) uo.TileFlags {
+		if fs == 4 {
+			return uo.TileFlags(binary.LittleEndian.Uint32(d))
+		}
+		return uo.TileFlags(binary.LittleEndian.Uint64(d))
+	}
+	name := func(sd []byte) string {
+		idx := bytes.IndexByte(sd, 0)
+		if idx < 0 {
+			return string(sd)
+		}
+		return string(sd[:idx])
+	}
 	dofs := 0
 	// Load tile definitions
 	tilei := 0
-	for tileDataChunk := 0; tileDataChunk < 512; tileDataChunk++ {
+	for tileDataChunk := 0; tileDataChunk < tileDataLandBlocks; tileDataChunk++ {
 		dofs += 4 // Skip header
-		for tileDataI := 0; tileDataI < 32; tileDataI++ {
-			sd := d[dofs+10 : dofs+30]
-			idx := bytes.IndexByte(sd, 0)
-			name := ""
-			if idx > 0 {
-				name = string(sd[:idx])
-			}
+		for tileDataI := 0; tileDataI < tileDataBlockEntries; tileDataI++ {
+			r := d[dofs : dofs+fs+22]
 			ret.tileDefinitions[tilei] = uo.TileDefinition{
 				Graphic:   uo.Graphic(tilei),
-				TileFlags: uo.TileFlags(binary.LittleEndian.Uint64(d[dofs+0 : dofs+8])),
-				Texture:   uo.Texture(binary.LittleEndian.Uint16(d[dofs+8 : dofs+10])),
-				Name:      name,
+				TileFlags: flags(r),
+				Texture:   uo.Texture(binary.LittleEndian.Uint16(r[fs : fs+2])),
+				Name:      name(r[fs+2:]),
 			}
 			tilei++
-			dofs += 30
+			dofs += fs + 22
 		}
 	}
 	// Load static definitions
 	statici := 0
-	for staticDataChunk := 0; dofs < len(d); staticDataChunk++ {
-		dofs += 4
-		for staticDataI := 0; staticDataI < 32; staticDataI++ {
-			sd := d[dofs+21 : dofs+41]
-			idx := bytes.IndexByte(sd, 0)
-			name := ""
-			if idx > 0 {
-				name = string(sd[:idx])
-			}
+	for dofs < len(d) && statici < len(ret.staticDefinitions) {
+		dofs += 4 // Skip header
+		for staticDataI := 0; staticDataI < tileDataBlockEntries; staticDataI++ {
+			r := d[dofs : dofs+fs+33]
+			sd := r[fs:]
 			ret.staticDefinitions[statici] = uo.StaticDefinition{
 				Graphic:   uo.Graphic(statici),
-				TileFlags: uo.TileFlags(binary.LittleEndian.Uint64(d[dofs+0 : dofs+8])),
-				Weight:    int(uint8(d[dofs+8])),
-				Layer:     uo.Layer(d[dofs+9]),
-				Count:     int(binary.LittleEndian.Uint32(d[dofs+10 : dofs+14])),
-				Animation: uo.AnimationType(binary.LittleEndian.Uint16(d[dofs+14 : dofs+16])),
-				Hue:       uo.Hue(binary.LittleEndian.Uint16(d[dofs+16 : dofs+18])),
-				Light:     uo.Light(binary.LittleEndian.Uint16(d[dofs+18 : dofs+20])),
-				Height:    int8(d[dofs+20]),
-				Name:      name,
+				TileFlags: flags(r),
+				Weight:    int(uint8(sd[0])),
+				Layer:     uo.Layer(sd[1]),
+				Count:     int(binary.LittleEndian.Uint32(sd[2:6])),
+				Animation: uo.AnimationType(binary.LittleEndian.Uint16(sd[6:8])),
+				Hue:       uo.Hue(binary.LittleEndian.Uint16(sd[8:10])),
+				Light:     uo.Light(binary.LittleEndian.Uint16(sd[10:12])),
+				Height:    int8(sd[12]),
+				Name:      name(sd[13:]),
 			}
 			statici++
-			dofs += 41
+			dofs += fs + 33
 		}
 	}
 	return ret
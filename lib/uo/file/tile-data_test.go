@@ -0,0 +1,79 @@
+package file
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/qbradq/sharduo/lib/uo"
+)
+
+// buildTileData returns a synthetic tiledata.mul with flags of the given size
+// and one block of static definitions. Land tile i and static i have flags
+// i, static i is i units tall and tile 1 is named.
+func buildTileData(flagSize int) []byte {
+	d := make([]byte, tileDataLandBlocks*tileDataLandBlockLength(flagSize)+
+		tileDataStaticBlockLength(flagSize))
+	putFlags := func(d []byte, v int) {
+		if flagSize == 4 {
+			binary.LittleEndian.PutUint32(d, uint32(v))
+		} else {
+			binary.LittleEndian.PutUint64(d, uint64(v)|1<<40)
+		}
+	}
+	ofs := 0
+	for i := 0; i < tileDataLandBlocks*tileDataBlockEntries; i++ {
+		if i%tileDataBlockEntries == 0 {
+			ofs += 4
+		}
+		putFlags(d[ofs:], i)
+		binary.LittleEndian.PutUint16(d[ofs+flagSize:], uint16(i))
+		if i == 1 {
+			copy(d[ofs+flagSize+2:], "grass")
+		}
+		ofs += flagSize + 22
+	}
+	ofs += 4
+	for i := 0; i < tileDataBlockEntries; i++ {
+		putFlags(d[ofs:], i)
+		d[ofs+flagSize+12] = byte(i)
+		if i == 1 {
+			copy(d[ofs+flagSize+13:], "a very long static name")
+		}
+		ofs += flagSize + 33
+	}
+	return d
+}
+
+func TestTileData(t *testing.T) {
+	for _, fs := range []int{4, 8} {
+		d := buildTileData(fs)
+		if got := tileDataFlagSize(len(d)); got != fs {
+			t.Fatalf("flag size %d detected as %d", fs, got)
+		}
+		m := newTileDataMul(d)
+		if m == nil {
+			t.Fatalf("flag size %d: tile data not loaded", fs)
+		}
+		var high uo.TileFlags
+		if fs == 8 {
+			high = 1 << 40
+		}
+		td := m.GetTileDefinition(0x3FFF)
+		if td.TileFlags != 0x3FFF|high || td.Texture != 0x3FFF {
+			t.Fatalf("flag size %d: land tile 0x3FFF read as %+v", fs, td)
+		}
+		if td := m.GetTileDefinition(1); td.Name != "grass" {
+			t.Fatalf("flag size %d: land tile name read as %q", fs, td.Name)
+		}
+		sd := m.GetStaticDefinition(31)
+		if sd.TileFlags != 31|high || sd.Height != 31 {
+			t.Fatalf("flag size %d: static 31 read as %+v", fs, sd)
+		}
+		if sd := m.GetStaticDefinition(1); sd.Name != "a very long static n" {
+			t.Fatalf("flag size %d: static name read as %q", fs, sd.Name)
+		}
+	}
+	if newTileDataMul(make([]byte, 1000)) != nil {
+		t.Fatal("tile data of unknown layout loaded")
+	}
+}
@@ -0,0 +1,252 @@
+package file
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// Magic number at the start of every UOP file, "MYP\0"
+const uopMagic uint32 = 0x0050594D
+
+// Length of the UOP file header
+const uopHeaderLength = 28
+
+// Length of one entry in the UOP hash table
+const uopEntryLength = 34
+
+// uopEntry describes one file stored within a UOP archive.
+type uopEntry struct {
+	// Offset of the data from the start of the archive
+	offset int
+	// Length of the data within the archive
+	length int
+	// Length of the data after decompression
+	decompressedLength int
+	// Compression method of the data, 0 for none and 1 for zlib
+	compression uint16
+}
+
+// UOPFile represents a UOP archive as shipped with modern clients. Entries of
+// the archive are found by the hash of their file name.
+type UOPFile struct {
+	// Entries by name hash
+	entries map[uint64]uopEntry
+	// Data of the archive
+	d []byte
+}
+
+// NewUOPFromFile returns a new UOPFile object initialized with the data of the
+// named file, or nil if an error was logged.
+func NewUOPFromFile(fname string) *UOPFile {
+	d, err := os.ReadFile(fname)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	u, err := NewUOP(d)
+	if err != nil {
+		log.Printf("error: %s: %s", fname, err)
+		return nil
+	}
+	return u
+}
+
+// NewUOP returns a new UOPFile object that reads from the archive data.
+func NewUOP(d []byte) (*UOPFile, error) {
+	if len(d) < uopHeaderLength {
+		return nil, errors.New("uop header truncated")
+	}
+	if binary.LittleEndian.Uint32(d[0:4]) != uopMagic {
+		return nil, errors.New("bad uop magic number")
+	}
+	u := &UOPFile{
+		entries: make(map[uint64]uopEntry),
+		d:       d,
+	}
+	// The hash table is a linked list of blocks of entries
+	next := binary.LittleEndian.Uint64(d[12:20])
+	seen := map[uint64]struct{}{}
+	for next != 0 {
+		if _, duplicate := seen[next]; duplicate {
+			return nil, errors.New("uop hash table blocks form a loop")
+		}
+		seen[next] = struct{}{}
+		ofs := int(next)
+		if next > uint64(len(d)) || ofs+12 > len(d) {
+			return nil, fmt.Errorf("uop hash table block at 0x%X out of range", next)
+		}
+		n := int(binary.LittleEndian.Uint32(d[ofs : ofs+4]))
+		next = binary.LittleEndian.Uint64(d[ofs+4 : ofs+12])
+		ofs += 12
+		if ofs+n*uopEntryLength > len(d) {
+			return nil, errors.New("uop hash table block truncated")
+		}
+		for i := 0; i < n; i++ {
+			ed := d[ofs : ofs+uopEntryLength]
+			ofs += uopEntryLength
+			dofs := binary.LittleEndian.Uint64(ed[0:8])
+			// Unused entries have no data
+			if dofs == 0 {
+				continue
+			}
+			e := uopEntry{
+				offset:             int(dofs) + int(binary.LittleEndian.Uint32(ed[8:12])),
+				length:             int(binary.LittleEndian.Uint32(ed[12:16])),
+				decompressedLength: int(binary.LittleEndian.Uint32(ed[16:20])),
+				compression:        binary.LittleEndian.Uint16(ed[32:34]),
+			}
+			if dofs > uint64(len(d)) || e.offset+e.length > len(d) {
+				return nil, fmt.Errorf("uop entry %d data out of range", i)
+			}
+			u.entries[binary.LittleEndian.Uint64(ed[20:28])] = e
+		}
+	}
+	return u, nil
+}
+
+// NumberOfEntries returns the number of files stored in the archive.
+func (u *UOPFile) NumberOfEntries() int {
+	return len(u.entries)
+}
+
+// GetEntry returns the data of the named file within the archive, or nil if
+// the file does not exist or could not be decompressed.
+func (u *UOPFile) GetEntry(name string) []byte {
+	e, found := u.entries[UOPHash(name)]
+	if !found {
+		return nil
+	}
+	d := u.d[e.offset : e.offset+e.length]
+	switch e.compression {
+	case 0:
+		return d
+	case 1:
+		r, err := zlib.NewReader(bytes.NewReader(d))
+		if err != nil {
+			log.Println(err)
+			return nil
+		}
+		defer r.Close()
+		ret := make([]byte, e.decompressedLength)
+		if _, err := io.ReadFull(r, ret); err != nil {
+			log.Println(err)
+			return nil
+		}
+		return ret
+	}
+	log.Printf("error: uop entry %s uses unsupported compression method %d", name,
+		e.compression)
+	return nil
+}
+
+// IndexedMul returns an IndexedMul view of the archive holding count
+// segments. The name of each segment is formed by passing its index to
+// fmt.Sprintf with the pattern, like "build/gumpartlegacymul/%08d.tga". Files
+// missing from the archive become empty segments.
+func (u *UOPFile) IndexedMul(pattern string, count int) *IndexedMul {
+	m := &IndexedMul{
+		index: &IndexMul{
+			indexes: make([]SegmentIndex, count),
+		},
+	}
+	for i := range m.index.indexes {
+		d := u.GetEntry(fmt.Sprintf(pattern, i))
+		if d == nil {
+			m.index.indexes[i] = SegmentIndex{
+				Offset: 0xFFFFFFFF,
+				Length: 0xFFFFFFFF,
+			}
+			continue
+		}
+		m.index.indexes[i] = SegmentIndex{
+			Offset: len(m.d),
+			Length: len(d),
+		}
+		m.d = append(m.d, d...)
+	}
+	return m
+}
+
+// Contents returns the concatenated data of the files named by passing
+// sequential indexes starting at zero to fmt.Sprintf with the pattern, up to
+// the first missing file. This restores the legacy MUL file of archives like
+// "build/map0legacymul/%08d.dat" that split one MUL file into parts.
+func (u *UOPFile) Contents(pattern string) []byte {
+	var ret []byte
+	for i := 0; ; i++ {
+		d := u.GetEntry(fmt.Sprintf(pattern, i))
+		if d == nil {
+			return ret
+		}
+		ret = append(ret, d...)
+	}
+}
+
+// UOPHash returns the hash of the file name as used in the hash tables of UOP
+// archives.
+func UOPHash(name string) uint64 {
+	return hashLittle2(strings.ToLower(name))
+}
+
+// hashLittle2 is Bob Jenkins' lookup3 hashlittle2 with both initial values
+// zero. The primary hash is returned in the low 32 bits.
+func hashLittle2(s string) uint64 {
+	n := len(s)
+	a := 0xDEADBEEF + uint32(n)
+	b := a
+	c := a
+	k := 0
+	for ; k+12 < n; k += 12 {
+		a += binary.LittleEndian.Uint32([]byte(s[k : k+4]))
+		b += binary.LittleEndian.Uint32([]byte(s[k+4 : k+8]))
+		c += binary.LittleEndian.Uint32([]byte(s[k+8 : k+12]))
+		a -= c
+		a ^= c<<4 | c>>28
+		c += b
+		b -= a
+		b ^= a<<6 | a>>26
+		a += c
+		c -= b
+		c ^= b<<8 | b>>24
+		b += a
+		a -= c
+		a ^= c<<16 | c>>16
+		c += b
+		b -= a
+		b ^= a<<19 | a>>13
+		a += c
+		c -= b
+		c ^= b<<4 | b>>28
+		b += a
+	}
+	if k < n {
+		// Remaining bytes are added little-endian, zero-padded to 12 bytes
+		var tail [12]byte
+		copy(tail[:], s[k:])
+		a += binary.LittleEndian.Uint32(tail[0:4])
+		b += binary.LittleEndian.Uint32(tail[4:8])
+		c += binary.LittleEndian.Uint32(tail[8:12])
+		c ^= b
+		c -= b<<14 | b>>18
+		a ^= c
+		a -= c<<11 | c>>21
+		b ^= a
+		b -= a<<25 | a>>7
+		c ^= b
+		c -= b<<16 | b>>16
+		a ^= c
+		a -= c<<4 | c>>28
+		b ^= a
+		b -= a<<14 | a>>18
+		c ^= b
+		c -= b<<24 | b>>8
+	}
+	return uint64(b)<<32 | uint64(c)
+}
@@ -0,0 +1,156 @@
+package file
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+// uopTestFile is one file stored in a synthetic UOP archive.
+type uopTestFile struct {
+	name       string
+	data       []byte
+	compressed bool
+}
+
+// buildUOP returns a synthetic UOP archive holding the files. Each block of
+// the hash table holds at most perBlock entries.
+func buildUOP(t *testing.T, files []uopTestFile, perBlock int) []byte {
+	var tables [][]byte
+	var data bytes.Buffer
+	nBlocks := (len(files) + perBlock - 1) / perBlock
+	tablesLength := 0
+	for i := 0; i < nBlocks; i++ {
+		n := len(files) - i*perBlock
+		if n > perBlock {
+			n = perBlock
+		}
+		tablesLength += 12 + n*uopEntryLength
+	}
+	dataStart := uopHeaderLength + tablesLength
+	ofs := uopHeaderLength
+	for i := 0; i < nBlocks; i++ {
+		block := files[i*perBlock:]
+		if len(block) > perBlock {
+			block = block[:perBlock]
+		}
+		table := make([]byte, 12+len(block)*uopEntryLength)
+		ofs += len(table)
+		binary.LittleEndian.PutUint32(table[0:4], uint32(len(block)))
+		if i < nBlocks-1 {
+			binary.LittleEndian.PutUint64(table[4:12], uint64(ofs))
+		}
+		for j, f := range block {
+			d := f.data
+			flag := uint16(0)
+			if f.compressed {
+				var buf bytes.Buffer
+				w := zlib.NewWriter(&buf)
+				w.Write(f.data)
+				w.Close()
+				d = buf.Bytes()
+				flag = 1
+			}
+			e := table[12+j*uopEntryLength:]
+			// Each file has an 8-byte header that is skipped
+			binary.LittleEndian.PutUint64(e[0:8], uint64(dataStart+data.Len()))
+			binary.LittleEndian.PutUint32(e[8:12], 8)
+			binary.LittleEndian.PutUint32(e[12:16], uint32(len(d)))
+			binary.LittleEndian.PutUint32(e[16:20], uint32(len(f.data)))
+			binary.LittleEndian.PutUint64(e[20:28], UOPHash(f.name))
+			binary.LittleEndian.PutUint16(e[32:34], flag)
+			data.Write(make([]byte, 8))
+			data.Write(d)
+		}
+		tables = append(tables, table)
+	}
+	ret := make([]byte, uopHeaderLength)
+	binary.LittleEndian.PutUint32(ret[0:4], uopMagic)
+	binary.LittleEndian.PutUint32(ret[4:8], 5)
+	binary.LittleEndian.PutUint32(ret[8:12], 0xFD23EC43)
+	binary.LittleEndian.PutUint64(ret[12:20], uopHeaderLength)
+	binary.LittleEndian.PutUint32(ret[20:24], uint32(perBlock))
+	binary.LittleEndian.PutUint32(ret[24:28], uint32(len(files)))
+	for _, table := range tables {
+		ret = append(ret, table...)
+	}
+	ret = append(ret, data.Bytes()...)
+	if len(ret) != dataStart+data.Len() {
+		t.Fatal("synthetic uop layout mismatch")
+	}
+	return ret
+}
+
+func TestUOPHash(t *testing.T) {
+	// Reference values of lookup3.c
+	if h := hashLittle2(""); h != 0xDEADBEEFDEADBEEF {
+		t.Fatalf("empty string hashed to %016X", h)
+	}
+	if h := hashLittle2("Four score and seven years ago"); h != 0xCE7226E617770551 {
+		t.Fatalf("reference string hashed to %016X", h)
+	}
+	if UOPHash("build/map0legacymul/00000000.dat") != UOPHash("build/Map0LegacyMUL/00000000.dat") {
+		t.Fatal("uop hashes must ignore case")
+	}
+}
+
+func TestUOP(t *testing.T) {
+	files := []uopTestFile{
+		{"build/testlegacymul/00000000.dat", []byte("zero"), false},
+		{"build/testlegacymul/00000001.dat", bytes.Repeat([]byte("one"), 100), true},
+		{"build/testlegacymul/00000003.dat", []byte("three"), false},
+	}
+	u, err := NewUOP(buildUOP(t, files, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.NumberOfEntries() != len(files) {
+		t.Fatalf("%d entries read, expected %d", u.NumberOfEntries(), len(files))
+	}
+	for _, f := range files {
+		if d := u.GetEntry(f.name); !bytes.Equal(d, f.data) {
+			t.Fatalf("entry %s read as %q", f.name, d)
+		}
+	}
+	if u.GetEntry("build/testlegacymul/00000002.dat") != nil {
+		t.Fatal("missing entry found")
+	}
+	// IndexedMul view
+	m := u.IndexedMul("build/testlegacymul/%08d.dat", 5)
+	if m.NumberOfSegments() != 5 {
+		t.Fatalf("%d segments, expected 5", m.NumberOfSegments())
+	}
+	for i := 0; i < 5; i++ {
+		var want []byte
+		if f := fmt.Sprintf("build/testlegacymul/%08d.dat", i); u.GetEntry(f) != nil {
+			want = u.GetEntry(f)
+		}
+		if got := m.GetSegment(i); !bytes.Equal(got, want) {
+			t.Fatalf("segment %d read as %q", i, got)
+		}
+	}
+	// Contents stops at the first missing file
+	want := append([]byte("zero"), files[1].data...)
+	if got := u.Contents("build/testlegacymul/%08d.dat"); !bytes.Equal(got, want) {
+		t.Fatalf("contents read as %q", got)
+	}
+}
+
+func TestUOPErrors(t *testing.T) {
+	d := buildUOP(t, []uopTestFile{{"a", []byte("a"), false}}, 1)
+	bad := append([]byte(nil), d...)
+	bad[0] = 0
+	if _, err := NewUOP(bad); err == nil {
+		t.Fatal("bad magic number accepted")
+	}
+	if _, err := NewUOP(d[:len(d)-1]); err == nil {
+		t.Fatal("truncated data accepted")
+	}
+	loop := append([]byte(nil), d...)
+	binary.LittleEndian.PutUint64(loop[uopHeaderLength+4:], uopHeaderLength)
+	if _, err := NewUOP(loop); err == nil {
+		t.Fatal("hash table loop accepted")
+	}
+}
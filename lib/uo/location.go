@@ -42,7 +42,7 @@ func (l Location) WrapToDungeonServer() Location {
 	for l.X < int16(MapOverworldWidth) {
 		l.X += int16(MapWidth) - int16(MapOverworldWidth)
 	}
-	for l.X > int16(MapWidth) {
+	for l.X >= int16(MapWidth) {
 		l.X -= int16(MapWidth) - int16(MapOverworldWidth)
 	}
 	for l.Y < 0 {
@@ -55,10 +55,10 @@ func (l Location) WrapToDungeonServer() Location {
 }
 
 // WrapAndBound wraps and bounds the spacial portion of the location to the
-// map dimensions relative to a reference point. UpdateAndBound will handle map
-// wrapping as appropriate based on the reference location.
+// map dimensions relative to a reference point. WrapAndBound will handle map
+// wrapping as appropriate based on the reference location. Reference points
+// west of the map are considered part of the overworld.
 func (l Location) WrapAndBound(ref Location) Location {
-	ref = ref.Bound()
 	if ref.X < int16(MapOverworldWidth) {
 		return l.WrapToOverworld()
 	} else {
@@ -66,6 +66,38 @@ func (l Location) WrapAndBound(ref Location) Location {
 	}
 }
 
+// InOverworld returns true if the location lies within the overworld portion
+// of the map, or west of the map.
+func (l Location) InOverworld() bool {
+	return l.X < int16(MapOverworldWidth)
+}
+
+// wrapDelta returns the shortest signed distance equivalent to d along an axis
+// that wraps every n units.
+func wrapDelta(d, n int16) int16 {
+	d %= n
+	if d > n/2 {
+		d -= n
+	} else if d < -n/2 {
+		d += n
+	}
+	return d
+}
+
+// Delta returns the X and Y offsets from l to d. The overworld wraps around
+// its edges, so offsets between two overworld locations take the shortest way
+// around the overworld. Offsets involving the dungeon server section of the
+// map never wrap.
+func (l Location) Delta(d Location) (dx, dy int16) {
+	dx = d.X - l.X
+	dy = d.Y - l.Y
+	if l.InOverworld() && d.InOverworld() {
+		dx = wrapDelta(dx, int16(MapOverworldWidth))
+		dy = wrapDelta(dy, int16(MapHeight))
+	}
+	return dx, dy
+}
+
 // Bound bounds the spacial portion of the location to the absolute dimensions
 // of the map.
 func (l Location) Bound() Location {
@@ -91,10 +123,9 @@ func (l Location) Bound() Location {
 }
 
 // XYDistance returns the maximum distance from l to d along either the X or Y
-// axis.
+// axis, taking the wrapping of the overworld into account.
 func (l Location) XYDistance(d Location) int16 {
-	dx := l.X - d.X
-	dy := l.Y - d.Y
+	dx, dy := l.Delta(d)
 	if dx < 0 {
 		dx = dx * -1
 	}
@@ -108,20 +139,27 @@ func (l Location) XYDistance(d Location) int16 {
 }
 
 // Forward moves the location in the given direction, affecting only the X and
-// Y coordinates.
+// Y coordinates. Steps off the edges of the overworld wrap around to the other
+// side of the overworld.
 func (l Location) Forward(d Direction) Location {
 	d = d & 0x07
-	return Location{
+	ret := Location{
 		X: l.X + dirOfs[d][0],
 		Y: l.Y + dirOfs[d][1],
 		Z: l.Z,
 	}
+	if l.X >= 0 && l.X < int16(MapOverworldWidth) && l.Y >= 0 && l.Y < int16(MapHeight) {
+		ret = ret.WrapToOverworld()
+	}
+	return ret
 }
 
 // DirectionTo returns the direction code that most closely matches the
-// direction of the argument location.
+// direction of the argument location, taking the wrapping of the overworld
+// into account.
 func (l Location) DirectionTo(a Location) Direction {
-	r := math.Atan2(float64(a.X-l.X), float64(a.Y-l.Y)) * 180 / math.Pi
+	dx, dy := l.Delta(a)
+	r := math.Atan2(float64(dx), float64(dy)) * 180 / math.Pi
 	b := -157.5
 	if r < b+45*0 {
 		return DirectionNorth
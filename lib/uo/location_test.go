@@ -0,0 +1,88 @@
+package uo
+
+import "testing"
+
+func TestLocationXYDistance(t *testing.T) {
+	var tests = []struct {
+		a, b Location
+		want int16
+	}{
+		{Location{X: 10, Y: 10}, Location{X: 13, Y: 8}, 3},
+		{Location{X: 5119, Y: 10}, Location{X: 2, Y: 10}, 3},
+		{Location{X: 100, Y: 4095}, Location{X: 100, Y: 1}, 2},
+		{Location{X: 0, Y: 0}, Location{X: 5119, Y: 4095}, 1},
+		// The dungeon server section does not wrap
+		{Location{X: 5120, Y: 10}, Location{X: 7167, Y: 10}, 2047},
+		{Location{X: 5119, Y: 10}, Location{X: 5120, Y: 10}, 1},
+		{Location{X: 5200, Y: 4095}, Location{X: 5200, Y: 0}, 4095},
+	}
+	for _, test := range tests {
+		if got := test.a.XYDistance(test.b); got != test.want {
+			t.Fatalf("distance from %v to %v is %d, expected %d", test.a, test.b, got, test.want)
+		}
+		if got := test.b.XYDistance(test.a); got != test.want {
+			t.Fatalf("distance from %v to %v is %d, expected %d", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+func TestLocationForward(t *testing.T) {
+	var tests = []struct {
+		l    Location
+		d    Direction
+		want Location
+	}{
+		{Location{X: 10, Y: 10, Z: 5}, DirectionSouthEast, Location{X: 11, Y: 11, Z: 5}},
+		{Location{X: 5119, Y: 10}, DirectionEast, Location{X: 0, Y: 10}},
+		{Location{X: 0, Y: 0}, DirectionNorthWest, Location{X: 5119, Y: 4095}},
+		{Location{X: 10, Y: 4095}, DirectionSouth, Location{X: 10, Y: 0}},
+		// Steps from the dungeon server section are left to the facet
+		{Location{X: 7167, Y: 10}, DirectionEast, Location{X: 7168, Y: 10}},
+	}
+	for _, test := range tests {
+		if got := test.l.Forward(test.d); got != test.want {
+			t.Fatalf("%v forward %d is %v, expected %v", test.l, test.d, got, test.want)
+		}
+	}
+}
+
+func TestLocationDirectionTo(t *testing.T) {
+	var tests = []struct {
+		a, b Location
+		want Direction
+	}{
+		{Location{X: 10, Y: 10}, Location{X: 20, Y: 10}, DirectionEast},
+		{Location{X: 5118, Y: 10}, Location{X: 3, Y: 10}, DirectionEast},
+		{Location{X: 3, Y: 10}, Location{X: 5118, Y: 10}, DirectionWest},
+		{Location{X: 10, Y: 2}, Location{X: 10, Y: 4090}, DirectionNorth},
+		{Location{X: 5125, Y: 10}, Location{X: 7160, Y: 10}, DirectionEast},
+	}
+	for _, test := range tests {
+		if got := test.a.DirectionTo(test.b); got != test.want {
+			t.Fatalf("direction from %v to %v is %d, expected %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestBoundsWrapping(t *testing.T) {
+	edge := Bounds{X: 5110, Y: 4090, Z: MapMinZ, W: 20, H: 20, D: 256}
+	if !edge.Contains(Location{X: 5, Y: 5}) {
+		t.Fatal("bounds across the overworld edge must contain wrapped locations")
+	}
+	if edge.Contains(Location{X: 20, Y: 5}) {
+		t.Fatal("bounds contain a location past their wrapped edge")
+	}
+	if !edge.Overlaps(Bounds{X: 2, Y: 2, W: 4, H: 4}) {
+		t.Fatal("bounds across the overworld edge must overlap wrapped bounds")
+	}
+	if edge.Overlaps(Bounds{X: 20, Y: 2, W: 4, H: 4}) {
+		t.Fatal("bounds overlap bounds past their wrapped edge")
+	}
+	dungeon := Bounds{X: 7160, Y: 10, Z: MapMinZ, W: 20, H: 20, D: 256}
+	if dungeon.Contains(Location{X: 5125, Y: 15}) {
+		t.Fatal("bounds within the dungeon server section must not wrap")
+	}
+	if !dungeon.Contains(Location{X: 7165, Y: 15}) {
+		t.Fatal("bounds do not contain an unwrapped location")
+	}
+}
@@ -211,3 +211,14 @@ const (
 	SkillCount                Skill = SkillLast + 1
 	SkillAll                  Skill = 0xFF // Asks for all skills in a status request
 )
+
+// SkillByName returns the skill with the given name as listed in SkillNames
+// and true, or false if there is no such skill.
+func SkillByName(name string) (Skill, bool) {
+	for i, n := range SkillNames {
+		if n == name {
+			return Skill(i), true
+		}
+	}
+	return SkillAlchemy, false
+}
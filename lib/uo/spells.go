@@ -0,0 +1,78 @@
+package uo
+
+// Spell represents a magery spell by numeric ID. Spell IDs are zero-based,
+// while the client counts spells starting from one.
+type Spell byte
+
+// All valid values for Spell
+const (
+	SpellClumsy          Spell = 0
+	SpellCreateFood      Spell = 1
+	SpellFeeblemind      Spell = 2
+	SpellHeal            Spell = 3
+	SpellMagicArrow      Spell = 4
+	SpellNightSight      Spell = 5
+	SpellReactiveArmor   Spell = 6
+	SpellWeaken          Spell = 7
+	SpellAgility         Spell = 8
+	SpellCunning         Spell = 9
+	SpellCure            Spell = 10
+	SpellHarm            Spell = 11
+	SpellMagicTrap       Spell = 12
+	SpellMagicUntrap     Spell = 13
+	SpellProtection      Spell = 14
+	SpellStrength        Spell = 15
+	SpellBless           Spell = 16
+	SpellFireball        Spell = 17
+	SpellMagicLock       Spell = 18
+	SpellPoison          Spell = 19
+	SpellTelekinesis     Spell = 20
+	SpellTeleport        Spell = 21
+	SpellUnlock          Spell = 22
+	SpellWallOfStone     Spell = 23
+	SpellArchCure        Spell = 24
+	SpellArchProtection  Spell = 25
+	SpellCurse           Spell = 26
+	SpellFireField       Spell = 27
+	SpellGreaterHeal     Spell = 28
+	SpellLightning       Spell = 29
+	SpellManaDrain       Spell = 30
+	SpellRecall          Spell = 31
+	SpellBladeSpirits    Spell = 32
+	SpellDispelField     Spell = 33
+	SpellIncognito       Spell = 34
+	SpellMagicReflection Spell = 35
+	SpellMindBlast       Spell = 36
+	SpellParalyze        Spell = 37
+	SpellPoisonField     Spell = 38
+	SpellSummonCreature  Spell = 39
+	SpellDispel          Spell = 40
+	SpellEnergyBolt      Spell = 41
+	SpellExplosion       Spell = 42
+	SpellInvisibility    Spell = 43
+	SpellMark            Spell = 44
+	SpellMassCurse       Spell = 45
+	SpellParalyzeField   Spell = 46
+	SpellReveal          Spell = 47
+	SpellChainLightning  Spell = 48
+	SpellEnergyField     Spell = 49
+	SpellFlamestrike     Spell = 50
+	SpellGateTravel      Spell = 51
+	SpellManaVampire     Spell = 52
+	SpellMassDispel      Spell = 53
+	SpellMeteorSwarm     Spell = 54
+	SpellPolymorph       Spell = 55
+	SpellEarthquake      Spell = 56
+	SpellEnergyVortex    Spell = 57
+	SpellResurrection    Spell = 58
+	SpellAirElemental    Spell = 59
+	SpellSummonDaemon    Spell = 60
+	SpellEarthElemental  Spell = 61
+	SpellFireElemental   Spell = 62
+	SpellWaterElemental  Spell = 63
+	SpellFirst           Spell = SpellClumsy
+	SpellLast            Spell = SpellWaterElemental
+)
+
+// Circle returns the spell circle of the spell, 1-8.
+func (s Spell) Circle() int { return int(s)/8 + 1 }
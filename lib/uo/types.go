@@ -17,10 +17,28 @@ const (
 	MapMinZ                   int8  = -128
 	MapMaxZ                   int8  = 127
 	StatsCapDefault           int   = 225
+	StatMinimum               int   = 10
+	StatMaximum               int   = 100
+	SkillCapDefault           int   = 1000
+	TotalSkillCapDefault      int   = 7000
 	MaxFollowers              int   = 5
 	MaxUseRange               int16 = 3
 	MaxLiftRange              int16 = 3
 	MaxDropRange              int16 = 3
+	MaxMeleeRange             int16 = 1
+	MaxSpellRange             int16 = 12
+	FistsMinDamage            int   = 1
+	FistsMaxDamage            int   = 4
+	FistsSpeed                int   = 50
+	MinSwingDelay             Time  = DurationSecond * 5 / 4
+	CorpseDecayTime           Time  = DurationMinute * 7
+	PlayerCorpseDecayTime     Time  = DurationMinute * 15
+	ResurrectionHitPoints     int   = 10
+	CriminalDuration          Time  = DurationMinute * 2
+	AggressorDuration         Time  = DurationMinute * 2
+	MurderCountThreshold      int   = 5
+	MurderCountDecayDefault   Time  = DurationHour * 8
+	GuardDespawnDelay         Time  = DurationMinute
 	MaxContainerViewRange     int16 = 3
 	MaxItemStackHeight        int8  = 18
 	DefaultMaxContainerWeight int   = 400
@@ -155,6 +173,20 @@ const (
 	LoginDeniedReasonAccountBlocked LoginDeniedReason = 2 // The account has been blocked for some reason
 )
 
+// CharacterDeleteDeniedReason represents the reason for refusing to delete a
+// character
+type CharacterDeleteDeniedReason byte
+
+// All CharacterDeleteDeniedReason values
+const (
+	CharacterDeleteDeniedReasonBadPass      CharacterDeleteDeniedReason = 0 // Password invalid for user
+	CharacterDeleteDeniedReasonNotFound     CharacterDeleteDeniedReason = 1 // The character does not exist
+	CharacterDeleteDeniedReasonInUse        CharacterDeleteDeniedReason = 2 // The character is being played right now
+	CharacterDeleteDeniedReasonTooYoung     CharacterDeleteDeniedReason = 3 // The character is not old enough to delete
+	CharacterDeleteDeniedReasonBackup       CharacterDeleteDeniedReason = 4 // The character is queued for backup
+	CharacterDeleteDeniedReasonCannotDelete CharacterDeleteDeniedReason = 5 // The request could not be carried out
+)
+
 // SpeechType represents the type of speech being requested or sent.
 type SpeechType byte
 
@@ -244,16 +276,66 @@ type Body uint16
 
 // Pre-defined values for Body
 const (
-	BodyNone        Body = 0
-	BodyHuman       Body = 400 // Human male body
-	BodyHumanMale   Body = 400
-	BodyHumanFemale Body = 401
-	BodyGhost       Body = 970
-	BodyCounselor   Body = 987 // GM body
-	BodyDefault     Body = 991 // Blackthorne
-	BodySystem      Body = 0x7fff
+	BodyNone                Body = 0
+	BodyHuman               Body = 400 // Human male body
+	BodyHumanMale           Body = 400
+	BodyHumanFemale         Body = 401
+	BodyGhostMale           Body = 402
+	BodyGhostFemale         Body = 403
+	BodyElfMale             Body = 605
+	BodyElfFemale           Body = 606
+	BodyElfGhostMale        Body = 607
+	BodyElfGhostFemale      Body = 608
+	BodyGargoyleMale        Body = 666
+	BodyGargoyleFemale      Body = 667
+	BodyGargoyleGhostMale   Body = 694
+	BodyGargoyleGhostFemale Body = 695
+	BodyGhost               Body = 970
+	BodyCounselor           Body = 987 // GM body
+	BodyDefault             Body = 991 // Blackthorne
+	BodySystem              Body = 0x7fff
 )
 
+// GhostBody returns the ghost body for the living body and gender given.
+func GhostBody(b Body, female bool) Body {
+	switch b {
+	case BodyElfMale, BodyElfFemale:
+		if female {
+			return BodyElfGhostFemale
+		}
+		return BodyElfGhostMale
+	case BodyGargoyleMale, BodyGargoyleFemale:
+		if female {
+			return BodyGargoyleGhostFemale
+		}
+		return BodyGargoyleGhostMale
+	}
+	if female {
+		return BodyGhostFemale
+	}
+	return BodyGhostMale
+}
+
+// LivingBody returns the living body for the ghost body given. Bodies that are
+// not ghost bodies are returned unchanged.
+func LivingBody(b Body) Body {
+	switch b {
+	case BodyGhostMale:
+		return BodyHumanMale
+	case BodyGhostFemale:
+		return BodyHumanFemale
+	case BodyElfGhostMale:
+		return BodyElfMale
+	case BodyElfGhostFemale:
+		return BodyElfFemale
+	case BodyGargoyleGhostMale:
+		return BodyGargoyleMale
+	case BodyGargoyleGhostFemale:
+		return BodyGargoyleFemale
+	}
+	return b
+}
+
 // MoveSpeed represents one of the available movement speeds.
 type MoveSpeed byte
 
@@ -290,6 +372,17 @@ const (
 	MoveItemRejectReasonUnspecified        MoveItemRejectReason = 5
 )
 
+// SecureTradeAction represents the action codes of the secure trade packet
+// 0x6F.
+type SecureTradeAction byte
+
+// All known values for SecureTradeAction
+const (
+	SecureTradeActionDisplay SecureTradeAction = 0 // Open the trade window
+	SecureTradeActionClose   SecureTradeAction = 1 // Close the trade window or cancel the trade
+	SecureTradeActionUpdate  SecureTradeAction = 2 // Update the accept check boxes
+)
+
 // GUMP represents a gump graphic.
 type GUMP uint16
 
@@ -348,6 +441,7 @@ const (
 	SoundDefaultLift Sound = 0x57
 	SoundDefaultDrop Sound = 0x42
 	SoundBagDrop     Sound = 0x48
+	SoundResurrect   Sound = 0x214
 )
 
 // AnimationType indicates which animation type to play on the client side
@@ -463,11 +557,12 @@ var DoorOffsets = []Location{
 type MacroType uint8
 
 const (
-	MacroTypeSkill    MacroType = 0 // Skill use request
-	MacroTypeSpell    MacroType = 1 // Spell cast request
-	MacroTypeOpenDoor MacroType = 2 // Open door request
-	MacroTypeAction   MacroType = 3 // 0 = bow, 1 = salute
-	MacroTypeInvalid  MacroType = 4 // Parsing error
+	MacroTypeSkill         MacroType = 0 // Skill use request
+	MacroTypeSpell         MacroType = 1 // Spell cast request
+	MacroTypeOpenDoor      MacroType = 2 // Open door request
+	MacroTypeAction        MacroType = 3 // 0 = bow, 1 = salute
+	MacroTypeInvalid       MacroType = 4 // Parsing error
+	MacroTypeOpenSpellbook MacroType = 5 // Open spellbook request, 1 = magery
 )
 
 // Music is a code that describes which music track to play on the client side.
@@ -47,3 +47,25 @@ func ParseLocation(strval string) (uo.Location, error) {
 	l.Z = int8(v)
 	return l, nil
 }
+
+// ParseFacetLocation parses a location with an optional trailing facet name in
+// the form X,Y,Z[,Facet]. The facet defaults to Felucca.
+func ParseFacetLocation(strval string) (uo.FacetLocation, error) {
+	parts := strings.Split(strval, ",")
+	if len(parts) != 4 {
+		l, err := ParseLocation(strval)
+		return uo.FacetLocation{Location: l}, err
+	}
+	l, err := ParseLocation(strings.Join(parts[:3], ","))
+	if err != nil {
+		return uo.FacetLocation{}, err
+	}
+	f, found := uo.FacetByName(parts[3])
+	if !found {
+		return uo.FacetLocation{}, fmt.Errorf("ParseFacetLocation(%s) unknown facet %s", strval, parts[3])
+	}
+	return uo.FacetLocation{
+		Facet:    f,
+		Location: l,
+	}, nil
+}